@@ -7,9 +7,21 @@ import (
 	"os"
 	"queue_system/config"
 	"queue_system/database"
+	"queue_system/internal/auth"
+	"queue_system/internal/calendarsync"
 	"queue_system/internal/controller"
+	"queue_system/internal/eta"
+	"queue_system/internal/events"
+	"queue_system/internal/notification"
+	"queue_system/internal/outbox"
+	"queue_system/internal/ratelimit"
+	"queue_system/internal/reminder"
 	"queue_system/internal/repository"
+	"queue_system/internal/retention"
 	"queue_system/internal/service"
+	"queue_system/internal/stream"
+	"queue_system/internal/tenant"
+	"queue_system/internal/webhook"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
@@ -29,7 +41,34 @@ func main() {
 			database.NewDatabase,
 			NewGinEngine,
 		),
+		// The dispatchers are started before the server so that on shutdown
+		// they stop after the server and can dispatch the last requests' events.
+		fx.Invoke(RegisterEventSubscribers, StartOutboxDispatcher, StartWebhookDispatcher, StartReminderScheduler, StartCalendarSyncer, StartRetentionPurger),
 		fx.Invoke(RegisterRoutesAndStartServer),
+		fx.Provide(
+			events.NewBus,
+			repository.NewOutboxRepository,
+			outbox.NewDispatcher,
+		),
+		fx.Provide(
+			stream.NewHub,
+			controller.NewStreamController,
+		),
+		fx.Provide(
+			repository.NewOrganizationRepository,
+			tenant.NewResolver,
+			service.NewOrganizationService,
+			controller.NewOrganizationController,
+		),
+		fx.Provide(
+			auth.NewTokens,
+			auth.NewMiddleware,
+			repository.NewAuthRepository,
+			service.NewAuthService,
+			controller.NewAuthController,
+			service.NewAPIKeyService,
+			controller.NewAPIKeyController,
+		),
 		fx.Provide(
 			repository.NewUserRepository,
 			service.NewUserService,
@@ -40,6 +79,59 @@ func main() {
 			service.NewAppointmentService,
 			controller.NewAppointmentController,
 		),
+		fx.Provide(
+			repository.NewCalendarRepository,
+			service.NewICalService,
+			controller.NewICalController,
+		),
+		fx.Provide(
+			calendarsync.NewSyncer,
+			service.NewBusyCalendarService,
+			controller.NewBusyCalendarController,
+		),
+		fx.Provide(
+			service.NewCalDAVService,
+			controller.NewCalDAVController,
+		),
+		fx.Provide(
+			repository.NewWorkingHoursRepository,
+			service.NewWorkingHoursService,
+			controller.NewWorkingHoursController,
+		),
+		fx.Provide(
+			service.NewAvailabilityService,
+			controller.NewAvailabilityController,
+		),
+		fx.Provide(
+			NewETAEstimator,
+			service.NewETAService,
+			controller.NewETAController,
+		),
+		fx.Provide(
+			repository.NewQueueRepository,
+			service.NewQueueService,
+			controller.NewQueueController,
+		),
+		fx.Provide(
+			repository.NewWebhookRepository,
+			service.NewWebhookService,
+			controller.NewWebhookController,
+			webhook.NewDispatcher,
+		),
+		fx.Provide(
+			repository.NewReminderRepository,
+			reminder.NewScheduler,
+		),
+		fx.Provide(
+			repository.NewRetentionRepository,
+			retention.NewPurger,
+		),
+		fx.Provide(
+			repository.NewNotificationRepository,
+			notification.NewNotifier,
+			notification.LoadTemplates,
+			notification.NewSender,
+		),
 	)
 
 	// Start the application
@@ -56,36 +148,252 @@ func NewGinEngine() *gin.Engine {
 	return router
 }
 
+// RegisterEventSubscribers connects in-process consumers to the event bus.
+func RegisterEventSubscribers(bus *events.Bus, hub *stream.Hub, webhooks *webhook.Dispatcher, notifications *notification.Sender, authService service.AuthService) {
+	bus.SubscribeAll("stream", hub.HandleEvent)
+	bus.SubscribeAll("webhooks", webhooks.HandleEvent)
+	bus.Subscribe("notifications", notifications.HandleEvent, notifications.EventTypes()...)
+	bus.Subscribe("magic-links", authService.SendMagicLink, events.MagicLinkRequested)
+}
+
+func StartOutboxDispatcher(lc fx.Lifecycle, dispatcher *outbox.Dispatcher) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			log.Info().Msg("Starting outbox dispatcher")
+			dispatcher.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			log.Info().Msg("Stopping outbox dispatcher")
+			return dispatcher.Stop(ctx)
+		},
+	})
+}
+
+func StartWebhookDispatcher(lc fx.Lifecycle, dispatcher *webhook.Dispatcher) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			log.Info().Msg("Starting webhook dispatcher")
+			dispatcher.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			log.Info().Msg("Stopping webhook dispatcher")
+			return dispatcher.Stop(ctx)
+		},
+	})
+}
+
+func StartReminderScheduler(lc fx.Lifecycle, scheduler *reminder.Scheduler) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			log.Info().Msg("Starting reminder scheduler")
+			scheduler.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			log.Info().Msg("Stopping reminder scheduler")
+			return scheduler.Stop(ctx)
+		},
+	})
+}
+
+func StartCalendarSyncer(lc fx.Lifecycle, syncer *calendarsync.Syncer) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			log.Info().Msg("Starting calendar syncer")
+			syncer.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			log.Info().Msg("Stopping calendar syncer")
+			return syncer.Stop(ctx)
+		},
+	})
+}
+
+func StartRetentionPurger(lc fx.Lifecycle, purger *retention.Purger) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			log.Info().Msg("Starting retention purger")
+			purger.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			log.Info().Msg("Stopping retention purger")
+			return purger.Stop(ctx)
+		},
+	})
+}
+
+func NewETAEstimator(cfg *config.Config) (eta.Estimator, error) {
+	return eta.New(cfg.ETA.Estimator, cfg.ETA.Window, cfg.ETA.Percentile)
+}
+
 func RegisterRoutesAndStartServer(
 	cfg *config.Config,
 	router *gin.Engine,
 	lc fx.Lifecycle,
+	tenantResolver *tenant.Resolver,
+	authMiddleware *auth.Middleware,
+	authController *controller.AuthController,
+	organizationController *controller.OrganizationController,
+	apiKeyController *controller.APIKeyController,
 	userController *controller.UserController,
 	appointmentController *controller.AppointmentController,
+	availabilityController *controller.AvailabilityController,
+	workingHoursController *controller.WorkingHoursController,
+	queueController *controller.QueueController,
+	etaController *controller.ETAController,
+	streamController *controller.StreamController,
+	webhookController *controller.WebhookController,
+	icalController *controller.ICalController,
+	busyCalendarController *controller.BusyCalendarController,
+	caldavController *controller.CalDAVController,
+	hub *stream.Hub,
 ) {
 
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Routes registered from here on belong to an organization.
+	router.Use(tenantResolver.Middleware())
+
+	requireUser := authMiddleware.RequireUser()
+	ownUser := auth.RequireSelfOr("id", auth.ManageUsers)
+	ownAppointments := auth.RequireSelfOr("id", auth.ManageAppointments)
+	ownSchedule := auth.RequireSelfOr("id", auth.ManageSchedules)
+	manageQueues := auth.RequirePermission(auth.ManageQueues)
+	manageUsers := auth.RequirePermission(auth.ManageUsers)
+	manageAppointments := auth.RequirePermission(auth.ManageAppointments)
+	restoreDeleted := auth.RequirePermission(auth.RestoreDeleted)
+	authLimit := ratelimit.New(cfg.Auth.RateLimit, cfg.Auth.RateLimitWindow).PerClient()
+
+	//Auth routes
+	authRoutes := router.Group("/api/v1/auth")
+	{
+		authRoutes.POST("/login", authLimit, authController.Login)
+		authRoutes.POST("/magic-link", authLimit, authController.RequestMagicLink)
+		authRoutes.POST("/magic-link/verify", authController.VerifyMagicLink)
+		authRoutes.POST("/refresh", authController.Refresh)
+		authRoutes.POST("/logout", authController.Logout)
+		authRoutes.GET("/me", requireUser, authController.Me)
+	}
+
+	//Organization routes
+	router.POST("/api/v1/organizations/", requireUser, auth.RequireSession(), auth.RequirePermission(auth.ManageOrganizations), organizationController.CreateOrganization)
+
+	//API key routes
+	apiKeyRoutes := router.Group("/api/v1/api-keys", requireUser, auth.RequireSession())
+	{
+		apiKeyRoutes.POST("/", apiKeyController.CreateAPIKey)
+		apiKeyRoutes.GET("/", apiKeyController.ListAPIKeys)
+		apiKeyRoutes.DELETE("/:id", apiKeyController.RevokeAPIKey)
+	}
+
+	// Sign-up and calendar feeds, which carry their own token, are public.
+	router.POST("/api/v1/users/", authMiddleware.IdentifyUser(), auth.RequireScope("users"), userController.CreateUser)
+	router.GET("/api/v1/users/:id/calendar.ics", icalController.ExportUserFeed)
+
 	//User routes
-	userRoutes := router.Group("/api/v1/users")
+	userRoutes := router.Group("/api/v1/users", requireUser, auth.RequireScope("users"))
 	{
-		userRoutes.POST("/", userController.CreateUser)
-		userRoutes.GET("/:id", userController.GetUserById)
+		userRoutes.GET("/", manageUsers, userController.ListUsers)
+		userRoutes.GET("/:id", ownUser, userController.GetUserById)
+		userRoutes.PATCH("/:id", ownUser, userController.UpdateUser)
+		userRoutes.POST("/:id/deactivate", manageUsers, userController.DeactivateUser)
+		userRoutes.POST("/:id/reactivate", manageUsers, userController.ReactivateUser)
+		userRoutes.DELETE("/:id", manageUsers, userController.DeleteUser)
+		userRoutes.POST("/:id/restore", restoreDeleted, userController.RestoreUser)
+		userRoutes.GET("/:id/appointments", ownAppointments, appointmentController.GetUserCalendar)
+		userRoutes.POST("/:id/calendar-token", ownUser, icalController.IssueFeedToken)
+		userRoutes.GET("/:id/busy-calendars", ownUser, busyCalendarController.ListBusyCalendars)
+		userRoutes.POST("/:id/busy-calendars", ownUser, busyCalendarController.SubscribeBusyCalendar)
+		userRoutes.POST("/:id/busy-calendars/upload", ownUser, busyCalendarController.ImportBusyCalendar)
+		userRoutes.DELETE("/:id/busy-calendars/:calendarId", ownUser, busyCalendarController.DeleteBusyCalendar)
+		userRoutes.GET("/:id/waiting-line", ownSchedule, appointmentController.GetWaitingLine)
+		userRoutes.POST("/:id/waiting-line/call-next", ownSchedule, appointmentController.CallNextInWaitingLine)
+		userRoutes.GET("/:id/working-hours", ownSchedule, workingHoursController.ListWorkingHours)
+		userRoutes.POST("/:id/working-hours", ownSchedule, workingHoursController.CreateWorkingHours)
+		userRoutes.PUT("/:id/working-hours/:windowId", ownSchedule, workingHoursController.UpdateWorkingHours)
+		userRoutes.DELETE("/:id/working-hours/:windowId", ownSchedule, workingHoursController.DeleteWorkingHours)
+		userRoutes.GET("/:id/availability-overrides", ownSchedule, workingHoursController.ListOverrides)
+		userRoutes.POST("/:id/availability-overrides", ownSchedule, workingHoursController.CreateOverride)
+		userRoutes.DELETE("/:id/availability-overrides/:overrideId", ownSchedule, workingHoursController.DeleteOverride)
 	}
 
 	//Appointment routes
-	appointmentRoutes := router.Group("/api/v1/appointments")
+	appointmentRoutes := router.Group("/api/v1/appointments", requireUser, auth.RequireScope("appointments"))
 	{
 		appointmentRoutes.POST("/", appointmentController.CreateAppointment)
-		appointmentRoutes.GET("/:id", appointmentController.GetAppointmentByID)
+		appointmentRoutes.POST("/series", appointmentController.CreateAppointmentSeries)
+		appointmentRoutes.GET("/", appointmentController.ListAppointments)
+		appointmentRoutes.GET("/:id", appointmentController.AuthorizeAppointment, icalController.WithAppointmentExport(appointmentController.GetAppointmentByID))
+		appointmentRoutes.PATCH("/:id", appointmentController.AuthorizeAppointment, appointmentController.UpdateAppointment)
+		appointmentRoutes.DELETE("/:id", manageAppointments, appointmentController.DeleteAppointment)
+		appointmentRoutes.POST("/:id/restore", restoreDeleted, appointmentController.RestoreAppointment)
+		appointmentRoutes.POST("/:id/confirm", appointmentController.AuthorizeAppointment, appointmentController.ConfirmAppointment)
+		appointmentRoutes.POST("/:id/cancel", appointmentController.AuthorizeAppointment, appointmentController.CancelAppointment)
+		appointmentRoutes.POST("/:id/start", appointmentController.AuthorizeAppointment, appointmentController.StartAppointment)
+		appointmentRoutes.POST("/:id/complete", appointmentController.AuthorizeAppointment, appointmentController.CompleteAppointment)
+		appointmentRoutes.POST("/:id/check-in", appointmentController.AuthorizeAppointment, appointmentController.CheckInAppointment)
+		appointmentRoutes.GET("/:id/eta", appointmentController.AuthorizeAppointment, etaController.GetAppointmentETA)
+	}
+
+	//Availability routes
+	router.GET("/api/v1/availability", requireUser, auth.RequireScope("appointments"), availabilityController.GetAvailability)
+
+	//Queue routes
+	queueRoutes := router.Group("/api/v1/queues", requireUser, auth.RequireScope("queues"))
+	{
+		queueRoutes.POST("/", manageQueues, queueController.CreateQueue)
+		queueRoutes.GET("/", queueController.ListQueues)
+		queueRoutes.GET("/:id", queueController.GetQueueByID)
+		queueRoutes.POST("/:id/tickets", queueController.TakeTicket)
+		queueRoutes.GET("/:id/tickets", manageQueues, queueController.ListTickets)
+		queueRoutes.POST("/:id/call-next", manageQueues, queueController.CallNextTicket)
+		queueRoutes.POST("/:id/tickets/:ticketId/serve", manageQueues, queueController.StartServingTicket)
+		queueRoutes.POST("/:id/tickets/:ticketId/done", manageQueues, queueController.CompleteTicket)
+		queueRoutes.POST("/:id/tickets/:ticketId/no-show", manageQueues, queueController.MarkTicketNoShow)
+	}
+
+	//Webhook routes
+	webhookRoutes := router.Group("/api/v1/webhooks", requireUser, auth.RequireScope("webhooks"), auth.RequirePermission(auth.ManageWebhooks))
+	{
+		webhookRoutes.POST("/", webhookController.CreateWebhook)
+		webhookRoutes.GET("/", webhookController.ListWebhooks)
+		webhookRoutes.GET("/:id", webhookController.GetWebhookByID)
+		webhookRoutes.PATCH("/:id", webhookController.UpdateWebhook)
+		webhookRoutes.DELETE("/:id", webhookController.DeleteWebhook)
+		webhookRoutes.GET("/:id/deliveries", webhookController.ListDeliveries)
+		webhookRoutes.GET("/:id/deliveries/:deliveryId", webhookController.GetDelivery)
+		webhookRoutes.POST("/:id/deliveries/:deliveryId/replay", webhookController.ReplayDelivery)
+	}
+
+	//Stream routes
+	router.GET("/api/v1/stream", requireUser, auth.RequireScope("appointments"), streamController.Stream)
+
+	//CalDAV routes
+	router.OPTIONS("/caldav/users/:id/*path", caldavController.Options)
+	caldavRoutes := router.Group("/caldav/users/:id", authMiddleware.RequireUserOrBasic("Appointments"), auth.RequireScope("appointments"))
+	{
+		caldavRoutes.Handle("PROPFIND", "/", caldavController.PropfindPrincipal)
+		caldavRoutes.Handle("PROPFIND", "/calendar/", caldavController.PropfindCollection)
+		caldavRoutes.Handle("REPORT", "/calendar/", caldavController.Report)
+		caldavRoutes.Handle("PROPFIND", "/calendar/:object", caldavController.PropfindObject)
+		caldavRoutes.GET("/calendar/:object", caldavController.GetObject)
+		caldavRoutes.PUT("/calendar/:object", caldavController.PutObject)
+		caldavRoutes.DELETE("/calendar/:object", caldavController.DeleteObject)
 	}
 
 	server := &http.Server{
 		Addr:    ":" + cfg.Server.Port,
 		Handler: router,
 	}
+	// Open event streams never finish on their own, so end them before
+	// Shutdown waits for active connections.
+	server.RegisterOnShutdown(hub.Close)
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
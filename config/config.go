@@ -2,14 +2,25 @@ package config
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   Server
-	Database Database
+	Server       Server
+	Database     Database
+	ETA          ETA
+	Outbox       Outbox
+	Webhook      Webhook
+	Reminder     Reminder
+	Notification Notification
+	Calendar     Calendar
+	Auth         Auth
+	Tenancy      Tenancy
+	Retention    Retention
 }
 
 type Server struct {
@@ -23,6 +34,125 @@ type Database struct {
 	Name     string
 }
 
+// ETA selects how appointment start times are predicted. Estimator is
+// "moving_average" (over the last Window overruns) or "percentile".
+type ETA struct {
+	Estimator  string
+	Window     int
+	Percentile float64
+}
+
+// Outbox controls how often the outbox dispatcher polls for new events, how
+// many it claims at once, how long a claim keeps other dispatchers away and
+// how many times an event is attempted before it is marked failed.
+type Outbox struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Lease        time.Duration
+	MaxAttempts  int
+}
+
+// Webhook controls outgoing webhook deliveries: the per-request Timeout,
+// how many times a delivery is attempted before it is marked failed, and
+// how often and in what batch size the delivery worker polls. Endpoints on
+// loopback, private and link-local addresses are refused unless
+// AllowPrivateTargets is set, which is meant for local development.
+type Webhook struct {
+	Timeout             time.Duration
+	MaxAttempts         int
+	PollInterval        time.Duration
+	BatchSize           int
+	AllowPrivateTargets bool
+}
+
+// Reminder configures appointment reminders: one is sent LeadTimes before
+// each confirmed appointment, checked every PollInterval.
+type Reminder struct {
+	LeadTimes    []time.Duration
+	PollInterval time.Duration
+}
+
+// Notification selects the channel notifications are sent through:
+// "console" (the default, logs them), "smtp" or "sms". Templates in
+// TemplateDir override the built-in ones of the same name.
+type Notification struct {
+	Channel     string
+	TemplateDir string
+	SMTP        SMTP
+	SMS         SMS
+}
+
+// SMTP configures the mail server. Timeout bounds connecting to it and
+// sending one message.
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// SMS configures a generic HTTP SMS gateway that accepts a JSON POST.
+type SMS struct {
+	GatewayURL string
+	Token      string
+	Sender     string
+	Timeout    time.Duration
+}
+
+// Calendar configures iCalendar export and import. UIDDomain is the
+// right-hand side of exported event UIDs and should be a domain the
+// deployment owns. Imported calendars are expanded ImportHorizon ahead and
+// fetched again every RefreshInterval, checked every PollInterval. As with
+// webhooks, calendars on non-public addresses are refused unless
+// AllowPrivateURLs is set.
+type Calendar struct {
+	UIDDomain        string
+	ImportHorizon    time.Duration
+	RefreshInterval  time.Duration
+	PollInterval     time.Duration
+	FetchTimeout     time.Duration
+	AllowPrivateURLs bool
+}
+
+// Auth configures login. Access tokens are JWTs signed with JWTSecret and
+// valid for AccessTokenTTL; the secret comes from AUTH_JWT_SECRET, which
+// is required and must be at least 32 characters. Refresh tokens last
+// RefreshTokenTTL and are replaced on every use. Magic links are MagicLinkURL with the token
+// appended as a query parameter and expire after MagicLinkTTL. Each client
+// IP may try to log in or request a magic link RateLimit times per
+// RateLimitWindow; zero turns the limit off.
+type Auth struct {
+	JWTSecret       string
+	Issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	MagicLinkURL    string
+	MagicLinkTTL    time.Duration
+	RateLimit       int
+	RateLimitWindow time.Duration
+}
+
+// Tenancy configures how a request is matched to an organization: by the
+// slug in Header, or by the first label of a host under BaseDomain, so
+// "north.clinics.example" is the "north" organization. Requests naming
+// neither belong to DefaultOrganization, which is created on startup so
+// single-clinic deployments need no setup.
+type Tenancy struct {
+	Header              string
+	BaseDomain          string
+	DefaultOrganization string
+}
+
+// Retention configures how long deleted users and appointments can be
+// restored: they are purged for good once they have been deleted for
+// Period, checked every PollInterval. A zero Period keeps them forever.
+type Retention struct {
+	Period       time.Duration
+	PollInterval time.Duration
+}
+
 func NewConfig() (*Config, error) {
 
 	var config Config
@@ -34,9 +164,125 @@ func NewConfig() (*Config, error) {
 	config.Database.Password = viper.GetString("DATABASE_PASSWORD")
 	config.Database.Name = viper.GetString("DATABASE_NAME")
 
+	viper.SetDefault("ETA_ESTIMATOR", "moving_average")
+	viper.SetDefault("ETA_WINDOW", 3)
+	viper.SetDefault("ETA_PERCENTILE", 80)
+	config.ETA.Estimator = viper.GetString("ETA_ESTIMATOR")
+	config.ETA.Window = viper.GetInt("ETA_WINDOW")
+	config.ETA.Percentile = viper.GetFloat64("ETA_PERCENTILE")
+
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", "500ms")
+	viper.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	viper.SetDefault("OUTBOX_LEASE", "5m")
+	viper.SetDefault("OUTBOX_MAX_ATTEMPTS", 10)
+	config.Outbox.PollInterval = viper.GetDuration("OUTBOX_POLL_INTERVAL")
+	config.Outbox.BatchSize = viper.GetInt("OUTBOX_BATCH_SIZE")
+	config.Outbox.Lease = viper.GetDuration("OUTBOX_LEASE")
+	config.Outbox.MaxAttempts = viper.GetInt("OUTBOX_MAX_ATTEMPTS")
+
+	viper.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	viper.SetDefault("WEBHOOK_POLL_INTERVAL", "1s")
+	viper.SetDefault("WEBHOOK_BATCH_SIZE", 50)
+	viper.SetDefault("WEBHOOK_ALLOW_PRIVATE_TARGETS", false)
+	config.Webhook.Timeout = viper.GetDuration("WEBHOOK_TIMEOUT")
+	config.Webhook.MaxAttempts = viper.GetInt("WEBHOOK_MAX_ATTEMPTS")
+	config.Webhook.PollInterval = viper.GetDuration("WEBHOOK_POLL_INTERVAL")
+	config.Webhook.BatchSize = viper.GetInt("WEBHOOK_BATCH_SIZE")
+	config.Webhook.AllowPrivateTargets = viper.GetBool("WEBHOOK_ALLOW_PRIVATE_TARGETS")
+
+	viper.SetDefault("REMINDER_LEAD_TIMES", "24h,1h")
+	viper.SetDefault("REMINDER_POLL_INTERVAL", "1m")
+	leadTimes, err := parseDurations(viper.GetString("REMINDER_LEAD_TIMES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REMINDER_LEAD_TIMES: %w", err)
+	}
+	config.Reminder.LeadTimes = leadTimes
+	config.Reminder.PollInterval = viper.GetDuration("REMINDER_POLL_INTERVAL")
+
+	viper.SetDefault("NOTIFICATION_CHANNEL", "console")
+	viper.SetDefault("SMTP_PORT", "587")
+	viper.SetDefault("SMTP_TIMEOUT", "10s")
+	viper.SetDefault("SMS_GATEWAY_TIMEOUT", "10s")
+	config.Notification.Channel = viper.GetString("NOTIFICATION_CHANNEL")
+	config.Notification.TemplateDir = viper.GetString("NOTIFICATION_TEMPLATE_DIR")
+	config.Notification.SMTP.Host = viper.GetString("SMTP_HOST")
+	config.Notification.SMTP.Port = viper.GetString("SMTP_PORT")
+	config.Notification.SMTP.Username = viper.GetString("SMTP_USERNAME")
+	config.Notification.SMTP.Password = viper.GetString("SMTP_PASSWORD")
+	config.Notification.SMTP.From = viper.GetString("SMTP_FROM")
+	config.Notification.SMTP.Timeout = viper.GetDuration("SMTP_TIMEOUT")
+	config.Notification.SMS.GatewayURL = viper.GetString("SMS_GATEWAY_URL")
+	config.Notification.SMS.Token = viper.GetString("SMS_GATEWAY_TOKEN")
+	config.Notification.SMS.Sender = viper.GetString("SMS_SENDER")
+	config.Notification.SMS.Timeout = viper.GetDuration("SMS_GATEWAY_TIMEOUT")
+
+	viper.SetDefault("CALENDAR_UID_DOMAIN", "queue-system.local")
+	viper.SetDefault("CALENDAR_IMPORT_HORIZON", "2160h")
+	viper.SetDefault("CALENDAR_REFRESH_INTERVAL", "15m")
+	viper.SetDefault("CALENDAR_POLL_INTERVAL", "1m")
+	viper.SetDefault("CALENDAR_FETCH_TIMEOUT", "30s")
+	config.Calendar.UIDDomain = viper.GetString("CALENDAR_UID_DOMAIN")
+	config.Calendar.ImportHorizon = viper.GetDuration("CALENDAR_IMPORT_HORIZON")
+	config.Calendar.RefreshInterval = viper.GetDuration("CALENDAR_REFRESH_INTERVAL")
+	config.Calendar.PollInterval = viper.GetDuration("CALENDAR_POLL_INTERVAL")
+	config.Calendar.FetchTimeout = viper.GetDuration("CALENDAR_FETCH_TIMEOUT")
+	config.Calendar.AllowPrivateURLs = viper.GetBool("CALENDAR_ALLOW_PRIVATE_URLS")
+
+	viper.SetDefault("AUTH_ISSUER", "queue-system")
+	viper.SetDefault("AUTH_ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("AUTH_REFRESH_TOKEN_TTL", "720h")
+	viper.SetDefault("AUTH_MAGIC_LINK_URL", "http://localhost:8080/login/magic")
+	viper.SetDefault("AUTH_MAGIC_LINK_TTL", "15m")
+	viper.SetDefault("AUTH_RATE_LIMIT", 10)
+	viper.SetDefault("AUTH_RATE_LIMIT_WINDOW", "1m")
+	config.Auth.JWTSecret = viper.GetString("AUTH_JWT_SECRET")
+	if len(config.Auth.JWTSecret) < 32 {
+		return nil, fmt.Errorf("AUTH_JWT_SECRET must be at least 32 characters")
+	}
+	config.Auth.Issuer = viper.GetString("AUTH_ISSUER")
+	config.Auth.AccessTokenTTL = viper.GetDuration("AUTH_ACCESS_TOKEN_TTL")
+	config.Auth.RefreshTokenTTL = viper.GetDuration("AUTH_REFRESH_TOKEN_TTL")
+	config.Auth.MagicLinkURL = viper.GetString("AUTH_MAGIC_LINK_URL")
+	config.Auth.MagicLinkTTL = viper.GetDuration("AUTH_MAGIC_LINK_TTL")
+	config.Auth.RateLimit = viper.GetInt("AUTH_RATE_LIMIT")
+	config.Auth.RateLimitWindow = viper.GetDuration("AUTH_RATE_LIMIT_WINDOW")
+
+	viper.SetDefault("TENANCY_HEADER", "X-Organization")
+	viper.SetDefault("TENANCY_DEFAULT_ORGANIZATION", "default")
+	config.Tenancy.Header = viper.GetString("TENANCY_HEADER")
+	config.Tenancy.BaseDomain = strings.ToLower(viper.GetString("TENANCY_BASE_DOMAIN"))
+	config.Tenancy.DefaultOrganization = viper.GetString("TENANCY_DEFAULT_ORGANIZATION")
+
+	viper.SetDefault("RETENTION_PERIOD", "2160h")
+	viper.SetDefault("RETENTION_POLL_INTERVAL", "1h")
+	config.Retention.Period = viper.GetDuration("RETENTION_PERIOD")
+	config.Retention.PollInterval = viper.GetDuration("RETENTION_POLL_INTERVAL")
+
 	return &config, nil
 }
 
+// parseDurations parses a comma separated list such as "24h,1h". Blank
+// entries are ignored.
+func parseDurations(value string) ([]time.Duration, error) {
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, err
+		}
+		if duration < time.Minute {
+			return nil, fmt.Errorf("%s is shorter than a minute", part)
+		}
+		durations = append(durations, duration)
+	}
+	return durations, nil
+}
+
 func InitViper() error {
 	viper.SetConfigName(".env")
 	viper.SetConfigType("env")
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"queue_system/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// migrateAppointmentSeriesOrganizations gives the series of a database from
+// before series belonged to an organization the organization of their user.
+func migrateAppointmentSeriesOrganizations(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.AppointmentSeries{}) || migrator.HasColumn(&model.AppointmentSeries{}, "organization_id") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`ALTER TABLE appointment_series ADD COLUMN organization_id bigint`,
+			`UPDATE appointment_series SET organization_id = users.organization_id FROM users WHERE users.id = appointment_series.user_id`,
+			`DELETE FROM appointment_series WHERE organization_id IS NULL`,
+			`ALTER TABLE appointment_series ALTER COLUMN organization_id SET NOT NULL`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"queue_system/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// migrateCalendarFeedTokens replaces the plaintext feed tokens of a
+// database from before they were hashed with their hashes, so existing
+// subscription URLs keep working.
+func migrateCalendarFeedTokens(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.CalendarFeed{}) || !migrator.HasColumn(&model.CalendarFeed{}, "token") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`ALTER TABLE calendar_feeds ADD COLUMN IF NOT EXISTS token_hash text`,
+			`UPDATE calendar_feeds SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex')`,
+			`ALTER TABLE calendar_feeds DROP COLUMN token`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateCalendarFeedOrganizations gives the feeds of a database from
+// before feeds were found by token the organization of their user.
+func migrateCalendarFeedOrganizations(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.CalendarFeed{}) || migrator.HasColumn(&model.CalendarFeed{}, "organization_id") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`ALTER TABLE calendar_feeds ADD COLUMN organization_id bigint`,
+			`UPDATE calendar_feeds SET organization_id = users.organization_id FROM users WHERE users.id = calendar_feeds.user_id`,
+			`DELETE FROM calendar_feeds WHERE organization_id IS NULL`,
+			`ALTER TABLE calendar_feeds ALTER COLUMN organization_id SET NOT NULL`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
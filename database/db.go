@@ -19,10 +19,28 @@ func NewDatabase(cfg *config.Config) (*gorm.DB, error) {
 		cfg.Database.Port,
 	)
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-
-	db.AutoMigrate(&model.User{}, &model.Appointment{})
 	if err != nil {
 		return nil, err
 	}
-	return db, err
+	if err := migrateOrganizations(db, cfg.Tenancy.DefaultOrganization); err != nil {
+		return nil, fmt.Errorf("failed to migrate organizations: %w", err)
+	}
+	if err := migrateSoftDeletes(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate soft deletes: %w", err)
+	}
+	if err := migrateLegacyRoles(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate legacy roles: %w", err)
+	}
+	if err := migrateCalendarFeedTokens(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate calendar feed tokens: %w", err)
+	}
+	if err := migrateCalendarFeedOrganizations(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate calendar feed organizations: %w", err)
+	}
+	if err := migrateAppointmentSeriesOrganizations(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate appointment series organizations: %w", err)
+	}
+
+	db.AutoMigrate(&model.Organization{}, &model.User{}, &model.Appointment{}, &model.AppointmentSeries{}, &model.WorkingHours{}, &model.AvailabilityOverride{}, &model.Queue{}, &model.QueueTicket{}, &model.OutboxEvent{}, &model.Webhook{}, &model.WebhookDelivery{}, &model.AppointmentReminder{}, &model.SentNotification{}, &model.CalendarFeed{}, &model.BusyCalendar{}, &model.BusyBlock{}, &model.RefreshToken{}, &model.MagicLink{}, &model.APIKey{})
+	return db, nil
 }
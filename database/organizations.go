@@ -0,0 +1,58 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"queue_system/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// tenantTables are the tables whose rows belong to an organization.
+var tenantTables = []string{
+	"users", "appointments", "refresh_tokens", "magic_links", "api_keys",
+	"queues", "queue_tickets", "webhooks", "webhook_deliveries", "outbox_events",
+}
+
+// migrateOrganizations creates the default organization and prepares a
+// database from before organizations for AutoMigrate: existing rows are
+// moved into the default organization and the global unique constraints on
+// user emails and queue names are dropped.
+func migrateOrganizations(db *gorm.DB, defaultSlug string) error {
+	if err := db.AutoMigrate(&model.Organization{}); err != nil {
+		return err
+	}
+	var defaultOrganization model.Organization
+	if defaultSlug != "" {
+		err := db.Where(model.Organization{Slug: defaultSlug}).
+			Attrs(model.Organization{Name: defaultSlug}).
+			FirstOrCreate(&defaultOrganization).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	migrator := db.Migrator()
+	for _, table := range tenantTables {
+		if !migrator.HasTable(table) || migrator.HasColumn(table, "organization_id") {
+			continue
+		}
+		if defaultOrganization.ID == 0 {
+			return errors.New("existing data needs TENANCY_DEFAULT_ORGANIZATION to migrate into")
+		}
+		// The ID is an integer, and DDL cannot take bound parameters.
+		err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN organization_id bigint NOT NULL DEFAULT %d", table, defaultOrganization.ID)).Error
+		if err != nil {
+			return fmt.Errorf("failed to add organization to %s: %w", table, err)
+		}
+	}
+	if migrator.HasConstraint(&model.User{}, "uni_users_email") {
+		if err := migrator.DropConstraint(&model.User{}, "uni_users_email"); err != nil {
+			return err
+		}
+	}
+	if migrator.HasIndex(&model.Queue{}, "idx_queue_name_service_point") {
+		return migrator.DropIndex(&model.Queue{}, "idx_queue_name_service_point")
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package database
+
+import (
+	"queue_system/internal/enums"
+	"queue_system/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// legacyMemberRole is the role most users were given before roles were
+// enforced. It grants nothing, so those users become clients.
+const legacyMemberRole = "member"
+
+// migrateLegacyRoles gives users with the legacy member role the client
+// role.
+func migrateLegacyRoles(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.User{}) {
+		return nil
+	}
+	return db.Model(&model.User{}).Unscoped().
+		Where("role = ?", legacyMemberRole).
+		Update("role", string(enums.RoleClient)).Error
+}
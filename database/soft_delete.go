@@ -0,0 +1,25 @@
+package database
+
+import (
+	"queue_system/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// usersEmailIndex is the unique index on user emails, which only covers
+// users that are not deleted.
+const usersEmailIndex = "idx_users_organization_email"
+
+// migrateSoftDeletes prepares a database from before soft deletion for
+// AutoMigrate: the unique index on user emails is dropped so that it is
+// recreated over users that are not deleted only.
+func migrateSoftDeletes(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.User{}) || migrator.HasColumn(&model.User{}, "DeletedAt") {
+		return nil
+	}
+	if migrator.HasIndex(&model.User{}, usersEmailIndex) {
+		return migrator.DropIndex(&model.User{}, usersEmailIndex)
+	}
+	return nil
+}
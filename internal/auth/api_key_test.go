@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, keyHash, prefix, err := NewAPIKey()
+
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(key, prefix))
+	assert.Len(t, prefix, len(APIKeyPrefix)+8)
+	assert.Equal(t, HashToken(key), keyHash)
+}
+
+func TestHasScope_WriteIncludesRead(t *testing.T) {
+	key := &model.APIKey{Scopes: []string{"appointments:write", "queues:read"}}
+
+	assert.True(t, HasScope(key, ScopeAppointmentsRead))
+	assert.True(t, HasScope(key, ScopeAppointmentsWrite))
+	assert.True(t, HasScope(key, ScopeQueuesRead))
+	assert.False(t, HasScope(key, ScopeQueuesWrite))
+	assert.False(t, HasScope(key, ScopeUsersRead))
+}
+
+func TestMiddleware_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	owner := &model.User{ID: 7, OrganizationID: 3, Role: "staff"}
+
+	for name, tc := range map[string]struct {
+		key      *model.APIKey
+		method   string
+		touched  bool
+		expected int
+	}{
+		"read with read scope":        {&model.APIKey{ID: 1, OrganizationID: 3, UserID: 7, Scopes: []string{"appointments:read"}}, http.MethodGet, true, http.StatusOK},
+		"write with read scope":       {&model.APIKey{ID: 1, OrganizationID: 3, UserID: 7, Scopes: []string{"appointments:read"}}, http.MethodPost, true, http.StatusForbidden},
+		"other resource":              {&model.APIKey{ID: 1, OrganizationID: 3, UserID: 7, Scopes: []string{"queues:write"}}, http.MethodGet, true, http.StatusForbidden},
+		"recently used is not stored": {&model.APIKey{ID: 1, OrganizationID: 3, UserID: 7, Scopes: []string{"appointments:read"}, LastUsedAt: ptrTime(now.Add(-time.Second))}, http.MethodGet, false, http.StatusOK},
+		"revoked":                     {&model.APIKey{ID: 1, OrganizationID: 3, UserID: 7, RevokedAt: ptrTime(now.Add(-time.Hour))}, http.MethodGet, false, http.StatusUnauthorized},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockAuthRepo := mocks.NewMockAuthRepository(ctrl)
+			mockUserRepo := mocks.NewMockUserRepository(ctrl)
+			middleware := NewMiddleware(newTestTokens("0123456789abcdef0123456789abcdef"), mockUserRepo, mockAuthRepo)
+			middleware.now = func() time.Time { return now }
+
+			mockAuthRepo.EXPECT().GetAPIKeyByHash(HashToken("qsk_secret")).Return(tc.key, nil)
+			if tc.key.RevokedAt == nil {
+				mockUserRepo.EXPECT().GetById(uint(3), uint(7)).Return(owner, nil)
+			}
+			if tc.touched {
+				mockAuthRepo.EXPECT().TouchAPIKey(uint(1), now).Return(nil)
+			}
+
+			var current *model.User
+			router := gin.New()
+			router.Handle(tc.method, "/appointments", middleware.RequireUser(), RequireScope("appointments"), func(ctx *gin.Context) {
+				current = CurrentUser(ctx)
+				ctx.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(tc.method, "/appointments", nil)
+			req.Header.Set("Authorization", "Bearer qsk_secret")
+
+			// WHEN
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// THEN
+			assert.Equal(t, tc.expected, rr.Code, rr.Body.String())
+			if tc.expected == http.StatusOK {
+				assert.Equal(t, owner, current, "the key acts as its creator")
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"queue_system/internal/tenant"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const userKey = "auth.user"
+
+var (
+	errMissingCredentials = errors.New("authentication required")
+	ErrWrongOrganization  = errors.New("credentials belong to another organization")
+	ErrAccountDeactivated = errors.New("account is deactivated")
+)
+
+// lastUsedResolution is how stale an API key's LastUsedAt may get, so that
+// a busy kiosk does not write to the database on every request.
+const lastUsedResolution = time.Minute
+
+// Middleware resolves the credentials of a request to a user, which
+// handlers read with CurrentUser.
+type Middleware struct {
+	tokens         *Tokens
+	userRepository repository.UserRepository
+	authRepository repository.AuthRepository
+	now            func() time.Time
+}
+
+func NewMiddleware(tokens *Tokens, userRepository repository.UserRepository, authRepository repository.AuthRepository) *Middleware {
+	return &Middleware{
+		tokens:         tokens,
+		userRepository: userRepository,
+		authRepository: authRepository,
+		now:            time.Now,
+	}
+}
+
+// RequireUser rejects requests without a valid bearer access token or API
+// key. An API key acts as the user who created it.
+func (m *Middleware) RequireUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := m.bearerUser(ctx)
+		if err != nil {
+			m.reject(ctx, err, `Bearer`)
+			return
+		}
+		ctx.Set(userKey, user)
+		ctx.Next()
+	}
+}
+
+// RequireUserOrBasic also accepts HTTP Basic credentials, the user's email
+// and password, for calendar clients that cannot obtain tokens.
+func (m *Middleware) RequireUserOrBasic(realm string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var (
+			user *model.User
+			err  error
+		)
+		if email, password, ok := ctx.Request.BasicAuth(); ok {
+			user, err = m.passwordUser(tenant.OrganizationID(ctx), email, password)
+		} else {
+			user, err = m.bearerUser(ctx)
+		}
+		if err != nil {
+			m.reject(ctx, err, `Basic realm="`+realm+`", Bearer`)
+			return
+		}
+		ctx.Set(userKey, user)
+		ctx.Next()
+	}
+}
+
+// IdentifyUser authenticates requests that carry a bearer token and lets
+// anonymous ones through, for routes that behave differently for signed-in
+// users. An invalid token is still rejected.
+func (m *Middleware) IdentifyUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			ctx.Next()
+			return
+		}
+		user, err := m.bearerUser(ctx)
+		if err != nil {
+			m.reject(ctx, err, `Bearer`)
+			return
+		}
+		ctx.Set(userKey, user)
+		ctx.Next()
+	}
+}
+
+// RequirePermission rejects users whose role does not grant permission. It
+// must run after RequireUser.
+func RequirePermission(permission Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !Can(CurrentUser(ctx), permission) {
+			Forbid(ctx, ErrPermissionDenied)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequireSelfOr lets users through to routes about themselves, where the
+// path parameter param is their ID, and others only with permission. An
+// unparsable ID is left for the handler to reject.
+func RequireSelfOr(param string, permission Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseUint(ctx.Param(param), 10, 32)
+		user := CurrentUser(ctx)
+		if err == nil && user != nil && uint(id) != user.ID && !Can(user, permission) {
+			Forbid(ctx, ErrNotOwner)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// CurrentUser returns the user authenticated by the middleware, or nil on
+// routes without it.
+func CurrentUser(ctx *gin.Context) *model.User {
+	user, _ := ctx.Get(userKey)
+	current, _ := user.(*model.User)
+	return current
+}
+
+func (m *Middleware) bearerUser(ctx *gin.Context) (*model.User, error) {
+	token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	token = strings.TrimSpace(token)
+	if !ok || token == "" {
+		return nil, errMissingCredentials
+	}
+	if strings.HasPrefix(token, APIKeyPrefix) {
+		return m.apiKeyUser(ctx, token)
+	}
+	claims, err := m.tokens.Verify(token, m.now())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, err
+	}
+	if err := holdToOrganization(ctx, claims.OrganizationID); err != nil {
+		return nil, err
+	}
+	user, err := m.userRepository.GetById(claims.OrganizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+	if !user.Active() {
+		return nil, ErrAccountDeactivated
+	}
+	return user, nil
+}
+
+// apiKeyUser resolves an API key to its creator and remembers the key for
+// RequireScope.
+func (m *Middleware) apiKeyUser(ctx *gin.Context, token string) (*model.User, error) {
+	key, err := m.authRepository.GetAPIKeyByHash(HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if err := holdToOrganization(ctx, key.OrganizationID); err != nil {
+		return nil, err
+	}
+	user, err := m.userRepository.GetById(key.OrganizationID, key.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if !user.Active() {
+		return nil, ErrAccountDeactivated
+	}
+
+	now := m.now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := m.authRepository.TouchAPIKey(key.ID, now); err != nil {
+			log.Warn().Err(err).Uint("apiKeyID", key.ID).Msg("Failed to record API key use")
+		}
+		key.LastUsedAt = &now
+	}
+	ctx.Set(apiKeyKey, key)
+	return user, nil
+}
+
+// holdToOrganization makes the credentials' organization the request's,
+// unless the request explicitly named another one.
+func holdToOrganization(ctx *gin.Context, organizationID uint) error {
+	if tenant.Explicit(ctx) && tenant.OrganizationID(ctx) != organizationID {
+		return ErrWrongOrganization
+	}
+	tenant.Set(ctx, organizationID, true)
+	return nil
+}
+
+func (m *Middleware) passwordUser(organizationID uint, email, password string) (*model.User, error) {
+	user, err := m.userRepository.GetByEmail(organizationID, email)
+	if err != nil {
+		return nil, err
+	}
+	hash := ""
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	if !CheckPassword(hash, password) || !user.Active() {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func (m *Middleware) reject(ctx *gin.Context, err error, challenge string) {
+	switch {
+	case errors.Is(err, errMissingCredentials),
+		errors.Is(err, ErrInvalidToken),
+		errors.Is(err, ErrTokenExpired),
+		errors.Is(err, ErrInvalidAPIKey),
+		errors.Is(err, ErrWrongOrganization),
+		errors.Is(err, ErrAccountDeactivated),
+		errors.Is(err, ErrInvalidCredentials):
+		ctx.Header("WWW-Authenticate", challenge)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg("Failed to authenticate request")
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate request"})
+	}
+}
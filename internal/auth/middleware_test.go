@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"queue_system/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_HoldsToTokenOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		resolved uint
+		explicit bool
+		expected int
+	}{
+		"default organization":       {1, false, http.StatusOK},
+		"same organization named":    {3, true, http.StatusOK},
+		"another organization named": {4, true, http.StatusUnauthorized},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockUserRepo := mocks.NewMockUserRepository(ctrl)
+			tokens := newTestTokens("0123456789abcdef0123456789abcdef")
+			middleware := NewMiddleware(tokens, mockUserRepo, mocks.NewMockAuthRepository(ctrl))
+			middleware.now = func() time.Time { return now }
+			token, _, err := tokens.Issue(7, 3, now)
+			require.NoError(t, err)
+
+			if tc.expected == http.StatusOK {
+				mockUserRepo.EXPECT().GetById(uint(3), uint(7)).Return(&model.User{ID: 7, OrganizationID: 3}, nil)
+			}
+
+			var organizationID uint
+			router := gin.New()
+			router.GET("/me", func(ctx *gin.Context) {
+				tenant.Set(ctx, tc.resolved, tc.explicit)
+			}, middleware.RequireUser(), func(ctx *gin.Context) {
+				organizationID = tenant.OrganizationID(ctx)
+				ctx.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			// WHEN
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// THEN
+			assert.Equal(t, tc.expected, rr.Code, rr.Body.String())
+			if tc.expected == http.StatusOK {
+				assert.Equal(t, uint(3), organizationID, "the token's organization is the request's")
+			}
+		})
+	}
+}
+
+func TestMiddleware_RejectsDeactivatedUser(t *testing.T) {
+	// GIVEN
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	tokens := newTestTokens("0123456789abcdef0123456789abcdef")
+	middleware := NewMiddleware(tokens, mockUserRepo, mocks.NewMockAuthRepository(ctrl))
+	middleware.now = func() time.Time { return now }
+	token, _, err := tokens.Issue(7, 3, now)
+	require.NoError(t, err)
+	deactivatedAt := now.Add(-time.Minute)
+
+	mockUserRepo.EXPECT().GetById(uint(3), uint(7)).Return(&model.User{ID: 7, OrganizationID: 3, DeactivatedAt: &deactivatedAt}, nil)
+
+	router := gin.New()
+	router.GET("/me", middleware.RequireUser(), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	// WHEN
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	// THEN
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), ErrAccountDeactivated.Error())
+}
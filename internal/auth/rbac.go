@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"net/http"
+	"queue_system/internal/enums"
+	"queue_system/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission names something a role may do beyond acting on its own
+// appointments and resources.
+type Permission string
+
+const (
+	// ManageUsers covers creating staff, providers and admins, and other
+	// users' calendar feeds and imported calendars.
+	ManageUsers Permission = "users:manage"
+	// ManageAppointments covers booking for, viewing and changing any
+	// user's appointments.
+	ManageAppointments Permission = "appointments:manage"
+	// ConfirmAppointments lets the participant of an appointment confirm,
+	// start and complete it.
+	ConfirmAppointments Permission = "appointments:confirm"
+	// ManageSchedules covers other users' working hours, availability
+	// overrides and waiting lines.
+	ManageSchedules Permission = "schedules:manage"
+	ManageQueues    Permission = "queues:manage"
+	ManageWebhooks  Permission = "webhooks:manage"
+	// RestoreDeleted covers listing and restoring deleted users and
+	// appointments.
+	RestoreDeleted Permission = "deleted:restore"
+	// ManageOrganizations covers creating organizations. Only admins of
+	// the default organization may use it.
+	ManageOrganizations Permission = "organizations:manage"
+)
+
+// rolePermissions is the permission matrix. Users whose role is not listed
+// have no permissions; the legacy member role is migrated to client on
+// startup.
+var rolePermissions = map[enums.Role][]Permission{
+	enums.RoleAdmin:    {ManageUsers, ManageAppointments, ConfirmAppointments, ManageSchedules, ManageQueues, ManageWebhooks, RestoreDeleted, ManageOrganizations},
+	enums.RoleStaff:    {ManageAppointments, ConfirmAppointments, ManageSchedules, ManageQueues},
+	enums.RoleProvider: {ConfirmAppointments},
+	enums.RoleClient:   {},
+}
+
+// Can reports whether the user's role grants the permission.
+func Can(user *model.User, permission Permission) bool {
+	if user == nil {
+		return false
+	}
+	for _, granted := range rolePermissions[enums.Role(user.Role)] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Denial is returned when a user may not do something. Reason is a stable
+// code clients can act on; Message is for people.
+type Denial struct {
+	Reason  string
+	Message string
+}
+
+func (d *Denial) Error() string {
+	return d.Message
+}
+
+var (
+	ErrPermissionDenied    = &Denial{Reason: "permission_denied", Message: "your role does not allow this"}
+	ErrNotOwner            = &Denial{Reason: "not_owner", Message: "you may only access your own resources"}
+	ErrBookingForOthers    = &Denial{Reason: "booking_for_others", Message: "you may only book appointments for yourself"}
+	ErrNotAppointmentParty = &Denial{Reason: "not_appointment_party", Message: "you are not the creator or participant of this appointment"}
+	ErrNotAppointmentHost  = &Denial{Reason: "not_appointment_host", Message: "only the appointment's participant provider may do this"}
+	ErrRoleNotAssignable   = &Denial{Reason: "role_not_assignable", Message: "only admins may assign this role"}
+	ErrOperatorsOnly       = &Denial{Reason: "operators_only", Message: "only admins of the default organization may do this"}
+)
+
+// Forbid ends the request with 403 and the denial's reason.
+func Forbid(ctx *gin.Context, denial *Denial) {
+	ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": denial.Message, "reason": denial.Reason})
+}
+
+// CanBookFor checks that the user may book an appointment created by
+// creatorID.
+func CanBookFor(user *model.User, creatorID uint) error {
+	if user.ID == creatorID || Can(user, ManageAppointments) {
+		return nil
+	}
+	return ErrBookingForOthers
+}
+
+// CanAccessAppointment checks that the user may view, reschedule or cancel
+// the appointment.
+func CanAccessAppointment(user *model.User, appointment *model.Appointment) error {
+	if user.ID == appointment.UserID || user.ID == appointment.ParticipantID || Can(user, ManageAppointments) {
+		return nil
+	}
+	return ErrNotAppointmentParty
+}
+
+// CanHostAppointment checks that the user may confirm, start or complete
+// the appointment.
+func CanHostAppointment(user *model.User, appointment *model.Appointment) error {
+	if Can(user, ManageAppointments) {
+		return nil
+	}
+	if user.ID == appointment.ParticipantID && Can(user, ConfirmAppointments) {
+		return nil
+	}
+	return ErrNotAppointmentHost
+}
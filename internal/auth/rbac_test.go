@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"queue_system/internal/model"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCan_FollowsPermissionMatrix(t *testing.T) {
+	admin := &model.User{ID: 1, Role: "admin"}
+	staff := &model.User{ID: 2, Role: "staff"}
+	provider := &model.User{ID: 3, Role: "provider"}
+	client := &model.User{ID: 4, Role: "client"}
+	legacy := &model.User{ID: 5, Role: "member"}
+
+	assert.True(t, Can(admin, ManageUsers))
+	assert.True(t, Can(admin, ManageWebhooks))
+	assert.True(t, Can(staff, ManageQueues))
+	assert.False(t, Can(staff, ManageUsers))
+	assert.False(t, Can(staff, ManageWebhooks))
+	assert.True(t, Can(provider, ConfirmAppointments))
+	assert.False(t, Can(provider, ManageAppointments))
+	assert.False(t, Can(client, ConfirmAppointments))
+	assert.False(t, Can(legacy, ConfirmAppointments), "unknown roles grant nothing")
+	assert.False(t, Can(nil, ConfirmAppointments))
+}
+
+func TestCanHostAppointment(t *testing.T) {
+	appointment := &model.Appointment{UserID: 1, ParticipantID: 2}
+
+	assert.NoError(t, CanHostAppointment(&model.User{ID: 2, Role: "provider"}, appointment))
+	assert.NoError(t, CanHostAppointment(&model.User{ID: 9, Role: "staff"}, appointment))
+	assert.Equal(t, ErrNotAppointmentHost, CanHostAppointment(&model.User{ID: 2, Role: "client"}, appointment),
+		"the participant needs a role that may confirm")
+	assert.Equal(t, ErrNotAppointmentHost, CanHostAppointment(&model.User{ID: 1, Role: "client"}, appointment),
+		"creators cannot confirm their own bookings")
+	assert.Equal(t, ErrNotAppointmentHost, CanHostAppointment(&model.User{ID: 9, Role: "provider"}, appointment))
+}
+
+func TestRequireSelfOr(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for name, tc := range map[string]struct {
+		user     *model.User
+		path     string
+		expected int
+	}{
+		"own resource":          {&model.User{ID: 4, Role: "client"}, "/users/4", http.StatusOK},
+		"someone else's":        {&model.User{ID: 4, Role: "client"}, "/users/5", http.StatusForbidden},
+		"granted by role":       {&model.User{ID: 1, Role: "admin"}, "/users/5", http.StatusOK},
+		"left to the handler":   {&model.User{ID: 4, Role: "client"}, "/users/abc", http.StatusOK},
+		"role lacks permission": {&model.User{ID: 2, Role: "staff"}, "/users/5", http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/users/:id", func(ctx *gin.Context) {
+				ctx.Set(userKey, tc.user)
+			}, RequireSelfOr("id", ManageUsers), func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.expected, rr.Code)
+			if tc.expected == http.StatusForbidden {
+				assert.Contains(t, rr.Body.String(), `"reason":"not_owner"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Package auth issues and checks the credentials API clients present:
+// JWT access tokens, opaque refresh and magic-link tokens, and passwords.
+// Its Gin middleware resolves them to the requesting user.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"queue_system/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid access token")
+	ErrTokenExpired = errors.New("access token has expired")
+)
+
+// header is the only JWT header access tokens are signed with. Tokens
+// naming another algorithm are rejected rather than verified with it.
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are the registered JWT claims of an access token, plus the user's
+// organization. Subject is the user's ID.
+type Claims struct {
+	Subject        string `json:"sub"`
+	OrganizationID uint   `json:"org"`
+	Issuer         string `json:"iss"`
+	IssuedAt       int64  `json:"iat"`
+	ExpiresAt      int64  `json:"exp"`
+	ID             string `json:"jti"`
+}
+
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 32)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidToken
+	}
+	return uint(id), nil
+}
+
+// Tokens signs and verifies HS256 access tokens.
+type Tokens struct {
+	secret []byte
+	issuer string
+	ttl    time.Duration
+}
+
+func NewTokens(cfg *config.Config) *Tokens {
+	return &Tokens{
+		secret: []byte(cfg.Auth.JWTSecret),
+		issuer: cfg.Auth.Issuer,
+		ttl:    cfg.Auth.AccessTokenTTL,
+	}
+}
+
+// Issue returns an access token for the user of the organization and when
+// it expires.
+func (t *Tokens) Issue(userID, organizationID uint, now time.Time) (string, time.Time, error) {
+	id, _, err := NewOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := now.Add(t.ttl)
+	payload, err := json.Marshal(Claims{
+		Subject:        strconv.FormatUint(uint64(userID), 10),
+		OrganizationID: organizationID,
+		Issuer:         t.issuer,
+		IssuedAt:       now.Unix(),
+		ExpiresAt:      expiresAt.Unix(),
+		ID:             id[:16],
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + t.sign(unsigned), expiresAt, nil
+}
+
+// Verify checks the token's signature, issuer and expiry and returns its
+// claims.
+func (t *Tokens) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(t.sign(parts[0]+"."+parts[1]))) {
+		return nil, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Issuer != t.issuer {
+		return nil, ErrInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+func (t *Tokens) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NewOpaqueToken returns a random token for a client to hold and the hash
+// to store in its place.
+func NewOpaqueToken() (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(secret)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the stored form of an opaque token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
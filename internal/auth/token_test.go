@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"encoding/base64"
+	"queue_system/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTokens(secret string) *Tokens {
+	return NewTokens(&config.Config{Auth: config.Auth{
+		JWTSecret:      secret,
+		Issuer:         "queue-system",
+		AccessTokenTTL: 15 * time.Minute,
+	}})
+}
+
+func TestTokens_IssueAndVerify(t *testing.T) {
+	// GIVEN
+	tokens := newTestTokens("0123456789abcdef0123456789abcdef")
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	// WHEN
+	token, expiresAt, err := tokens.Issue(42, 3, now)
+	require.NoError(t, err)
+	claims, err := tokens.Verify(token, now.Add(time.Minute))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(15*time.Minute), expiresAt)
+	userID, err := claims.UserID()
+	require.NoError(t, err)
+	assert.Equal(t, uint(42), userID)
+	assert.Equal(t, uint(3), claims.OrganizationID)
+	assert.Equal(t, "queue-system", claims.Issuer)
+	assert.Equal(t, expiresAt.Unix(), claims.ExpiresAt)
+	assert.NotEmpty(t, claims.ID)
+}
+
+func TestTokens_VerifyRejectsExpiredToken(t *testing.T) {
+	tokens := newTestTokens("0123456789abcdef0123456789abcdef")
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	token, _, err := tokens.Issue(42, 3, now)
+	require.NoError(t, err)
+
+	_, err = tokens.Verify(token, now.Add(15*time.Minute))
+
+	assert.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestTokens_VerifyRejectsForgedTokens(t *testing.T) {
+	tokens := newTestTokens("0123456789abcdef0123456789abcdef")
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	token, _, err := tokens.Issue(42, 3, now)
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+
+	otherKey, _, err := newTestTokens("fedcba9876543210fedcba9876543210").Issue(42, 3, now)
+	require.NoError(t, err)
+	otherIssuer := NewTokens(&config.Config{Auth: config.Auth{
+		JWTSecret: "0123456789abcdef0123456789abcdef", Issuer: "elsewhere", AccessTokenTTL: time.Hour,
+	}})
+	wrongIssuer, _, err := otherIssuer.Issue(42, 3, now)
+	require.NoError(t, err)
+
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1","iss":"queue-system","exp":4102444800}`))
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+
+	for name, forged := range map[string]string{
+		"other key":        otherKey,
+		"other issuer":     wrongIssuer,
+		"tampered payload": parts[0] + "." + tampered + "." + parts[2],
+		"alg none":         none + "." + parts[1] + ".",
+		"not a jwt":        "not-a-token",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := tokens.Verify(forged, now)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestPassword_HashAndCheck(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	require.NoError(t, err)
+
+	assert.True(t, CheckPassword(hash, "correct horse"))
+	assert.False(t, CheckPassword(hash, "wrong horse"))
+	assert.False(t, CheckPassword("", ""), "users without a password cannot log in with one")
+
+	_, err = HashPassword(strings.Repeat("x", 73))
+	assert.ErrorIs(t, err, ErrPasswordTooLong)
+}
@@ -0,0 +1,269 @@
+// Package caldav reads and writes the WebDAV (RFC 4918) and CalDAV
+// (RFC 4791) XML that calendar clients exchange with appointment
+// collections.
+package caldav
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	NamespaceDAV            = "DAV:"
+	NamespaceCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	NamespaceCalendarServer = "http://calendarserver.org/ns/"
+
+	timeRangeLayout = "20060102T150405Z"
+)
+
+// Request kinds.
+var (
+	Propfind         = xml.Name{Space: NamespaceDAV, Local: "propfind"}
+	CalendarQuery    = xml.Name{Space: NamespaceCalDAV, Local: "calendar-query"}
+	CalendarMultiget = xml.Name{Space: NamespaceCalDAV, Local: "calendar-multiget"}
+)
+
+var ErrInvalidRequest = errors.New("invalid WebDAV request body")
+
+// prefixes are declared on every multistatus, so property values may use
+// them.
+var prefixes = map[string]string{
+	NamespaceDAV:            "d",
+	NamespaceCalDAV:         "c",
+	NamespaceCalendarServer: "cs",
+}
+
+// Object is one calendar object resource: a single appointment.
+type Object struct {
+	Name  string
+	ETag  string
+	Start time.Time
+	End   time.Time
+	Data  []byte
+}
+
+// CTag changes whenever an object in the collection is added, changed or
+// removed, so clients know when to look for changes.
+func CTag(objects []Object) string {
+	hash := sha256.New()
+	for _, object := range objects {
+		fmt.Fprintf(hash, "%s %s\n", object.Name, object.ETag)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:32]
+}
+
+// Property is a WebDAV property. Value is its content as XML, using the d:,
+// c: and cs: prefixes for the DAV, CalDAV and CalendarServer namespaces.
+type Property struct {
+	Name  xml.Name
+	Value string
+}
+
+// Text returns a property whose content is text.
+func Text(name xml.Name, value string) Property {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(value))
+	return Property{Name: name, Value: buf.String()}
+}
+
+// Href returns a property whose content is a single href.
+func Href(name xml.Name, href string) Property {
+	return Property{Name: name, Value: "<d:href>" + Text(name, href).Value + "</d:href>"}
+}
+
+// Request is a PROPFIND or REPORT body. Props are the properties asked for;
+// AllProps is set when every property is wanted. Hrefs are the resources of
+// a calendar-multiget and Start and End the time-range of a calendar-query.
+type Request struct {
+	Kind     xml.Name
+	AllProps bool
+	Props    []xml.Name
+	Hrefs    []string
+	Start    *time.Time
+	End      *time.Time
+}
+
+// node is any XML element.
+type node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []node     `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// ParseRequest reads a PROPFIND or REPORT body. An empty PROPFIND asks for
+// all properties.
+func ParseRequest(body []byte) (*Request, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return &Request{Kind: Propfind, AllProps: true}, nil
+	}
+	var root node
+	if err := xml.Unmarshal(body, &root); err != nil {
+		return nil, ErrInvalidRequest
+	}
+	if root.XMLName != Propfind && root.XMLName != CalendarQuery && root.XMLName != CalendarMultiget {
+		return nil, ErrInvalidRequest
+	}
+
+	req := &Request{Kind: root.XMLName}
+	for _, child := range root.Children {
+		switch {
+		case child.XMLName == xml.Name{Space: NamespaceDAV, Local: "allprop"}:
+			req.AllProps = true
+		case child.XMLName == xml.Name{Space: NamespaceDAV, Local: "prop"}:
+			for _, prop := range child.Children {
+				req.Props = append(req.Props, prop.XMLName)
+			}
+		case child.XMLName == xml.Name{Space: NamespaceDAV, Local: "href"}:
+			req.Hrefs = append(req.Hrefs, string(bytes.TrimSpace([]byte(child.Text))))
+		case child.XMLName == xml.Name{Space: NamespaceCalDAV, Local: "filter"}:
+			if err := req.readTimeRange(child); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(req.Props) == 0 {
+		req.AllProps = true
+	}
+	return req, nil
+}
+
+// readTimeRange finds the time-range of a calendar-query filter, if any.
+func (r *Request) readTimeRange(filter node) error {
+	for _, child := range filter.Children {
+		if child.XMLName != (xml.Name{Space: NamespaceCalDAV, Local: "time-range"}) {
+			if err := r.readTimeRange(child); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, attr := range child.Attrs {
+			at, err := time.Parse(timeRangeLayout, attr.Value)
+			if err != nil {
+				return ErrInvalidRequest
+			}
+			switch attr.Name.Local {
+			case "start":
+				r.Start = &at
+			case "end":
+				r.End = &at
+			}
+		}
+	}
+	return nil
+}
+
+// Overlaps reports whether object falls within the request's time-range.
+func (r *Request) Overlaps(object Object) bool {
+	return (r.End == nil || object.Start.Before(*r.End)) && (r.Start == nil || object.End.After(*r.Start))
+}
+
+// Response is the multistatus entry of one resource. A response with a
+// Status only reports that status, e.g. 404 for an unknown multiget href.
+type Response struct {
+	Href     string
+	Status   int
+	Found    []Property
+	NotFound []xml.Name
+}
+
+// NewResponse answers req for the resource at href that has the available
+// properties.
+func NewResponse(href string, available []Property, req *Request) Response {
+	response := Response{Href: href}
+	if req.AllProps {
+		response.Found = available
+		return response
+	}
+	for _, name := range req.Props {
+		found := false
+		for _, property := range available {
+			if property.Name == name {
+				response.Found = append(response.Found, property)
+				found = true
+				break
+			}
+		}
+		if !found {
+			response.NotFound = append(response.NotFound, name)
+		}
+	}
+	return response
+}
+
+// Multistatus encodes the responses as a 207 Multi-Status body.
+func Multistatus(responses []Response) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<d:multistatus`)
+	namespaces := make([]string, 0, len(prefixes))
+	for namespace := range prefixes {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		fmt.Fprintf(&buf, ` xmlns:%s="%s"`, prefixes[namespace], namespace)
+	}
+	buf.WriteString(">")
+
+	for _, response := range responses {
+		buf.WriteString("<d:response><d:href>")
+		xml.EscapeText(&buf, []byte(response.Href))
+		buf.WriteString("</d:href>")
+		if response.Status != 0 {
+			writeStatus(&buf, response.Status)
+		}
+		if len(response.Found) > 0 {
+			buf.WriteString("<d:propstat><d:prop>")
+			for _, property := range response.Found {
+				writeElement(&buf, property.Name, property.Value)
+			}
+			buf.WriteString("</d:prop>")
+			writeStatus(&buf, http.StatusOK)
+			buf.WriteString("</d:propstat>")
+		}
+		if len(response.NotFound) > 0 {
+			buf.WriteString("<d:propstat><d:prop>")
+			for _, name := range response.NotFound {
+				writeElement(&buf, name, "")
+			}
+			buf.WriteString("</d:prop>")
+			writeStatus(&buf, http.StatusNotFound)
+			buf.WriteString("</d:propstat>")
+		}
+		buf.WriteString("</d:response>")
+	}
+	buf.WriteString("</d:multistatus>")
+	return buf.Bytes()
+}
+
+func writeStatus(buf *bytes.Buffer, status int) {
+	fmt.Fprintf(buf, "<d:status>HTTP/1.1 %d %s</d:status>", status, http.StatusText(status))
+}
+
+// writeElement writes an element named name with the given content. Names
+// outside the known namespaces declare their namespace inline.
+func writeElement(buf *bytes.Buffer, name xml.Name, content string) {
+	tag := name.Local
+	declaration := ""
+	if prefix, ok := prefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		tag = "x:" + name.Local
+		var escaped bytes.Buffer
+		xml.EscapeText(&escaped, []byte(name.Space))
+		declaration = ` xmlns:x="` + escaped.String() + `"`
+	}
+	if content == "" {
+		fmt.Fprintf(buf, "<%s%s/>", tag, declaration)
+		return
+	}
+	fmt.Fprintf(buf, "<%s%s>%s</%s>", tag, declaration, content, tag)
+}
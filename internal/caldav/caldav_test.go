@@ -0,0 +1,124 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequest_Propfind(t *testing.T) {
+	body := `<?xml version="1.0"?>
+<d:propfind xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/">
+  <d:prop><d:displayname/><cs:getctag/></d:prop>
+</d:propfind>`
+
+	req, err := ParseRequest([]byte(body))
+
+	require.NoError(t, err)
+	assert.Equal(t, Propfind, req.Kind)
+	assert.False(t, req.AllProps)
+	assert.Equal(t, []xml.Name{
+		{Space: NamespaceDAV, Local: "displayname"},
+		{Space: NamespaceCalendarServer, Local: "getctag"},
+	}, req.Props)
+}
+
+func TestParseRequest_EmptyBodyAsksForAllProps(t *testing.T) {
+	req, err := ParseRequest(nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, Propfind, req.Kind)
+	assert.True(t, req.AllProps)
+}
+
+func TestParseRequest_CalendarQueryTimeRange(t *testing.T) {
+	body := `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/></d:prop>
+  <c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VEVENT">
+    <c:time-range start="20300603T000000Z" end="20300604T000000Z"/>
+  </c:comp-filter></c:comp-filter></c:filter>
+</c:calendar-query>`
+
+	req, err := ParseRequest([]byte(body))
+
+	require.NoError(t, err)
+	assert.Equal(t, CalendarQuery, req.Kind)
+	require.NotNil(t, req.Start)
+	require.NotNil(t, req.End)
+	day := time.Date(2030, 6, 3, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, day, *req.Start)
+	assert.Equal(t, day.AddDate(0, 0, 1), *req.End)
+
+	assert.True(t, req.Overlaps(Object{Start: day.Add(23 * time.Hour), End: day.Add(25 * time.Hour)}))
+	assert.False(t, req.Overlaps(Object{Start: day.Add(-time.Hour), End: day}))
+}
+
+func TestParseRequest_CalendarMultiget(t *testing.T) {
+	body := `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <d:href> /caldav/users/1/calendar/a.ics </d:href>
+  <d:href>/caldav/users/1/calendar/b.ics</d:href>
+</c:calendar-multiget>`
+
+	req, err := ParseRequest([]byte(body))
+
+	require.NoError(t, err)
+	assert.Equal(t, CalendarMultiget, req.Kind)
+	assert.Equal(t, []string{"/caldav/users/1/calendar/a.ics", "/caldav/users/1/calendar/b.ics"}, req.Hrefs)
+}
+
+func TestParseRequest_RejectsInvalidBodies(t *testing.T) {
+	for _, body := range []string{
+		"not xml",
+		`<d:sync-collection xmlns:d="DAV:"/>`,
+		`<c:calendar-query xmlns:c="urn:ietf:params:xml:ns:caldav"><c:filter><c:time-range start="tomorrow"/></c:filter></c:calendar-query>`,
+	} {
+		_, err := ParseRequest([]byte(body))
+
+		assert.ErrorIs(t, err, ErrInvalidRequest, body)
+	}
+}
+
+func TestMultistatus_SplitsFoundAndMissingProperties(t *testing.T) {
+	req := &Request{Kind: Propfind, Props: []xml.Name{
+		{Space: NamespaceDAV, Local: "displayname"},
+		{Space: "urn:example", Local: "color"},
+	}}
+	available := []Property{
+		Text(xml.Name{Space: NamespaceDAV, Local: "displayname"}, "Ada & Bob"),
+		Href(xml.Name{Space: NamespaceDAV, Local: "owner"}, "/caldav/users/1/"),
+	}
+
+	body := string(Multistatus([]Response{
+		NewResponse("/caldav/users/1/calendar/", available, req),
+		{Href: "/missing.ics", Status: http.StatusNotFound},
+	}))
+
+	assert.Contains(t, body, `<d:multistatus xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/" xmlns:c="urn:ietf:params:xml:ns:caldav">`)
+	assert.Contains(t, body, "<d:propstat><d:prop><d:displayname>Ada &amp; Bob</d:displayname></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>")
+	assert.Contains(t, body, `<d:propstat><d:prop><x:color xmlns:x="urn:example"/></d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat>`)
+	assert.NotContains(t, body, "d:owner")
+	assert.Contains(t, body, "<d:response><d:href>/missing.ics</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response>")
+
+	var parsed struct {
+		Responses []struct {
+			Href string `xml:"href"`
+		} `xml:"response"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(body), &parsed))
+	assert.Len(t, parsed.Responses, 2)
+}
+
+func TestCTag_ChangesWithObjects(t *testing.T) {
+	objects := []Object{{Name: "a.ics", ETag: `"1"`}}
+
+	before := CTag(objects)
+	objects[0].ETag = `"2"`
+
+	assert.NotEqual(t, before, CTag(objects))
+	assert.Len(t, before, 32)
+}
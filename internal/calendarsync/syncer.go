@@ -0,0 +1,310 @@
+// Package calendarsync imports the busy time of calendars users keep
+// elsewhere, either uploaded or fetched from a URL, and keeps it current
+// with a background worker.
+package calendarsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"queue_system/config"
+	"queue_system/internal/ical"
+	"queue_system/internal/model"
+	"queue_system/internal/netguard"
+	"queue_system/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// MaxCalendarSize bounds how much of an uploaded or fetched calendar is read.
+const MaxCalendarSize = 5 << 20
+
+const (
+	// history is how far back blocks are imported; only future bookings are
+	// checked against them, but one may start before the refresh.
+	history = 24 * time.Hour
+	// uploadRefreshInterval is how often uploaded calendars are expanded
+	// again, so that recurring events keep blocking time as time moves on.
+	uploadRefreshInterval  = 24 * time.Hour
+	batchSize              = 10
+	defaultImportHorizon   = 90 * 24 * time.Hour
+	defaultRefreshInterval = 15 * time.Minute
+	defaultPollInterval    = time.Minute
+	defaultFetchTimeout    = 30 * time.Second
+)
+
+var (
+	ErrCalendarTooLarge = fmt.Errorf("calendar is larger than %d MB", MaxCalendarSize>>20)
+	ErrFetchFailed      = errors.New("calendar could not be fetched")
+	ErrInvalidCalendar  = errors.New("calendar is not a valid iCalendar file")
+)
+
+type Syncer struct {
+	db                 *gorm.DB
+	calendarRepository repository.CalendarRepository
+	guard              *netguard.Guard
+	client             *http.Client
+	lease              time.Duration
+	importHorizon      time.Duration
+	refreshInterval    time.Duration
+	pollInterval       time.Duration
+
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+func NewSyncer(db *gorm.DB, calendarRepository repository.CalendarRepository, cfg *config.Config) *Syncer {
+	timeout := cfg.Calendar.FetchTimeout
+	if timeout <= 0 {
+		timeout = defaultFetchTimeout
+	}
+	guard := netguard.New(cfg.Calendar.AllowPrivateURLs)
+	syncer := &Syncer{
+		db:                 db,
+		calendarRepository: calendarRepository,
+		guard:              guard,
+		client:             guard.Client(timeout),
+		// Calendars of a batch are fetched one after another, so the lease
+		// covers every fetch timing out.
+		lease:           batchSize*timeout + time.Minute,
+		importHorizon:   cfg.Calendar.ImportHorizon,
+		refreshInterval: cfg.Calendar.RefreshInterval,
+		pollInterval:    cfg.Calendar.PollInterval,
+	}
+	if syncer.importHorizon <= 0 {
+		syncer.importHorizon = defaultImportHorizon
+	}
+	if syncer.refreshInterval <= 0 {
+		syncer.refreshInterval = defaultRefreshInterval
+	}
+	if syncer.pollInterval <= 0 {
+		syncer.pollInterval = defaultPollInterval
+	}
+	return syncer
+}
+
+// Start refreshes due calendars until Stop is called.
+func (s *Syncer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		for {
+			for {
+				refreshed, err := s.RefreshDue(ctx, time.Now())
+				if err != nil {
+					log.Error().Err(err).Msg("Error refreshing imported calendars")
+					break
+				}
+				if refreshed < batchSize {
+					break
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop waits for the batch in flight to finish or ctx to expire.
+func (s *Syncer) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	finished := make(chan struct{})
+	go func() {
+		s.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RefreshDue refreshes one batch of calendars due at now and returns how
+// many were refreshed. The batch is claimed in a short transaction and
+// fetched after it commits, so no rows stay locked while remote servers
+// respond; each calendar is then written in a transaction of its own. A
+// calendar that cannot be fetched or read keeps its blocks and records the
+// error; it is tried again after the usual interval. One that cannot be
+// written is tried again once the claim runs out.
+func (s *Syncer) RefreshDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.claim(now)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range due {
+		calendar := &due[i]
+		data := []byte(calendar.Data)
+		var fetchErr error
+		if calendar.URL != "" {
+			data, fetchErr = s.Fetch(ctx, calendar.URL)
+		}
+		if err := s.store(calendar.ID, data, fetchErr, now); err != nil {
+			log.Error().Err(err).Uint("calendarID", calendar.ID).Msg("Error saving refreshed calendar")
+		}
+	}
+	return len(due), nil
+}
+
+// claim leases up to batchSize due calendars to this worker.
+func (s *Syncer) claim(now time.Time) ([]model.BusyCalendar, error) {
+	tx := s.db.Begin()
+
+	due, err := s.calendarRepository.FetchDueBusyCalendarsForUpdate(tx, now, batchSize)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.calendarRepository.ClaimBusyCalendarsWithTx(tx, due, now.Add(s.lease)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return due, nil
+}
+
+// store imports data into the calendar, or records fetchErr if it could not
+// be fetched. Calendars deleted while they were fetched are left deleted.
+func (s *Syncer) store(calendarID uint, data []byte, fetchErr error, now time.Time) error {
+	tx := s.db.Begin()
+
+	calendar, err := s.calendarRepository.GetBusyCalendarForUpdate(tx, calendarID)
+	if err != nil || calendar == nil {
+		tx.Rollback()
+		return err
+	}
+	if fetchErr != nil {
+		err = s.recordFailure(tx, calendar, ErrFetchFailed, fetchErr, now)
+	} else if err = s.Import(tx, calendar, data, now); errors.Is(err, ical.ErrInvalidCalendar) {
+		err = s.recordFailure(tx, calendar, ErrInvalidCalendar, err, now)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// recordFailure logs cause and shows the calendar's owner only reason, so
+// that what a remote server answered is not passed on.
+func (s *Syncer) recordFailure(tx *gorm.DB, calendar *model.BusyCalendar, reason, cause error, now time.Time) error {
+	log.Warn().Err(cause).Uint("calendarID", calendar.ID).Msg("Error refreshing imported calendar")
+	calendar.LastError = reason.Error()
+	next := now.Add(s.nextInterval(calendar))
+	calendar.NextSyncAt = &next
+	return s.calendarRepository.UpdateBusyCalendarWithTx(tx, calendar)
+}
+
+// Import replaces the calendar's busy blocks with the events in data that
+// fall between a day ago and the import horizon, and saves the calendar,
+// creating it if it is new. It returns ical.ErrInvalidCalendar if data is
+// not a calendar.
+func (s *Syncer) Import(tx *gorm.DB, calendar *model.BusyCalendar, data []byte, now time.Time) error {
+	busy, err := ical.ParseBusy(data, now.Add(-history), now.Add(s.importHorizon))
+	if err != nil {
+		return err
+	}
+
+	blocks := make([]model.BusyBlock, 0, len(busy))
+	for _, interval := range busy {
+		blocks = append(blocks, model.BusyBlock{StartTime: interval.Start, EndTime: interval.End})
+	}
+	next := now.Add(s.nextInterval(calendar))
+	calendar.BlockCount = len(blocks)
+	calendar.LastSyncedAt = &now
+	calendar.LastError = ""
+	calendar.NextSyncAt = &next
+	return s.calendarRepository.ReplaceBusyBlocksWithTx(tx, calendar, blocks)
+}
+
+func (s *Syncer) nextInterval(calendar *model.BusyCalendar) time.Duration {
+	if calendar.URL == "" {
+		return uploadRefreshInterval
+	}
+	return s.refreshInterval
+}
+
+// CheckURL fails unless rawURL can be subscribed to: an http, https or
+// webcal URL on a public address. The errors wrap those of netguard.
+func (s *Syncer) CheckURL(ctx context.Context, rawURL string) error {
+	_, err := s.target(ctx, rawURL)
+	return err
+}
+
+// target returns the URL rawURL is fetched from. webcal:// URLs, which
+// calendar apps hand out for subscriptions, are fetched over HTTPS.
+func (s *Syncer) target(ctx context.Context, rawURL string) (*url.URL, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch target.Scheme {
+	case "http", "https":
+	case "webcal":
+		target.Scheme = "https"
+	default:
+		return nil, fmt.Errorf("%w: unsupported URL scheme %q", netguard.ErrInvalidURL, target.Scheme)
+	}
+	if err := s.guard.CheckURL(ctx, target.String()); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// Fetch downloads the calendar at rawURL, which CheckURL must accept.
+func (s *Syncer) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	target, err := s.target(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/calendar")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxCalendarSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxCalendarSize {
+		return nil, ErrCalendarTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,59 @@
+package calendarsync
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"queue_system/config"
+	"queue_system/internal/netguard"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncer_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/calendar.ics":
+			assert.Equal(t, "text/calendar", r.Header.Get("Accept"))
+			w.Write([]byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"))
+		case "/huge.ics":
+			w.Write([]byte(strings.Repeat("X", MaxCalendarSize+1)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	cfg := &config.Config{}
+	cfg.Calendar.AllowPrivateURLs = true
+	syncer := NewSyncer(nil, nil, cfg)
+
+	data, err := syncer.Fetch(context.Background(), server.URL+"/calendar.ics")
+	require.NoError(t, err)
+	assert.Equal(t, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", string(data))
+
+	_, err = syncer.Fetch(context.Background(), server.URL+"/missing.ics")
+	assert.EqualError(t, err, "unexpected response status 404")
+
+	_, err = syncer.Fetch(context.Background(), server.URL+"/huge.ics")
+	assert.Equal(t, ErrCalendarTooLarge, err)
+
+	_, err = syncer.Fetch(context.Background(), "ftp://example.com/calendar.ics")
+	assert.ErrorIs(t, err, netguard.ErrInvalidURL)
+}
+
+func TestSyncer_Fetch_RefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("calendar on a private address was fetched")
+	}))
+	defer server.Close()
+	syncer := NewSyncer(nil, nil, &config.Config{})
+
+	_, err := syncer.Fetch(context.Background(), server.URL+"/calendar.ics")
+	assert.ErrorIs(t, err, netguard.ErrForbiddenAddress)
+
+	err = syncer.CheckURL(context.Background(), "webcal://10.0.0.1/calendar.ics")
+	assert.ErrorIs(t, err, netguard.ErrForbiddenAddress)
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type APIKeyController struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService service.APIKeyService) *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (c *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	var req request.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	key, err := c.apiKeyService.CreateAPIKey(auth.CurrentUser(ctx), &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, key)
+}
+
+func (c *APIKeyController) ListAPIKeys(ctx *gin.Context) {
+	keys, err := c.apiKeyService.ListAPIKeys(auth.CurrentUser(ctx))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, keys)
+}
+
+func (c *APIKeyController) RevokeAPIKey(ctx *gin.Context) {
+	keyID, ok := parseIDParam(ctx, "id", "Invalid API key ID format")
+	if !ok {
+		return
+	}
+	if err := c.apiKeyService.RevokeAPIKey(auth.CurrentUser(ctx), keyID); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *APIKeyController) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAPIKeyNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAPIKeyAlreadyRevoked):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg("API key request failed")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process API key request"})
+	}
+}
@@ -3,9 +3,12 @@ package controller
 import (
 	"errors"
 	"net/http"
+	"queue_system/internal/auth"
 	"queue_system/internal/dto/request"
 	"queue_system/internal/service"
+	"queue_system/internal/tenant"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
@@ -28,16 +31,64 @@ func (c *AppointmentController) CreateAppointment(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	actor := auth.CurrentUser(ctx)
+	if req.UserID == 0 {
+		req.UserID = actor.ID
+	}
 
-	createdAppointment, err := c.appointmentService.CreateAppointment(&req)
+	createdAppointment, err := c.appointmentService.CreateAppointment(actor, &req)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to create appointment")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if denied(ctx, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidTimeFormat),
+			errors.Is(err, service.ErrEndTimeBeforeStartTime),
+			errors.Is(err, service.ErrCannotBookWithSelf):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserOrParticipantNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAppointmentConflict),
+			errors.Is(err, service.ErrBusyTimeConflict):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrOutsideWorkingHours),
+			errors.Is(err, service.ErrUserDeactivated):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 	ctx.JSON(http.StatusCreated, createdAppointment)
 
 }
+
+// AuthorizeAppointment only lets users who may access the appointment named
+// by the :id parameter through. Malformed IDs are left for the handler to
+// reject.
+func (c *AppointmentController) AuthorizeAppointment(ctx *gin.Context) {
+	id, err := strconv.ParseUint(strings.TrimSuffix(ctx.Param("id"), ".ics"), 10, 32)
+	if err != nil {
+		ctx.Next()
+		return
+	}
+	appointment, err := c.appointmentService.GetAppointmentByID(tenant.OrganizationID(ctx), uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrAppointmentNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to get appointment for authorization")
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve appointment"})
+		return
+	}
+	if denied(ctx, auth.CanAccessAppointment(auth.CurrentUser(ctx), appointment)) {
+		return
+	}
+	ctx.Next()
+}
+
 func (c *AppointmentController) GetAppointmentByID(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
@@ -46,7 +97,7 @@ func (c *AppointmentController) GetAppointmentByID(ctx *gin.Context) {
 		return
 	}
 
-	appointment, err := c.appointmentService.GetAppointmentByID(uint(id))
+	appointment, err := c.appointmentService.GetAppointmentByID(tenant.OrganizationID(ctx), uint(id))
 	if err != nil {
 		if errors.Is(err, service.ErrAppointmentNotFound) {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -58,3 +109,333 @@ func (c *AppointmentController) GetAppointmentByID(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, appointment)
 }
+
+func (c *AppointmentController) ListAppointments(ctx *gin.Context) {
+	var req request.ListAppointmentsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Warn().Err(err).Msg("Failed to bind appointment list query")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	appointments, total, err := c.appointmentService.ListAppointments(auth.CurrentUser(ctx), &req)
+	if err != nil {
+		if denied(ctx, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTimeFormat) || errors.Is(err, service.ErrEndTimeBeforeStartTime) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to list appointments")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list appointments"})
+		return
+	}
+	ctx.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	ctx.JSON(http.StatusOK, appointments)
+}
+
+func (c *AppointmentController) GetUserCalendar(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	var req request.UserCalendarRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	calendar, err := c.appointmentService.GetUserCalendar(tenant.OrganizationID(ctx), uint(id), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidDateFormat),
+			errors.Is(err, service.ErrInvalidTimezone),
+			errors.Is(err, service.ErrEndTimeBeforeStartTime),
+			errors.Is(err, service.ErrCalendarRangeTooLarge):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to get user calendar")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve calendar"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, calendar)
+}
+
+func (c *AppointmentController) UpdateAppointment(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID format"})
+		return
+	}
+
+	var req request.UpdateAppointmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Failed to bind appointment update")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scope, ok := parseScope(ctx)
+	if !ok {
+		return
+	}
+
+	// The signed-in user is the actor.
+	actorID := auth.CurrentUser(ctx).ID
+	var updated interface{}
+	if scope == scopeFollowing {
+		updated, err = c.appointmentService.UpdateFollowingAppointments(tenant.OrganizationID(ctx), uint(id), actorID, &req)
+	} else {
+		updated, err = c.appointmentService.UpdateAppointment(tenant.OrganizationID(ctx), uint(id), actorID, &req)
+	}
+	if err != nil {
+		if denied(ctx, err) {
+			return
+		}
+		var seriesConflict *service.SeriesConflictError
+		switch {
+		case errors.As(err, &seriesConflict):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflicts": seriesConflict.Conflicts})
+		case errors.Is(err, service.ErrAppointmentNotFound), errors.Is(err, service.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidTimeFormat),
+			errors.Is(err, service.ErrEndTimeBeforeStartTime),
+			errors.Is(err, service.ErrInvalidAppointmentStatus),
+			errors.Is(err, service.ErrAppointmentNotRecurring),
+			errors.Is(err, service.ErrSeriesStatusChange):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAppointmentConflict),
+			errors.Is(err, service.ErrBusyTimeConflict),
+			errors.Is(err, service.ErrAppointmentClosed),
+			errors.Is(err, service.ErrInvalidStatusTransition):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrOutsideWorkingHours):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to update appointment")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update appointment"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+func (c *AppointmentController) ConfirmAppointment(ctx *gin.Context) {
+	changeStatus(ctx, c.appointmentService.ConfirmAppointment)
+}
+
+func (c *AppointmentController) CancelAppointment(ctx *gin.Context) {
+	scope, ok := parseScope(ctx)
+	if !ok {
+		return
+	}
+	if scope == scopeFollowing {
+		changeStatus(ctx, c.appointmentService.CancelFollowingAppointments)
+		return
+	}
+	changeStatus(ctx, c.appointmentService.CancelAppointment)
+}
+
+func (c *AppointmentController) StartAppointment(ctx *gin.Context) {
+	changeStatus(ctx, c.appointmentService.StartAppointment)
+}
+
+func (c *AppointmentController) CompleteAppointment(ctx *gin.Context) {
+	changeStatus(ctx, c.appointmentService.CompleteAppointment)
+}
+
+func (c *AppointmentController) DeleteAppointment(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "Invalid appointment ID format")
+	if !ok {
+		return
+	}
+
+	if err := c.appointmentService.DeleteAppointment(tenant.OrganizationID(ctx), id); err != nil {
+		switch {
+		case errors.Is(err, service.ErrAppointmentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, service.ErrAppointmentNotDeletable):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Failed to delete appointment")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete appointment"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *AppointmentController) RestoreAppointment(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "Invalid appointment ID format")
+	if !ok {
+		return
+	}
+
+	appointment, err := c.appointmentService.RestoreAppointment(tenant.OrganizationID(ctx), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAppointmentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAppointmentConflict),
+			errors.Is(err, service.ErrBusyTimeConflict):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserOrParticipantNotFound),
+			errors.Is(err, service.ErrUserDeactivated):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Uint("appointmentID", id).Msg("Failed to restore appointment")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore appointment"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, appointment)
+}
+
+func (c *AppointmentController) CheckInAppointment(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "Invalid appointment ID format")
+	if !ok {
+		return
+	}
+
+	entry, err := c.appointmentService.CheckInAppointment(tenant.OrganizationID(ctx), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAppointmentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAppointmentNotConfirmed),
+			errors.Is(err, service.ErrAlreadyCheckedIn),
+			errors.Is(err, service.ErrOutsideCheckInWindow):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to check in appointment")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in appointment"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, entry)
+}
+
+func (c *AppointmentController) GetWaitingLine(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+
+	line, err := c.appointmentService.GetWaitingLine(tenant.OrganizationID(ctx), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to get waiting line")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve waiting line"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, line)
+}
+
+func (c *AppointmentController) CallNextInWaitingLine(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+
+	appointment, err := c.appointmentService.CallNextInWaitingLine(tenant.OrganizationID(ctx), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrWaitingLineEmpty):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to call next in waiting line")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to call next in waiting line"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, appointment)
+}
+
+func changeStatus[T any](ctx *gin.Context, transition func(organizationID, id, actorID uint) (T, error)) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID format"})
+		return
+	}
+
+	// The signed-in user is the actor.
+	result, err := transition(tenant.OrganizationID(ctx), uint(id), auth.CurrentUser(ctx).ID)
+	if err != nil {
+		if denied(ctx, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrAppointmentNotFound), errors.Is(err, service.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAppointmentNotRecurring):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidStatusTransition),
+			errors.Is(err, service.ErrAppointmentClosed),
+			errors.Is(err, service.ErrAppointmentNotConfirmed),
+			errors.Is(err, service.ErrAppointmentAlreadyStarted):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to change appointment status")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change appointment status"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *AppointmentController) CreateAppointmentSeries(ctx *gin.Context) {
+	var req request.RecurringAppointmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Failed to bind appointment series")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actor := auth.CurrentUser(ctx)
+	if req.UserID == 0 {
+		req.UserID = actor.ID
+	}
+
+	series, err := c.appointmentService.CreateAppointmentSeries(actor, &req)
+	if err != nil {
+		if denied(ctx, err) {
+			return
+		}
+		var seriesConflict *service.SeriesConflictError
+		switch {
+		case errors.As(err, &seriesConflict):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflicts": seriesConflict.Conflicts})
+		case errors.Is(err, service.ErrInvalidTimeFormat),
+			errors.Is(err, service.ErrEndTimeBeforeStartTime),
+			errors.Is(err, service.ErrCannotBookWithSelf),
+			errors.Is(err, service.ErrInvalidTimezone),
+			errors.Is(err, service.ErrInvalidRecurrenceRule),
+			errors.Is(err, service.ErrOverlappingOccurrences):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserOrParticipantNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserDeactivated):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to create appointment series")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create appointment series"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, series)
+}
@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/service"
+	"queue_system/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type AuthController struct {
+	authService service.AuthService
+}
+
+func NewAuthController(authService service.AuthService) *AuthController {
+	return &AuthController{
+		authService: authService,
+	}
+}
+
+func (c *AuthController) Login(ctx *gin.Context) {
+	var req request.LoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, err := c.authService.Login(tenant.OrganizationID(ctx), &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// RequestMagicLink answers 202 to every well-formed request, whether or not
+// the email belongs to a user and whether or not the link could be queued.
+func (c *AuthController) RequestMagicLink(ctx *gin.Context) {
+	var req request.MagicLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.authService.RequestMagicLink(tenant.OrganizationID(ctx), &req); err != nil {
+		log.Error().Err(err).Msg("Magic link request failed")
+	}
+	ctx.Status(http.StatusAccepted)
+}
+
+func (c *AuthController) VerifyMagicLink(ctx *gin.Context) {
+	var req request.VerifyMagicLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, err := c.authService.VerifyMagicLink(&req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (c *AuthController) Refresh(ctx *gin.Context) {
+	var req request.RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, err := c.authService.Refresh(&req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (c *AuthController) Logout(ctx *gin.Context) {
+	var req request.RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.authService.Logout(&req); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// Me returns the signed-in user.
+func (c *AuthController) Me(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, auth.CurrentUser(ctx))
+}
+
+func (c *AuthController) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials),
+		errors.Is(err, service.ErrInvalidRefreshToken),
+		errors.Is(err, service.ErrInvalidMagicLink):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg("Auth request failed")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process authentication request"})
+	}
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/service"
+	"queue_system/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type AvailabilityController struct {
+	availabilityService service.AvailabilityService
+}
+
+func NewAvailabilityController(availabilityService service.AvailabilityService) *AvailabilityController {
+	return &AvailabilityController{
+		availabilityService: availabilityService,
+	}
+}
+
+func (c *AvailabilityController) GetAvailability(ctx *gin.Context) {
+	var req request.AvailabilityRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Warn().Err(err).Msg("Failed to bind availability query")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	availability, err := c.availabilityService.FindAvailability(tenant.OrganizationID(ctx), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidUserIDs),
+			errors.Is(err, service.ErrTooManyUsers),
+			errors.Is(err, service.ErrInvalidTimeFormat),
+			errors.Is(err, service.ErrEndTimeBeforeStartTime),
+			errors.Is(err, service.ErrAvailabilityRangeTooLarge),
+			errors.Is(err, service.ErrInvalidDuration):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to compute availability")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute availability"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, availability)
+}
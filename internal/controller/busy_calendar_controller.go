@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"queue_system/internal/calendarsync"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/service"
+	"queue_system/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type BusyCalendarController struct {
+	busyCalendarService service.BusyCalendarService
+}
+
+func NewBusyCalendarController(busyCalendarService service.BusyCalendarService) *BusyCalendarController {
+	return &BusyCalendarController{
+		busyCalendarService: busyCalendarService,
+	}
+}
+
+// ImportBusyCalendar reads an .ics file uploaded as the "file" form field,
+// with an optional "name".
+func (c *BusyCalendarController) ImportBusyCalendar(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "An .ics file is required in the file form field"})
+		return
+	}
+	if header.Size > calendarsync.MaxCalendarSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": calendarsync.ErrCalendarTooLarge.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the uploaded file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the uploaded file"})
+		return
+	}
+
+	name := ctx.PostForm("name")
+	if name == "" {
+		name = header.Filename
+	}
+	calendar, err := c.busyCalendarService.ImportBusyCalendar(tenant.OrganizationID(ctx), userID, name, data)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, calendar)
+}
+
+func (c *BusyCalendarController) SubscribeBusyCalendar(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	var req request.SubscribeBusyCalendarRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	calendar, err := c.busyCalendarService.SubscribeBusyCalendar(ctx.Request.Context(), tenant.OrganizationID(ctx), userID, &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, calendar)
+}
+
+func (c *BusyCalendarController) ListBusyCalendars(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	calendars, err := c.busyCalendarService.ListBusyCalendars(tenant.OrganizationID(ctx), userID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, calendars)
+}
+
+func (c *BusyCalendarController) DeleteBusyCalendar(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	calendarID, ok := parseIDParam(ctx, "calendarId", "Invalid calendar ID format")
+	if !ok {
+		return
+	}
+	if err := c.busyCalendarService.DeleteBusyCalendar(tenant.OrganizationID(ctx), userID, calendarID); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *BusyCalendarController) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrBusyCalendarNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCalendarURLNotAllowed):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidCalendarFile),
+		errors.Is(err, service.ErrCalendarFetchFailed):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg("Imported calendar request failed")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process calendar request"})
+	}
+}
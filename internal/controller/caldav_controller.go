@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"queue_system/internal/auth"
+	"queue_system/internal/caldav"
+	"queue_system/internal/service"
+	"queue_system/internal/tenant"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	caldavAllow           = "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT"
+	calendarObjectType    = "text/calendar; charset=utf-8; component=VEVENT"
+	multistatusType       = "application/xml; charset=utf-8"
+	maxCalendarObjectSize = 1 << 20
+)
+
+func davName(local string) xml.Name {
+	return xml.Name{Space: caldav.NamespaceDAV, Local: local}
+}
+
+func calDAVName(local string) xml.Name {
+	return xml.Name{Space: caldav.NamespaceCalDAV, Local: local}
+}
+
+// CalDAVController serves each user's appointments as a CalDAV calendar at
+// /caldav/users/:id/calendar/, with the user's principal at
+// /caldav/users/:id/.
+type CalDAVController struct {
+	caldavService service.CalDAVService
+}
+
+func NewCalDAVController(caldavService service.CalDAVService) *CalDAVController {
+	return &CalDAVController{
+		caldavService: caldavService,
+	}
+}
+
+func principalPath(userID uint) string {
+	return fmt.Sprintf("/caldav/users/%d/", userID)
+}
+
+func collectionPath(userID uint) string {
+	return principalPath(userID) + "calendar/"
+}
+
+func (c *CalDAVController) Options(ctx *gin.Context) {
+	ctx.Header("DAV", "1, 3, calendar-access")
+	ctx.Header("Allow", caldavAllow)
+	ctx.Status(http.StatusOK)
+}
+
+// PropfindPrincipal describes the user as a principal whose calendar home
+// holds the appointment calendar.
+func (c *CalDAVController) PropfindPrincipal(ctx *gin.Context) {
+	userID, ok := calendarOwnerParam(ctx)
+	if !ok {
+		return
+	}
+	req, ok := parseDAVRequest(ctx)
+	if !ok {
+		return
+	}
+	owner, err := c.caldavService.GetCalendarOwner(tenant.OrganizationID(ctx), userID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	principal := principalPath(userID)
+	properties := []caldav.Property{
+		{Name: davName("resourcetype"), Value: "<d:collection/><d:principal/>"},
+		caldav.Text(davName("displayname"), owner.Name),
+		caldav.Href(davName("current-user-principal"), principal),
+		caldav.Href(davName("principal-URL"), principal),
+		caldav.Href(calDAVName("calendar-home-set"), principal),
+		caldav.Href(calDAVName("calendar-user-address-set"), "mailto:"+owner.Email),
+	}
+	responses := []caldav.Response{caldav.NewResponse(principal, properties, req)}
+	if davDepth(ctx) > 0 {
+		objects, err := c.caldavService.ListObjects(tenant.OrganizationID(ctx), userID)
+		if err != nil {
+			c.handleError(ctx, err)
+			return
+		}
+		responses = append(responses, caldav.NewResponse(collectionPath(userID), collectionProperties(userID, objects), req))
+	}
+	ctx.Data(http.StatusMultiStatus, multistatusType, caldav.Multistatus(responses))
+}
+
+// PropfindCollection describes the calendar and, at depth 1, its objects.
+func (c *CalDAVController) PropfindCollection(ctx *gin.Context) {
+	userID, ok := calendarOwnerParam(ctx)
+	if !ok {
+		return
+	}
+	req, ok := parseDAVRequest(ctx)
+	if !ok {
+		return
+	}
+	objects, err := c.caldavService.ListObjects(tenant.OrganizationID(ctx), userID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	responses := []caldav.Response{caldav.NewResponse(collectionPath(userID), collectionProperties(userID, objects), req)}
+	if davDepth(ctx) > 0 {
+		for _, object := range objects {
+			responses = append(responses, caldav.NewResponse(objectHref(userID, object.Name), objectProperties(object, false), req))
+		}
+	}
+	ctx.Data(http.StatusMultiStatus, multistatusType, caldav.Multistatus(responses))
+}
+
+// Report answers calendar-query, optionally limited to a time-range, and
+// calendar-multiget.
+func (c *CalDAVController) Report(ctx *gin.Context) {
+	userID, ok := calendarOwnerParam(ctx)
+	if !ok {
+		return
+	}
+	req, ok := parseDAVRequest(ctx)
+	if !ok {
+		return
+	}
+	if req.Kind != caldav.CalendarQuery && req.Kind != caldav.CalendarMultiget {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Only calendar-query and calendar-multiget reports are supported"})
+		return
+	}
+	objects, err := c.caldavService.ListObjects(tenant.OrganizationID(ctx), userID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	var responses []caldav.Response
+	if req.Kind == caldav.CalendarQuery {
+		for _, object := range objects {
+			if req.Overlaps(object) {
+				responses = append(responses, caldav.NewResponse(objectHref(userID, object.Name), objectProperties(object, true), req))
+			}
+		}
+	} else {
+		byName := make(map[string]caldav.Object, len(objects))
+		for _, object := range objects {
+			byName[object.Name] = object
+		}
+		for _, href := range req.Hrefs {
+			object, found := byName[objectNameFromHref(userID, href)]
+			if !found {
+				responses = append(responses, caldav.Response{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			responses = append(responses, caldav.NewResponse(href, objectProperties(object, true), req))
+		}
+	}
+	ctx.Data(http.StatusMultiStatus, multistatusType, caldav.Multistatus(responses))
+}
+
+func (c *CalDAVController) PropfindObject(ctx *gin.Context) {
+	userID, ok := calendarOwnerParam(ctx)
+	if !ok {
+		return
+	}
+	req, ok := parseDAVRequest(ctx)
+	if !ok {
+		return
+	}
+	object, err := c.caldavService.GetObject(tenant.OrganizationID(ctx), userID, ctx.Param("object"))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	response := caldav.NewResponse(objectHref(userID, object.Name), objectProperties(*object, false), req)
+	ctx.Data(http.StatusMultiStatus, multistatusType, caldav.Multistatus([]caldav.Response{response}))
+}
+
+func (c *CalDAVController) GetObject(ctx *gin.Context) {
+	userID, ok := calendarOwnerParam(ctx)
+	if !ok {
+		return
+	}
+	object, err := c.caldavService.GetObject(tenant.OrganizationID(ctx), userID, ctx.Param("object"))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.Header("ETag", object.ETag)
+	ctx.Data(http.StatusOK, calendarObjectType, object.Data)
+}
+
+// PutObject books or changes an appointment. No ETag is returned because
+// the stored object differs from the one sent, so clients fetch it again.
+func (c *CalDAVController) PutObject(ctx *gin.Context) {
+	userID, ok := calendarOwnerParam(ctx)
+	if !ok {
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxCalendarObjectSize))
+	if err != nil {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Calendar object is too large"})
+		return
+	}
+	created, err := c.caldavService.PutObject(tenant.OrganizationID(ctx), userID, ctx.Param("object"), data, ctx.GetHeader("If-Match"), ctx.GetHeader("If-None-Match"))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	if created {
+		ctx.Status(http.StatusCreated)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// DeleteObject cancels the appointment.
+func (c *CalDAVController) DeleteObject(ctx *gin.Context) {
+	userID, ok := calendarOwnerParam(ctx)
+	if !ok {
+		return
+	}
+	if err := c.caldavService.DeleteObject(tenant.OrganizationID(ctx), userID, ctx.Param("object"), ctx.GetHeader("If-Match")); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// calendarOwnerParam reads the user whose calendar is requested, who must be
+// the signed-in user.
+func calendarOwnerParam(ctx *gin.Context) (uint, bool) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return 0, false
+	}
+	if userID != auth.CurrentUser(ctx).ID {
+		auth.Forbid(ctx, auth.ErrNotOwner)
+		return 0, false
+	}
+	return userID, true
+}
+
+func collectionProperties(userID uint, objects []caldav.Object) []caldav.Property {
+	return []caldav.Property{
+		{Name: davName("resourcetype"), Value: "<d:collection/><c:calendar/>"},
+		caldav.Text(davName("displayname"), "Appointments"),
+		caldav.Href(davName("current-user-principal"), principalPath(userID)),
+		caldav.Href(davName("owner"), principalPath(userID)),
+		{Name: davName("current-user-privilege-set"), Value: "<d:privilege><d:read/></d:privilege><d:privilege><d:write/></d:privilege>"},
+		{Name: davName("supported-report-set"), Value: "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>"},
+		{Name: calDAVName("supported-calendar-component-set"), Value: `<c:comp name="VEVENT"/>`},
+		caldav.Text(xml.Name{Space: caldav.NamespaceCalendarServer, Local: "getctag"}, caldav.CTag(objects)),
+	}
+}
+
+// objectProperties describes an object; calendar-data is only given in
+// reports.
+func objectProperties(object caldav.Object, withData bool) []caldav.Property {
+	properties := []caldav.Property{
+		{Name: davName("resourcetype")},
+		caldav.Text(davName("getetag"), object.ETag),
+		caldav.Text(davName("getcontenttype"), calendarObjectType),
+	}
+	if withData {
+		properties = append(properties, caldav.Text(calDAVName("calendar-data"), string(object.Data)))
+	}
+	return properties
+}
+
+func objectHref(userID uint, name string) string {
+	return collectionPath(userID) + url.PathEscape(name)
+}
+
+// objectNameFromHref returns the object name in an href of the user's
+// collection, which may be a path or a full URL.
+func objectNameFromHref(userID uint, href string) string {
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	name, ok := strings.CutPrefix(parsed.Path, collectionPath(userID))
+	if !ok || strings.Contains(name, "/") {
+		return ""
+	}
+	return name
+}
+
+// davDepth reads the Depth header, treating infinity as 1.
+func davDepth(ctx *gin.Context) int {
+	if ctx.GetHeader("Depth") == "0" {
+		return 0
+	}
+	return 1
+}
+
+func parseDAVRequest(ctx *gin.Context) (*caldav.Request, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxCalendarObjectSize))
+	if err != nil {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+		return nil, false
+	}
+	req, err := caldav.ParseRequest(body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return req, true
+}
+
+func (c *CalDAVController) handleError(ctx *gin.Context, err error) {
+	if denied(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrCalendarObjectNotFound),
+		errors.Is(err, service.ErrUserOrParticipantNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCalendarPreconditionFailed):
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidCalendarObject),
+		errors.Is(err, service.ErrInvalidTimeFormat),
+		errors.Is(err, service.ErrEndTimeBeforeStartTime),
+		errors.Is(err, service.ErrCannotBookWithSelf):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAppointmentConflict),
+		errors.Is(err, service.ErrBusyTimeConflict),
+		errors.Is(err, service.ErrAppointmentClosed),
+		errors.Is(err, service.ErrInvalidStatusTransition):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOutsideWorkingHours),
+		errors.Is(err, service.ErrCalendarAttendeeRequired),
+		errors.Is(err, service.ErrUserDeactivated):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg("CalDAV request failed")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process calendar request"})
+	}
+}
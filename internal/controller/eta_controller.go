@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/service"
+	"queue_system/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type ETAController struct {
+	etaService service.ETAService
+}
+
+func NewETAController(etaService service.ETAService) *ETAController {
+	return &ETAController{
+		etaService: etaService,
+	}
+}
+
+func (c *ETAController) GetAppointmentETA(ctx *gin.Context) {
+	id, ok := parseIDParam(ctx, "id", "Invalid appointment ID format")
+	if !ok {
+		return
+	}
+
+	var req request.AppointmentETARequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	estimate, err := c.etaService.GetAppointmentETA(tenant.OrganizationID(ctx), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAppointmentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidTimezone):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAppointmentClosed):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to estimate appointment start")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate appointment start"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, estimate)
+}
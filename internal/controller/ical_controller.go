@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"queue_system/internal/service"
+	"queue_system/internal/tenant"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+type ICalController struct {
+	icalService service.ICalService
+}
+
+func NewICalController(icalService service.ICalService) *ICalController {
+	return &ICalController{
+		icalService: icalService,
+	}
+}
+
+// WithAppointmentExport serves GET /appointments/:id.ics. Gin cannot route
+// that apart from GET /appointments/:id, so both share the route and
+// requests without the suffix go to next.
+func (c *ICalController) WithAppointmentExport(next gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		idParam, ok := strings.CutSuffix(ctx.Param("id"), ".ics")
+		if !ok {
+			next(ctx)
+			return
+		}
+		id, err := strconv.ParseUint(idParam, 10, 32)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID format"})
+			return
+		}
+		data, err := c.icalService.ExportAppointment(tenant.OrganizationID(ctx), uint(id))
+		if err != nil {
+			c.handleError(ctx, err)
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="appointment-%d.ics"`, id))
+		ctx.Data(http.StatusOK, calendarContentType, data)
+	}
+}
+
+func (c *ICalController) IssueFeedToken(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	feed, err := c.icalService.IssueFeedToken(tenant.OrganizationID(ctx), userID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, feed)
+}
+
+func (c *ICalController) ExportUserFeed(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	data, err := c.icalService.ExportUserFeed(userID, ctx.Query("token"))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.Data(http.StatusOK, calendarContentType, data)
+}
+
+func (c *ICalController) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAppointmentNotFound),
+		errors.Is(err, service.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidCalendarToken):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg("Calendar export failed")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export calendar"})
+	}
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type OrganizationController struct {
+	organizationService service.OrganizationService
+}
+
+func NewOrganizationController(organizationService service.OrganizationService) *OrganizationController {
+	return &OrganizationController{
+		organizationService: organizationService,
+	}
+}
+
+func (c *OrganizationController) CreateOrganization(ctx *gin.Context) {
+	var req request.CreateOrganizationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	organization, err := c.organizationService.CreateOrganization(auth.CurrentUser(ctx), &req)
+	if err != nil {
+		if denied(ctx, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidOrganizationSlug),
+			errors.Is(err, auth.ErrPasswordTooLong):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrOrganizationExists):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to create organization")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, organization)
+}
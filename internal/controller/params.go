@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"queue_system/internal/auth"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Edit scopes for appointments that belong to a series.
+const (
+	scopeThis      = "this"
+	scopeFollowing = "following"
+)
+
+// parseIDParam reads a numeric path parameter, writing a 400 response and
+// returning false when it is malformed.
+func parseIDParam(ctx *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseScope reads the ?scope= query parameter, defaulting to this
+// occurrence only, and writes a 400 response when it is unknown.
+func parseScope(ctx *gin.Context) (string, bool) {
+	scope := ctx.DefaultQuery("scope", scopeThis)
+	if scope != scopeThis && scope != scopeFollowing {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "scope must be 'this' or 'following'"})
+		return "", false
+	}
+	return scope, true
+}
+
+// denied writes a 403 response and returns true when err is an
+// authorization failure.
+func denied(ctx *gin.Context, err error) bool {
+	var denial *auth.Denial
+	if !errors.As(err, &denial) {
+		return false
+	}
+	auth.Forbid(ctx, denial)
+	return true
+}
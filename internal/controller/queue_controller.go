@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/model"
+	"queue_system/internal/service"
+	"queue_system/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type QueueController struct {
+	queueService service.QueueService
+}
+
+func NewQueueController(queueService service.QueueService) *QueueController {
+	return &QueueController{
+		queueService: queueService,
+	}
+}
+
+func (c *QueueController) CreateQueue(ctx *gin.Context) {
+	var req request.CreateQueueRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	queue, err := c.queueService.CreateQueue(tenant.OrganizationID(ctx), &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, queue)
+}
+
+func (c *QueueController) ListQueues(ctx *gin.Context) {
+	queues, err := c.queueService.ListQueues(tenant.OrganizationID(ctx))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, queues)
+}
+
+func (c *QueueController) GetQueueByID(ctx *gin.Context) {
+	queueID, ok := parseIDParam(ctx, "id", "Invalid queue ID format")
+	if !ok {
+		return
+	}
+	queue, err := c.queueService.GetQueueByID(tenant.OrganizationID(ctx), queueID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, queue)
+}
+
+func (c *QueueController) TakeTicket(ctx *gin.Context) {
+	queueID, ok := parseIDParam(ctx, "id", "Invalid queue ID format")
+	if !ok {
+		return
+	}
+	var req request.TakeTicketRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ticket, err := c.queueService.TakeTicket(auth.CurrentUser(ctx), queueID, &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, ticket)
+}
+
+func (c *QueueController) ListTickets(ctx *gin.Context) {
+	queueID, ok := parseIDParam(ctx, "id", "Invalid queue ID format")
+	if !ok {
+		return
+	}
+	var req request.ListTicketsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tickets, err := c.queueService.ListTickets(tenant.OrganizationID(ctx), queueID, &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tickets)
+}
+
+func (c *QueueController) CallNextTicket(ctx *gin.Context) {
+	queueID, ok := parseIDParam(ctx, "id", "Invalid queue ID format")
+	if !ok {
+		return
+	}
+	var req request.CallNextTicketRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ticket, err := c.queueService.CallNextTicket(tenant.OrganizationID(ctx), queueID, &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ticket)
+}
+
+func (c *QueueController) StartServingTicket(ctx *gin.Context) {
+	c.changeTicketStatus(ctx, c.queueService.StartServingTicket)
+}
+
+func (c *QueueController) CompleteTicket(ctx *gin.Context) {
+	c.changeTicketStatus(ctx, c.queueService.CompleteTicket)
+}
+
+func (c *QueueController) MarkTicketNoShow(ctx *gin.Context) {
+	c.changeTicketStatus(ctx, c.queueService.MarkTicketNoShow)
+}
+
+func (c *QueueController) changeTicketStatus(ctx *gin.Context, transition func(organizationID, queueID, ticketID uint) (*model.QueueTicket, error)) {
+	queueID, ok := parseIDParam(ctx, "id", "Invalid queue ID format")
+	if !ok {
+		return
+	}
+	ticketID, ok := parseIDParam(ctx, "ticketId", "Invalid ticket ID format")
+	if !ok {
+		return
+	}
+	ticket, err := transition(tenant.OrganizationID(ctx), queueID, ticketID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ticket)
+}
+
+func (c *QueueController) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrQueueNotFound),
+		errors.Is(err, service.ErrTicketNotFound),
+		errors.Is(err, service.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrQueueAlreadyExists),
+		errors.Is(err, service.ErrQueueEmpty),
+		errors.Is(err, service.ErrInvalidTicketTransition):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg("Queue request failed")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process queue request"})
+	}
+}
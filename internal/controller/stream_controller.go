@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/enums"
+	"queue_system/internal/stream"
+	"queue_system/internal/tenant"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// heartbeatInterval keeps idle connections from being closed by proxies.
+const heartbeatInterval = 15 * time.Second
+
+type StreamController struct {
+	hub *stream.Hub
+}
+
+func NewStreamController(hub *stream.Hub) *StreamController {
+	return &StreamController{
+		hub: hub,
+	}
+}
+
+// Stream serves change events as Server-Sent Events. A client resuming with
+// Last-Event-ID first receives what it missed; if that is no longer
+// available it gets a "reset" event and should reload its state.
+func (c *StreamController) Stream(ctx *gin.Context) {
+	var req request.StreamRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Users who may not manage all appointments only follow their own.
+	user := auth.CurrentUser(ctx)
+	if !auth.Can(user, auth.ManageAppointments) {
+		if req.UserID != nil && *req.UserID != user.ID {
+			auth.Forbid(ctx, auth.ErrNotOwner)
+			return
+		}
+		req.UserID = &user.ID
+	}
+
+	filter := stream.Filter{OrganizationID: tenant.OrganizationID(ctx), UserID: req.UserID}
+	if req.Status != "" {
+		for _, status := range strings.Split(req.Status, ",") {
+			if !enums.AppointmentStatus(status).IsValid() {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status filter"})
+				return
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = req.LastEventID
+	}
+	var since uint64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+		since = parsed
+	}
+
+	sub, replay, complete := c.hub.Subscribe(filter, since)
+	defer c.hub.Unsubscribe(sub)
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	if !complete {
+		fmt.Fprint(ctx.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range replay {
+		if !writeEvent(ctx, event) {
+			return
+		}
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if !writeEvent(ctx, event) {
+				return
+			}
+			ctx.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(ctx.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(ctx *gin.Context, event stream.Event) bool {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Uint64("eventID", event.ID).Msg("Failed to encode stream event")
+		return true
+	}
+	if _, err := fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+		return false
+	}
+	return true
+}
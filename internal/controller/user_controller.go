@@ -3,7 +3,9 @@ package controller
 import (
 	"errors"
 	"net/http"
+	"queue_system/internal/auth"
 	"queue_system/internal/service"
+	"queue_system/internal/tenant"
 	"strconv"
 
 	"queue_system/internal/dto/request"
@@ -28,13 +30,22 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	userResponse, err := uc.UserService.CreateUser(&req)
+	userResponse, err := uc.UserService.CreateUser(tenant.OrganizationID(c), auth.CurrentUser(c), &req)
 	if err != nil {
 		log.Error().Err(err).Interface("request", req).Msg("CreateUser: Service error") // Log lỗi từ service
 		if errors.Is(err, service.ErrEmailExists) {                                     // KIỂM TRA LỖI CỤ THỂ
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()}) // TRẢ VỀ 409
 			return
 		}
+		var denial *auth.Denial
+		if errors.As(err, &denial) {
+			auth.Forbid(c, denial)
+			return
+		}
+		if errors.Is(err, auth.ErrPasswordTooLong) || errors.Is(err, service.ErrOrganizationRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, service.ErrCreateUserFailed) { // Xử lý lỗi chung hơn nếu có
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -54,7 +65,7 @@ func (uc *UserController) GetUserById(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
-	user, err := uc.UserService.GetUserById(uint(id))
+	user, err := uc.UserService.GetUserById(tenant.OrganizationID(c), uint(id))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -65,3 +76,149 @@ func (uc *UserController) GetUserById(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, user)
 }
+
+func (uc *UserController) ListUsers(c *gin.Context) {
+	var req request.ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, total, err := uc.UserService.ListUsers(tenant.OrganizationID(c), auth.CurrentUser(c), &req)
+	if err != nil {
+		if denied(c, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to list users")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, users)
+}
+
+func (uc *UserController) UpdateUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid ID")
+	if !ok {
+		return
+	}
+	var req request.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := uc.UserService.UpdateUser(tenant.OrganizationID(c), auth.CurrentUser(c), id, &req)
+	if err != nil {
+		if denied(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrEmailExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Uint("userID", id).Msg("Failed to update user")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (uc *UserController) DeactivateUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid ID")
+	if !ok {
+		return
+	}
+	var req request.DeactivateUserRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	deactivated, err := uc.UserService.DeactivateUser(tenant.OrganizationID(c), auth.CurrentUser(c), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrCannotDeactivateSelf):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserAlreadyDeactivated):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Uint("userID", id).Msg("Failed to deactivate user")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate user"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, deactivated)
+}
+
+func (uc *UserController) ReactivateUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid ID")
+	if !ok {
+		return
+	}
+
+	user, err := uc.UserService.ReactivateUser(tenant.OrganizationID(c), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserNotDeactivated):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Uint("userID", id).Msg("Failed to reactivate user")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reactivate user"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (uc *UserController) DeleteUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid ID")
+	if !ok {
+		return
+	}
+
+	if err := uc.UserService.DeleteUser(tenant.OrganizationID(c), id); err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserNotDeactivated):
+			c.JSON(http.StatusConflict, gin.H{"error": "deactivate the user before deleting them"})
+		default:
+			log.Error().Err(err).Uint("userID", id).Msg("Failed to delete user")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (uc *UserController) RestoreUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid ID")
+	if !ok {
+		return
+	}
+
+	user, err := uc.UserService.RestoreUser(tenant.OrganizationID(c), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrEmailExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Uint("userID", id).Msg("Failed to restore user")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore user"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
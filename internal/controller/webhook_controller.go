@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/service"
+	"queue_system/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type WebhookController struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookController(webhookService service.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: webhookService,
+	}
+}
+
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	var req request.CreateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	webhook, err := c.webhookService.CreateWebhook(ctx.Request.Context(), tenant.OrganizationID(ctx), &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, webhook)
+}
+
+func (c *WebhookController) ListWebhooks(ctx *gin.Context) {
+	webhooks, err := c.webhookService.ListWebhooks(tenant.OrganizationID(ctx))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, webhooks)
+}
+
+func (c *WebhookController) GetWebhookByID(ctx *gin.Context) {
+	webhookID, ok := parseIDParam(ctx, "id", "Invalid webhook ID format")
+	if !ok {
+		return
+	}
+	webhook, err := c.webhookService.GetWebhookByID(tenant.OrganizationID(ctx), webhookID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	webhookID, ok := parseIDParam(ctx, "id", "Invalid webhook ID format")
+	if !ok {
+		return
+	}
+	var req request.UpdateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	webhook, err := c.webhookService.UpdateWebhook(ctx.Request.Context(), tenant.OrganizationID(ctx), webhookID, &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	webhookID, ok := parseIDParam(ctx, "id", "Invalid webhook ID format")
+	if !ok {
+		return
+	}
+	if err := c.webhookService.DeleteWebhook(tenant.OrganizationID(ctx), webhookID); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *WebhookController) ListDeliveries(ctx *gin.Context) {
+	webhookID, ok := parseIDParam(ctx, "id", "Invalid webhook ID format")
+	if !ok {
+		return
+	}
+	var req request.ListWebhookDeliveriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	deliveries, err := c.webhookService.ListDeliveries(tenant.OrganizationID(ctx), webhookID, &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+func (c *WebhookController) GetDelivery(ctx *gin.Context) {
+	webhookID, deliveryID, ok := c.parseDeliveryParams(ctx)
+	if !ok {
+		return
+	}
+	delivery, err := c.webhookService.GetDelivery(tenant.OrganizationID(ctx), webhookID, deliveryID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+func (c *WebhookController) ReplayDelivery(ctx *gin.Context) {
+	webhookID, deliveryID, ok := c.parseDeliveryParams(ctx)
+	if !ok {
+		return
+	}
+	replay, err := c.webhookService.ReplayDelivery(tenant.OrganizationID(ctx), webhookID, deliveryID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, replay)
+}
+
+func (c *WebhookController) parseDeliveryParams(ctx *gin.Context) (uint, uint, bool) {
+	webhookID, ok := parseIDParam(ctx, "id", "Invalid webhook ID format")
+	if !ok {
+		return 0, 0, false
+	}
+	deliveryID, ok := parseIDParam(ctx, "deliveryId", "Invalid delivery ID format")
+	if !ok {
+		return 0, 0, false
+	}
+	return webhookID, deliveryID, true
+}
+
+func (c *WebhookController) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound),
+		errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUnknownEventType),
+		errors.Is(err, service.ErrWebhookURLNotAllowed):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWebhookInactive):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg("Webhook request failed")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process webhook request"})
+	}
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/service"
+	"queue_system/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type WorkingHoursController struct {
+	workingHoursService service.WorkingHoursService
+}
+
+func NewWorkingHoursController(workingHoursService service.WorkingHoursService) *WorkingHoursController {
+	return &WorkingHoursController{
+		workingHoursService: workingHoursService,
+	}
+}
+
+func (c *WorkingHoursController) ListWorkingHours(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	windows, err := c.workingHoursService.ListWorkingHours(tenant.OrganizationID(ctx), userID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, windows)
+}
+
+func (c *WorkingHoursController) CreateWorkingHours(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	var req request.WorkingHoursRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	window, err := c.workingHoursService.CreateWorkingHours(tenant.OrganizationID(ctx), userID, &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, window)
+}
+
+func (c *WorkingHoursController) UpdateWorkingHours(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	windowID, ok := parseIDParam(ctx, "windowId", "Invalid working hours ID format")
+	if !ok {
+		return
+	}
+	var req request.WorkingHoursRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	window, err := c.workingHoursService.UpdateWorkingHours(tenant.OrganizationID(ctx), userID, windowID, &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, window)
+}
+
+func (c *WorkingHoursController) DeleteWorkingHours(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	windowID, ok := parseIDParam(ctx, "windowId", "Invalid working hours ID format")
+	if !ok {
+		return
+	}
+	if err := c.workingHoursService.DeleteWorkingHours(tenant.OrganizationID(ctx), userID, windowID); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *WorkingHoursController) ListOverrides(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	overrides, err := c.workingHoursService.ListOverrides(tenant.OrganizationID(ctx), userID)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, overrides)
+}
+
+func (c *WorkingHoursController) CreateOverride(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	var req request.AvailabilityOverrideRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	override, err := c.workingHoursService.CreateOverride(tenant.OrganizationID(ctx), userID, &req)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, override)
+}
+
+func (c *WorkingHoursController) DeleteOverride(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	overrideID, ok := parseIDParam(ctx, "overrideId", "Invalid override ID format")
+	if !ok {
+		return
+	}
+	if err := c.workingHoursService.DeleteOverride(tenant.OrganizationID(ctx), userID, overrideID); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *WorkingHoursController) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrWorkingHoursNotFound),
+		errors.Is(err, service.ErrOverrideNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidClockTime),
+		errors.Is(err, service.ErrInvalidTimezone),
+		errors.Is(err, service.ErrInvalidDateFormat),
+		errors.Is(err, service.ErrEndTimeBeforeStartTime),
+		errors.Is(err, service.ErrOverrideHoursMismatch):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg("Working hours request failed")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process working hours request"})
+	}
+}
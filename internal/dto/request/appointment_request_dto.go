@@ -1,11 +1,16 @@
 package request
 
 type AppointmentRequest struct {
-	UserID        uint   `json:"user_id" binding:"required"`
+	// UserID is who the booking is made for. It defaults to the signed-in
+	// user; only staff and admins may book for someone else.
+	UserID        uint   `json:"user_id"`
 	ParticipantID uint   `json:"participant_id" binding:"required"`
 	StartTime     string `json:"start_time" binding:"required"`
 	EndTime       string `json:"end_time" binding:"required"`
 	Description   string `json:"description"`
+	// Set by the CalDAV endpoint for appointments booked from calendar apps.
+	ExternalUID  string `json:"-"`
+	ResourceName string `json:"-"`
 }
 
 type UpdateAppointmentRequest struct {
@@ -14,3 +19,39 @@ type UpdateAppointmentRequest struct {
 	Description *string `json:"description"`
 	Status      *string `json:"status" binding:"omitempty,oneof=pending confirmed cancelled"`
 }
+
+type ListAppointmentsRequest struct {
+	UserID        *uint  `form:"user_id"`
+	ParticipantID *uint  `form:"participant_id"`
+	Status        string `form:"status" binding:"omitempty,oneof=pending confirmed cancelled completed"`
+	From          string `form:"from"`
+	To            string `form:"to"`
+	Sort          string `form:"sort" binding:"omitempty,oneof=asc desc"`
+	// IncludeDeleted also lists deleted appointments, for admins only.
+	IncludeDeleted bool `form:"include_deleted"`
+	Page           int  `form:"page" binding:"omitempty,min=1"`
+	PageSize       int  `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type UserCalendarRequest struct {
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Timezone string `form:"tz"`
+}
+
+type RecurringAppointmentRequest struct {
+	// UserID defaults to the signed-in user, as for AppointmentRequest.
+	UserID        uint   `json:"user_id"`
+	ParticipantID uint   `json:"participant_id" binding:"required"`
+	StartTime     string `json:"start_time" binding:"required"`
+	EndTime       string `json:"end_time" binding:"required"`
+	RRule         string `json:"rrule" binding:"required"`
+	Timezone      string `json:"timezone"`
+	Description   string `json:"description"`
+}
+
+// AppointmentETARequest picks the timezone whose calendar day bounds the
+// participant's earlier appointments. It defaults to UTC.
+type AppointmentETARequest struct {
+	Timezone string `form:"tz"`
+}
@@ -0,0 +1,8 @@
+package request
+
+type AvailabilityRequest struct {
+	UserIDs  string `form:"user_ids" binding:"required"`
+	From     string `form:"from" binding:"required"`
+	To       string `form:"to" binding:"required"`
+	Duration string `form:"duration" binding:"required"`
+}
@@ -0,0 +1,9 @@
+package request
+
+// SubscribeBusyCalendarRequest registers an ICS URL, e.g. a calendar app's
+// secret address, whose events block the user's time. webcal:// URLs are
+// accepted.
+type SubscribeBusyCalendarRequest struct {
+	URL  string `json:"url" binding:"required,url"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,19 @@
+package request
+
+// CreateOrganizationRequest adds an organization together with its first
+// admin, who then adds the other users.
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+	// Slug names the organization in the tenancy header or subdomain, e.g.
+	// north-clinic.
+	Slug  string                   `json:"slug" binding:"required,max=63"`
+	Admin OrganizationAdminRequest `json:"admin"`
+}
+
+type OrganizationAdminRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	// Password is optional; without one the admin signs in with magic
+	// links.
+	Password string `json:"password" binding:"omitempty,min=8,max=72"`
+}
@@ -0,0 +1,20 @@
+package request
+
+type CreateQueueRequest struct {
+	Name         string `json:"name" binding:"required"`
+	ServicePoint string `json:"service_point" binding:"required"`
+	Description  string `json:"description"`
+}
+
+type TakeTicketRequest struct {
+	UserID       *uint  `json:"user_id"`
+	CustomerName string `json:"customer_name"`
+}
+
+type CallNextTicketRequest struct {
+	Counter string `json:"counter" binding:"required"`
+}
+
+type ListTicketsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=waiting called serving done no_show"`
+}
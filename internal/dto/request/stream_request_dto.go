@@ -0,0 +1,10 @@
+package request
+
+// StreamRequest filters the event stream. Status is a comma-separated list
+// of appointment statuses. LastEventID is a fallback for clients that cannot
+// send the Last-Event-ID header.
+type StreamRequest struct {
+	UserID      *uint  `form:"user_id"`
+	Status      string `form:"status"`
+	LastEventID string `form:"last_event_id"`
+}
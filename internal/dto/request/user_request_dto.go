@@ -2,12 +2,41 @@ package request
 
 type CreateUserRequest struct {
 	Name  string `json:"name" binding:"required"`
-	Email string `json:"email" binding:"required"`
-	Role  string `json:"role" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	// Role defaults to client; only admins may pick another.
+	Role  string `json:"role" binding:"omitempty,oneof=admin staff provider client"`
+	Phone string `json:"phone"`
+	// Timezone is an IANA name, e.g. Europe/Berlin; notifications show
+	// times in UTC without one.
+	Timezone string `json:"timezone" binding:"omitempty,timezone"`
+	// Password is optional; users without one sign in with magic links.
+	Password string `json:"password" binding:"omitempty,min=8,max=72"`
 }
 
+// UpdateUserRequest changes the fields that are set. Only admins may
+// change roles.
 type UpdateUserRequest struct {
-	Name  *string `json:"name"`
-	Email *string `json:"email"`
-	Role  *string `json:"role"`
+	Name     *string `json:"name" binding:"omitempty,min=1"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	Phone    *string `json:"phone"`
+	Role     *string `json:"role" binding:"omitempty,oneof=admin staff provider client"`
+	Timezone *string `json:"timezone" binding:"omitempty,timezone"`
+}
+
+type ListUsersRequest struct {
+	// Search matches part of the name or email.
+	Search string `form:"search"`
+	Role   string `form:"role" binding:"omitempty,oneof=admin staff provider client"`
+	Status string `form:"status" binding:"omitempty,oneof=active deactivated"`
+	// IncludeDeleted also lists deleted users, for admins only.
+	IncludeDeleted bool `form:"include_deleted"`
+	Page           int  `form:"page" binding:"omitempty,min=1"`
+	PageSize       int  `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// DeactivateUserRequest says what happens to the user's upcoming pending
+// and confirmed appointments: they are cancelled unless Appointments is
+// "keep".
+type DeactivateUserRequest struct {
+	Appointments string `json:"appointments" binding:"omitempty,oneof=cancel keep"`
 }
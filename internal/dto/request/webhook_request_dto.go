@@ -0,0 +1,20 @@
+package request
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,http_url"`
+	Secret      string   `json:"secret" binding:"required,min=16"`
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description"`
+}
+
+type UpdateWebhookRequest struct {
+	URL         *string   `json:"url" binding:"omitempty,http_url"`
+	Secret      *string   `json:"secret" binding:"omitempty,min=16"`
+	EventTypes  *[]string `json:"event_types"`
+	Description *string   `json:"description"`
+	Active      *bool     `json:"active"`
+}
+
+type ListWebhookDeliveriesRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+}
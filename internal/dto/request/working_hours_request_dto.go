@@ -0,0 +1,17 @@
+package request
+
+type WorkingHoursRequest struct {
+	Weekday   *int   `json:"weekday" binding:"required,min=0,max=6"`
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+	Timezone  string `json:"timezone" binding:"required"`
+}
+
+type AvailabilityOverrideRequest struct {
+	Date      string `json:"date" binding:"required"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Closed    bool   `json:"closed"`
+	Timezone  string `json:"timezone" binding:"required"`
+	Reason    string `json:"reason"`
+}
@@ -0,0 +1,19 @@
+package response
+
+import (
+	"queue_system/internal/model"
+	"time"
+)
+
+type AppointmentSeriesResponse struct {
+	Series       model.AppointmentSeries `json:"series"`
+	Appointments []model.Appointment     `json:"appointments"`
+}
+
+// OccurrenceConflict explains why one occurrence of a series cannot be booked.
+type OccurrenceConflict struct {
+	Start                     time.Time `json:"start"`
+	End                       time.Time `json:"end"`
+	Reason                    string    `json:"reason"`
+	ConflictingAppointmentIDs []uint    `json:"conflicting_appointment_ids,omitempty"`
+}
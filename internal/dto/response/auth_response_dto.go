@@ -0,0 +1,22 @@
+package response
+
+import (
+	"queue_system/internal/model"
+	"time"
+)
+
+// TokenResponse carries a new access token, valid for ExpiresIn seconds,
+// and the refresh token that replaces the one used, if any.
+type TokenResponse struct {
+	AccessToken           string    `json:"access_token"`
+	TokenType             string    `json:"token_type"`
+	ExpiresIn             int       `json:"expires_in"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// APIKeyResponse is a newly created API key. Key is only ever shown here.
+type APIKeyResponse struct {
+	model.APIKey
+	Key string `json:"key"`
+}
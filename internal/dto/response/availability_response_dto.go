@@ -0,0 +1,16 @@
+package response
+
+import "time"
+
+type TimeSlot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type AvailabilityResponse struct {
+	UserIDs  []uint     `json:"user_ids"`
+	From     time.Time  `json:"from"`
+	To       time.Time  `json:"to"`
+	Duration string     `json:"duration"`
+	Slots    []TimeSlot `json:"slots"`
+}
@@ -0,0 +1,24 @@
+package response
+
+import "queue_system/internal/model"
+
+type CalendarDay struct {
+	Date         string              `json:"date"`
+	Appointments []model.Appointment `json:"appointments"`
+}
+
+type UserCalendarResponse struct {
+	UserID   uint          `json:"user_id"`
+	Timezone string        `json:"timezone"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Days     []CalendarDay `json:"days"`
+}
+
+// CalendarFeedResponse carries a freshly issued feed token and the path of
+// the feed it unlocks.
+type CalendarFeedResponse struct {
+	UserID uint   `json:"user_id"`
+	Token  string `json:"token"`
+	URL    string `json:"url"`
+}
@@ -0,0 +1,13 @@
+package response
+
+import "time"
+
+type AppointmentETAResponse struct {
+	AppointmentID  uint      `json:"appointment_id"`
+	ScheduledStart time.Time `json:"scheduled_start"`
+	EstimatedStart time.Time `json:"estimated_start"`
+	DelayMinutes   int       `json:"delay_minutes"`
+	// Samples is how many of the participant's finished appointments that
+	// day the estimate is based on.
+	Samples int `json:"samples"`
+}
@@ -0,0 +1,9 @@
+package response
+
+import "queue_system/internal/model"
+
+// OrganizationResponse is a new organization and its first admin.
+type OrganizationResponse struct {
+	model.Organization
+	Admin model.User `json:"admin"`
+}
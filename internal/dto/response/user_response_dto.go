@@ -0,0 +1,10 @@
+package response
+
+import "queue_system/internal/model"
+
+// DeactivatedUserResponse is a deactivated user and the appointments that
+// were cancelled with them.
+type DeactivatedUserResponse struct {
+	model.User
+	CancelledAppointments []model.Appointment `json:"cancelled_appointments"`
+}
@@ -0,0 +1,15 @@
+package response
+
+import "queue_system/internal/model"
+
+// WaitingLineEntry is an appointment in a participant's waiting line.
+// Position starts at 1 for the next person to be called in.
+type WaitingLineEntry struct {
+	Position    int               `json:"position"`
+	Appointment model.Appointment `json:"appointment"`
+}
+
+type WaitingLineResponse struct {
+	ParticipantID uint               `json:"participant_id"`
+	Entries       []WaitingLineEntry `json:"entries"`
+}
@@ -0,0 +1,9 @@
+package enums
+
+type ArrivalStatus string
+
+const (
+	ArrivedEarly  ArrivalStatus = "early"
+	ArrivedOnTime ArrivalStatus = "on_time"
+	ArrivedLate   ArrivalStatus = "late"
+)
@@ -0,0 +1,18 @@
+package enums
+
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleStaff    Role = "staff"
+	RoleProvider Role = "provider"
+	RoleClient   Role = "client"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleStaff, RoleProvider, RoleClient:
+		return true
+	}
+	return false
+}
@@ -9,6 +9,13 @@ const (
 	Completed AppointmentStatus = "completed"
 )
 
+// allowedTransitions lists the statuses each status may move to.
+// Cancelled and completed are terminal and have no entry.
+var allowedTransitions = map[AppointmentStatus][]AppointmentStatus{
+	Pending:   {Confirmed, Cancelled},
+	Confirmed: {Completed, Cancelled},
+}
+
 func (s AppointmentStatus) IsValid() bool {
 	switch s {
 	case Pending, Confirmed, Cancelled, Completed:
@@ -16,3 +23,16 @@ func (s AppointmentStatus) IsValid() bool {
 	}
 	return false
 }
+
+func (s AppointmentStatus) IsTerminal() bool {
+	return s == Cancelled || s == Completed
+}
+
+func (s AppointmentStatus) CanTransitionTo(next AppointmentStatus) bool {
+	for _, allowed := range allowedTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
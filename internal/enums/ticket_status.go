@@ -0,0 +1,34 @@
+package enums
+
+type TicketStatus string
+
+const (
+	TicketWaiting TicketStatus = "waiting"
+	TicketCalled  TicketStatus = "called"
+	TicketServing TicketStatus = "serving"
+	TicketDone    TicketStatus = "done"
+	TicketNoShow  TicketStatus = "no_show"
+)
+
+var allowedTicketTransitions = map[TicketStatus][]TicketStatus{
+	TicketWaiting: {TicketCalled},
+	TicketCalled:  {TicketServing, TicketNoShow},
+	TicketServing: {TicketDone},
+}
+
+func (s TicketStatus) IsValid() bool {
+	switch s {
+	case TicketWaiting, TicketCalled, TicketServing, TicketDone, TicketNoShow:
+		return true
+	}
+	return false
+}
+
+func (s TicketStatus) CanTransitionTo(next TicketStatus) bool {
+	for _, allowed := range allowedTicketTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+// Package eta predicts how far an appointment will run over its scheduled
+// end from the overruns observed earlier the same day.
+package eta
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	MovingAverageEstimator = "moving_average"
+	PercentileEstimator    = "percentile"
+)
+
+var (
+	ErrUnknownEstimator  = errors.New("unknown ETA estimator, use moving_average or percentile")
+	ErrInvalidWindow     = errors.New("ETA moving average window must be at least 1")
+	ErrInvalidPercentile = errors.New("ETA percentile must be between 0 and 100")
+)
+
+// Estimator turns observed overruns, oldest first, into the overrun
+// expected for each remaining appointment. A negative overrun means the
+// appointment finished early. No samples means no expected overrun.
+type Estimator interface {
+	EstimateOverrun(samples []time.Duration) time.Duration
+}
+
+// New builds the estimator called name. window applies to the moving
+// average and percentile to the percentile estimator.
+func New(name string, window int, percentile float64) (Estimator, error) {
+	switch name {
+	case MovingAverageEstimator:
+		if window < 1 {
+			return nil, ErrInvalidWindow
+		}
+		return MovingAverage{Window: window}, nil
+	case PercentileEstimator:
+		if percentile < 0 || percentile > 100 {
+			return nil, ErrInvalidPercentile
+		}
+		return Percentile{Percentile: percentile}, nil
+	default:
+		return nil, ErrUnknownEstimator
+	}
+}
+
+// MovingAverage averages the most recent Window overruns, so the estimate
+// follows how the day is currently going.
+type MovingAverage struct {
+	Window int
+}
+
+func (m MovingAverage) EstimateOverrun(samples []time.Duration) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	if len(samples) > m.Window {
+		samples = samples[len(samples)-m.Window:]
+	}
+	var total time.Duration
+	for _, sample := range samples {
+		total += sample
+	}
+	return total / time.Duration(len(samples))
+}
+
+// Percentile returns the overrun that the given share of samples did not
+// exceed, using the nearest-rank method. Higher percentiles give more
+// conservative estimates.
+type Percentile struct {
+	Percentile float64
+}
+
+func (p Percentile) EstimateOverrun(samples []time.Duration) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p.Percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
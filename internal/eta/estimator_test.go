@@ -0,0 +1,42 @@
+package eta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	estimator, err := New(MovingAverageEstimator, 3, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, MovingAverage{Window: 3}, estimator)
+
+	estimator, err = New(PercentileEstimator, 0, 80)
+	assert.NoError(t, err)
+	assert.Equal(t, Percentile{Percentile: 80}, estimator)
+
+	_, err = New(MovingAverageEstimator, 0, 0)
+	assert.Equal(t, ErrInvalidWindow, err)
+	_, err = New(PercentileEstimator, 0, 120)
+	assert.Equal(t, ErrInvalidPercentile, err)
+	_, err = New("median", 3, 50)
+	assert.Equal(t, ErrUnknownEstimator, err)
+}
+
+func TestMovingAverage_UsesMostRecentWindow(t *testing.T) {
+	estimator := MovingAverage{Window: 2}
+	samples := []time.Duration{30 * time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+	assert.Equal(t, 10*time.Minute, estimator.EstimateOverrun(samples))
+	assert.Equal(t, time.Duration(0), estimator.EstimateOverrun(nil))
+}
+
+func TestPercentile_NearestRank(t *testing.T) {
+	samples := []time.Duration{10 * time.Minute, -2 * time.Minute, 4 * time.Minute, 20 * time.Minute}
+
+	assert.Equal(t, -2*time.Minute, Percentile{Percentile: 0}.EstimateOverrun(samples))
+	assert.Equal(t, 4*time.Minute, Percentile{Percentile: 50}.EstimateOverrun(samples))
+	assert.Equal(t, 20*time.Minute, Percentile{Percentile: 80}.EstimateOverrun(samples))
+	assert.Equal(t, time.Duration(0), Percentile{Percentile: 80}.EstimateOverrun(nil))
+}
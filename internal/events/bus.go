@@ -0,0 +1,152 @@
+// Package events defines the domain events the system emits and the bus
+// that hands them to in-process subscribers. Events are written to the
+// outbox in the same transaction as the change they describe and reach the
+// bus through the outbox dispatcher, so subscribers see each event at least
+// once and must tolerate duplicates.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	AppointmentCreated     = "appointment.created"
+	AppointmentUpdated     = "appointment.updated"
+	AppointmentRescheduled = "appointment.rescheduled"
+	AppointmentConfirmed   = "appointment.confirmed"
+	AppointmentCancelled   = "appointment.cancelled"
+	AppointmentCompleted   = "appointment.completed"
+	AppointmentStarted     = "appointment.started"
+	AppointmentCheckedIn   = "appointment.checked_in"
+	AppointmentCalledIn    = "appointment.called_in"
+	AppointmentReminder    = "appointment.reminder"
+	AppointmentDeleted     = "appointment.deleted"
+	AppointmentRestored    = "appointment.restored"
+	UserCreated            = "user.created"
+	UserUpdated            = "user.updated"
+	UserDeactivated        = "user.deactivated"
+	UserDeleted            = "user.deleted"
+	UserRestored           = "user.restored"
+
+	// MagicLinkRequested is internal: it is not a known type, so webhooks
+	// are never sent it.
+	MagicLinkRequested = "auth.magic_link_requested"
+)
+
+var knownTypes = map[string]bool{
+	AppointmentCreated:     true,
+	AppointmentUpdated:     true,
+	AppointmentRescheduled: true,
+	AppointmentConfirmed:   true,
+	AppointmentCancelled:   true,
+	AppointmentCompleted:   true,
+	AppointmentStarted:     true,
+	AppointmentCheckedIn:   true,
+	AppointmentCalledIn:    true,
+	AppointmentReminder:    true,
+	AppointmentDeleted:     true,
+	AppointmentRestored:    true,
+	UserCreated:            true,
+	UserUpdated:            true,
+	UserDeactivated:        true,
+	UserDeleted:            true,
+	UserRestored:           true,
+}
+
+// IsKnownType reports whether eventType is one of the events above.
+func IsKnownType(eventType string) bool {
+	return knownTypes[eventType]
+}
+
+// MagicLinkRequest is the payload of MagicLinkRequested. Whether the email
+// belongs to a user is only checked when the link is sent.
+type MagicLinkRequest struct {
+	OrganizationID uint   `json:"organization_id"`
+	Email          string `json:"email"`
+}
+
+// Event is a dispatched outbox entry. Payload is the JSON of the entity the
+// event is about, e.g. a model.Appointment for appointment events, and
+// OrganizationID the organization it happened in.
+type Event struct {
+	ID             uint
+	OrganizationID uint
+	Type           string
+	Payload        json.RawMessage
+	OccurredAt     time.Time
+}
+
+type Handler func(ctx context.Context, event Event) error
+
+type subscription struct {
+	name    string
+	handler Handler
+}
+
+type Bus struct {
+	mu       sync.RWMutex
+	byType   map[string][]subscription
+	wildcard []subscription
+}
+
+func NewBus() *Bus {
+	return &Bus{byType: make(map[string][]subscription)}
+}
+
+// Subscribe registers handler for the given event types. name identifies the
+// subscriber in logs and errors.
+func (b *Bus) Subscribe(name string, handler Handler, eventTypes ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, eventType := range eventTypes {
+		b.byType[eventType] = append(b.byType[eventType], subscription{name: name, handler: handler})
+	}
+}
+
+// SubscribeAll registers handler for every event type.
+func (b *Bus) SubscribeAll(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.wildcard = append(b.wildcard, subscription{name: name, handler: handler})
+}
+
+// Dispatch runs every subscriber of the event and returns their combined
+// errors.
+func (b *Bus) Dispatch(ctx context.Context, event Event) error {
+	_, err := b.Deliver(ctx, event, nil)
+	return err
+}
+
+// Deliver runs the subscribers of the event that are not named in
+// delivered and returns delivered extended by those that succeeded,
+// together with the combined errors of the others. Retrying a failed event
+// with the returned names only runs the subscribers that failed.
+func (b *Bus) Deliver(ctx context.Context, event Event, delivered []string) ([]string, error) {
+	b.mu.RLock()
+	subscriptions := append(append([]subscription(nil), b.byType[event.Type]...), b.wildcard...)
+	b.mu.RUnlock()
+
+	done := make(map[string]bool, len(delivered))
+	for _, name := range delivered {
+		done[name] = true
+	}
+	delivered = append([]string(nil), delivered...)
+	var errs []error
+	for _, sub := range subscriptions {
+		if done[sub.name] {
+			continue
+		}
+		if err := sub.handler(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sub.name, err))
+			continue
+		}
+		done[sub.name] = true
+		delivered = append(delivered, sub.name)
+	}
+	return delivered, errors.Join(errs...)
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus_DispatchesToTypedAndWildcardSubscribers(t *testing.T) {
+	bus := NewBus()
+	var received []string
+	bus.Subscribe("created", func(_ context.Context, event Event) error {
+		received = append(received, "created:"+event.Type)
+		return nil
+	}, AppointmentCreated)
+	bus.SubscribeAll("all", func(_ context.Context, event Event) error {
+		received = append(received, "all:"+event.Type)
+		return nil
+	})
+
+	assert.NoError(t, bus.Dispatch(context.Background(), Event{Type: AppointmentCreated}))
+	assert.NoError(t, bus.Dispatch(context.Background(), Event{Type: UserCreated}))
+
+	assert.Equal(t, []string{"created:appointment.created", "all:appointment.created", "all:user.created"}, received)
+}
+
+func TestBus_RunsEverySubscriberAndJoinsErrors(t *testing.T) {
+	bus := NewBus()
+	boom := errors.New("boom")
+	calls := 0
+	bus.SubscribeAll("failing", func(context.Context, Event) error {
+		calls++
+		return boom
+	})
+	bus.SubscribeAll("healthy", func(context.Context, Event) error {
+		calls++
+		return nil
+	})
+
+	err := bus.Dispatch(context.Background(), Event{Type: UserCreated})
+
+	assert.ErrorIs(t, err, boom)
+	assert.Contains(t, err.Error(), "failing")
+	assert.Equal(t, 2, calls)
+}
+
+func TestBus_DeliverSkipsSubscribersThatAlreadySucceeded(t *testing.T) {
+	bus := NewBus()
+	healthyCalls, failingCalls := 0, 0
+	bus.SubscribeAll("healthy", func(context.Context, Event) error {
+		healthyCalls++
+		return nil
+	})
+	bus.SubscribeAll("failing", func(context.Context, Event) error {
+		failingCalls++
+		return errors.New("boom")
+	})
+
+	delivered, err := bus.Deliver(context.Background(), Event{Type: UserCreated}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"healthy"}, delivered)
+
+	delivered, err = bus.Deliver(context.Background(), Event{Type: UserCreated}, delivered)
+
+	assert.Error(t, err)
+	assert.Equal(t, []string{"healthy"}, delivered)
+	assert.Equal(t, 1, healthyCalls, "a retry only runs the subscribers that failed")
+	assert.Equal(t, 2, failingCalls)
+}
@@ -0,0 +1,186 @@
+// Package ical writes appointments as iCalendar (RFC 5545) data for
+// calendar clients and email invitations, and reads the busy times of
+// calendars users import.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"queue_system/internal/enums"
+	"queue_system/internal/model"
+	"strings"
+	"time"
+)
+
+const (
+	productID = "-//queue_system//Appointments//EN"
+	// maxLineOctets is the longest a content line may be before folding.
+	maxLineOctets = 75
+	timeLayout    = "20060102T150405Z"
+)
+
+// Calendar methods (RFC 5546). Feeds and downloads are published;
+// invitations request or cancel.
+const (
+	MethodPublish = "PUBLISH"
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+type Person struct {
+	Name  string
+	Email string
+}
+
+// Event is one VEVENT. UID must stay the same for every version of the
+// event and Sequence must grow whenever it changes, so that clients update
+// their copy rather than add another.
+type Event struct {
+	UID         string
+	Sequence    int
+	Start       time.Time
+	End         time.Time
+	Created     time.Time
+	Modified    time.Time
+	Summary     string
+	Description string
+	Status      string
+	Organizer   Person
+	Attendee    Person
+}
+
+type Calendar struct {
+	Method string
+	Name   string
+	Events []Event
+}
+
+// UID returns the stable UID of an appointment. domain makes it globally
+// unique, as RFC 5545 asks.
+func UID(appointmentID uint, domain string) string {
+	return fmt.Sprintf("appointment-%d@%s", appointmentID, domain)
+}
+
+// DefaultSummary is the summary of appointments without a description.
+func DefaultSummary(organizer, participant *model.User) string {
+	return fmt.Sprintf("Appointment: %s and %s", organizer.Name, participant.Name)
+}
+
+// AppointmentEvent describes appointment as an event organized by
+// organizer and attended by participant.
+func AppointmentEvent(appointment *model.Appointment, organizer, participant *model.User, domain string) Event {
+	summary := DefaultSummary(organizer, participant)
+	if appointment.Description != "" {
+		summary = appointment.Description
+	}
+	uid := UID(appointment.ID, domain)
+	if appointment.ExternalUID != "" {
+		uid = appointment.ExternalUID
+	}
+	return Event{
+		UID:         uid,
+		Sequence:    appointment.Sequence,
+		Start:       appointment.StartTime,
+		End:         appointment.EndTime,
+		Created:     appointment.CreatedAt,
+		Modified:    appointment.UpdatedAt,
+		Summary:     summary,
+		Description: appointment.Description,
+		Status:      eventStatus(enums.AppointmentStatus(appointment.Status)),
+		Organizer:   Person{Name: organizer.Name, Email: organizer.Email},
+		Attendee:    Person{Name: participant.Name, Email: participant.Email},
+	}
+}
+
+func eventStatus(status enums.AppointmentStatus) string {
+	switch status {
+	case enums.Pending:
+		return "TENTATIVE"
+	case enums.Cancelled:
+		return "CANCELLED"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+// Encode returns the calendar as an iCalendar object with CRLF line
+// endings and long lines folded.
+func (c *Calendar) Encode() []byte {
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeLine(&buf, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", productID)
+	line("CALSCALE", "GREGORIAN")
+	if c.Method != "" {
+		line("METHOD", c.Method)
+	}
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	for i := range c.Events {
+		event := &c.Events[i]
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("SEQUENCE", fmt.Sprint(event.Sequence))
+		line("DTSTAMP", formatTime(event.Modified))
+		line("DTSTART", formatTime(event.Start))
+		line("DTEND", formatTime(event.End))
+		line("CREATED", formatTime(event.Created))
+		line("LAST-MODIFIED", formatTime(event.Modified))
+		line("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeText(event.Description))
+		}
+		line("STATUS", event.Status)
+		writeLine(&buf, "ORGANIZER"+personParams(event.Organizer)+":mailto:"+event.Organizer.Email)
+		writeLine(&buf, "ATTENDEE"+personParams(event.Attendee)+";ROLE=REQ-PARTICIPANT:mailto:"+event.Attendee.Email)
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+func personParams(person Person) string {
+	if person.Name == "" {
+		return ""
+	}
+	// Parameter values cannot be escaped, only quoted; quotes are dropped.
+	return `;CN="` + strings.ReplaceAll(person.Name, `"`, "") + `"`
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+// writeLine writes a content line, folding it into continuation lines of
+// at most maxLineOctets octets without splitting UTF-8 sequences.
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts.
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
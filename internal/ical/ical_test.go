@@ -0,0 +1,76 @@
+package ical
+
+import (
+	"queue_system/internal/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar_EncodesAppointmentEvent(t *testing.T) {
+	start := time.Date(2030, 6, 3, 16, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	appointment := &model.Appointment{
+		ID:          42,
+		StartTime:   start,
+		EndTime:     start.Add(30 * time.Minute),
+		Description: "Check-up; bring results, please",
+		Status:      "cancelled",
+		Sequence:    3,
+		CreatedAt:   time.Date(2030, 5, 1, 8, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2030, 5, 2, 8, 0, 0, 0, time.UTC),
+	}
+	organizer := &model.User{Name: "Dr. Smith", Email: "smith@example.com"}
+	participant := &model.User{Name: "Ana", Email: "ana@example.com"}
+
+	calendar := Calendar{Method: MethodPublish, Events: []Event{AppointmentEvent(appointment, organizer, participant, "example.com")}}
+	encoded := string(calendar.Encode())
+
+	assert.True(t, strings.HasPrefix(encoded, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(encoded, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	for _, line := range []string{
+		"METHOD:PUBLISH",
+		"UID:appointment-42@example.com",
+		"SEQUENCE:3",
+		"DTSTART:20300603T140000Z",
+		"DTEND:20300603T143000Z",
+		"DTSTAMP:20300502T080000Z",
+		`SUMMARY:Check-up\; bring results\, please`,
+		"STATUS:CANCELLED",
+		`ORGANIZER;CN="Dr. Smith":mailto:smith@example.com`,
+		`ATTENDEE;CN="Ana";ROLE=REQ-PARTICIPANT:mailto:ana@example.com`,
+	} {
+		assert.Contains(t, encoded, "\r\n"+line+"\r\n")
+	}
+}
+
+func TestAppointmentEvent_StatusAndSummary(t *testing.T) {
+	organizer := &model.User{Name: "Dr. Smith"}
+	participant := &model.User{Name: "Ana"}
+
+	pending := AppointmentEvent(&model.Appointment{Status: "pending"}, organizer, participant, "example.com")
+	assert.Equal(t, "TENTATIVE", pending.Status)
+	assert.Equal(t, "Appointment: Dr. Smith and Ana", pending.Summary)
+
+	confirmed := AppointmentEvent(&model.Appointment{Status: "confirmed"}, organizer, participant, "example.com")
+	assert.Equal(t, "CONFIRMED", confirmed.Status)
+}
+
+func TestWriteLine_FoldsLongLinesOnRuneBoundaries(t *testing.T) {
+	calendar := Calendar{Name: strings.Repeat("é", 60)}
+	encoded := string(calendar.Encode())
+
+	var folded []string
+	for _, line := range strings.Split(strings.TrimSuffix(encoded, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+		if strings.HasPrefix(line, " ") {
+			folded = append(folded, line)
+		}
+	}
+	require.NotEmpty(t, folded)
+
+	unfolded := strings.ReplaceAll(encoded, "\r\n ", "")
+	assert.Contains(t, unfolded, "X-WR-CALNAME:"+strings.Repeat("é", 60)+"\r\n")
+}
@@ -0,0 +1,411 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"queue_system/internal/recurrence"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "20060102"
+
+var (
+	ErrInvalidCalendar  = errors.New("not an iCalendar file")
+	ErrUnsupportedEvent = errors.New("only single events with a start and end time are supported")
+)
+
+// Busy is one interval in which an imported calendar's owner is busy.
+// Instances of a recurring event share its UID.
+type Busy struct {
+	UID   string
+	Start time.Time
+	End   time.Time
+}
+
+// Incoming is an event a calendar client sent. Organizer and Attendees are
+// email addresses.
+type Incoming struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Status      string
+	Organizer   string
+	Attendees   []string
+}
+
+// property is one content line, e.g. DTSTART;TZID=Europe/Berlin:20300603T090000.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// component collects the properties of one VEVENT. EXDATE and ATTENDEE may
+// be given on several lines.
+type component struct {
+	props     map[string]property
+	exdates   []property
+	attendees []property
+}
+
+// ParseBusy returns the times the events in data block that overlap
+// [from, to), ordered by start. Cancelled and transparent (free) events are
+// left out, as are events it cannot read. Recurring events are expanded
+// within the window, honouring EXDATE and RECURRENCE-ID overrides; when a
+// rule is not supported only its first instance is kept. Dates, and times
+// with an unknown TZID or none, are read as UTC.
+func ParseBusy(data []byte, from, to time.Time) ([]Busy, error) {
+	events, err := readEvents(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Overridden instances are replaced by their own VEVENT.
+	overridden := make(map[string]map[int64]bool)
+	for _, event := range events {
+		if recurrenceID, ok := event.props["RECURRENCE-ID"]; ok {
+			at, _, err := parseTime(recurrenceID)
+			if err != nil {
+				continue
+			}
+			uid := event.props["UID"].value
+			if overridden[uid] == nil {
+				overridden[uid] = make(map[int64]bool)
+			}
+			overridden[uid][at.Unix()] = true
+		}
+	}
+
+	var busy []Busy
+	for _, event := range events {
+		if strings.EqualFold(event.props["STATUS"].value, "CANCELLED") ||
+			strings.EqualFold(event.props["TRANSP"].value, "TRANSPARENT") {
+			continue
+		}
+		start, allDay, err := parseTime(event.props["DTSTART"])
+		if err != nil {
+			continue
+		}
+		duration, err := eventDuration(event, start, allDay)
+		if err != nil || duration <= 0 {
+			continue
+		}
+		uid := event.props["UID"].value
+
+		starts := []time.Time{start}
+		if rrule, ok := event.props["RRULE"]; ok {
+			if _, isOverride := event.props["RECURRENCE-ID"]; !isOverride {
+				starts = expand(rrule.value, start, from.Add(-duration), to)
+			}
+		}
+		excluded := exdates(event)
+		for _, instance := range starts {
+			if excluded[instance.Unix()] {
+				continue
+			}
+			if _, isOverride := event.props["RECURRENCE-ID"]; !isOverride && overridden[uid][instance.Unix()] {
+				continue
+			}
+			end := instance.Add(duration)
+			if instance.Before(to) && end.After(from) {
+				busy = append(busy, Busy{UID: uid, Start: instance.UTC(), End: end.UTC()})
+			}
+		}
+	}
+
+	sort.SliceStable(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+	return busy, nil
+}
+
+// ReadEvent reads the single event of a calendar object resource, as sent
+// by CalDAV clients. Recurring and all-day events return
+// ErrUnsupportedEvent.
+func ReadEvent(data []byte) (*Incoming, error) {
+	events, err := readEvents(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) != 1 {
+		return nil, ErrUnsupportedEvent
+	}
+	event := events[0]
+	if _, ok := event.props["RRULE"]; ok {
+		return nil, ErrUnsupportedEvent
+	}
+
+	start, allDay, err := parseTime(event.props["DTSTART"])
+	if err != nil || allDay {
+		return nil, ErrUnsupportedEvent
+	}
+	duration, err := eventDuration(event, start, allDay)
+	if err != nil || duration <= 0 {
+		return nil, ErrUnsupportedEvent
+	}
+
+	incoming := &Incoming{
+		UID:         event.props["UID"].value,
+		Start:       start.UTC(),
+		End:         start.Add(duration).UTC(),
+		Summary:     unescapeText(event.props["SUMMARY"].value),
+		Description: unescapeText(event.props["DESCRIPTION"].value),
+		Status:      strings.ToUpper(event.props["STATUS"].value),
+		Organizer:   mailAddress(event.props["ORGANIZER"].value),
+	}
+	for _, attendee := range event.attendees {
+		if address := mailAddress(attendee.value); address != "" {
+			incoming.Attendees = append(incoming.Attendees, address)
+		}
+	}
+	return incoming, nil
+}
+
+func mailAddress(value string) string {
+	if len(value) >= len("mailto:") && strings.EqualFold(value[:len("mailto:")], "mailto:") {
+		return strings.TrimSpace(value[len("mailto:"):])
+	}
+	return ""
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(value string) string {
+	return textUnescaper.Replace(value)
+}
+
+// expand returns the instances of rule starting in [from, to), or just start
+// when the rule cannot be expanded.
+func expand(rule string, start, from, to time.Time) []time.Time {
+	parsed, err := recurrence.ParseUnbounded(rule)
+	if err != nil {
+		return []time.Time{start}
+	}
+	instances, err := parsed.Between(start, from, to)
+	if err != nil {
+		return []time.Time{start}
+	}
+	return instances
+}
+
+func eventDuration(event component, start time.Time, allDay bool) (time.Duration, error) {
+	if end, ok := event.props["DTEND"]; ok {
+		endTime, _, err := parseTime(end)
+		if err != nil {
+			return 0, err
+		}
+		return endTime.Sub(start), nil
+	}
+	if duration, ok := event.props["DURATION"]; ok {
+		return parseDuration(duration.value)
+	}
+	// Without an end, an all-day event lasts the day and any other event
+	// takes no time.
+	if allDay {
+		return 24 * time.Hour, nil
+	}
+	return 0, nil
+}
+
+func exdates(event component) map[int64]bool {
+	excluded := make(map[int64]bool)
+	for _, exdate := range event.exdates {
+		for _, value := range strings.Split(exdate.value, ",") {
+			at, _, err := parseTime(property{params: exdate.params, value: value})
+			if err == nil {
+				excluded[at.Unix()] = true
+			}
+		}
+	}
+	return excluded
+}
+
+// parseTime reads a DATE or DATE-TIME value and reports whether it was a
+// date.
+func parseTime(prop property) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.value)
+	if prop.params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		date, err := time.Parse(dateLayout, value)
+		return date, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		at, err := time.Parse(timeLayout, value)
+		return at, false, err
+	}
+	location := time.UTC
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			location = loaded
+		}
+	}
+	at, err := time.ParseInLocation("20060102T150405", value, location)
+	return at, false, err
+}
+
+// parseDuration reads an RFC 5545 duration such as PT1H30M or P1D.
+// Negative durations are rejected.
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "+")
+	rest, ok := strings.CutPrefix(value, "P")
+	if !ok || rest == "" {
+		return 0, ErrInvalidCalendar
+	}
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, r := range rest {
+		switch {
+		case r >= '0' && r <= '9':
+			number += string(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, ErrInvalidCalendar
+		}
+		number = ""
+		switch {
+		case r == 'W' && !inTime:
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			total += time.Duration(n) * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += time.Duration(n) * time.Hour
+		case r == 'M' && inTime:
+			total += time.Duration(n) * time.Minute
+		case r == 'S' && inTime:
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, ErrInvalidCalendar
+		}
+	}
+	if number != "" {
+		return 0, ErrInvalidCalendar
+	}
+	return total, nil
+}
+
+// readEvents unfolds data and collects its VEVENTs, ignoring nested
+// components such as VALARM.
+func readEvents(data []byte) ([]component, error) {
+	var (
+		events     []component
+		current    *component
+		depth      int
+		inCalendar bool
+	)
+	for _, line := range unfold(data) {
+		prop, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+		switch prop.name {
+		case "BEGIN":
+			switch {
+			case strings.EqualFold(prop.value, "VCALENDAR"):
+				inCalendar = true
+			case current != nil:
+				depth++
+			case strings.EqualFold(prop.value, "VEVENT"):
+				current = &component{props: make(map[string]property)}
+			}
+			continue
+		case "END":
+			switch {
+			case current != nil && depth > 0:
+				depth--
+			case current != nil && strings.EqualFold(prop.value, "VEVENT"):
+				events = append(events, *current)
+				current = nil
+			}
+			continue
+		}
+		if current == nil || depth > 0 {
+			continue
+		}
+		switch prop.name {
+		case "EXDATE":
+			current.exdates = append(current.exdates, prop)
+			continue
+		case "ATTENDEE":
+			current.attendees = append(current.attendees, prop)
+			continue
+		}
+		current.props[prop.name] = prop
+	}
+
+	if !inCalendar {
+		return nil, ErrInvalidCalendar
+	}
+	return events, nil
+}
+
+// unfold joins folded content lines.
+func unfold(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseLine splits a content line into its name, parameters and value.
+// Colons and semicolons inside quoted parameter values are not separators.
+func parseLine(line string) (property, bool) {
+	quoted := false
+	split := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			split = i
+			break
+		}
+	}
+	if split <= 0 {
+		return property{}, false
+	}
+
+	prop := property{params: make(map[string]string), value: line[split+1:]}
+	parts := splitParams(line[:split])
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, ok := strings.Cut(param, "=")
+		if ok {
+			prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, true
+}
+
+func splitParams(value string) []string {
+	var parts []string
+	quoted := false
+	last := 0
+	for i, r := range value {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ';' && !quoted:
+			parts = append(parts, value[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, value[last:])
+}
@@ -0,0 +1,203 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func calendarData(lines ...string) []byte {
+	return []byte(strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR"), "\r\n") + "\r\n")
+}
+
+func utc(day, hour, minute int) time.Time {
+	return time.Date(2030, 6, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseBusy_ReadsEventTimes(t *testing.T) {
+	data := calendarData(
+		"BEGIN:VEVENT",
+		"UID:utc@example.com",
+		"DTSTART:20300603T090000Z",
+		"DTEND:20300603T100000Z",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT15M",
+		"DTSTART:20300101T000000Z",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:zoned@example.com",
+		`DTSTART;TZID="Europe/Berlin":20300603T140000`,
+		"DURATION:PT1H30M",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:all-day@example.com",
+		"DTSTART;VALUE=DATE:20300604",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:free@example.com",
+		"DTSTART:20300603T110000Z",
+		"DTEND:20300603T120000Z",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:cancelled@example.com",
+		"DTSTART:20300603T110000Z",
+		"DTEND:20300603T120000Z",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:broken@example.com",
+		"DTSTART:tomorrow",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:outside@example.com",
+		"DTSTART:20300701T090000Z",
+		"DTEND:20300701T100000Z",
+		"END:VEVENT",
+	)
+
+	busy, err := ParseBusy(data, utc(1, 0, 0), utc(30, 0, 0))
+
+	require.NoError(t, err)
+	assert.Equal(t, []Busy{
+		{UID: "utc@example.com", Start: utc(3, 9, 0), End: utc(3, 10, 0)},
+		{UID: "zoned@example.com", Start: utc(3, 12, 0), End: utc(3, 13, 30)},
+		{UID: "all-day@example.com", Start: utc(4, 0, 0), End: utc(5, 0, 0)},
+	}, busy)
+}
+
+func TestParseBusy_ExpandsRecurringEvents(t *testing.T) {
+	data := calendarData(
+		"BEGIN:VEVENT",
+		"UID:standup@example.com",
+		"DTSTART:20300603T083000Z",
+		"DTEND:20300603T084500Z",
+		"RRULE:FREQ=DAILY",
+		"EXDATE:20300605T083000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:standup@example.com",
+		"RECURRENCE-ID:20300606T083000Z",
+		"DTSTART:20300606T100000Z",
+		"DTEND:20300606T101500Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:yearly@example.com",
+		"DTSTART:20300604T120000Z",
+		"DTEND:20300604T130000Z",
+		"RRULE:FREQ=YEARLY",
+		"END:VEVENT",
+	)
+
+	busy, err := ParseBusy(data, utc(3, 12, 0), utc(8, 0, 0))
+
+	require.NoError(t, err)
+	assert.Equal(t, []Busy{
+		{UID: "standup@example.com", Start: utc(4, 8, 30), End: utc(4, 8, 45)},
+		{UID: "yearly@example.com", Start: utc(4, 12, 0), End: utc(4, 13, 0)},
+		{UID: "standup@example.com", Start: utc(6, 10, 0), End: utc(6, 10, 15)},
+		{UID: "standup@example.com", Start: utc(7, 8, 30), End: utc(7, 8, 45)},
+	}, busy)
+}
+
+func TestParseBusy_UnfoldsLines(t *testing.T) {
+	data := calendarData(
+		"BEGIN:VEVENT",
+		"UID:folded@exa",
+		" mple.com",
+		"DTSTART:20300603T09",
+		"\t0000Z",
+		"DTEND:20300603T100000Z",
+		"END:VEVENT",
+	)
+
+	busy, err := ParseBusy(data, utc(1, 0, 0), utc(30, 0, 0))
+
+	require.NoError(t, err)
+	require.Len(t, busy, 1)
+	assert.Equal(t, "folded@example.com", busy[0].UID)
+	assert.Equal(t, utc(3, 9, 0), busy[0].Start)
+}
+
+func TestParseBusy_RejectsOtherData(t *testing.T) {
+	_, err := ParseBusy([]byte("<html>Not found</html>"), utc(1, 0, 0), utc(30, 0, 0))
+	assert.ErrorIs(t, err, ErrInvalidCalendar)
+}
+
+func TestParseDuration(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"PT15M":    15 * time.Minute,
+		"P1DT2H":   26 * time.Hour,
+		"P1W":      7 * 24 * time.Hour,
+		"+PT1H30S": time.Hour + 30*time.Second,
+	} {
+		duration, err := parseDuration(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, duration, value)
+	}
+	for _, value := range []string{"", "P", "-PT1H", "PT1D", "P1H"} {
+		_, err := parseDuration(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestReadEvent_ReadsSingleEvent(t *testing.T) {
+	data := calendarData(
+		"BEGIN:VEVENT",
+		"UID:client-1@example.com",
+		`DTSTART;TZID=Europe/Berlin:20300603T140000`,
+		"DURATION:PT45M",
+		`SUMMARY:Checkup\, yearly`,
+		"STATUS:confirmed",
+		"ORGANIZER;CN=Ada:mailto:ada@example.com",
+		`ATTENDEE;CN="Lovelace, Ada":MAILTO:ada@example.com`,
+		"ATTENDEE:mailto:bob@exam",
+		" ple.com",
+		"ATTENDEE:urn:uuid:1234",
+		"END:VEVENT",
+	)
+
+	event, err := ReadEvent(data)
+
+	require.NoError(t, err)
+	assert.Equal(t, "client-1@example.com", event.UID)
+	assert.Equal(t, utc(3, 12, 0), event.Start)
+	assert.Equal(t, utc(3, 12, 45), event.End)
+	assert.Equal(t, "Checkup, yearly", event.Summary)
+	assert.Equal(t, "CONFIRMED", event.Status)
+	assert.Equal(t, "ada@example.com", event.Organizer)
+	assert.Equal(t, []string{"ada@example.com", "bob@example.com"}, event.Attendees)
+}
+
+func TestReadEvent_RejectsUnsupportedEvents(t *testing.T) {
+	tests := map[string][]byte{
+		"two events": calendarData(
+			"BEGIN:VEVENT", "DTSTART:20300603T090000Z", "DTEND:20300603T100000Z", "END:VEVENT",
+			"BEGIN:VEVENT", "DTSTART:20300604T090000Z", "DTEND:20300604T100000Z", "END:VEVENT",
+		),
+		"recurring": calendarData(
+			"BEGIN:VEVENT", "DTSTART:20300603T090000Z", "DTEND:20300603T100000Z", "RRULE:FREQ=DAILY", "END:VEVENT",
+		),
+		"all day": calendarData(
+			"BEGIN:VEVENT", "DTSTART;VALUE=DATE:20300603", "END:VEVENT",
+		),
+		"no end": calendarData(
+			"BEGIN:VEVENT", "DTSTART:20300603T090000Z", "END:VEVENT",
+		),
+		"no events": calendarData(),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadEvent(data)
+
+			assert.ErrorIs(t, err, ErrUnsupportedEvent)
+		})
+	}
+
+	_, err := ReadEvent([]byte("BEGIN:VEVENT\r\nEND:VEVENT\r\n"))
+	assert.ErrorIs(t, err, ErrInvalidCalendar)
+}
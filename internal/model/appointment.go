@@ -1,15 +1,40 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Appointment struct {
-	ID            uint      `gorm:"primaryKey"`
-	UserID        uint      `gorm:"not null"`
-	ParticipantID uint      `gorm:"not null"`
-	StartTime     time.Time `gorm:"not null"`
-	EndTime       time.Time `gorm:"not null"`
-	Description   string
-	Status        string    `gorm:"default:'pending'"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID             uint      `gorm:"primaryKey"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id"`
+	UserID         uint      `gorm:"not null"`
+	ParticipantID  uint      `gorm:"not null"`
+	StartTime      time.Time `gorm:"not null"`
+	EndTime        time.Time `gorm:"not null"`
+	Description    string
+	SeriesID       *uint  `gorm:"index" json:"series_id"`
+	Status         string `gorm:"default:'pending'"`
+	Sequence       int    `gorm:"not null;default:0" json:"sequence"`
+	// ExternalUID and ResourceName are the iCalendar UID and CalDAV resource
+	// name a calendar client chose when it created the appointment.
+	ExternalUID   string     `json:"-"`
+	ResourceName  string     `gorm:"index" json:"-"`
+	ConfirmedByID *uint      `json:"confirmed_by_id"`
+	ConfirmedAt   *time.Time `json:"confirmed_at"`
+	CancelledByID *uint      `json:"cancelled_by_id"`
+	CancelledAt   *time.Time `json:"cancelled_at"`
+	CompletedByID *uint      `json:"completed_by_id"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	CheckedInAt   *time.Time `json:"checked_in_at"`
+	ArrivalStatus string     `json:"arrival_status"`
+	CalledInAt    *time.Time `json:"called_in_at"`
+	ActualStartAt *time.Time `json:"actual_start_at"`
+	ActualEndAt   *time.Time `json:"actual_end_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	// DeletedAt is set when the appointment is deleted. Deleted appointments
+	// are left out of queries until they are restored or purged.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
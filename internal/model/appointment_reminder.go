@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// AppointmentReminder records that the reminder LeadMinutes before an
+// appointment starting at StartTime has been handled, so it is never sent
+// twice. A rescheduled appointment has a new StartTime and gets reminded
+// again. Skipped reminders were superseded by a shorter lead time that was
+// already due and were not sent.
+type AppointmentReminder struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	AppointmentID uint      `gorm:"not null;uniqueIndex:idx_appointment_reminder" json:"appointment_id"`
+	LeadMinutes   int       `gorm:"not null;uniqueIndex:idx_appointment_reminder" json:"lead_minutes"`
+	StartTime     time.Time `gorm:"not null;uniqueIndex:idx_appointment_reminder" json:"start_time"`
+	Skipped       bool      `gorm:"not null;default:false" json:"skipped"`
+	SentAt        time.Time `gorm:"not null" json:"sent_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
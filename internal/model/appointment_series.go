@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// AppointmentSeries groups the appointments expanded from one recurrence rule.
+type AppointmentSeries struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id"`
+	UserID         uint      `gorm:"not null" json:"user_id"`
+	ParticipantID  uint      `gorm:"not null" json:"participant_id"`
+	RRule          string    `gorm:"not null" json:"rrule"`
+	Timezone       string    `gorm:"not null" json:"timezone"`
+	Description    string    `json:"description"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
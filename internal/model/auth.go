@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// RefreshToken lets a client obtain new access tokens. Only a hash of the
+// token is stored. Each use revokes the token and issues a new one in the
+// same family; presenting a revoked token revokes the whole family, since
+// the token must have been copied.
+type RefreshToken struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null" json:"organization_id"`
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	FamilyID       string     `gorm:"not null;index" json:"-"`
+	TokenHash      string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// MagicLink is a single-use login link sent by email. Only a hash of its
+// token is stored.
+type MagicLink struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null" json:"organization_id"`
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash      string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt         *time.Time `json:"used_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// APIKey lets a machine client, such as a kiosk, act as the user who
+// created it, limited to Scopes. Only a hash of the key is stored; Prefix
+// is kept so users can tell their keys apart.
+type APIKey struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null" json:"organization_id"`
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	Name           string     `gorm:"not null" json:"name"`
+	Prefix         string     `gorm:"not null" json:"prefix"`
+	KeyHash        string     `gorm:"not null;uniqueIndex" json:"-"`
+	Scopes         []string   `gorm:"type:jsonb;serializer:json" json:"scopes"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// BusyCalendar is a calendar a user keeps elsewhere whose events block the
+// user's time. A calendar with a URL is fetched again every refresh
+// interval; an uploaded one keeps its Data, which is expanded again as time
+// moves on but changes only when it is uploaded again. The URL is not
+// returned by the API, since calendar apps put a secret in it.
+type BusyCalendar struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;index" json:"user_id"`
+	Name         string     `json:"name"`
+	URL          string     `json:"-"`
+	Data         string     `gorm:"type:text" json:"-"`
+	BlockCount   int        `gorm:"not null;default:0" json:"block_count"`
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	LastError    string     `json:"last_error,omitempty"`
+	NextSyncAt   *time.Time `gorm:"index" json:"next_sync_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// BusyBlock is one interval in which an imported calendar's owner is busy.
+// Only the times are kept, not what the owner is doing.
+type BusyBlock struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CalendarID uint      `gorm:"not null;index" json:"calendar_id"`
+	UserID     uint      `gorm:"not null;index:idx_busy_blocks_user_time" json:"user_id"`
+	StartTime  time.Time `gorm:"not null;index:idx_busy_blocks_user_time" json:"start_time"`
+	EndTime    time.Time `gorm:"not null" json:"end_time"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// CalendarFeed holds the hash of the secret token that lets calendar
+// clients read a user's appointment feed without other credentials. The
+// token itself is only shown when issued, and the feed is found by it, so
+// subscription URLs need not name the organization. Rotating the token
+// revokes every earlier subscription URL.
+type CalendarFeed struct {
+	UserID         uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	OrganizationID uint      `gorm:"not null" json:"organization_id"`
+	TokenHash      string    `gorm:"not null;uniqueIndex" json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
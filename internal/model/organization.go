@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Organization is a tenant, such as one clinic. Users, appointments, queues
+// and webhooks belong to exactly one; requests name theirs by Slug.
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Slug      string    `gorm:"not null;uniqueIndex" json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
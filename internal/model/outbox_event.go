@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// OutboxEvent is a domain event waiting to be dispatched. Rows are written
+// in the same transaction as the change they describe; DispatchedAt is set
+// once every subscriber has handled the event. DeliveredTo names the
+// subscribers that already have, so a retry skips them. An event that still
+// fails after the configured number of attempts gets FailedAt and is no
+// longer retried. OrganizationID is the organization the event happened in.
+type OutboxEvent struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null" json:"organization_id"`
+	Type           string     `gorm:"not null;index" json:"type"`
+	Payload        string     `gorm:"type:jsonb;not null" json:"payload"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	LastError      string     `json:"last_error"`
+	DeliveredTo    []string   `gorm:"type:jsonb;serializer:json" json:"delivered_to"`
+	AvailableAt    time.Time  `gorm:"not null;index" json:"available_at"`
+	DispatchedAt   *time.Time `gorm:"index" json:"dispatched_at"`
+	FailedAt       *time.Time `gorm:"index" json:"failed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
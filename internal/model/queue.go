@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// Queue is a named walk-in line at a service point of an organization.
+// NextNumber is the number the next ticket taken from it will get.
+type Queue struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;uniqueIndex:idx_queues_organization_name_service_point" json:"organization_id"`
+	Name           string    `gorm:"not null;uniqueIndex:idx_queues_organization_name_service_point" json:"name"`
+	ServicePoint   string    `gorm:"not null;uniqueIndex:idx_queues_organization_name_service_point" json:"service_point"`
+	Description    string    `json:"description"`
+	NextNumber     int       `gorm:"not null;default:1" json:"next_number"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type QueueTicket struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null;index" json:"organization_id"`
+	QueueID        uint       `gorm:"not null;uniqueIndex:idx_queue_ticket_number" json:"queue_id"`
+	Number         int        `gorm:"not null;uniqueIndex:idx_queue_ticket_number" json:"number"`
+	UserID         *uint      `json:"user_id"`
+	CustomerName   string     `json:"customer_name"`
+	Status         string     `gorm:"not null;default:'waiting';index" json:"status"`
+	Counter        string     `json:"counter"`
+	CalledAt       *time.Time `json:"called_at"`
+	ServingAt      *time.Time `json:"serving_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
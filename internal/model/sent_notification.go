@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// SentNotification records that the message for an outbox event has been
+// sent to a user, so a retried event skips the recipients it already
+// reached.
+type SentNotification struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	EventID   uint      `gorm:"not null;uniqueIndex:idx_sent_notification" json:"event_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_sent_notification;index" json:"user_id"`
+	Kind      string    `gorm:"not null" json:"kind"`
+	SentAt    time.Time `gorm:"not null" json:"sent_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -1,12 +1,42 @@
 package model
 
-import "time"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// User belongs to one organization. Emails are unique among its users that
+// are not deleted, so the same person may have an account at several
+// organizations and a deleted user's email can be used again.
 type User struct {
-	ID        uint      `gorm:"primaryKey"`
-	Name      string    `gorm:"not null"`
-	Email     string    `gorm:"unique;not null"`
-	Role      string    `gorm:"not null"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             uint   `gorm:"primaryKey"`
+	OrganizationID uint   `gorm:"not null;uniqueIndex:idx_users_organization_email,where:deleted_at IS NULL" json:"organization_id"`
+	Name           string `gorm:"not null"`
+	Email          string `gorm:"not null;uniqueIndex:idx_users_organization_email"`
+	Phone          string
+	Role           string `gorm:"not null"`
+	// Timezone is the IANA name of the zone notifications show times in,
+	// empty for UTC.
+	Timezone string `json:"timezone"`
+	// PasswordHash is a bcrypt hash, empty for users who only sign in with
+	// magic links.
+	PasswordHash string `json:"-"`
+	// DeactivatedAt is set while the user may neither sign in nor be
+	// booked. Their history is kept.
+	DeactivatedAt *time.Time `json:"deactivated_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	// DeletedAt is set when the user is deleted. Deleted users are left out
+	// of queries until they are restored or purged.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	// PurgedAt is set when a deleted user is purged. Their personal details
+	// are erased but the row stays, so the appointments they took part in
+	// still name a user. Purged users cannot be restored.
+	PurgedAt *time.Time `json:"-"`
+}
+
+// Active reports whether the user has not been deactivated.
+func (u *User) Active() bool {
+	return u.DeactivatedAt == nil
 }
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Webhook is an integrator endpoint that is sent the organization's events
+// listed in EventTypes, or all of them when EventTypes is empty. Requests
+// are signed with Secret, which is never returned by the API.
+type Webhook struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id"`
+	URL            string    `gorm:"not null" json:"url"`
+	Secret         string    `gorm:"not null" json:"-"`
+	EventTypes     []string  `gorm:"type:jsonb;serializer:json" json:"event_types"`
+	Description    string    `json:"description"`
+	Active         bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the webhook wants events of eventType.
+func (w *Webhook) Subscribes(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, subscribed := range w.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent, or still to be sent, to a webhook.
+// Each event is delivered at most once per webhook unless it is replayed, in
+// which case the replay is a new delivery pointing at ReplayOfID.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null" json:"organization_id"`
+	WebhookID      uint       `gorm:"not null;index;uniqueIndex:idx_webhook_delivery_event,where:replay_of_id IS NULL" json:"webhook_id"`
+	EventID        uint       `gorm:"not null;uniqueIndex:idx_webhook_delivery_event,where:replay_of_id IS NULL" json:"event_id"`
+	EventType      string     `gorm:"not null" json:"event_type"`
+	Payload        string     `gorm:"type:jsonb;not null" json:"payload"`
+	OccurredAt     time.Time  `json:"occurred_at"`
+	Status         string     `gorm:"not null;default:'pending';index" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	ResponseCode   int        `json:"response_code"`
+	LastError      string     `json:"last_error"`
+	NextAttemptAt  time.Time  `gorm:"not null;index" json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	ReplayOfID     *uint      `json:"replay_of_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
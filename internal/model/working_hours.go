@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// WorkingHours is a weekly recurring window in which a user can be booked.
+// StartTime and EndTime are wall-clock times (HH:MM) in Timezone.
+type WorkingHours struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Weekday   int       `gorm:"not null" json:"weekday"`
+	StartTime string    `gorm:"not null" json:"start_time"`
+	EndTime   string    `gorm:"not null" json:"end_time"`
+	Timezone  string    `gorm:"not null" json:"timezone"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AvailabilityOverride replaces a user's weekly working hours on one date.
+// A closed override marks a holiday or day off.
+type AvailabilityOverride struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Date      string    `gorm:"not null;index" json:"date"`
+	StartTime string    `json:"start_time"`
+	EndTime   string    `json:"end_time"`
+	Closed    bool      `gorm:"not null;default:false" json:"closed"`
+	Timezone  string    `gorm:"not null" json:"timezone"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,117 @@
+// Package netguard keeps requests to user-supplied URLs, such as webhooks
+// and subscribed calendars, away from the deployment's own network.
+// Loopback, private, link-local and other non-public addresses are refused
+// both when a URL is registered and when a connection is dialed, so a DNS
+// answer that changes in between cannot reach them either.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL       = errors.New("url must be an absolute http or https url")
+	ErrForbiddenAddress = errors.New("url resolves to a non-public address")
+)
+
+// blockedPrefixes are non-public ranges not covered by the netip
+// predicates used in Blocked.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Blocked reports whether addr is not a public unicast address.
+func Blocked(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Guard checks outgoing URLs and dials. AllowPrivate turns the checks off,
+// for development setups and tests whose endpoints run on localhost.
+type Guard struct {
+	AllowPrivate bool
+	resolver     *net.Resolver
+}
+
+func New(allowPrivate bool) *Guard {
+	return &Guard{AllowPrivate: allowPrivate, resolver: net.DefaultResolver}
+}
+
+// CheckURL fails unless rawURL is an http or https URL whose host resolves
+// only to public addresses.
+func (g *Guard) CheckURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrInvalidURL
+	}
+	if g.AllowPrivate {
+		return nil
+	}
+
+	host := parsed.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if Blocked(addr) {
+			return ErrForbiddenAddress
+		}
+		return nil
+	}
+	addrs, err := g.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("%w: %s cannot be resolved", ErrInvalidURL, host)
+	}
+	for _, addr := range addrs {
+		if Blocked(addr) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// DialContext dials like a net.Dialer with the given timeout but refuses
+// to connect to blocked addresses once the host has been resolved.
+func (g *Guard) DialContext(timeout time.Duration) func(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !g.AllowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if Blocked(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+	return dialer.DialContext
+}
+
+// Client returns an HTTP client whose connections go through DialContext.
+// Proxies from the environment are ignored so they cannot bypass the check.
+func (g *Guard) Client(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = g.DialContext(timeout)
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
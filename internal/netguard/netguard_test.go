@@ -0,0 +1,51 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocked(t *testing.T) {
+	for _, blocked := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1"} {
+		assert.True(t, Blocked(netip.MustParseAddr(blocked)), blocked)
+	}
+	for _, public := range []string{"93.184.216.34", "2606:4700::1111"} {
+		assert.False(t, Blocked(netip.MustParseAddr(public)), public)
+	}
+}
+
+func TestGuard_CheckURL(t *testing.T) {
+	guard := New(false)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, guard.CheckURL(ctx, "ftp://example.com/feed"), ErrInvalidURL)
+	assert.ErrorIs(t, guard.CheckURL(ctx, "/relative"), ErrInvalidURL)
+	assert.ErrorIs(t, guard.CheckURL(ctx, "http://127.0.0.1:8080/hook"), ErrForbiddenAddress)
+	assert.ErrorIs(t, guard.CheckURL(ctx, "http://[::1]/hook"), ErrForbiddenAddress)
+	assert.ErrorIs(t, guard.CheckURL(ctx, "http://169.254.169.254/latest/meta-data"), ErrForbiddenAddress)
+	assert.ErrorIs(t, guard.CheckURL(ctx, "http://localhost/hook"), ErrForbiddenAddress)
+	assert.NoError(t, guard.CheckURL(ctx, "https://93.184.216.34/hook"))
+
+	assert.NoError(t, New(true).CheckURL(ctx, "http://127.0.0.1:8080/hook"))
+}
+
+func TestGuard_ClientRefusesPrivateAddressesWhenDialing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := New(false).Client(time.Second).Get(server.URL)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrForbiddenAddress), err.Error())
+
+	resp, err := New(true).Client(time.Second).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
@@ -0,0 +1,24 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ConsoleNotifier logs notifications instead of sending them. It is meant
+// for development.
+type ConsoleNotifier struct{}
+
+func NewConsoleNotifier() *ConsoleNotifier {
+	return &ConsoleNotifier{}
+}
+
+func (n *ConsoleNotifier) Notify(_ context.Context, recipient Recipient, message Message) error {
+	log.Info().
+		Str("to", recipient.Email).
+		Str("phone", recipient.Phone).
+		Str("subject", message.Subject).
+		Msg(message.Text)
+	return nil
+}
@@ -0,0 +1,54 @@
+// Package notification tells users about their appointments through a
+// configurable channel. Messages are rendered from templates and sent by a
+// Notifier; the Sender turns appointment events into messages.
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"queue_system/config"
+)
+
+// ErrNoAddress is returned by a Notifier when the recipient has no address
+// on its channel, e.g. no phone number for SMS.
+var ErrNoAddress = errors.New("recipient has no address for this channel")
+
+type Recipient struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+// Message is a rendered notification. Channels that cannot show HTML send
+// Text; channels that cannot carry files drop Attachments.
+type Message struct {
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, recipient Recipient, message Message) error
+}
+
+// NewNotifier builds the Notifier for the configured channel.
+func NewNotifier(cfg *config.Config) (Notifier, error) {
+	switch cfg.Notification.Channel {
+	case "", "console":
+		return NewConsoleNotifier(), nil
+	case "smtp":
+		return NewSMTPNotifier(cfg.Notification.SMTP)
+	case "sms":
+		return NewSMSNotifier(cfg.Notification.SMS)
+	default:
+		return nil, fmt.Errorf("unknown notification channel %q", cfg.Notification.Channel)
+	}
+}
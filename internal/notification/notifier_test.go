@@ -0,0 +1,199 @@
+package notification
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"queue_system/config"
+	"queue_system/internal/notification/smtptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNotifier_SelectsConfiguredChannel(t *testing.T) {
+	cfg := &config.Config{}
+	notifier, err := NewNotifier(cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &ConsoleNotifier{}, notifier)
+
+	cfg.Notification.Channel = "smtp"
+	_, err = NewNotifier(cfg)
+	assert.Error(t, err, "smtp without a host is rejected")
+
+	cfg.Notification.SMTP = config.SMTP{Host: "localhost", Port: "25", From: "Clinic <noreply@example.com>"}
+	notifier, err = NewNotifier(cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &SMTPNotifier{}, notifier)
+
+	cfg.Notification.Channel = "pigeon"
+	_, err = NewNotifier(cfg)
+	assert.Error(t, err)
+}
+
+func TestSMTPNotifier_SendsMultipartMail(t *testing.T) {
+	// GIVEN
+	server, err := smtptest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	notifier, err := NewSMTPNotifier(config.SMTP{Host: server.Host(), Port: server.Port(), From: "Clinic <noreply@example.com>"})
+	require.NoError(t, err)
+
+	// WHEN
+	err = notifier.Notify(context.Background(), Recipient{Name: "Ana", Email: "ana@example.com"}, Message{
+		Subject: "Appointment confirmed ✓",
+		Text:    "See you soon.\n",
+		HTML:    "<p>See you soon.</p>\n",
+	})
+
+	// THEN
+	require.NoError(t, err)
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "noreply@example.com", messages[0].From)
+	assert.Equal(t, []string{"ana@example.com"}, messages[0].To)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(messages[0].Data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Appointment confirmed ✓", subject)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, err)
+		bodies = append(bodies, part.Header.Get("Content-Type")+"|"+string(body))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=utf-8|See you soon.\r\n",
+		"text/html; charset=utf-8|<p>See you soon.</p>\r\n",
+	}, bodies)
+}
+
+func TestSMTPNotifier_GivesUpWhenContextEnds(t *testing.T) {
+	// GIVEN a server that accepts connections but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	notifier, err := NewSMTPNotifier(config.SMTP{Host: host, Port: port, From: "noreply@example.com", Timeout: time.Minute})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// WHEN
+	started := time.Now()
+	err = notifier.Notify(ctx, Recipient{Email: "ana@example.com"}, Message{Subject: "Hi", Text: "Hi\n"})
+
+	// THEN
+	assert.Error(t, err)
+	assert.Less(t, time.Since(started), 5*time.Second)
+}
+
+func TestSMSNotifier_PostsToGateway(t *testing.T) {
+	// GIVEN
+	var received smsRequest
+	var authorization string
+	status := http.StatusAccepted
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer gateway.Close()
+
+	notifier, err := NewSMSNotifier(config.SMS{GatewayURL: gateway.URL, Token: "gateway-token", Sender: "Clinic"})
+	require.NoError(t, err)
+	message := Message{Subject: "ignored", Text: "Reminder: appointment in 1 hour"}
+
+	// WHEN
+	err = notifier.Notify(context.Background(), Recipient{Phone: "+15550100"}, message)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer gateway-token", authorization)
+	assert.Equal(t, smsRequest{To: "+15550100", From: "Clinic", Message: "Reminder: appointment in 1 hour"}, received)
+
+	assert.ErrorIs(t, notifier.Notify(context.Background(), Recipient{Email: "ana@example.com"}, message), ErrNoAddress)
+
+	status = http.StatusBadGateway
+	assert.ErrorContains(t, notifier.Notify(context.Background(), Recipient{Phone: "+15550100"}, message), "502")
+}
+
+func TestSMTPNotifier_AttachesFiles(t *testing.T) {
+	// GIVEN
+	server, err := smtptest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	notifier, err := NewSMTPNotifier(config.SMTP{Host: server.Host(), Port: server.Port(), From: "noreply@example.com"})
+	require.NoError(t, err)
+	invite := []byte("BEGIN:VCALENDAR\r\nMETHOD:REQUEST\r\nEND:VCALENDAR\r\n")
+
+	// WHEN
+	err = notifier.Notify(context.Background(), Recipient{Email: "ana@example.com"}, Message{
+		Subject:     "Appointment confirmed",
+		Text:        "See you soon.",
+		HTML:        "<p>See you soon.</p>",
+		Attachments: []Attachment{{Filename: "invite.ics", ContentType: "text/calendar; method=REQUEST", Content: invite}},
+	})
+
+	// THEN
+	require.NoError(t, err)
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	parsed, err := mail.ReadMessage(strings.NewReader(messages[0].Data))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+
+	body, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(body.Header.Get("Content-Type"), "multipart/alternative"))
+
+	attachment, err := parts.NextRawPart()
+	require.NoError(t, err)
+	assert.Equal(t, "invite.ics", attachment.FileName())
+	assert.Equal(t, "text/calendar; method=REQUEST", attachment.Header.Get("Content-Type"))
+	content, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+	require.NoError(t, err)
+	assert.Equal(t, invite, content)
+
+	_, err = parts.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,173 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"queue_system/config"
+	"queue_system/internal/events"
+	"queue_system/internal/ical"
+	"queue_system/internal/model"
+	"queue_system/internal/reminder"
+	"queue_system/internal/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// eventKinds maps the events users are notified about to message kinds.
+var eventKinds = map[string]string{
+	events.AppointmentCreated:     BookingConfirmation,
+	events.AppointmentConfirmed:   BookingConfirmation,
+	events.AppointmentCancelled:   Cancellation,
+	events.AppointmentRescheduled: Reschedule,
+	events.AppointmentReminder:    Reminder,
+}
+
+// invitationMethods maps the events whose messages carry a calendar
+// invitation to its iTIP method.
+var invitationMethods = map[string]string{
+	events.AppointmentCreated:     ical.MethodRequest,
+	events.AppointmentConfirmed:   ical.MethodRequest,
+	events.AppointmentRescheduled: ical.MethodRequest,
+	events.AppointmentCancelled:   ical.MethodCancel,
+}
+
+// Sender notifies both people on an appointment when it is booked,
+// confirmed, cancelled, rescheduled or due for a reminder.
+type Sender struct {
+	notifier               Notifier
+	templates              *Templates
+	userRepository         repository.UserRepository
+	notificationRepository repository.NotificationRepository
+	uidDomain              string
+}
+
+func NewSender(notifier Notifier, templates *Templates, userRepository repository.UserRepository, notificationRepository repository.NotificationRepository, cfg *config.Config) *Sender {
+	return &Sender{
+		notifier:               notifier,
+		templates:              templates,
+		userRepository:         userRepository,
+		notificationRepository: notificationRepository,
+		uidDomain:              cfg.Calendar.UIDDomain,
+	}
+}
+
+// EventTypes lists the events HandleEvent should be subscribed to.
+func (s *Sender) EventTypes() []string {
+	eventTypes := make([]string, 0, len(eventKinds))
+	for eventType := range eventKinds {
+		eventTypes = append(eventTypes, eventType)
+	}
+	return eventTypes
+}
+
+// HandleEvent sends the message for event to the appointment's organizer
+// and participant, showing times in each recipient's timezone. Every send
+// is recorded, so when a failure is returned and the event is retried only
+// the recipients that were not reached get the message. Recipients without
+// an address on the channel are skipped.
+func (s *Sender) HandleEvent(ctx context.Context, event events.Event) error {
+	kind, ok := eventKinds[event.Type]
+	if !ok {
+		return nil
+	}
+	// Reminder payloads are appointments with a lead time, so every event
+	// handled here decodes into a reminder payload.
+	var payload reminder.Payload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+
+	organizer, err := s.userRepository.GetById(payload.OrganizationID, payload.UserID)
+	if err != nil {
+		return err
+	}
+	participant, err := s.userRepository.GetById(payload.OrganizationID, payload.ParticipantID)
+	if err != nil {
+		return err
+	}
+	if organizer == nil || participant == nil {
+		log.Warn().Uint("appointmentID", payload.ID).Msg("Skipping notification for appointment with a missing user")
+		return nil
+	}
+
+	data := TemplateData{
+		Organizer:   *organizer,
+		Participant: *participant,
+		Appointment: payload.Appointment,
+	}
+	if payload.LeadMinutes > 0 {
+		data.LeadTime = formatLeadTime(payload.LeadMinutes)
+	}
+	var attachments []Attachment
+	if method, ok := invitationMethods[event.Type]; ok {
+		attachments = append(attachments, invitation(method, ical.AppointmentEvent(&payload.Appointment, organizer, participant, s.uidDomain)))
+	}
+
+	var errs []error
+	for _, recipient := range []*model.User{organizer, participant} {
+		sent, err := s.notificationRepository.WasSent(event.ID, recipient.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if sent {
+			continue
+		}
+		data.Recipient = *recipient
+		data.Appointment = inTimezone(payload.Appointment, recipient.Timezone)
+		message, err := s.templates.Render(kind, data)
+		if err != nil {
+			return err
+		}
+		message.Attachments = attachments
+		err = s.notifier.Notify(ctx, Recipient{Name: recipient.Name, Email: recipient.Email, Phone: recipient.Phone}, message)
+		if errors.Is(err, ErrNoAddress) {
+			log.Warn().Uint("userID", recipient.ID).Str("kind", kind).Msg("Skipping notification for user without an address")
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.notificationRepository.RecordSent(&model.SentNotification{EventID: event.ID, UserID: recipient.ID, Kind: kind, SentAt: time.Now()}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// inTimezone returns the appointment with its times in the named zone, or
+// in UTC when the name is empty or unknown.
+func inTimezone(appointment model.Appointment, timezone string) model.Appointment {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	appointment.StartTime = appointment.StartTime.In(loc)
+	appointment.EndTime = appointment.EndTime.In(loc)
+	return appointment
+}
+
+// SendMagicLink sends user a login link that is valid for validity.
+func (s *Sender) SendMagicLink(ctx context.Context, user *model.User, link string, validity time.Duration) error {
+	message, err := s.templates.Render(MagicLink, TemplateData{
+		Recipient: *user,
+		LeadTime:  formatLeadTime(int(validity.Minutes())),
+		Link:      link,
+	})
+	if err != nil {
+		return err
+	}
+	return s.notifier.Notify(ctx, Recipient{Name: user.Name, Email: user.Email, Phone: user.Phone}, message)
+}
+
+func invitation(method string, event ical.Event) Attachment {
+	calendar := ical.Calendar{Method: method, Events: []ical.Event{event}}
+	return Attachment{
+		Filename:    "invite.ics",
+		ContentType: "text/calendar; charset=utf-8; method=" + method,
+		Content:     calendar.Encode(),
+	}
+}
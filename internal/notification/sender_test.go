@@ -0,0 +1,190 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"queue_system/config"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMessage struct {
+	recipient Recipient
+	message   Message
+}
+
+type recordingNotifier struct {
+	sent []sentMessage
+	err  error
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, recipient Recipient, message Message) error {
+	if recipient.Phone == "" {
+		return ErrNoAddress
+	}
+	n.sent = append(n.sent, sentMessage{recipient: recipient, message: message})
+	return n.err
+}
+
+func newTestSender(t *testing.T, notifier Notifier) (*Sender, *mocks.MockUserRepository, *mocks.MockNotificationRepository) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	templates, err := loadTemplates("")
+	require.NoError(t, err)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockNotificationRepo := mocks.NewMockNotificationRepository(ctrl)
+	cfg := &config.Config{}
+	cfg.Calendar.UIDDomain = "example.com"
+	return NewSender(notifier, templates, mockUserRepo, mockNotificationRepo, cfg), mockUserRepo, mockNotificationRepo
+}
+
+func TestSender_HandleEvent_NotifiesBothUsers(t *testing.T) {
+	// GIVEN
+	notifier := &recordingNotifier{}
+	sender, mockUserRepo, mockNotificationRepo := newTestSender(t, notifier)
+	mockUserRepo.EXPECT().GetById(uint(5), uint(1)).Return(&model.User{ID: 1, Name: "Dr. Smith", Phone: "+15550101"}, nil).Times(1)
+	mockUserRepo.EXPECT().GetById(uint(5), uint(2)).Return(&model.User{ID: 2, Name: "Ana", Phone: "+15550102"}, nil).Times(1)
+	mockNotificationRepo.EXPECT().WasSent(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+	mockNotificationRepo.EXPECT().RecordSent(gomock.Any()).Return(nil).Times(2)
+
+	// WHEN
+	err := sender.HandleEvent(context.Background(), events.Event{
+		Type:    events.AppointmentReminder,
+		Payload: []byte(`{"ID":3,"organization_id":5,"UserID":1,"ParticipantID":2,"StartTime":"2030-06-03T14:00:00Z","EndTime":"2030-06-03T14:30:00Z","lead_minutes":60}`),
+	})
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, notifier.sent, 2)
+	assert.Equal(t, "+15550101", notifier.sent[0].recipient.Phone)
+	assert.Equal(t, "+15550102", notifier.sent[1].recipient.Phone)
+	assert.Equal(t, "Reminder: appointment in 1 hour", notifier.sent[1].message.Subject)
+	assert.Contains(t, notifier.sent[1].message.Text, "Hello Ana,")
+	assert.Empty(t, notifier.sent[1].message.Attachments, "reminders carry no invitation")
+}
+
+func TestSender_HandleEvent_SkipsUsersWithoutAddress(t *testing.T) {
+	// GIVEN
+	notifier := &recordingNotifier{}
+	sender, mockUserRepo, mockNotificationRepo := newTestSender(t, notifier)
+	mockUserRepo.EXPECT().GetById(uint(5), uint(1)).Return(&model.User{ID: 1, Name: "Dr. Smith"}, nil).Times(1)
+	mockUserRepo.EXPECT().GetById(uint(5), uint(2)).Return(&model.User{ID: 2, Name: "Ana", Phone: "+15550102"}, nil).Times(1)
+	mockNotificationRepo.EXPECT().WasSent(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+	mockNotificationRepo.EXPECT().RecordSent(gomock.Any()).Return(nil).Times(1)
+
+	// WHEN
+	err := sender.HandleEvent(context.Background(), events.Event{
+		Type:    events.AppointmentCancelled,
+		Payload: []byte(`{"ID":3,"organization_id":5,"UserID":1,"ParticipantID":2}`),
+	})
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, notifier.sent, 1)
+	assert.Contains(t, notifier.sent[0].message.Subject, "cancelled")
+	require.Len(t, notifier.sent[0].message.Attachments, 1)
+	invite := notifier.sent[0].message.Attachments[0]
+	assert.Equal(t, "text/calendar; charset=utf-8; method=CANCEL", invite.ContentType)
+	assert.Contains(t, string(invite.Content), "UID:appointment-3@example.com\r\n")
+}
+
+func TestSender_HandleEvent_ReturnsDeliveryErrors(t *testing.T) {
+	// GIVEN
+	notifier := &recordingNotifier{err: errors.New("gateway down")}
+	sender, mockUserRepo, mockNotificationRepo := newTestSender(t, notifier)
+	mockUserRepo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.User{Phone: "+15550100"}, nil).Times(2)
+	mockNotificationRepo.EXPECT().WasSent(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+	mockNotificationRepo.EXPECT().RecordSent(gomock.Any()).Times(0)
+
+	// WHEN
+	err := sender.HandleEvent(context.Background(), events.Event{
+		Type:    events.AppointmentConfirmed,
+		Payload: []byte(`{"ID":3,"organization_id":5,"UserID":1,"ParticipantID":2}`),
+	})
+
+	// THEN
+	assert.ErrorContains(t, err, "gateway down")
+}
+
+func TestSender_HandleEvent_SkipsRecipientsAlreadyReached(t *testing.T) {
+	// GIVEN an event whose message reached the organizer before failing
+	notifier := &recordingNotifier{}
+	sender, mockUserRepo, mockNotificationRepo := newTestSender(t, notifier)
+	mockUserRepo.EXPECT().GetById(uint(5), uint(1)).Return(&model.User{ID: 1, Name: "Dr. Smith", Phone: "+15550101"}, nil).Times(1)
+	mockUserRepo.EXPECT().GetById(uint(5), uint(2)).Return(&model.User{ID: 2, Name: "Ana", Phone: "+15550102"}, nil).Times(1)
+	mockNotificationRepo.EXPECT().WasSent(uint(7), uint(1)).Return(true, nil).Times(1)
+	mockNotificationRepo.EXPECT().WasSent(uint(7), uint(2)).Return(false, nil).Times(1)
+	mockNotificationRepo.EXPECT().RecordSent(gomock.Any()).DoAndReturn(func(sent *model.SentNotification) error {
+		assert.Equal(t, uint(7), sent.EventID)
+		assert.Equal(t, uint(2), sent.UserID)
+		assert.Equal(t, Cancellation, sent.Kind)
+		return nil
+	}).Times(1)
+
+	// WHEN it is retried
+	err := sender.HandleEvent(context.Background(), events.Event{
+		ID:      7,
+		Type:    events.AppointmentCancelled,
+		Payload: []byte(`{"ID":3,"organization_id":5,"UserID":1,"ParticipantID":2}`),
+	})
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, "+15550102", notifier.sent[0].recipient.Phone)
+}
+
+func TestSender_HandleEvent_BookingInRecipientTimezone(t *testing.T) {
+	// GIVEN
+	notifier := &recordingNotifier{}
+	sender, mockUserRepo, mockNotificationRepo := newTestSender(t, notifier)
+	mockUserRepo.EXPECT().GetById(uint(5), uint(1)).Return(&model.User{ID: 1, Name: "Dr. Smith", Phone: "+15550101"}, nil).Times(1)
+	mockUserRepo.EXPECT().GetById(uint(5), uint(2)).Return(&model.User{ID: 2, Name: "Ana", Phone: "+15550102", Timezone: "Asia/Ho_Chi_Minh"}, nil).Times(1)
+	mockNotificationRepo.EXPECT().WasSent(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+	mockNotificationRepo.EXPECT().RecordSent(gomock.Any()).Return(nil).Times(2)
+
+	// WHEN
+	err := sender.HandleEvent(context.Background(), events.Event{
+		Type:    events.AppointmentCreated,
+		Payload: []byte(`{"ID":3,"organization_id":5,"UserID":1,"ParticipantID":2,"Status":"pending","StartTime":"2030-06-03T14:00:00Z","EndTime":"2030-06-03T14:30:00Z"}`),
+	})
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, notifier.sent, 2)
+	assert.Equal(t, "Appointment booked: Mon, 03 Jun 2030 14:00 UTC", notifier.sent[0].message.Subject)
+	assert.Contains(t, notifier.sent[0].message.Text, "is booked and awaiting confirmation")
+	assert.Equal(t, "Appointment booked: Mon, 03 Jun 2030 21:00 +07", notifier.sent[1].message.Subject)
+	require.Len(t, notifier.sent[1].message.Attachments, 1, "bookings carry an invitation")
+}
+
+func TestSender_HandleEvent_IgnoresOtherEvents(t *testing.T) {
+	sender, _, _ := newTestSender(t, &recordingNotifier{})
+
+	err := sender.HandleEvent(context.Background(), events.Event{Type: events.UserCreated, Payload: []byte(`{}`)})
+
+	assert.NoError(t, err)
+}
+
+func TestSender_SendMagicLink(t *testing.T) {
+	// GIVEN
+	notifier := &recordingNotifier{}
+	sender, _, _ := newTestSender(t, notifier)
+
+	// WHEN
+	err := sender.SendMagicLink(context.Background(), &model.User{ID: 1, Name: "Ana", Phone: "+15550102"}, "https://example.com/login?token=abc", 15*time.Minute)
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, "Your sign-in link", notifier.sent[0].message.Subject)
+	assert.Contains(t, notifier.sent[0].message.Text, "https://example.com/login?token=abc")
+	assert.Contains(t, notifier.sent[0].message.Text, "expires in 15 minutes")
+}
@@ -0,0 +1,71 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"queue_system/config"
+)
+
+// SMSNotifier sends the text of a notification through an HTTP SMS
+// gateway. It POSTs {"to", "from", "message"} as JSON with the configured
+// token as a bearer token; any 2xx response counts as sent.
+type SMSNotifier struct {
+	gatewayURL string
+	token      string
+	sender     string
+	client     *http.Client
+}
+
+type smsRequest struct {
+	To      string `json:"to"`
+	From    string `json:"from,omitempty"`
+	Message string `json:"message"`
+}
+
+func NewSMSNotifier(cfg config.SMS) (*SMSNotifier, error) {
+	if cfg.GatewayURL == "" {
+		return nil, errors.New("sms notifications need SMS_GATEWAY_URL")
+	}
+	return &SMSNotifier{
+		gatewayURL: cfg.GatewayURL,
+		token:      cfg.Token,
+		sender:     cfg.Sender,
+		client:     &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (n *SMSNotifier) Notify(ctx context.Context, recipient Recipient, message Message) error {
+	if recipient.Phone == "" {
+		return ErrNoAddress
+	}
+	body, err := json.Marshal(smsRequest{To: recipient.Phone, From: n.sender, Message: message.Text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.gatewayURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sms gateway responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"queue_system/config"
+	"sort"
+	"time"
+)
+
+const defaultSMTPTimeout = 10 * time.Second
+
+// SMTPNotifier sends notifications as email. Messages with HTML are sent
+// as multipart/alternative with the text version first. A message that is
+// not sent within the configured timeout, or before the context ends, is
+// abandoned.
+type SMTPNotifier struct {
+	host    string
+	addr    string
+	from    mail.Address
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+func NewSMTPNotifier(cfg config.SMTP) (*SMTPNotifier, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, errors.New("smtp notifications need SMTP_HOST and SMTP_FROM")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP_FROM: %w", err)
+	}
+
+	notifier := &SMTPNotifier{
+		host:    cfg.Host,
+		addr:    net.JoinHostPort(cfg.Host, cfg.Port),
+		from:    *from,
+		timeout: cfg.Timeout,
+	}
+	if notifier.timeout <= 0 {
+		notifier.timeout = defaultSMTPTimeout
+	}
+	if cfg.Username != "" {
+		notifier.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return notifier, nil
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, recipient Recipient, message Message) error {
+	if recipient.Email == "" {
+		return ErrNoAddress
+	}
+	to := mail.Address{Name: recipient.Name, Address: recipient.Email}
+
+	body, err := n.compose(to, message)
+	if err != nil {
+		return err
+	}
+	return n.send(ctx, to.Address, body)
+}
+
+// send does what smtp.SendMail does, upgrading to TLS when the server
+// offers it, on a connection bounded by the timeout and by ctx.
+func (n *SMTPNotifier) send(ctx context.Context, to string, body []byte) error {
+	dialer := &net.Dialer{Timeout: n.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(n.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Ending ctx interrupts whatever command is in flight.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := client.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(body); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (n *SMTPNotifier) compose(to mail.Address, message Message) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(message.Attachments) == 0 {
+		if err := writeBody(&buf, message, func(header textproto.MIMEHeader) (io.Writer, error) {
+			writeHeader(&buf, header)
+			return &buf, nil
+		}); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	writeHeader(&buf, textproto.MIMEHeader{"Content-Type": {"multipart/mixed; boundary=" + mixed.Boundary()}})
+	if err := writeBody(&buf, message, mixed.CreatePart); err != nil {
+		return nil, err
+	}
+	for _, attachment := range message.Attachments {
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, attachment.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBody writes the text and HTML of message as one entity created by
+// createPart: plain text, or multipart/alternative with the text first.
+func writeBody(buf *bytes.Buffer, message Message, createPart func(textproto.MIMEHeader) (io.Writer, error)) error {
+	if message.HTML == "" {
+		writer, err := createPart(textPartHeader("text/plain; charset=utf-8"))
+		if err != nil {
+			return err
+		}
+		return writeQuotedPrintable(writer, message.Text)
+	}
+
+	var alternative bytes.Buffer
+	parts := multipart.NewWriter(&alternative)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		writer, err := parts.CreatePart(textPartHeader(part.contentType))
+		if err != nil {
+			return err
+		}
+		if err := writeQuotedPrintable(writer, part.content); err != nil {
+			return err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return err
+	}
+
+	writer, err := createPart(textproto.MIMEHeader{"Content-Type": {"multipart/alternative; boundary=" + parts.Boundary()}})
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(alternative.Bytes())
+	return err
+}
+
+func textPartHeader(contentType string) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}
+}
+
+// writeHeader writes header fields followed by the blank line that ends
+// them, in a stable order.
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+// writeBase64 writes content base64 encoded in 76 character lines.
+func writeBase64(w io.Writer, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	encoder := quotedprintable.NewWriter(w)
+	if _, err := encoder.Write([]byte(content)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,155 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"queue_system/config"
+	"queue_system/internal/model"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Message kinds. Each has a <kind>.subject.tmpl and <kind>.text.tmpl text
+// template and a <kind>.html.tmpl HTML template.
+const (
+	BookingConfirmation = "booking_confirmation"
+	Cancellation        = "cancellation"
+	Reschedule          = "reschedule"
+	Reminder            = "reminder"
+	MagicLink           = "magic_link"
+)
+
+var kinds = []string{BookingConfirmation, Cancellation, Reschedule, Reminder, MagicLink}
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// templateFuncs are available to every template. datetime formats a time
+// in its own location; the Sender has already moved appointment times to
+// the recipient's timezone.
+var templateFuncs = map[string]any{
+	"datetime": func(t time.Time) string {
+		return t.Format("Mon, 02 Jan 2006 15:04 MST")
+	},
+}
+
+// TemplateData is what templates are executed with. LeadTime is only set
+// for reminders, e.g. "24 hours", and for magic links, where it is how long
+// Link stays valid.
+type TemplateData struct {
+	Recipient   model.User
+	Organizer   model.User
+	Participant model.User
+	Appointment model.Appointment
+	LeadTime    string
+	Link        string
+}
+
+type Templates struct {
+	subjects map[string]*texttemplate.Template
+	texts    map[string]*texttemplate.Template
+	htmls    map[string]*htmltemplate.Template
+}
+
+// LoadTemplates parses the built-in templates, replacing any that have a
+// file of the same name in the configured template directory.
+func LoadTemplates(cfg *config.Config) (*Templates, error) {
+	return loadTemplates(cfg.Notification.TemplateDir)
+}
+
+func loadTemplates(dir string) (*Templates, error) {
+	templates := &Templates{
+		subjects: make(map[string]*texttemplate.Template),
+		texts:    make(map[string]*texttemplate.Template),
+		htmls:    make(map[string]*htmltemplate.Template),
+	}
+	for _, kind := range kinds {
+		for _, name := range []string{kind + ".subject.tmpl", kind + ".text.tmpl", kind + ".html.tmpl"} {
+			source, err := readTemplate(dir, name)
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasSuffix(name, ".html.tmpl") {
+				parsed, err := htmltemplate.New(name).Funcs(templateFuncs).Parse(source)
+				if err != nil {
+					return nil, fmt.Errorf("parsing %s: %w", name, err)
+				}
+				templates.htmls[kind] = parsed
+				continue
+			}
+			parsed, err := texttemplate.New(name).Funcs(templateFuncs).Parse(source)
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", name, err)
+			}
+			if strings.HasSuffix(name, ".subject.tmpl") {
+				templates.subjects[kind] = parsed
+			} else {
+				templates.texts[kind] = parsed
+			}
+		}
+	}
+	return templates, nil
+}
+
+func readTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		source, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(source), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	source, err := defaultTemplates.ReadFile("templates/" + name)
+	if err != nil {
+		return "", err
+	}
+	return string(source), nil
+}
+
+// Render executes the templates of kind with data.
+func (t *Templates) Render(kind string, data TemplateData) (Message, error) {
+	subject, ok := t.subjects[kind]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown message kind %q", kind)
+	}
+
+	var subjectBuf, textBuf, htmlBuf bytes.Buffer
+	if err := subject.Execute(&subjectBuf, data); err != nil {
+		return Message{}, err
+	}
+	if err := t.texts[kind].Execute(&textBuf, data); err != nil {
+		return Message{}, err
+	}
+	if err := t.htmls[kind].Execute(&htmlBuf, data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		Subject: strings.TrimSpace(subjectBuf.String()),
+		Text:    strings.TrimSpace(textBuf.String()) + "\n",
+		HTML:    strings.TrimSpace(htmlBuf.String()) + "\n",
+	}, nil
+}
+
+// formatLeadTime describes a reminder lead time, e.g. "1 hour" or
+// "90 minutes".
+func formatLeadTime(minutes int) string {
+	switch {
+	case minutes == 60:
+		return "1 hour"
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d hours", minutes/60)
+	case minutes == 1:
+		return "1 minute"
+	default:
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+}
@@ -0,0 +1,65 @@
+package notification
+
+import (
+	"os"
+	"path/filepath"
+	"queue_system/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTemplateData() TemplateData {
+	start := time.Date(2030, 6, 3, 14, 0, 0, 0, time.UTC)
+	return TemplateData{
+		Recipient:   model.User{Name: "Ana"},
+		Organizer:   model.User{Name: "Dr. Smith"},
+		Participant: model.User{Name: "Ana"},
+		Appointment: model.Appointment{StartTime: start, EndTime: start.Add(30 * time.Minute), Description: "Check-up <annual>"},
+		LeadTime:    formatLeadTime(24 * 60),
+	}
+}
+
+func TestTemplates_RenderDefaults(t *testing.T) {
+	templates, err := loadTemplates("")
+	require.NoError(t, err)
+
+	message, err := templates.Render(Reminder, testTemplateData())
+	require.NoError(t, err)
+	assert.Equal(t, "Reminder: appointment in 24 hours", message.Subject)
+	assert.Contains(t, message.Text, "Hello Ana,")
+	assert.Contains(t, message.Text, "Mon, 03 Jun 2030 14:00 UTC")
+
+	message, err = templates.Render(BookingConfirmation, testTemplateData())
+	require.NoError(t, err)
+	assert.Contains(t, message.Text, "What: Check-up <annual>")
+	assert.Contains(t, message.HTML, "Check-up &lt;annual&gt;", "HTML templates escape their data")
+
+	_, err = templates.Render("newsletter", testTemplateData())
+	assert.Error(t, err)
+}
+
+func TestTemplates_FilesOverrideDefaults(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cancellation.subject.tmpl"), []byte("Cancelled with {{.Organizer.Name}}\n"), 0o644))
+
+	templates, err := loadTemplates(dir)
+	require.NoError(t, err)
+
+	message, err := templates.Render(Cancellation, testTemplateData())
+	require.NoError(t, err)
+	assert.Equal(t, "Cancelled with Dr. Smith", message.Subject)
+	assert.Contains(t, message.Text, "has been cancelled", "files that are not overridden keep the default")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "reminder.text.tmpl"), []byte("{{.Broken"), 0o644))
+	_, err = loadTemplates(dir)
+	assert.ErrorContains(t, err, "reminder.text.tmpl")
+}
+
+func TestFormatLeadTime(t *testing.T) {
+	assert.Equal(t, "1 hour", formatLeadTime(60))
+	assert.Equal(t, "24 hours", formatLeadTime(1440))
+	assert.Equal(t, "90 minutes", formatLeadTime(90))
+}
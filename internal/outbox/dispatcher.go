@@ -0,0 +1,201 @@
+// Package outbox delivers events recorded in the outbox table to the event
+// bus from a background worker.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"queue_system/config"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxRetryDelay caps the exponential backoff between attempts.
+	maxRetryDelay       = 5 * time.Minute
+	defaultPollInterval = 500 * time.Millisecond
+	defaultBatchSize    = 100
+	defaultLease        = 5 * time.Minute
+	defaultMaxAttempts  = 10
+)
+
+type Dispatcher struct {
+	db               *gorm.DB
+	outboxRepository repository.OutboxRepository
+	bus              *events.Bus
+	pollInterval     time.Duration
+	batchSize        int
+	lease            time.Duration
+	maxAttempts      int
+
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+func NewDispatcher(db *gorm.DB, outboxRepository repository.OutboxRepository, bus *events.Bus, cfg *config.Config) *Dispatcher {
+	dispatcher := &Dispatcher{
+		db:               db,
+		outboxRepository: outboxRepository,
+		bus:              bus,
+		pollInterval:     cfg.Outbox.PollInterval,
+		batchSize:        cfg.Outbox.BatchSize,
+		lease:            cfg.Outbox.Lease,
+		maxAttempts:      cfg.Outbox.MaxAttempts,
+	}
+	if dispatcher.pollInterval <= 0 {
+		dispatcher.pollInterval = defaultPollInterval
+	}
+	if dispatcher.batchSize <= 0 {
+		dispatcher.batchSize = defaultBatchSize
+	}
+	if dispatcher.lease <= 0 {
+		dispatcher.lease = defaultLease
+	}
+	if dispatcher.maxAttempts <= 0 {
+		dispatcher.maxAttempts = defaultMaxAttempts
+	}
+	return dispatcher
+}
+
+// Start polls the outbox until Stop is called.
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done.Add(1)
+	go func() {
+		defer d.done.Done()
+		ticker := time.NewTicker(d.pollInterval)
+		defer ticker.Stop()
+		for {
+			// Keep draining while full batches come back.
+			for {
+				dispatched, err := d.DispatchPending(ctx)
+				if err != nil {
+					log.Error().Err(err).Msg("Error dispatching outbox events")
+					break
+				}
+				if dispatched < d.batchSize {
+					break
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop waits for the batch in flight to finish or ctx to expire.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	if d.cancel == nil {
+		return nil
+	}
+	d.cancel()
+	finished := make(chan struct{})
+	go func() {
+		d.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DispatchPending hands one batch of due events to the bus and returns how
+// many were claimed. The batch is claimed in a short transaction and
+// delivered after it commits, so subscribers never run while outbox rows
+// are locked. Subscribers that fail are retried with exponential backoff
+// until maxAttempts; those that succeeded are not run again.
+func (d *Dispatcher) DispatchPending(ctx context.Context) (int, error) {
+	pending, err := d.claim(time.Now())
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	causes := make([]error, len(pending))
+	for i := range pending {
+		event := &pending[i]
+		event.DeliveredTo, causes[i] = d.bus.Deliver(ctx, toBusEvent(event), event.DeliveredTo)
+	}
+
+	tx := d.db.Begin()
+	for i := range pending {
+		if err := d.recordResult(tx, &pending[i], causes[i], time.Now()); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return len(pending), nil
+}
+
+// claim leases up to batchSize due events to this dispatcher.
+func (d *Dispatcher) claim(now time.Time) ([]model.OutboxEvent, error) {
+	tx := d.db.Begin()
+
+	pending, err := d.outboxRepository.FetchPendingForUpdate(tx, now, d.batchSize)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := d.outboxRepository.ClaimWithTx(tx, pending, now.Add(d.lease)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return pending, nil
+}
+
+func (d *Dispatcher) recordResult(tx *gorm.DB, event *model.OutboxEvent, cause error, now time.Time) error {
+	if cause == nil {
+		return d.outboxRepository.MarkDispatchedWithTx(tx, event, now)
+	}
+	attempts := event.Attempts + 1
+	if attempts >= d.maxAttempts {
+		log.Error().Err(cause).Uint("eventID", event.ID).Str("type", event.Type).Int("attempts", attempts).Msg("Outbox event failed for good")
+		return d.outboxRepository.MarkDeadWithTx(tx, event, cause, now)
+	}
+	log.Warn().Err(cause).Uint("eventID", event.ID).Str("type", event.Type).Int("attempts", attempts).Msg("Outbox event delivery failed")
+	return d.outboxRepository.MarkFailedWithTx(tx, event, cause, now.Add(RetryDelay(attempts)))
+}
+
+// RetryDelay is the wait before the next attempt after the given number of
+// failed attempts: one second, doubling up to maxRetryDelay.
+func RetryDelay(attempts int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+func toBusEvent(event *model.OutboxEvent) events.Event {
+	return events.Event{
+		ID:             event.ID,
+		OrganizationID: event.OrganizationID,
+		Type:           event.Type,
+		Payload:        json.RawMessage(event.Payload),
+		OccurredAt:     event.CreatedAt,
+	}
+}
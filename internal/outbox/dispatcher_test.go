@@ -0,0 +1,15 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay_DoublesUpToCap(t *testing.T) {
+	assert.Equal(t, time.Second, RetryDelay(1))
+	assert.Equal(t, 2*time.Second, RetryDelay(2))
+	assert.Equal(t, 8*time.Second, RetryDelay(4))
+	assert.Equal(t, maxRetryDelay, RetryDelay(20))
+}
@@ -0,0 +1,93 @@
+// Package ratelimit slows down clients that call an endpoint too often,
+// such as ones guessing passwords or flooding inboxes with sign-in links.
+// Counts are kept in memory, so each instance of the server limits on its
+// own.
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// window counts the requests of one key since start.
+type window struct {
+	start time.Time
+	count int
+}
+
+// Limiter allows each key limit requests per window. A limit of zero or
+// less allows everything.
+type Limiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	windows   map[string]*window
+	lastPrune time.Time
+}
+
+func New(limit int, period time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		window:  period,
+		now:     time.Now,
+		windows: make(map[string]*window),
+	}
+}
+
+// Allow counts a request for key and reports whether it is within the
+// limit. If it is not, retryAfter says when the key may try again.
+func (l *Limiter) Allow(key string) (allowed bool, retryAfter time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+	current, ok := l.windows[key]
+	if !ok || !now.Before(current.start.Add(l.window)) {
+		current = &window{start: now}
+		l.windows[key] = current
+	}
+	if current.count >= l.limit {
+		return false, current.start.Add(l.window).Sub(now)
+	}
+	current.count++
+	return true, 0
+}
+
+// prune drops the windows that have ended, at most once per window, so
+// that keys seen once do not pile up.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.window {
+		return
+	}
+	for key, current := range l.windows {
+		if !now.Before(current.start.Add(l.window)) {
+			delete(l.windows, key)
+		}
+	}
+	l.lastPrune = now
+}
+
+// PerClient limits each client IP on each route it is used on, answering
+// 429 with a Retry-After header once the limit is reached.
+func (l *Limiter) PerClient() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		allowed, retryAfter := l.Allow(ctx.ClientIP() + " " + ctx.FullPath())
+		if !allowed {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests, try again later"})
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	// GIVEN
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	limiter := New(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	// WHEN
+	first, _ := limiter.Allow("a")
+	second, _ := limiter.Allow("a")
+	third, retryAfter := limiter.Allow("a")
+	other, _ := limiter.Allow("b")
+	now = now.Add(time.Minute)
+	later, _ := limiter.Allow("a")
+
+	// THEN
+	assert.True(t, first)
+	assert.True(t, second)
+	assert.False(t, third)
+	assert.Equal(t, time.Minute, retryAfter)
+	assert.True(t, other, "keys are limited separately")
+	assert.True(t, later, "the limit resets after the window")
+}
+
+func TestLimiter_PerClient(t *testing.T) {
+	// GIVEN
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	limiter := New(1, time.Minute)
+	router.POST("/login", limiter.PerClient(), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	router.POST("/magic-link", limiter.PerClient(), func(ctx *gin.Context) { ctx.Status(http.StatusAccepted) })
+	send := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// WHEN
+	first := send("/login", "192.0.2.1:1000")
+	second := send("/login", "192.0.2.1:1001")
+	otherRoute := send("/magic-link", "192.0.2.1:1002")
+	otherClient := send("/login", "192.0.2.2:1000")
+
+	// THEN
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	assert.Equal(t, "60", second.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusAccepted, otherRoute.Code)
+	assert.Equal(t, http.StatusOK, otherClient.Code)
+}
+
+func TestLimiter_ZeroLimitAllowsEverything(t *testing.T) {
+	limiter := New(0, time.Minute)
+	for i := 0; i < 100; i++ {
+		allowed, _ := limiter.Allow("a")
+		assert.True(t, allowed)
+	}
+}
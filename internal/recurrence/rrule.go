@@ -0,0 +1,271 @@
+// Package recurrence expands the subset of RFC 5545 recurrence rules the
+// scheduler supports: FREQ=DAILY|WEEKLY|MONTHLY with INTERVAL, COUNT or
+// UNTIL, and BYDAY for weekly rules.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxOccurrences bounds how many instances a single rule may expand to.
+const MaxOccurrences = 200
+
+// maxIterations guards against rules that rarely produce an instance, such
+// as a monthly rule anchored on the 31st.
+const maxIterations = 10000
+
+// Every error returned by this package wraps ErrInvalidRule.
+var (
+	ErrInvalidRule        = errors.New("invalid recurrence rule")
+	ErrUnsupportedRule    = fmt.Errorf("%w: use FREQ=DAILY|WEEKLY|MONTHLY with INTERVAL, COUNT, UNTIL or BYDAY", ErrInvalidRule)
+	ErrUnboundedRule      = fmt.Errorf("%w: exactly one of COUNT or UNTIL is required", ErrInvalidRule)
+	ErrTooManyOccurrences = fmt.Errorf("%w: expands to more than %d occurrences", ErrInvalidRule, MaxOccurrences)
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	Count    int
+	Until    *time.Time
+	ByDay    []time.Weekday
+}
+
+// Parse reads an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10".
+// A leading "RRULE:" is accepted.
+func Parse(value string) (*Rule, error) {
+	rule, err := parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if (rule.Count == 0) == (rule.Until == nil) {
+		return nil, ErrUnboundedRule
+	}
+	return rule, nil
+}
+
+// ParseUnbounded is like Parse but also accepts rules without COUNT or
+// UNTIL, as found in imported calendars. Expand them with Between.
+func ParseUnbounded(value string) (*Rule, error) {
+	rule, err := parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Count != 0 && rule.Until != nil {
+		return nil, ErrUnboundedRule
+	}
+	return rule, nil
+}
+
+func parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, ErrInvalidRule
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, ErrInvalidRule
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(val))
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly {
+				return nil, ErrUnsupportedRule
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return nil, ErrInvalidRule
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return nil, ErrInvalidRule
+			}
+			if count > MaxOccurrences {
+				return nil, ErrTooManyOccurrences
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return nil, ErrInvalidRule
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, ErrUnsupportedRule
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "WKST":
+			if strings.ToUpper(val) != "MO" {
+				return nil, ErrUnsupportedRule
+			}
+		default:
+			return nil, ErrUnsupportedRule
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, ErrInvalidRule
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly {
+		return nil, ErrUnsupportedRule
+	}
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+	date, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// A date-only UNTIL includes the whole day.
+	return date.Add(24*time.Hour - time.Nanosecond), nil
+}
+
+// String formats the rule as an RRULE value that Parse reads back. UNTIL is
+// written in UTC.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			for name, weekday := range weekdays {
+				if weekday == day {
+					days = append(days, name)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences expands the rule from start, which is the first candidate
+// instance. Wall-clock time is preserved in start's location across DST
+// changes.
+func (r *Rule) Occurrences(start time.Time) ([]time.Time, error) {
+	var occurrences []time.Time
+	r.each(start, func(candidate time.Time) bool {
+		if r.Until != nil && candidate.After(*r.Until) {
+			return false
+		}
+		occurrences = append(occurrences, candidate)
+		return (r.Count == 0 || len(occurrences) < r.Count) && len(occurrences) <= MaxOccurrences
+	})
+
+	if len(occurrences) > MaxOccurrences {
+		return nil, ErrTooManyOccurrences
+	}
+	return occurrences, nil
+}
+
+// Between expands the rule from start like Occurrences but returns only the
+// instances in [from, to), so that rules without an end can be expanded over
+// a bounded window.
+func (r *Rule) Between(start, from, to time.Time) ([]time.Time, error) {
+	var occurrences []time.Time
+	instances := 0
+	r.each(start, func(candidate time.Time) bool {
+		if !candidate.Before(to) || (r.Until != nil && candidate.After(*r.Until)) {
+			return false
+		}
+		instances++
+		if !candidate.Before(from) {
+			occurrences = append(occurrences, candidate)
+		}
+		return (r.Count == 0 || instances < r.Count) && len(occurrences) <= MaxOccurrences
+	})
+
+	if len(occurrences) > MaxOccurrences {
+		return nil, ErrTooManyOccurrences
+	}
+	return occurrences, nil
+}
+
+// each passes the rule's candidate instances from start to yield, in order,
+// until yield returns false or maxIterations periods have been tried.
+func (r *Rule) each(start time.Time, yield func(time.Time) bool) {
+	if r.Freq == Weekly && len(r.ByDay) > 0 {
+		offsets := make([]int, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			offsets = append(offsets, (int(day)+6)%7)
+		}
+		sort.Ints(offsets)
+		weekStart := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		for week := 0; week < maxIterations; week++ {
+			for _, offset := range offsets {
+				candidate := weekStart.AddDate(0, 0, week*7*r.Interval+offset)
+				if candidate.Before(start) {
+					continue
+				}
+				if !yield(candidate) {
+					return
+				}
+			}
+		}
+		return
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		var candidate time.Time
+		switch r.Freq {
+		case Daily:
+			candidate = start.AddDate(0, 0, i*r.Interval)
+		case Weekly:
+			candidate = start.AddDate(0, 0, 7*i*r.Interval)
+		case Monthly:
+			candidate = time.Date(start.Year(), start.Month()+time.Month(i*r.Interval), start.Day(),
+				start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+			// Months without this day, e.g. the 31st, are skipped.
+			if candidate.Day() != start.Day() {
+				continue
+			}
+		}
+		if !yield(candidate) {
+			return
+		}
+	}
+}
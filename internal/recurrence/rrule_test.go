@@ -0,0 +1,105 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]error{
+		"":                                  ErrInvalidRule,
+		"FREQ=YEARLY;COUNT=2":               ErrUnsupportedRule,
+		"FREQ=DAILY":                        ErrUnboundedRule,
+		"FREQ=DAILY;COUNT=2;UNTIL=20300101": ErrUnboundedRule,
+		"FREQ=DAILY;COUNT=0":                ErrInvalidRule,
+		"FREQ=DAILY;COUNT=500":              ErrTooManyOccurrences,
+		"FREQ=DAILY;BYDAY=MO;COUNT=2":       ErrUnsupportedRule,
+		"FREQ=WEEKLY;BYDAY=XX;COUNT=2":      ErrUnsupportedRule,
+		"FREQ=WEEKLY;BYSETPOS=1;COUNT=2":    ErrUnsupportedRule,
+	}
+	for value, expected := range cases {
+		_, err := Parse(value)
+		assert.Equal(t, expected, err, value)
+	}
+}
+
+func TestOccurrences_DailyWithInterval(t *testing.T) {
+	rule, err := Parse("RRULE:FREQ=DAILY;INTERVAL=2;COUNT=3")
+	assert.NoError(t, err)
+
+	start := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	occurrences, err := rule.Occurrences(start)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{start, start.AddDate(0, 0, 2), start.AddDate(0, 0, 4)}, occurrences)
+}
+
+func TestOccurrences_WeeklyByDayUntil(t *testing.T) {
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=FR,MO;UNTIL=20300114")
+	assert.NoError(t, err)
+
+	// 2030-01-02 is a Wednesday, so the first instance is Friday the 4th.
+	start := time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC)
+	occurrences, err := rule.Occurrences(start)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2030, 1, 4, 9, 0, 0, 0, time.UTC),
+		time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC),
+		time.Date(2030, 1, 11, 9, 0, 0, 0, time.UTC),
+		time.Date(2030, 1, 14, 9, 0, 0, 0, time.UTC),
+	}, occurrences)
+}
+
+func TestOccurrences_MonthlySkipsShortMonths(t *testing.T) {
+	rule, err := Parse("FREQ=MONTHLY;COUNT=3")
+	assert.NoError(t, err)
+
+	start := time.Date(2030, 1, 31, 9, 0, 0, 0, time.UTC)
+	occurrences, err := rule.Occurrences(start)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		start,
+		time.Date(2030, 3, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2030, 5, 31, 9, 0, 0, 0, time.UTC),
+	}, occurrences)
+}
+
+func TestOccurrences_KeepsWallClockAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	rule, err := Parse("FREQ=WEEKLY;COUNT=2")
+	assert.NoError(t, err)
+
+	// Clocks go forward on 2030-03-31 in Berlin.
+	start := time.Date(2030, 3, 25, 9, 0, 0, 0, loc)
+	occurrences, err := rule.Occurrences(start)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9, occurrences[1].Hour())
+	assert.Equal(t, 167*time.Hour, occurrences[1].Sub(occurrences[0]))
+}
+
+func TestOccurrences_UntilTooFarAhead(t *testing.T) {
+	rule, err := Parse("FREQ=DAILY;UNTIL=20400101T000000Z")
+	assert.NoError(t, err)
+
+	_, err = rule.Occurrences(time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, ErrTooManyOccurrences, err)
+}
+
+func TestString_RoundTrips(t *testing.T) {
+	for _, value := range []string{
+		"FREQ=DAILY;COUNT=3",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;UNTIL=20300114T090000Z",
+		"FREQ=MONTHLY;UNTIL=20301231T235959Z",
+	} {
+		rule, err := Parse(value)
+		assert.NoError(t, err)
+		assert.Equal(t, value, rule.String())
+	}
+}
@@ -0,0 +1,169 @@
+// Package reminder sends reminders ahead of confirmed appointments. A
+// reminder is an appointment.reminder event recorded in the outbox together
+// with the AppointmentReminder row that stops it from being sent again,
+// including after a restart.
+package reminder
+
+import (
+	"context"
+	"errors"
+	"queue_system/config"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const defaultPollInterval = time.Minute
+
+// Payload is the appointment.reminder event payload: the appointment plus
+// how long before its start the reminder is for.
+type Payload struct {
+	model.Appointment
+	LeadMinutes int `json:"lead_minutes"`
+}
+
+type Scheduler struct {
+	db                 *gorm.DB
+	reminderRepository repository.ReminderRepository
+	outboxRepository   repository.OutboxRepository
+	leadTimes          []time.Duration
+	pollInterval       time.Duration
+
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+func NewScheduler(db *gorm.DB, reminderRepository repository.ReminderRepository, outboxRepository repository.OutboxRepository, cfg *config.Config) *Scheduler {
+	leadTimes := append([]time.Duration(nil), cfg.Reminder.LeadTimes...)
+	sort.Slice(leadTimes, func(i, j int) bool { return leadTimes[i] < leadTimes[j] })
+
+	scheduler := &Scheduler{
+		db:                 db,
+		reminderRepository: reminderRepository,
+		outboxRepository:   outboxRepository,
+		leadTimes:          leadTimes,
+		pollInterval:       cfg.Reminder.PollInterval,
+	}
+	if scheduler.pollInterval <= 0 {
+		scheduler.pollInterval = defaultPollInterval
+	}
+	return scheduler
+}
+
+// Start sends due reminders until Stop is called.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.SendDue(time.Now()); err != nil {
+				log.Error().Err(err).Msg("Error sending appointment reminders")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop waits for the run in flight to finish or ctx to expire.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	finished := make(chan struct{})
+	go func() {
+		s.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SendDue records a reminder for every appointment that has come within a
+// lead time of now and returns how many were sent. When several lead times
+// are due at once, e.g. for an appointment booked an hour ahead, only the
+// shortest is sent and the others are recorded as skipped. A failure for one
+// appointment does not hold up the others.
+func (s *Scheduler) SendDue(now time.Time) (int, error) {
+	sent := 0
+	var errs []error
+	for _, lead := range s.leadTimes {
+		appointments, err := s.reminderRepository.FindDueAppointments(now, lead)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for i := range appointments {
+			skipped := s.shorterLeadDue(&appointments[i], lead, now)
+			recorded, err := s.record(&appointments[i], lead, skipped, now)
+			if err != nil {
+				log.Error().Err(err).Uint("appointmentID", appointments[i].ID).Dur("lead", lead).Msg("Error recording appointment reminder")
+				errs = append(errs, err)
+				continue
+			}
+			if recorded && !skipped {
+				sent++
+			}
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+func (s *Scheduler) shorterLeadDue(appointment *model.Appointment, lead time.Duration, now time.Time) bool {
+	for _, shorter := range s.leadTimes {
+		if shorter < lead && !appointment.StartTime.After(now.Add(shorter)) {
+			return true
+		}
+	}
+	return false
+}
+
+// record stores the reminder and, unless it is skipped, its event in one
+// transaction. It reports false if the reminder had already been recorded.
+func (s *Scheduler) record(appointment *model.Appointment, lead time.Duration, skipped bool, now time.Time) (bool, error) {
+	tx := s.db.Begin()
+
+	recorded, err := s.reminderRepository.RecordReminderWithTx(tx, &model.AppointmentReminder{
+		AppointmentID: appointment.ID,
+		LeadMinutes:   int(lead.Minutes()),
+		StartTime:     appointment.StartTime,
+		Skipped:       skipped,
+		SentAt:        now,
+	})
+	if err != nil || !recorded {
+		tx.Rollback()
+		return false, err
+	}
+
+	if !skipped {
+		payload := Payload{Appointment: *appointment, LeadMinutes: int(lead.Minutes())}
+		if err := s.outboxRepository.RecordWithTx(tx, appointment.OrganizationID, events.AppointmentReminder, payload); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	return true, nil
+}
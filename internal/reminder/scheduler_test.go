@@ -0,0 +1,37 @@
+package reminder
+
+import (
+	"encoding/json"
+	"queue_system/config"
+	"queue_system/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_ShorterLeadDue(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Reminder.LeadTimes = []time.Duration{time.Hour, 24 * time.Hour}
+	scheduler := NewScheduler(nil, nil, nil, cfg)
+	now := time.Date(2030, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	soon := &model.Appointment{StartTime: now.Add(30 * time.Minute)}
+	later := &model.Appointment{StartTime: now.Add(5 * time.Hour)}
+
+	assert.True(t, scheduler.shorterLeadDue(soon, 24*time.Hour, now), "1h reminder supersedes 24h")
+	assert.False(t, scheduler.shorterLeadDue(soon, time.Hour, now))
+	assert.False(t, scheduler.shorterLeadDue(later, 24*time.Hour, now))
+}
+
+func TestPayload_FlattensAppointment(t *testing.T) {
+	data, err := json.Marshal(Payload{Appointment: model.Appointment{ID: 4, UserID: 1, ParticipantID: 2}, LeadMinutes: 60})
+	require.NoError(t, err)
+
+	var appointment model.Appointment
+	require.NoError(t, json.Unmarshal(data, &appointment))
+	assert.Equal(t, uint(4), appointment.ID)
+	assert.Equal(t, uint(2), appointment.ParticipantID)
+	assert.Contains(t, string(data), `"lead_minutes":60`)
+}
@@ -2,15 +2,72 @@ package repository
 
 import (
 	"errors"
+	"queue_system/internal/events"
 	"queue_system/internal/model"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// userLockNamespace is the advisory lock key space of users. Bookings
+// conflict when they share a person in either role, so each person has one
+// key whether they create or attend.
+const userLockNamespace int64 = 1
+
+// AppointmentFilter narrows List results. Nil and zero-valued fields are
+// ignored; From/To select appointments overlapping that window.
+type AppointmentFilter struct {
+	// OrganizationID is always applied.
+	OrganizationID uint
+	UserID         *uint
+	ParticipantID  *uint
+	// PartyID limits the results to appointments the user created or
+	// takes part in.
+	PartyID *uint
+	Status  string
+	From    *time.Time
+	To      *time.Time
+	// IncludeDeleted also lists deleted appointments.
+	IncludeDeleted bool
+	SortDesc       bool
+	Offset         int
+	Limit          int
+}
+
+// AppointmentRepository finds appointments and series only within an
+// organization. Lookups by user need no organization, as user IDs already
+// belong to one.
 type AppointmentRepository interface {
 	CreateWithTx(tx *gorm.DB, appointment *model.Appointment) error
-	GetByID(id uint) (*model.Appointment, error)
-	FindConflictingAppointments(tx *gorm.DB, appointment *model.Appointment) ([]model.Appointment, error)
+	CreateSeriesWithTx(tx *gorm.DB, series *model.AppointmentSeries) error
+	GetSeriesByIDForUpdate(tx *gorm.DB, organizationID, id uint) (*model.AppointmentSeries, error)
+	UpdateSeriesWithTx(tx *gorm.DB, series *model.AppointmentSeries) error
+	GetByID(organizationID, id uint) (*model.Appointment, error)
+	GetByIDForUpdate(tx *gorm.DB, organizationID, id uint) (*model.Appointment, error)
+	GetDeletedByIDForUpdate(tx *gorm.DB, organizationID, id uint) (*model.Appointment, error)
+	List(filter AppointmentFilter) ([]model.Appointment, int64, error)
+	FindActiveForUser(userID uint, from, to time.Time) ([]model.Appointment, error)
+	FindForUserEndingAfter(userID uint, after time.Time) ([]model.Appointment, error)
+	FindByResourceName(userID uint, name string) (*model.Appointment, error)
+	FindBusyForUsers(userIDs []uint, from, to time.Time) ([]model.Appointment, error)
+	FindFollowingInSeriesForUpdate(tx *gorm.DB, organizationID, seriesID uint, from time.Time) ([]model.Appointment, error)
+	CountInSeriesBefore(tx *gorm.DB, organizationID, seriesID uint, before time.Time) (int64, error)
+	FindUpcomingForUserForUpdate(tx *gorm.DB, userID uint, from time.Time) ([]model.Appointment, error)
+	FindWaitingLine(participantID uint) ([]model.Appointment, error)
+	NextInWaitingLineForUpdate(tx *gorm.DB, participantID uint) (*model.Appointment, error)
+	UpdateWithTx(tx *gorm.DB, appointment *model.Appointment, eventType string) error
+	DeleteWithTx(tx *gorm.DB, appointment *model.Appointment) error
+	RestoreWithTx(tx *gorm.DB, appointment *model.Appointment) error
+	LockParticipants(tx *gorm.DB, userID, participantID uint) error
+	FindConflictingAppointments(tx *gorm.DB, appointment *model.Appointment) (*Conflicts, error)
+}
+
+// Conflicts is what keeps an appointment from being booked: other
+// appointments of its users and time blocked in their imported calendars.
+type Conflicts struct {
+	Appointments []model.Appointment
+	BusyBlocks   []model.BusyBlock
 }
 
 type appointmentRepository struct {
@@ -21,14 +78,39 @@ func NewAppointmentRepository(db *gorm.DB) AppointmentRepository {
 	return &appointmentRepository{db: db}
 }
 
+// CreateWithTx stores the appointment and records its appointment.created
+// event as part of tx.
 func (ar *appointmentRepository) CreateWithTx(tx *gorm.DB, appointment *model.Appointment) error {
-	return tx.Create(appointment).Error
+	if err := tx.Create(appointment).Error; err != nil {
+		return err
+	}
+	return recordEvent(tx, appointment.OrganizationID, events.AppointmentCreated, appointment)
+}
+
+func (ar *appointmentRepository) CreateSeriesWithTx(tx *gorm.DB, series *model.AppointmentSeries) error {
+	return tx.Create(series).Error
 }
 
-func (ar *appointmentRepository) GetByID(id uint) (*model.Appointment, error) {
+func (ar *appointmentRepository) GetSeriesByIDForUpdate(tx *gorm.DB, organizationID, id uint) (*model.AppointmentSeries, error) {
+	var series model.AppointmentSeries
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("organization_id = ? AND id = ?", organizationID, id).First(&series).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (ar *appointmentRepository) UpdateSeriesWithTx(tx *gorm.DB, series *model.AppointmentSeries) error {
+	return tx.Save(series).Error
+}
+
+func (ar *appointmentRepository) GetByID(organizationID, id uint) (*model.Appointment, error) {
 	var appointment model.Appointment
 
-	if err := ar.db.Where("id = ?", id).First(&appointment).Error; err != nil {
+	if err := ar.db.Where("organization_id = ? AND id = ?", organizationID, id).First(&appointment).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -38,20 +120,311 @@ func (ar *appointmentRepository) GetByID(id uint) (*model.Appointment, error) {
 
 }
 
-func (ar *appointmentRepository) FindConflictingAppointments(tx *gorm.DB, req *model.Appointment) ([]model.Appointment, error) {
+func (ar *appointmentRepository) GetByIDForUpdate(tx *gorm.DB, organizationID, id uint) (*model.Appointment, error) {
+	var appointment model.Appointment
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("organization_id = ? AND id = ?", organizationID, id).First(&appointment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &appointment, nil
+}
 
-	var conflictingAppointments []model.Appointment
+// GetDeletedByIDForUpdate locks and returns the appointment only if it is
+// deleted.
+func (ar *appointmentRepository) GetDeletedByIDForUpdate(tx *gorm.DB, organizationID, id uint) (*model.Appointment, error) {
+	var appointment model.Appointment
+
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("organization_id = ? AND id = ? AND deleted_at IS NOT NULL", organizationID, id).First(&appointment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+func (ar *appointmentRepository) List(filter AppointmentFilter) ([]model.Appointment, int64, error) {
+	query := ar.db.Model(&model.Appointment{})
+	if filter.IncludeDeleted {
+		query = query.Unscoped()
+	}
+	query = query.Where("organization_id = ?", filter.OrganizationID)
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.ParticipantID != nil {
+		query = query.Where("participant_id = ?", *filter.ParticipantID)
+	}
+	if filter.PartyID != nil {
+		query = query.Where("(user_id = ? OR participant_id = ?)", *filter.PartyID, *filter.PartyID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("end_time > ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("start_time < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "start_time ASC, id ASC"
+	if filter.SortDesc {
+		order = "start_time DESC, id DESC"
+	}
+	var appointments []model.Appointment
+	if err := query.Order(order).Offset(filter.Offset).Limit(filter.Limit).Find(&appointments).Error; err != nil {
+		return nil, 0, err
+	}
+	return appointments, total, nil
+}
+
+// FindActiveForUser returns the non-cancelled appointments overlapping
+// [from, to) that userID either created or attends, ordered by start time.
+func (ar *appointmentRepository) FindActiveForUser(userID uint, from, to time.Time) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+
+	err := ar.db.Model(&model.Appointment{}).
+		Where(ar.db.Where("start_time<? AND end_time>?", to, from)).
+		Where(involvingUsers(ar.db, userID)).
+		Where("status <> ?", "cancelled").
+		Order("start_time ASC, id ASC").
+		Find(&appointments).Error
+	if err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// FindForUserEndingAfter returns the appointments in any status that userID
+// created or attends and that end after the given time, ordered by start
+// time.
+func (ar *appointmentRepository) FindForUserEndingAfter(userID uint, after time.Time) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+
+	err := ar.db.Model(&model.Appointment{}).
+		Where("end_time > ?", after).
+		Where(involvingUsers(ar.db, userID)).
+		Order("start_time ASC, id ASC").
+		Find(&appointments).Error
+	if err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// FindByResourceName returns the appointment of userID that a calendar
+// client created under the CalDAV resource name.
+func (ar *appointmentRepository) FindByResourceName(userID uint, name string) (*model.Appointment, error) {
+	var appointment model.Appointment
+
+	err := ar.db.Where("resource_name = ?", name).
+		Where(involvingUsers(ar.db, userID)).
+		Order("id DESC").
+		First(&appointment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// FindBusyForUsers returns the pending or confirmed appointments overlapping
+// [from, to) that any of userIDs created or attends, ordered by start time.
+func (ar *appointmentRepository) FindBusyForUsers(userIDs []uint, from, to time.Time) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+
+	err := ar.db.Model(&model.Appointment{}).
+		Where(ar.db.Where("start_time<? AND end_time>?", to, from)).
+		Where(ar.db.Where("user_id IN (?)", userIDs).Or("participant_id IN (?)", userIDs)).
+		Where("status NOT IN (?)", []string{"cancelled", "completed"}).
+		Order("start_time ASC, id ASC").
+		Find(&appointments).Error
+	if err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// FindFollowingInSeriesForUpdate locks and returns the pending or confirmed
+// occurrences of a series starting at or after from, in start order.
+func (ar *appointmentRepository) FindFollowingInSeriesForUpdate(tx *gorm.DB, organizationID, seriesID uint, from time.Time) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND series_id = ? AND start_time >= ?", organizationID, seriesID, from).
+		Where("status NOT IN (?)", []string{"cancelled", "completed"}).
+		Order("start_time ASC, id ASC").
+		Find(&appointments).Error
+	if err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// CountInSeriesBefore counts the occurrences of a series, in any status,
+// starting before before.
+func (ar *appointmentRepository) CountInSeriesBefore(tx *gorm.DB, organizationID, seriesID uint, before time.Time) (int64, error) {
+	var count int64
+
+	err := tx.Model(&model.Appointment{}).
+		Where("organization_id = ? AND series_id = ? AND start_time < ?", organizationID, seriesID, before).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// FindUpcomingForUserForUpdate locks and returns the pending or confirmed
+// appointments starting at or after from that userID created or attends,
+// in start order.
+func (ar *appointmentRepository) FindUpcomingForUserForUpdate(tx *gorm.DB, userID uint, from time.Time) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("start_time >= ?", from).
+		Where(involvingUsers(tx, userID)).
+		Where("status NOT IN (?)", []string{"cancelled", "completed"}).
+		Order("start_time ASC, id ASC").
+		Find(&appointments).Error
+	if err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// FindWaitingLine returns the participant's checked-in appointments that have
+// not been called in yet, in the order they will be seen: by scheduled
+// start, then by arrival.
+func (ar *appointmentRepository) FindWaitingLine(participantID uint) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+
+	err := waitingLine(ar.db, participantID).Find(&appointments).Error
+	if err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// NextInWaitingLineForUpdate locks and returns the head of the participant's
+// waiting line. Rows already locked by a concurrent call are skipped so two
+// callers never receive the same appointment.
+func (ar *appointmentRepository) NextInWaitingLineForUpdate(tx *gorm.DB, participantID uint) (*model.Appointment, error) {
+	var appointment model.Appointment
+
+	err := waitingLine(tx, participantID).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		First(&appointment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+func waitingLine(db *gorm.DB, participantID uint) *gorm.DB {
+	return db.Model(&model.Appointment{}).
+		Where("participant_id = ? AND status = ?", participantID, "confirmed").
+		Where("checked_in_at IS NOT NULL AND called_in_at IS NULL").
+		Order("start_time ASC, checked_in_at ASC, id ASC")
+}
+
+// UpdateWithTx stores the appointment and records eventType about it as
+// part of tx.
+func (ar *appointmentRepository) UpdateWithTx(tx *gorm.DB, appointment *model.Appointment, eventType string) error {
+	if err := tx.Save(appointment).Error; err != nil {
+		return err
+	}
+	return recordEvent(tx, appointment.OrganizationID, eventType, appointment)
+}
+
+// DeleteWithTx soft-deletes the appointment and records its
+// appointment.deleted event as part of tx.
+func (ar *appointmentRepository) DeleteWithTx(tx *gorm.DB, appointment *model.Appointment) error {
+	appointment.DeletedAt = gorm.DeletedAt{Time: tx.NowFunc(), Valid: true}
+	return ar.UpdateWithTx(tx, appointment, events.AppointmentDeleted)
+}
+
+// RestoreWithTx undeletes the appointment and records its
+// appointment.restored event as part of tx.
+func (ar *appointmentRepository) RestoreWithTx(tx *gorm.DB, appointment *model.Appointment) error {
+	appointment.DeletedAt = gorm.DeletedAt{}
+	return ar.UpdateWithTx(tx.Unscoped(), appointment, events.AppointmentRestored)
+}
+
+// LockParticipants serialises bookings involving either user until tx ends
+// by taking a transaction-scoped advisory lock per user. The locks are
+// always taken in ascending ID order so callers cannot deadlock.
+func (ar *appointmentRepository) LockParticipants(tx *gorm.DB, userID, participantID uint) error {
+	ids := []uint{userID, participantID}
+	if participantID < userID {
+		ids = []uint{participantID, userID}
+	}
+	for i, id := range ids {
+		if i > 0 && id == ids[i-1] {
+			continue
+		}
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey(userLockNamespace, id)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// advisoryLockKey packs a namespace and an ID into a single bigint key.
+// IDs beyond 32 bits wrap, which only costs extra serialisation.
+func advisoryLockKey(namespace int64, id uint) int64 {
+	return namespace<<32 | int64(id&0xffffffff)
+}
+
+// FindConflictingAppointments returns the open appointments and the
+// imported busy blocks of either user that overlap req, or nil if there are
+// none.
+func (ar *appointmentRepository) FindConflictingAppointments(tx *gorm.DB, req *model.Appointment) (*Conflicts, error) {
+
+	var conflicts Conflicts
 
 	query := tx.Model(&model.Appointment{}).
+		Where("organization_id = ?", req.OrganizationID).
 		Where(tx.Where("start_time<? AND end_time>?", req.EndTime, req.StartTime)).
-		Where(tx.Where("user_id=?", req.UserID).Or("participant_id=?", req.ParticipantID)).
+		Where(involvingUsers(tx, req.UserID, req.ParticipantID)).
 		Where(tx.Where("status NOT IN (?)", []string{"cancelled", "completed"}))
 
-	if err := query.Find(&conflictingAppointments).Error; err != nil {
+	// When re-checking an existing appointment it must not conflict with itself.
+	if req.ID != 0 {
+		query = query.Where("id <> ?", req.ID)
+	}
+
+	if err := query.Find(&conflicts.Appointments).Error; err != nil {
 		return nil, err
 	}
-	if len(conflictingAppointments) > 0 {
-		return conflictingAppointments, nil
+	err := tx.Model(&model.BusyBlock{}).
+		Where("start_time<? AND end_time>?", req.EndTime, req.StartTime).
+		Where("user_id IN (?)", []uint{req.UserID, req.ParticipantID}).
+		Find(&conflicts.BusyBlocks).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts.Appointments) > 0 || len(conflicts.BusyBlocks) > 0 {
+		return &conflicts, nil
 	}
 	return nil, nil
 }
+
+// involvingUsers matches appointments that any of userIDs created or attends.
+func involvingUsers(db *gorm.DB, userIDs ...uint) *gorm.DB {
+	return db.Where("user_id IN ?", userIDs).Or("participant_id IN ?", userIDs)
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"errors"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AuthRepository interface {
+	CreateRefreshTokenWithTx(tx *gorm.DB, token *model.RefreshToken) error
+	GetRefreshTokenForUpdate(tx *gorm.DB, tokenHash string) (*model.RefreshToken, error)
+	UpdateRefreshTokenWithTx(tx *gorm.DB, token *model.RefreshToken) error
+	RevokeRefreshTokenFamilyWithTx(tx *gorm.DB, familyID string, at time.Time) error
+	RevokeUserRefreshTokensWithTx(tx *gorm.DB, userID uint, at time.Time) error
+	RecordMagicLinkRequest(organizationID uint, email string) error
+	CreateMagicLink(link *model.MagicLink) error
+	GetMagicLinkForUpdate(tx *gorm.DB, tokenHash string) (*model.MagicLink, error)
+	UpdateMagicLinkWithTx(tx *gorm.DB, link *model.MagicLink) error
+	CreateAPIKey(key *model.APIKey) error
+	ListAPIKeysByUser(userID uint) ([]model.APIKey, error)
+	GetAPIKeyByID(id uint) (*model.APIKey, error)
+	GetAPIKeyByHash(keyHash string) (*model.APIKey, error)
+	UpdateAPIKey(key *model.APIKey) error
+	TouchAPIKey(id uint, at time.Time) error
+}
+
+type authRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthRepository(db *gorm.DB) AuthRepository {
+	return &authRepository{db: db}
+}
+
+func (ar *authRepository) CreateRefreshTokenWithTx(tx *gorm.DB, token *model.RefreshToken) error {
+	return tx.Create(token).Error
+}
+
+// GetRefreshTokenForUpdate locks the token so that two concurrent refreshes
+// cannot both rotate it.
+func (ar *authRepository) GetRefreshTokenForUpdate(tx *gorm.DB, tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (ar *authRepository) UpdateRefreshTokenWithTx(tx *gorm.DB, token *model.RefreshToken) error {
+	return tx.Save(token).Error
+}
+
+// RevokeRefreshTokenFamilyWithTx revokes every token of the family that is
+// still valid.
+func (ar *authRepository) RevokeRefreshTokenFamilyWithTx(tx *gorm.DB, familyID string, at time.Time) error {
+	return tx.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+// RevokeUserRefreshTokensWithTx revokes every valid refresh token of the
+// user, signing them out of all sessions.
+func (ar *authRepository) RevokeUserRefreshTokensWithTx(tx *gorm.DB, userID uint, at time.Time) error {
+	return tx.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// RecordMagicLinkRequest queues a magic link to be sent to the email.
+func (ar *authRepository) RecordMagicLinkRequest(organizationID uint, email string) error {
+	return recordEvent(ar.db, organizationID, events.MagicLinkRequested, events.MagicLinkRequest{OrganizationID: organizationID, Email: email})
+}
+
+func (ar *authRepository) CreateMagicLink(link *model.MagicLink) error {
+	return ar.db.Create(link).Error
+}
+
+func (ar *authRepository) GetMagicLinkForUpdate(tx *gorm.DB, tokenHash string) (*model.MagicLink, error) {
+	var link model.MagicLink
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", tokenHash).First(&link).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (ar *authRepository) UpdateMagicLinkWithTx(tx *gorm.DB, link *model.MagicLink) error {
+	return tx.Save(link).Error
+}
+
+func (ar *authRepository) CreateAPIKey(key *model.APIKey) error {
+	return ar.db.Create(key).Error
+}
+
+func (ar *authRepository) ListAPIKeysByUser(userID uint) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := ar.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (ar *authRepository) GetAPIKeyByID(id uint) (*model.APIKey, error) {
+	var key model.APIKey
+	err := ar.db.First(&key, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (ar *authRepository) GetAPIKeyByHash(keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := ar.db.Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (ar *authRepository) UpdateAPIKey(key *model.APIKey) error {
+	return ar.db.Save(key).Error
+}
+
+// TouchAPIKey records when the key was last used without touching the rest
+// of the row.
+func (ar *authRepository) TouchAPIKey(id uint, at time.Time) error {
+	return ar.db.Model(&model.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"errors"
+	"queue_system/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const busyBlockBatchSize = 500
+
+type CalendarRepository interface {
+	GetFeedByTokenHash(tokenHash string) (*model.CalendarFeed, error)
+	SaveFeed(feed *model.CalendarFeed) error
+	GetBusyCalendar(id uint) (*model.BusyCalendar, error)
+	ListBusyCalendars(userID uint) ([]model.BusyCalendar, error)
+	DeleteBusyCalendar(id uint) (bool, error)
+	FetchDueBusyCalendarsForUpdate(tx *gorm.DB, now time.Time, limit int) ([]model.BusyCalendar, error)
+	ClaimBusyCalendarsWithTx(tx *gorm.DB, calendars []model.BusyCalendar, until time.Time) error
+	GetBusyCalendarForUpdate(tx *gorm.DB, id uint) (*model.BusyCalendar, error)
+	UpdateBusyCalendarWithTx(tx *gorm.DB, calendar *model.BusyCalendar) error
+	ReplaceBusyBlocksWithTx(tx *gorm.DB, calendar *model.BusyCalendar, blocks []model.BusyBlock) error
+	FindBusyBlocksForUsers(userIDs []uint, from, to time.Time) ([]model.BusyBlock, error)
+}
+
+type calendarRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarRepository(db *gorm.DB) CalendarRepository {
+	return &calendarRepository{db: db}
+}
+
+func (cr *calendarRepository) GetFeedByTokenHash(tokenHash string) (*model.CalendarFeed, error) {
+	var feed model.CalendarFeed
+	if err := cr.db.Where("token_hash = ?", tokenHash).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// SaveFeed creates the user's feed or replaces its token.
+func (cr *calendarRepository) SaveFeed(feed *model.CalendarFeed) error {
+	return cr.db.Save(feed).Error
+}
+
+func (cr *calendarRepository) GetBusyCalendar(id uint) (*model.BusyCalendar, error) {
+	var calendar model.BusyCalendar
+	if err := cr.db.First(&calendar, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+func (cr *calendarRepository) ListBusyCalendars(userID uint) ([]model.BusyCalendar, error) {
+	var calendars []model.BusyCalendar
+	if err := cr.db.Where("user_id = ?", userID).Order("id ASC").Find(&calendars).Error; err != nil {
+		return nil, err
+	}
+	return calendars, nil
+}
+
+// DeleteBusyCalendar removes the calendar and the time it blocked.
+func (cr *calendarRepository) DeleteBusyCalendar(id uint) (bool, error) {
+	var deleted bool
+	err := cr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", id).Delete(&model.BusyBlock{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&model.BusyCalendar{}, id)
+		deleted = result.RowsAffected > 0
+		return result.Error
+	})
+	return deleted, err
+}
+
+// FetchDueBusyCalendarsForUpdate locks up to limit calendars due for a
+// refresh, skipping those another worker is refreshing.
+func (cr *calendarRepository) FetchDueBusyCalendarsForUpdate(tx *gorm.DB, now time.Time, limit int) ([]model.BusyCalendar, error) {
+	var calendars []model.BusyCalendar
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("next_sync_at <= ?", now).
+		Order("next_sync_at ASC, id ASC").
+		Limit(limit).
+		Find(&calendars).Error
+	if err != nil {
+		return nil, err
+	}
+	return calendars, nil
+}
+
+// ClaimBusyCalendarsWithTx moves the calendars' next refresh to until, so
+// that other workers leave them alone while they are fetched and pick them
+// up again if this worker stops before then.
+func (cr *calendarRepository) ClaimBusyCalendarsWithTx(tx *gorm.DB, calendars []model.BusyCalendar, until time.Time) error {
+	if len(calendars) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(calendars))
+	for i := range calendars {
+		ids[i] = calendars[i].ID
+		calendars[i].NextSyncAt = &until
+	}
+	return tx.Model(&model.BusyCalendar{}).Where("id IN ?", ids).Update("next_sync_at", until).Error
+}
+
+// GetBusyCalendarForUpdate locks the calendar, or returns nil if it has
+// been deleted.
+func (cr *calendarRepository) GetBusyCalendarForUpdate(tx *gorm.DB, id uint) (*model.BusyCalendar, error) {
+	var calendar model.BusyCalendar
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&calendar, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+func (cr *calendarRepository) UpdateBusyCalendarWithTx(tx *gorm.DB, calendar *model.BusyCalendar) error {
+	return tx.Save(calendar).Error
+}
+
+// ReplaceBusyBlocksWithTx saves the calendar, creating it if it is new, and
+// swaps its blocks for blocks.
+func (cr *calendarRepository) ReplaceBusyBlocksWithTx(tx *gorm.DB, calendar *model.BusyCalendar, blocks []model.BusyBlock) error {
+	if err := tx.Save(calendar).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("calendar_id = ?", calendar.ID).Delete(&model.BusyBlock{}).Error; err != nil {
+		return err
+	}
+	for i := range blocks {
+		blocks[i].CalendarID = calendar.ID
+		blocks[i].UserID = calendar.UserID
+	}
+	if len(blocks) > 0 {
+		return tx.CreateInBatches(blocks, busyBlockBatchSize).Error
+	}
+	return nil
+}
+
+// FindBusyBlocksForUsers returns the busy blocks of the users overlapping
+// [from, to), ordered by start time.
+func (cr *calendarRepository) FindBusyBlocksForUsers(userIDs []uint, from, to time.Time) ([]model.BusyBlock, error) {
+	var blocks []model.BusyBlock
+
+	err := cr.db.Where("start_time<? AND end_time>?", to, from).
+		Where("user_id IN (?)", userIDs).
+		Order("start_time ASC, id ASC").
+		Find(&blocks).Error
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
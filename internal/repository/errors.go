@@ -0,0 +1,14 @@
+package repository
+
+import "errors"
+
+// uniqueViolation is the SQLSTATE of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// IsUniqueViolation reports whether err was caused by a unique constraint,
+// e.g. a user saved with an email another user of the organization took in
+// the meantime.
+func IsUniqueViolation(err error) bool {
+	var sqlErr interface{ SQLState() string }
+	return errors.As(err, &sqlErr) && sqlErr.SQLState() == uniqueViolation
+}
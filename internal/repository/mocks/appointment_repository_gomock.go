@@ -6,7 +6,9 @@ package mocks
 
 import (
 	model "queue_system/internal/model"
+	repository "queue_system/internal/repository"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	gorm "gorm.io/gorm"
@@ -35,6 +37,35 @@ func (m *MockAppointmentRepository) EXPECT() *MockAppointmentRepositoryMockRecor
 	return m.recorder
 }
 
+// CountInSeriesBefore mocks base method.
+func (m *MockAppointmentRepository) CountInSeriesBefore(tx *gorm.DB, organizationID uint, seriesID uint, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountInSeriesBefore", tx, organizationID, seriesID, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountInSeriesBefore indicates an expected call of CountInSeriesBefore.
+func (mr *MockAppointmentRepositoryMockRecorder) CountInSeriesBefore(tx, organizationID, seriesID, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountInSeriesBefore", reflect.TypeOf((*MockAppointmentRepository)(nil).CountInSeriesBefore), tx, organizationID, seriesID, before)
+}
+
+// CreateSeriesWithTx mocks base method.
+func (m *MockAppointmentRepository) CreateSeriesWithTx(tx *gorm.DB, series *model.AppointmentSeries) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSeriesWithTx", tx, series)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSeriesWithTx indicates an expected call of CreateSeriesWithTx.
+func (mr *MockAppointmentRepositoryMockRecorder) CreateSeriesWithTx(tx, series interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSeriesWithTx", reflect.TypeOf((*MockAppointmentRepository)(nil).CreateSeriesWithTx), tx, series)
+}
+
 // CreateWithTx mocks base method.
 func (m *MockAppointmentRepository) CreateWithTx(tx *gorm.DB, appointment *model.Appointment) error {
 	m.ctrl.T.Helper()
@@ -49,11 +80,70 @@ func (mr *MockAppointmentRepositoryMockRecorder) CreateWithTx(tx, appointment in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithTx", reflect.TypeOf((*MockAppointmentRepository)(nil).CreateWithTx), tx, appointment)
 }
 
+// DeleteWithTx mocks base method.
+func (m *MockAppointmentRepository) DeleteWithTx(tx *gorm.DB, appointment *model.Appointment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWithTx", tx, appointment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWithTx indicates an expected call of DeleteWithTx.
+func (mr *MockAppointmentRepositoryMockRecorder) DeleteWithTx(tx, appointment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWithTx", reflect.TypeOf((*MockAppointmentRepository)(nil).DeleteWithTx), tx, appointment)
+}
+
+// FindActiveForUser mocks base method.
+func (m *MockAppointmentRepository) FindActiveForUser(userID uint, from time.Time, to time.Time) ([]model.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindActiveForUser", userID, from, to)
+	ret0, _ := ret[0].([]model.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindActiveForUser indicates an expected call of FindActiveForUser.
+func (mr *MockAppointmentRepositoryMockRecorder) FindActiveForUser(userID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindActiveForUser", reflect.TypeOf((*MockAppointmentRepository)(nil).FindActiveForUser), userID, from, to)
+}
+
+// FindBusyForUsers mocks base method.
+func (m *MockAppointmentRepository) FindBusyForUsers(userIDs []uint, from time.Time, to time.Time) ([]model.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindBusyForUsers", userIDs, from, to)
+	ret0, _ := ret[0].([]model.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindBusyForUsers indicates an expected call of FindBusyForUsers.
+func (mr *MockAppointmentRepositoryMockRecorder) FindBusyForUsers(userIDs, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBusyForUsers", reflect.TypeOf((*MockAppointmentRepository)(nil).FindBusyForUsers), userIDs, from, to)
+}
+
+// FindByResourceName mocks base method.
+func (m *MockAppointmentRepository) FindByResourceName(userID uint, name string) (*model.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByResourceName", userID, name)
+	ret0, _ := ret[0].(*model.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByResourceName indicates an expected call of FindByResourceName.
+func (mr *MockAppointmentRepositoryMockRecorder) FindByResourceName(userID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByResourceName", reflect.TypeOf((*MockAppointmentRepository)(nil).FindByResourceName), userID, name)
+}
+
 // FindConflictingAppointments mocks base method.
-func (m *MockAppointmentRepository) FindConflictingAppointments(tx *gorm.DB, appointment *model.Appointment) ([]model.Appointment, error) {
+func (m *MockAppointmentRepository) FindConflictingAppointments(tx *gorm.DB, appointment *model.Appointment) (*repository.Conflicts, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindConflictingAppointments", tx, appointment)
-	ret0, _ := ret[0].([]model.Appointment)
+	ret0, _ := ret[0].(*repository.Conflicts)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -64,17 +154,209 @@ func (mr *MockAppointmentRepositoryMockRecorder) FindConflictingAppointments(tx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindConflictingAppointments", reflect.TypeOf((*MockAppointmentRepository)(nil).FindConflictingAppointments), tx, appointment)
 }
 
+// FindFollowingInSeriesForUpdate mocks base method.
+func (m *MockAppointmentRepository) FindFollowingInSeriesForUpdate(tx *gorm.DB, organizationID uint, seriesID uint, from time.Time) ([]model.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindFollowingInSeriesForUpdate", tx, organizationID, seriesID, from)
+	ret0, _ := ret[0].([]model.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindFollowingInSeriesForUpdate indicates an expected call of FindFollowingInSeriesForUpdate.
+func (mr *MockAppointmentRepositoryMockRecorder) FindFollowingInSeriesForUpdate(tx, organizationID, seriesID, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindFollowingInSeriesForUpdate", reflect.TypeOf((*MockAppointmentRepository)(nil).FindFollowingInSeriesForUpdate), tx, organizationID, seriesID, from)
+}
+
+// FindForUserEndingAfter mocks base method.
+func (m *MockAppointmentRepository) FindForUserEndingAfter(userID uint, after time.Time) ([]model.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindForUserEndingAfter", userID, after)
+	ret0, _ := ret[0].([]model.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindForUserEndingAfter indicates an expected call of FindForUserEndingAfter.
+func (mr *MockAppointmentRepositoryMockRecorder) FindForUserEndingAfter(userID, after interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindForUserEndingAfter", reflect.TypeOf((*MockAppointmentRepository)(nil).FindForUserEndingAfter), userID, after)
+}
+
+// FindUpcomingForUserForUpdate mocks base method.
+func (m *MockAppointmentRepository) FindUpcomingForUserForUpdate(tx *gorm.DB, userID uint, from time.Time) ([]model.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUpcomingForUserForUpdate", tx, userID, from)
+	ret0, _ := ret[0].([]model.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUpcomingForUserForUpdate indicates an expected call of FindUpcomingForUserForUpdate.
+func (mr *MockAppointmentRepositoryMockRecorder) FindUpcomingForUserForUpdate(tx, userID, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUpcomingForUserForUpdate", reflect.TypeOf((*MockAppointmentRepository)(nil).FindUpcomingForUserForUpdate), tx, userID, from)
+}
+
+// FindWaitingLine mocks base method.
+func (m *MockAppointmentRepository) FindWaitingLine(participantID uint) ([]model.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWaitingLine", participantID)
+	ret0, _ := ret[0].([]model.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWaitingLine indicates an expected call of FindWaitingLine.
+func (mr *MockAppointmentRepositoryMockRecorder) FindWaitingLine(participantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWaitingLine", reflect.TypeOf((*MockAppointmentRepository)(nil).FindWaitingLine), participantID)
+}
+
 // GetByID mocks base method.
-func (m *MockAppointmentRepository) GetByID(id uint) (*model.Appointment, error) {
+func (m *MockAppointmentRepository) GetByID(organizationID uint, id uint) (*model.Appointment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", id)
+	ret := m.ctrl.Call(m, "GetByID", organizationID, id)
 	ret0, _ := ret[0].(*model.Appointment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByID indicates an expected call of GetByID.
-func (mr *MockAppointmentRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+func (mr *MockAppointmentRepositoryMockRecorder) GetByID(organizationID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockAppointmentRepository)(nil).GetByID), organizationID, id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockAppointmentRepository) GetByIDForUpdate(tx *gorm.DB, organizationID uint, id uint) (*model.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", tx, organizationID, id)
+	ret0, _ := ret[0].(*model.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockAppointmentRepositoryMockRecorder) GetByIDForUpdate(tx, organizationID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockAppointmentRepository)(nil).GetByIDForUpdate), tx, organizationID, id)
+}
+
+// GetDeletedByIDForUpdate mocks base method.
+func (m *MockAppointmentRepository) GetDeletedByIDForUpdate(tx *gorm.DB, organizationID uint, id uint) (*model.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedByIDForUpdate", tx, organizationID, id)
+	ret0, _ := ret[0].(*model.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedByIDForUpdate indicates an expected call of GetDeletedByIDForUpdate.
+func (mr *MockAppointmentRepositoryMockRecorder) GetDeletedByIDForUpdate(tx, organizationID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedByIDForUpdate", reflect.TypeOf((*MockAppointmentRepository)(nil).GetDeletedByIDForUpdate), tx, organizationID, id)
+}
+
+// GetSeriesByIDForUpdate mocks base method.
+func (m *MockAppointmentRepository) GetSeriesByIDForUpdate(tx *gorm.DB, organizationID uint, id uint) (*model.AppointmentSeries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSeriesByIDForUpdate", tx, organizationID, id)
+	ret0, _ := ret[0].(*model.AppointmentSeries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSeriesByIDForUpdate indicates an expected call of GetSeriesByIDForUpdate.
+func (mr *MockAppointmentRepositoryMockRecorder) GetSeriesByIDForUpdate(tx, organizationID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSeriesByIDForUpdate", reflect.TypeOf((*MockAppointmentRepository)(nil).GetSeriesByIDForUpdate), tx, organizationID, id)
+}
+
+// List mocks base method.
+func (m *MockAppointmentRepository) List(filter repository.AppointmentFilter) ([]model.Appointment, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter)
+	ret0, _ := ret[0].([]model.Appointment)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockAppointmentRepositoryMockRecorder) List(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAppointmentRepository)(nil).List), filter)
+}
+
+// LockParticipants mocks base method.
+func (m *MockAppointmentRepository) LockParticipants(tx *gorm.DB, userID uint, participantID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockParticipants", tx, userID, participantID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockParticipants indicates an expected call of LockParticipants.
+func (mr *MockAppointmentRepositoryMockRecorder) LockParticipants(tx, userID, participantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockParticipants", reflect.TypeOf((*MockAppointmentRepository)(nil).LockParticipants), tx, userID, participantID)
+}
+
+// NextInWaitingLineForUpdate mocks base method.
+func (m *MockAppointmentRepository) NextInWaitingLineForUpdate(tx *gorm.DB, participantID uint) (*model.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextInWaitingLineForUpdate", tx, participantID)
+	ret0, _ := ret[0].(*model.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextInWaitingLineForUpdate indicates an expected call of NextInWaitingLineForUpdate.
+func (mr *MockAppointmentRepositoryMockRecorder) NextInWaitingLineForUpdate(tx, participantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextInWaitingLineForUpdate", reflect.TypeOf((*MockAppointmentRepository)(nil).NextInWaitingLineForUpdate), tx, participantID)
+}
+
+// RestoreWithTx mocks base method.
+func (m *MockAppointmentRepository) RestoreWithTx(tx *gorm.DB, appointment *model.Appointment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreWithTx", tx, appointment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreWithTx indicates an expected call of RestoreWithTx.
+func (mr *MockAppointmentRepositoryMockRecorder) RestoreWithTx(tx, appointment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreWithTx", reflect.TypeOf((*MockAppointmentRepository)(nil).RestoreWithTx), tx, appointment)
+}
+
+// UpdateSeriesWithTx mocks base method.
+func (m *MockAppointmentRepository) UpdateSeriesWithTx(tx *gorm.DB, series *model.AppointmentSeries) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSeriesWithTx", tx, series)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSeriesWithTx indicates an expected call of UpdateSeriesWithTx.
+func (mr *MockAppointmentRepositoryMockRecorder) UpdateSeriesWithTx(tx, series interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSeriesWithTx", reflect.TypeOf((*MockAppointmentRepository)(nil).UpdateSeriesWithTx), tx, series)
+}
+
+// UpdateWithTx mocks base method.
+func (m *MockAppointmentRepository) UpdateWithTx(tx *gorm.DB, appointment *model.Appointment, eventType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWithTx", tx, appointment, eventType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWithTx indicates an expected call of UpdateWithTx.
+func (mr *MockAppointmentRepositoryMockRecorder) UpdateWithTx(tx, appointment, eventType interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockAppointmentRepository)(nil).GetByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithTx", reflect.TypeOf((*MockAppointmentRepository)(nil).UpdateWithTx), tx, appointment, eventType)
 }
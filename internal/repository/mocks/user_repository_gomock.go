@@ -6,9 +6,11 @@ package mocks
 
 import (
 	model "queue_system/internal/model"
+	repository "queue_system/internal/repository"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	gorm "gorm.io/gorm"
 )
 
 // MockUserRepository is a mock of UserRepository interface.
@@ -49,34 +51,152 @@ func (mr *MockUserRepositoryMockRecorder) CreateUser(user interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserRepository)(nil).CreateUser), user)
 }
 
+// CreateUserWithTx mocks base method.
+func (m *MockUserRepository) CreateUserWithTx(tx *gorm.DB, user *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserWithTx", tx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUserWithTx indicates an expected call of CreateUserWithTx.
+func (mr *MockUserRepositoryMockRecorder) CreateUserWithTx(tx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserWithTx", reflect.TypeOf((*MockUserRepository)(nil).CreateUserWithTx), tx, user)
+}
+
+// DeleteUserWithTx mocks base method.
+func (m *MockUserRepository) DeleteUserWithTx(tx *gorm.DB, user *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserWithTx", tx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserWithTx indicates an expected call of DeleteUserWithTx.
+func (mr *MockUserRepositoryMockRecorder) DeleteUserWithTx(tx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserWithTx", reflect.TypeOf((*MockUserRepository)(nil).DeleteUserWithTx), tx, user)
+}
+
 // GetByEmail mocks base method.
-func (m *MockUserRepository) GetByEmail(email string) (*model.User, error) {
+func (m *MockUserRepository) GetByEmail(organizationID uint, email string) (*model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByEmail", email)
+	ret := m.ctrl.Call(m, "GetByEmail", organizationID, email)
 	ret0, _ := ret[0].(*model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByEmail indicates an expected call of GetByEmail.
-func (mr *MockUserRepositoryMockRecorder) GetByEmail(email interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) GetByEmail(organizationID, email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetByEmail), email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetByEmail), organizationID, email)
+}
+
+// GetByEmailWithTx mocks base method.
+func (m *MockUserRepository) GetByEmailWithTx(tx *gorm.DB, organizationID uint, email string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByEmailWithTx", tx, organizationID, email)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByEmailWithTx indicates an expected call of GetByEmailWithTx.
+func (mr *MockUserRepositoryMockRecorder) GetByEmailWithTx(tx, organizationID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmailWithTx", reflect.TypeOf((*MockUserRepository)(nil).GetByEmailWithTx), tx, organizationID, email)
 }
 
 // GetById mocks base method.
-func (m *MockUserRepository) GetById(id uint) (*model.User, error) {
+func (m *MockUserRepository) GetById(organizationID uint, id uint) (*model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetById", id)
+	ret := m.ctrl.Call(m, "GetById", organizationID, id)
 	ret0, _ := ret[0].(*model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetById indicates an expected call of GetById.
-func (mr *MockUserRepositoryMockRecorder) GetById(id interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) GetById(organizationID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockUserRepository)(nil).GetById), organizationID, id)
+}
+
+// GetByIdForUpdate mocks base method.
+func (m *MockUserRepository) GetByIdForUpdate(tx *gorm.DB, organizationID uint, id uint) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIdForUpdate", tx, organizationID, id)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIdForUpdate indicates an expected call of GetByIdForUpdate.
+func (mr *MockUserRepositoryMockRecorder) GetByIdForUpdate(tx, organizationID, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockUserRepository)(nil).GetById), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIdForUpdate", reflect.TypeOf((*MockUserRepository)(nil).GetByIdForUpdate), tx, organizationID, id)
+}
+
+// GetByIds mocks base method.
+func (m *MockUserRepository) GetByIds(organizationID uint, ids []uint) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIds", organizationID, ids)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIds indicates an expected call of GetByIds.
+func (mr *MockUserRepositoryMockRecorder) GetByIds(organizationID, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIds", reflect.TypeOf((*MockUserRepository)(nil).GetByIds), organizationID, ids)
+}
+
+// GetDeletedByIdForUpdate mocks base method.
+func (m *MockUserRepository) GetDeletedByIdForUpdate(tx *gorm.DB, organizationID uint, id uint) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedByIdForUpdate", tx, organizationID, id)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedByIdForUpdate indicates an expected call of GetDeletedByIdForUpdate.
+func (mr *MockUserRepositoryMockRecorder) GetDeletedByIdForUpdate(tx, organizationID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedByIdForUpdate", reflect.TypeOf((*MockUserRepository)(nil).GetDeletedByIdForUpdate), tx, organizationID, id)
+}
+
+// List mocks base method.
+func (m *MockUserRepository) List(filter repository.UserFilter) ([]model.User, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockUserRepositoryMockRecorder) List(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserRepository)(nil).List), filter)
+}
+
+// RestoreUserWithTx mocks base method.
+func (m *MockUserRepository) RestoreUserWithTx(tx *gorm.DB, user *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUserWithTx", tx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUserWithTx indicates an expected call of RestoreUserWithTx.
+func (mr *MockUserRepositoryMockRecorder) RestoreUserWithTx(tx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUserWithTx", reflect.TypeOf((*MockUserRepository)(nil).RestoreUserWithTx), tx, user)
 }
 
 // UpdateUser mocks base method.
@@ -92,3 +212,17 @@ func (mr *MockUserRepositoryMockRecorder) UpdateUser(user interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepository)(nil).UpdateUser), user)
 }
+
+// UpdateUserWithTx mocks base method.
+func (m *MockUserRepository) UpdateUserWithTx(tx *gorm.DB, user *model.User, eventType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserWithTx", tx, user, eventType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserWithTx indicates an expected call of UpdateUserWithTx.
+func (mr *MockUserRepositoryMockRecorder) UpdateUserWithTx(tx, user, eventType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserWithTx", reflect.TypeOf((*MockUserRepository)(nil).UpdateUserWithTx), tx, user, eventType)
+}
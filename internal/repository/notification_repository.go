@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"queue_system/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	WasSent(eventID, userID uint) (bool, error)
+	RecordSent(sent *model.SentNotification) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// WasSent reports whether the message for the event has reached the user.
+func (nr *notificationRepository) WasSent(eventID, userID uint) (bool, error) {
+	var count int64
+	if err := nr.db.Model(&model.SentNotification{}).Where("event_id = ? AND user_id = ?", eventID, userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RecordSent stores that a message was sent. Recording the same event and
+// user twice is harmless.
+func (nr *notificationRepository) RecordSent(sent *model.SentNotification) error {
+	return nr.db.Clauses(clause.OnConflict{DoNothing: true}).Create(sent).Error
+}
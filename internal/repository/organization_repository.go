@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"errors"
+	"queue_system/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// OrganizationRepository stores organizations. The default one is created
+// on startup; admins of it add the others.
+type OrganizationRepository interface {
+	CreateOrganizationWithTx(tx *gorm.DB, organization *model.Organization) error
+	GetByID(id uint) (*model.Organization, error)
+	GetBySlug(slug string) (*model.Organization, error)
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+func (or *organizationRepository) CreateOrganizationWithTx(tx *gorm.DB, organization *model.Organization) error {
+	return tx.Create(organization).Error
+}
+
+func (or *organizationRepository) GetByID(id uint) (*model.Organization, error) {
+	var organization model.Organization
+	if err := or.db.Where("id = ?", id).First(&organization).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (or *organizationRepository) GetBySlug(slug string) (*model.Organization, error) {
+	var organization model.Organization
+	if err := or.db.Where("slug = ?", slug).First(&organization).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
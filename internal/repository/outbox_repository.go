@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"encoding/json"
+	"queue_system/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	RecordWithTx(tx *gorm.DB, organizationID uint, eventType string, payload interface{}) error
+	FetchPendingForUpdate(tx *gorm.DB, now time.Time, limit int) ([]model.OutboxEvent, error)
+	ClaimWithTx(tx *gorm.DB, events []model.OutboxEvent, until time.Time) error
+	MarkDispatchedWithTx(tx *gorm.DB, event *model.OutboxEvent, at time.Time) error
+	MarkFailedWithTx(tx *gorm.DB, event *model.OutboxEvent, cause error, retryAt time.Time) error
+	MarkDeadWithTx(tx *gorm.DB, event *model.OutboxEvent, cause error, at time.Time) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// RecordWithTx adds an event of the organization to the outbox as part of
+// tx, so it is only dispatched if tx commits.
+func (obr *outboxRepository) RecordWithTx(tx *gorm.DB, organizationID uint, eventType string, payload interface{}) error {
+	return recordEvent(tx, organizationID, eventType, payload)
+}
+
+// FetchPendingForUpdate locks up to limit undispatched events that are due
+// and have not failed for good, oldest first. Rows locked by another
+// dispatcher are skipped.
+func (obr *outboxRepository) FetchPendingForUpdate(tx *gorm.DB, now time.Time, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("dispatched_at IS NULL AND failed_at IS NULL AND available_at <= ?", now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ClaimWithTx makes the events unavailable to other dispatchers until the
+// lease expires, so they can be delivered after tx commits. Events whose
+// dispatcher dies before recording a result are picked up again then.
+func (obr *outboxRepository) ClaimWithTx(tx *gorm.DB, events []model.OutboxEvent, until time.Time) error {
+	if len(events) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(events))
+	for i := range events {
+		ids[i] = events[i].ID
+		events[i].AvailableAt = until
+	}
+	return tx.Model(&model.OutboxEvent{}).Where("id IN ?", ids).Update("available_at", until).Error
+}
+
+func (obr *outboxRepository) MarkDispatchedWithTx(tx *gorm.DB, event *model.OutboxEvent, at time.Time) error {
+	event.DispatchedAt = &at
+	event.Attempts++
+	event.LastError = ""
+	return tx.Save(event).Error
+}
+
+func (obr *outboxRepository) MarkFailedWithTx(tx *gorm.DB, event *model.OutboxEvent, cause error, retryAt time.Time) error {
+	event.Attempts++
+	event.LastError = cause.Error()
+	event.AvailableAt = retryAt
+	return tx.Save(event).Error
+}
+
+// MarkDeadWithTx gives up on the event after its last failed attempt.
+func (obr *outboxRepository) MarkDeadWithTx(tx *gorm.DB, event *model.OutboxEvent, cause error, at time.Time) error {
+	event.Attempts++
+	event.LastError = cause.Error()
+	event.FailedAt = &at
+	return tx.Save(event).Error
+}
+
+func recordEvent(tx *gorm.DB, organizationID uint, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&model.OutboxEvent{
+		OrganizationID: organizationID,
+		Type:           eventType,
+		Payload:        string(data),
+		AvailableAt:    time.Now(),
+	}).Error
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"errors"
+	"queue_system/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QueueRepository finds queues and tickets by ID only within an
+// organization. Lookups by queue need no organization once the queue has
+// been found in it.
+type QueueRepository interface {
+	CreateQueue(queue *model.Queue) error
+	GetQueueByID(organizationID, id uint) (*model.Queue, error)
+	GetQueueByName(organizationID uint, name, servicePoint string) (*model.Queue, error)
+	ListQueues(organizationID uint) ([]model.Queue, error)
+	GetQueueForUpdate(tx *gorm.DB, organizationID, id uint) (*model.Queue, error)
+	UpdateQueueWithTx(tx *gorm.DB, queue *model.Queue) error
+	CreateTicketWithTx(tx *gorm.DB, ticket *model.QueueTicket) error
+	GetTicketForUpdate(tx *gorm.DB, organizationID, queueID, ticketID uint) (*model.QueueTicket, error)
+	NextWaitingTicket(tx *gorm.DB, queueID uint) (*model.QueueTicket, error)
+	UpdateTicketWithTx(tx *gorm.DB, ticket *model.QueueTicket) error
+	ListTickets(queueID uint, status string) ([]model.QueueTicket, error)
+}
+
+type queueRepository struct {
+	db *gorm.DB
+}
+
+func NewQueueRepository(db *gorm.DB) QueueRepository {
+	return &queueRepository{db: db}
+}
+
+func (qr *queueRepository) CreateQueue(queue *model.Queue) error {
+	return qr.db.Create(queue).Error
+}
+
+func (qr *queueRepository) GetQueueByID(organizationID, id uint) (*model.Queue, error) {
+	var queue model.Queue
+	if err := qr.db.Where("organization_id = ? AND id = ?", organizationID, id).First(&queue).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &queue, nil
+}
+
+func (qr *queueRepository) GetQueueByName(organizationID uint, name, servicePoint string) (*model.Queue, error) {
+	var queue model.Queue
+	if err := qr.db.Where("organization_id = ? AND name = ? AND service_point = ?", organizationID, name, servicePoint).First(&queue).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &queue, nil
+}
+
+func (qr *queueRepository) ListQueues(organizationID uint) ([]model.Queue, error) {
+	var queues []model.Queue
+	if err := qr.db.Where("organization_id = ?", organizationID).Order("service_point ASC, name ASC").Find(&queues).Error; err != nil {
+		return nil, err
+	}
+	return queues, nil
+}
+
+// GetQueueForUpdate locks the queue row. Taking a ticket and calling the
+// next one both hold this lock, which serialises numbering and keeps calls
+// in FIFO order.
+func (qr *queueRepository) GetQueueForUpdate(tx *gorm.DB, organizationID, id uint) (*model.Queue, error) {
+	var queue model.Queue
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("organization_id = ? AND id = ?", organizationID, id).First(&queue).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &queue, nil
+}
+
+func (qr *queueRepository) UpdateQueueWithTx(tx *gorm.DB, queue *model.Queue) error {
+	return tx.Save(queue).Error
+}
+
+func (qr *queueRepository) CreateTicketWithTx(tx *gorm.DB, ticket *model.QueueTicket) error {
+	return tx.Create(ticket).Error
+}
+
+func (qr *queueRepository) GetTicketForUpdate(tx *gorm.DB, organizationID, queueID, ticketID uint) (*model.QueueTicket, error) {
+	var ticket model.QueueTicket
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND id = ? AND queue_id = ?", organizationID, ticketID, queueID).
+		First(&ticket).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// NextWaitingTicket returns the lowest-numbered waiting ticket. The caller
+// must hold the queue lock from GetQueueForUpdate.
+func (qr *queueRepository) NextWaitingTicket(tx *gorm.DB, queueID uint) (*model.QueueTicket, error) {
+	var ticket model.QueueTicket
+	err := tx.Where("queue_id = ? AND status = ?", queueID, "waiting").
+		Order("number ASC").
+		First(&ticket).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+func (qr *queueRepository) UpdateTicketWithTx(tx *gorm.DB, ticket *model.QueueTicket) error {
+	return tx.Save(ticket).Error
+}
+
+func (qr *queueRepository) ListTickets(queueID uint, status string) ([]model.QueueTicket, error) {
+	var tickets []model.QueueTicket
+	query := qr.db.Where("queue_id = ?", queueID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("number ASC").Find(&tickets).Error; err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"queue_system/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReminderRepository interface {
+	FindDueAppointments(now time.Time, lead time.Duration) ([]model.Appointment, error)
+	RecordReminderWithTx(tx *gorm.DB, reminder *model.AppointmentReminder) (bool, error)
+}
+
+type reminderRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderRepository(db *gorm.DB) ReminderRepository {
+	return &reminderRepository{db: db}
+}
+
+// FindDueAppointments returns confirmed appointments starting within lead
+// of now that have no reminder recorded for that lead time and start time.
+func (rr *reminderRepository) FindDueAppointments(now time.Time, lead time.Duration) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+
+	err := rr.db.Where("status = ? AND start_time > ? AND start_time <= ?", "confirmed", now, now.Add(lead)).
+		Where("NOT EXISTS (?)", rr.db.Model(&model.AppointmentReminder{}).Select("1").
+			Where("appointment_reminders.appointment_id = appointments.id AND appointment_reminders.start_time = appointments.start_time AND appointment_reminders.lead_minutes = ?", int(lead.Minutes()))).
+		Order("start_time ASC, id ASC").
+		Find(&appointments).Error
+	if err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// RecordReminderWithTx stores reminder unless an identical one exists and
+// reports whether it was stored. Concurrent schedulers racing for the same
+// reminder therefore see true exactly once.
+func (rr *reminderRepository) RecordReminderWithTx(tx *gorm.DB, reminder *model.AppointmentReminder) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
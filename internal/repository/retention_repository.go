@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"queue_system/internal/enums"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userRecords are the records that belong to a user and are purged with
+// them. The user's appointments and series stay, as they are the other
+// party's history too.
+var userRecords = []interface{}{
+	&model.WorkingHours{},
+	&model.AvailabilityOverride{},
+	&model.BusyBlock{},
+	&model.BusyCalendar{},
+	&model.CalendarFeed{},
+	&model.RefreshToken{},
+	&model.MagicLink{},
+	&model.APIKey{},
+	&model.SentNotification{},
+}
+
+// purgedUserName replaces the name of a purged user.
+const purgedUserName = "Deleted user"
+
+// RetentionRepository permanently removes records that were soft-deleted
+// before a cutoff, across all organizations.
+type RetentionRepository interface {
+	PurgeAppointmentsWithTx(tx *gorm.DB, deletedBefore time.Time) (int64, error)
+	PurgeUsersWithTx(tx *gorm.DB, deletedBefore time.Time) (int64, error)
+}
+
+type retentionRepository struct {
+	db *gorm.DB
+}
+
+func NewRetentionRepository(db *gorm.DB) RetentionRepository {
+	return &retentionRepository{db: db}
+}
+
+// PurgeAppointmentsWithTx removes the appointments deleted before
+// deletedBefore and their reminders, and returns how many appointments
+// were removed.
+func (rr *retentionRepository) PurgeAppointmentsWithTx(tx *gorm.DB, deletedBefore time.Time) (int64, error) {
+	expired := rr.db.Unscoped().Model(&model.Appointment{}).Select("id").Where("deleted_at < ?", deletedBefore)
+	if err := tx.Where("appointment_id IN (?)", expired).Delete(&model.AppointmentReminder{}).Error; err != nil {
+		return 0, err
+	}
+	result := tx.Unscoped().Where("deleted_at < ?", deletedBefore).Delete(&model.Appointment{})
+	return result.RowsAffected, result.Error
+}
+
+// PurgeUsersWithTx removes the records of the users deleted before
+// deletedBefore and returns how many users were purged. The users
+// themselves are kept as anonymous tombstones, because appointments and
+// series still refer to them; their queue tickets are unlinked instead.
+// Handled events about them and their appointments, which carry their
+// details, are removed together with their webhook deliveries.
+func (rr *retentionRepository) PurgeUsersWithTx(tx *gorm.DB, deletedBefore time.Time) (int64, error) {
+	expired := rr.db.Unscoped().Model(&model.User{}).Select("id").Where("deleted_at < ? AND purged_at IS NULL", deletedBefore)
+	for _, records := range userRecords {
+		if err := tx.Where("user_id IN (?)", expired).Delete(records).Error; err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Model(&model.QueueTicket{}).Where("user_id IN (?)", expired).Update("user_id", nil).Error; err != nil {
+		return 0, err
+	}
+	if err := rr.purgeUserEventsWithTx(tx, deletedBefore); err != nil {
+		return 0, err
+	}
+	result := tx.Unscoped().Model(&model.User{}).Where("deleted_at < ? AND purged_at IS NULL", deletedBefore).Updates(map[string]interface{}{
+		"name":          purgedUserName,
+		"email":         "",
+		"phone":         "",
+		"timezone":      "",
+		"password_hash": "",
+		"purged_at":     tx.NowFunc(),
+	})
+	return result.RowsAffected, result.Error
+}
+
+// purgeUserEventsWithTx removes the outbox events and webhook deliveries
+// from before deletedBefore that are about users about to be purged or
+// their appointments, once they are no longer waiting to be sent. It must
+// run before the users are anonymised, as magic link requests only name
+// the email.
+func (rr *retentionRepository) purgeUserEventsWithTx(tx *gorm.DB, deletedBefore time.Time) error {
+	expired := rr.db.Unscoped().Model(&model.User{}).Where("deleted_at < ? AND purged_at IS NULL", deletedBefore)
+	ids := expired.Session(&gorm.Session{}).Select("CAST(id AS text)")
+	emails := expired.Session(&gorm.Session{}).Select("organization_id, email")
+	aboutUsers := func(typeColumn string) *gorm.DB {
+		return rr.db.Where(typeColumn+" LIKE 'user.%' AND payload->>'ID' IN (?)", ids).
+			Or(typeColumn+" LIKE 'appointment.%' AND (payload->>'UserID' IN (?) OR payload->>'ParticipantID' IN (?))", ids, ids).
+			Or(typeColumn+" = ? AND (organization_id, payload->>'email') IN (?)", events.MagicLinkRequested, emails)
+	}
+
+	err := tx.Where("created_at < ? AND status <> ?", deletedBefore, enums.WebhookDeliveryPending).
+		Where(aboutUsers("event_type")).
+		Delete(&model.WebhookDelivery{}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("created_at < ? AND (dispatched_at IS NOT NULL OR failed_at IS NOT NULL)", deletedBefore).
+		Where(aboutUsers("type")).
+		Delete(&model.OutboxEvent{}).Error
+}
@@ -2,18 +2,46 @@ package repository
 
 import (
 	"errors"
+	"queue_system/internal/events"
 	"queue_system/internal/model"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// UserFilter narrows List results. Zero-valued fields are ignored.
+type UserFilter struct {
+	// OrganizationID is always applied.
+	OrganizationID uint
+	// Search matches part of the name or email, ignoring case.
+	Search string
+	Role   string
+	Active *bool
+	// IncludeDeleted also lists deleted users.
+	IncludeDeleted bool
+	Offset         int
+	Limit          int
+}
+
 type UserRepository interface {
 	CreateUser(user *model.User) (*model.User, error)
-	GetByEmail(email string) (*model.User, error)
-	GetById(id uint) (*model.User, error)
+	CreateUserWithTx(tx *gorm.DB, user *model.User) error
+	GetByEmail(organizationID uint, email string) (*model.User, error)
+	GetByEmailWithTx(tx *gorm.DB, organizationID uint, email string) (*model.User, error)
+	GetById(organizationID, id uint) (*model.User, error)
+	GetByIdForUpdate(tx *gorm.DB, organizationID, id uint) (*model.User, error)
+	GetByIds(organizationID uint, ids []uint) ([]model.User, error)
+	GetDeletedByIdForUpdate(tx *gorm.DB, organizationID, id uint) (*model.User, error)
+	List(filter UserFilter) ([]model.User, int64, error)
 	UpdateUser(user *model.User) error
+	UpdateUserWithTx(tx *gorm.DB, user *model.User, eventType string) error
+	DeleteUserWithTx(tx *gorm.DB, user *model.User) error
+	RestoreUserWithTx(tx *gorm.DB, user *model.User) error
 }
 
+// Users are looked up within one organization; users of other
+// organizations are not found.
 type userRepository struct {
 	db *gorm.DB
 }
@@ -22,13 +50,51 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// CreateUser stores the user together with its user.created outbox event.
 func (ur *userRepository) CreateUser(user *model.User) (*model.User, error) {
-	return user, ur.db.Create(user).Error
+	return user, ur.db.Transaction(func(tx *gorm.DB) error {
+		return ur.CreateUserWithTx(tx, user)
+	})
+}
+
+// CreateUserWithTx stores the user and records its user.created event as
+// part of tx.
+func (ur *userRepository) CreateUserWithTx(tx *gorm.DB, user *model.User) error {
+	if err := tx.Create(user).Error; err != nil {
+		return err
+	}
+	return recordEvent(tx, user.OrganizationID, events.UserCreated, user)
+}
+
+func (ur *userRepository) GetByEmail(organizationID uint, email string) (*model.User, error) {
+	return ur.GetByEmailWithTx(ur.db, organizationID, email)
+}
+
+func (ur *userRepository) GetByEmailWithTx(tx *gorm.DB, organizationID uint, email string) (*model.User, error) {
+	var user model.User
+	if err := tx.Where("organization_id=? AND email=?", organizationID, email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (ur *userRepository) GetById(organizationID, id uint) (*model.User, error) {
+	var user model.User
+	if err := ur.db.Where("organization_id=? AND id=?", organizationID, id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
 }
 
-func (ur *userRepository) GetByEmail(email string) (*model.User, error) {
+func (ur *userRepository) GetByIdForUpdate(tx *gorm.DB, organizationID, id uint) (*model.User, error) {
 	var user model.User
-	if err := ur.db.Where("email=?", email).First(&user).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("organization_id=? AND id=?", organizationID, id).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -37,9 +103,19 @@ func (ur *userRepository) GetByEmail(email string) (*model.User, error) {
 	return &user, nil
 }
 
-func (ur *userRepository) GetById(id uint) (*model.User, error) {
+func (ur *userRepository) GetByIds(organizationID uint, ids []uint) ([]model.User, error) {
+	var users []model.User
+	if err := ur.db.Where("organization_id = ? AND id IN ?", organizationID, ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetDeletedByIdForUpdate returns the user only if it is deleted and has
+// not been purged.
+func (ur *userRepository) GetDeletedByIdForUpdate(tx *gorm.DB, organizationID, id uint) (*model.User, error) {
 	var user model.User
-	if err := ur.db.Where("id=?", id).First(&user).Error; err != nil {
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("organization_id=? AND id=? AND deleted_at IS NOT NULL AND purged_at IS NULL", organizationID, id).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -48,6 +124,78 @@ func (ur *userRepository) GetById(id uint) (*model.User, error) {
 	return &user, nil
 }
 
+// List returns a page of the filtered users ordered by name, and how many
+// users match in total.
+func (ur *userRepository) List(filter UserFilter) ([]model.User, int64, error) {
+	query := ur.db.Model(&model.User{})
+	if filter.IncludeDeleted {
+		query = query.Unscoped().Where("purged_at IS NULL")
+	}
+	query = query.Where("organization_id = ?", filter.OrganizationID)
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		query = query.Where("(name ILIKE ? OR email ILIKE ?)", pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Active != nil {
+		if *filter.Active {
+			query = query.Where("deactivated_at IS NULL")
+		} else {
+			query = query.Where("deactivated_at IS NOT NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []model.User
+	if err := query.Order("name ASC, id ASC").Offset(filter.Offset).Limit(filter.Limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// UpdateUser stores the user together with its user.updated outbox event.
 func (ur *userRepository) UpdateUser(user *model.User) error {
-	return ur.db.Save(user).Error
+	return ur.db.Transaction(func(tx *gorm.DB) error {
+		return ur.UpdateUserWithTx(tx, user, events.UserUpdated)
+	})
+}
+
+// UpdateUserWithTx stores the user and records eventType about it as part
+// of tx.
+func (ur *userRepository) UpdateUserWithTx(tx *gorm.DB, user *model.User, eventType string) error {
+	if err := tx.Save(user).Error; err != nil {
+		return err
+	}
+	return recordEvent(tx, user.OrganizationID, eventType, user)
+}
+
+// DeleteUserWithTx soft-deletes the user, revokes their refresh tokens and
+// API keys and records its user.deleted event as part of tx. A restored
+// user has to sign in again and create new API keys.
+func (ur *userRepository) DeleteUserWithTx(tx *gorm.DB, user *model.User) error {
+	now := tx.NowFunc()
+	for _, credentials := range []interface{}{&model.RefreshToken{}, &model.APIKey{}} {
+		if err := tx.Model(credentials).Where("user_id = ? AND revoked_at IS NULL", user.ID).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+	}
+	user.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	return ur.UpdateUserWithTx(tx, user, events.UserDeleted)
+}
+
+// RestoreUserWithTx undeletes the user and records its user.restored event
+// as part of tx.
+func (ur *userRepository) RestoreUserWithTx(tx *gorm.DB, user *model.User) error {
+	user.DeletedAt = gorm.DeletedAt{}
+	return ur.UpdateUserWithTx(tx.Unscoped(), user, events.UserRestored)
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"errors"
+	"queue_system/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository finds webhooks and their deliveries only within an
+// organization, except for the background worker's due deliveries.
+type WebhookRepository interface {
+	CreateWebhook(webhook *model.Webhook) error
+	GetWebhookByID(organizationID, id uint) (*model.Webhook, error)
+	ListWebhooks(organizationID uint) ([]model.Webhook, error)
+	ListActiveWebhooks(organizationID uint) ([]model.Webhook, error)
+	UpdateWebhook(webhook *model.Webhook) error
+	DeleteWebhook(organizationID, id uint) (bool, error)
+	CreateDeliveries(deliveries []model.WebhookDelivery) error
+	CreateDelivery(delivery *model.WebhookDelivery) error
+	GetDelivery(organizationID, webhookID, id uint) (*model.WebhookDelivery, error)
+	ListDeliveries(organizationID, webhookID uint, status string) ([]model.WebhookDelivery, error)
+	FetchDueDeliveriesForUpdate(tx *gorm.DB, now time.Time, limit int) ([]model.WebhookDelivery, error)
+	ClaimDeliveriesWithTx(tx *gorm.DB, deliveries []model.WebhookDelivery, until time.Time) error
+	UpdateDeliveryWithTx(tx *gorm.DB, delivery *model.WebhookDelivery) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (wr *webhookRepository) CreateWebhook(webhook *model.Webhook) error {
+	return wr.db.Create(webhook).Error
+}
+
+func (wr *webhookRepository) GetWebhookByID(organizationID, id uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := wr.db.Where("organization_id = ? AND id = ?", organizationID, id).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (wr *webhookRepository) ListWebhooks(organizationID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := wr.db.Where("organization_id = ?", organizationID).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (wr *webhookRepository) ListActiveWebhooks(organizationID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := wr.db.Where("organization_id = ? AND active = ?", organizationID, true).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (wr *webhookRepository) UpdateWebhook(webhook *model.Webhook) error {
+	return wr.db.Save(webhook).Error
+}
+
+// DeleteWebhook removes the webhook together with its delivery log.
+func (wr *webhookRepository) DeleteWebhook(organizationID, id uint) (bool, error) {
+	var deleted bool
+	err := wr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ? AND webhook_id = ?", organizationID, id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("organization_id = ? AND id = ?", organizationID, id).Delete(&model.Webhook{})
+		deleted = result.RowsAffected > 0
+		return result.Error
+	})
+	return deleted, err
+}
+
+// CreateDeliveries queues deliveries, skipping any event a webhook has
+// already been given. This makes redelivery of an outbox event harmless.
+func (wr *webhookRepository) CreateDeliveries(deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return wr.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+func (wr *webhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	return wr.db.Create(delivery).Error
+}
+
+func (wr *webhookRepository) GetDelivery(organizationID, webhookID, id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := wr.db.Where("organization_id = ? AND id = ? AND webhook_id = ?", organizationID, id, webhookID).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns the webhook's delivery log, newest first.
+func (wr *webhookRepository) ListDeliveries(organizationID, webhookID uint, status string) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	query := wr.db.Where("organization_id = ? AND webhook_id = ?", organizationID, webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("id DESC").Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// FetchDueDeliveriesForUpdate locks up to limit pending deliveries whose
+// next attempt is due, oldest first. Rows locked by another worker are
+// skipped.
+func (wr *webhookRepository) FetchDueDeliveriesForUpdate(tx *gorm.DB, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_attempt_at <= ?", "pending", now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDeliveriesWithTx postpones the deliveries' next attempt until the
+// lease expires, so no other worker picks them up while they are sent
+// after tx commits. A worker that dies mid-batch leaves them to be retried
+// then.
+func (wr *webhookRepository) ClaimDeliveriesWithTx(tx *gorm.DB, deliveries []model.WebhookDelivery, until time.Time) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(deliveries))
+	for i := range deliveries {
+		ids[i] = deliveries[i].ID
+		deliveries[i].NextAttemptAt = until
+	}
+	return tx.Model(&model.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", until).Error
+}
+
+func (wr *webhookRepository) UpdateDeliveryWithTx(tx *gorm.DB, delivery *model.WebhookDelivery) error {
+	return tx.Save(delivery).Error
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+	"queue_system/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type WorkingHoursRepository interface {
+	CreateWindow(window *model.WorkingHours) error
+	GetWindow(userID, id uint) (*model.WorkingHours, error)
+	ListWindows(userID uint) ([]model.WorkingHours, error)
+	UpdateWindow(window *model.WorkingHours) error
+	DeleteWindow(userID, id uint) (bool, error)
+	CreateOverride(override *model.AvailabilityOverride) error
+	ListOverrides(userID uint) ([]model.AvailabilityOverride, error)
+	ListOverridesBetween(userID uint, fromDate, toDate string) ([]model.AvailabilityOverride, error)
+	DeleteOverride(userID, id uint) (bool, error)
+}
+
+type workingHoursRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkingHoursRepository(db *gorm.DB) WorkingHoursRepository {
+	return &workingHoursRepository{db: db}
+}
+
+func (wr *workingHoursRepository) CreateWindow(window *model.WorkingHours) error {
+	return wr.db.Create(window).Error
+}
+
+func (wr *workingHoursRepository) GetWindow(userID, id uint) (*model.WorkingHours, error) {
+	var window model.WorkingHours
+	if err := wr.db.Where("id=? AND user_id=?", id, userID).First(&window).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (wr *workingHoursRepository) ListWindows(userID uint) ([]model.WorkingHours, error) {
+	var windows []model.WorkingHours
+	if err := wr.db.Where("user_id=?", userID).Order("weekday ASC, start_time ASC").Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+func (wr *workingHoursRepository) UpdateWindow(window *model.WorkingHours) error {
+	return wr.db.Save(window).Error
+}
+
+func (wr *workingHoursRepository) DeleteWindow(userID, id uint) (bool, error) {
+	result := wr.db.Where("id=? AND user_id=?", id, userID).Delete(&model.WorkingHours{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (wr *workingHoursRepository) CreateOverride(override *model.AvailabilityOverride) error {
+	return wr.db.Create(override).Error
+}
+
+func (wr *workingHoursRepository) ListOverrides(userID uint) ([]model.AvailabilityOverride, error) {
+	var overrides []model.AvailabilityOverride
+	if err := wr.db.Where("user_id=?", userID).Order("date ASC, start_time ASC").Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// ListOverridesBetween returns overrides whose date falls within the
+// inclusive YYYY-MM-DD range.
+func (wr *workingHoursRepository) ListOverridesBetween(userID uint, fromDate, toDate string) ([]model.AvailabilityOverride, error) {
+	var overrides []model.AvailabilityOverride
+	if err := wr.db.Where("user_id=? AND date BETWEEN ? AND ?", userID, fromDate, toDate).Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+func (wr *workingHoursRepository) DeleteOverride(userID, id uint) (bool, error) {
+	result := wr.db.Where("id=? AND user_id=?", id, userID).Delete(&model.AvailabilityOverride{})
+	return result.RowsAffected > 0, result.Error
+}
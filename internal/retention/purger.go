@@ -0,0 +1,118 @@
+// Package retention purges users and appointments that have been deleted
+// for longer than the retention period. Until then they can be restored.
+// Purged appointments are removed; purged users are reduced to anonymous
+// tombstones that the appointments they took part in still refer to.
+package retention
+
+import (
+	"context"
+	"queue_system/config"
+	"queue_system/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const defaultPollInterval = time.Hour
+
+type Purger struct {
+	db                  *gorm.DB
+	retentionRepository repository.RetentionRepository
+	period              time.Duration
+	pollInterval        time.Duration
+
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+func NewPurger(db *gorm.DB, retentionRepository repository.RetentionRepository, cfg *config.Config) *Purger {
+	purger := &Purger{
+		db:                  db,
+		retentionRepository: retentionRepository,
+		period:              cfg.Retention.Period,
+		pollInterval:        cfg.Retention.PollInterval,
+	}
+	if purger.pollInterval <= 0 {
+		purger.pollInterval = defaultPollInterval
+	}
+	return purger
+}
+
+// Start purges expired records until Stop is called. Without a retention
+// period it does nothing.
+func (p *Purger) Start() {
+	if p.period <= 0 {
+		log.Info().Msg("No retention period set, deleted records are kept")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done.Add(1)
+	go func() {
+		defer p.done.Done()
+		ticker := time.NewTicker(p.pollInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := p.Purge(time.Now()); err != nil {
+				log.Error().Err(err).Msg("Error purging deleted records")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop waits for the run in flight to finish or ctx to expire.
+func (p *Purger) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+	finished := make(chan struct{})
+	go func() {
+		p.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Purge purges the users and appointments deleted more than
+// the retention period before now, in one transaction, and returns how
+// many were purged.
+func (p *Purger) Purge(now time.Time) (int64, error) {
+	if p.period <= 0 {
+		return 0, nil
+	}
+	cutoff := now.Add(-p.period)
+	tx := p.db.Begin()
+
+	appointments, err := p.retentionRepository.PurgeAppointmentsWithTx(tx, cutoff)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	users, err := p.retentionRepository.PurgeUsersWithTx(tx, cutoff)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if appointments > 0 || users > 0 {
+		log.Info().Int64("appointments", appointments).Int64("users", users).Time("deletedBefore", cutoff).Msg("Purged deleted records")
+	}
+	return appointments + users, nil
+}
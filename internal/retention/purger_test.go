@@ -0,0 +1,74 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"queue_system/config"
+	"queue_system/internal/repository/mocks"
+	"queue_system/test/txdb"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurger_KeepsRecordsWithoutPeriod(t *testing.T) {
+	// GIVEN
+	purger := NewPurger(nil, nil, &config.Config{})
+
+	// WHEN
+	purger.Start()
+	purged, err := purger.Purge(time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC))
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Zero(t, purged)
+	assert.Equal(t, defaultPollInterval, purger.pollInterval)
+	assert.NoError(t, purger.Stop(context.Background()))
+}
+
+func TestPurger_PurgesRecordsDeletedBeforeCutoff(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db, txLog := txdb.Open(t)
+	mockRetentionRepo := mocks.NewMockRetentionRepository(ctrl)
+	purger := NewPurger(db, mockRetentionRepo, &config.Config{Retention: config.Retention{Period: 30 * 24 * time.Hour}})
+	now := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	cutoff := time.Date(2030, 5, 2, 9, 0, 0, 0, time.UTC)
+
+	gomock.InOrder(
+		mockRetentionRepo.EXPECT().PurgeAppointmentsWithTx(gomock.Any(), cutoff).Return(int64(2), nil),
+		mockRetentionRepo.EXPECT().PurgeUsersWithTx(gomock.Any(), cutoff).Return(int64(1), nil),
+	)
+
+	// WHEN
+	purged, err := purger.Purge(now)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	assert.Equal(t, []string{"begin", "commit"}, txLog.Entries())
+}
+
+func TestPurger_RollsBackWhenPurgeFails(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db, txLog := txdb.Open(t)
+	mockRetentionRepo := mocks.NewMockRetentionRepository(ctrl)
+	purger := NewPurger(db, mockRetentionRepo, &config.Config{Retention: config.Retention{Period: time.Hour}})
+	failure := errors.New("connection lost")
+
+	mockRetentionRepo.EXPECT().PurgeAppointmentsWithTx(gomock.Any(), gomock.Any()).Return(int64(2), nil)
+	mockRetentionRepo.EXPECT().PurgeUsersWithTx(gomock.Any(), gomock.Any()).Return(int64(0), failure)
+
+	// WHEN
+	purged, err := purger.Purge(time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC))
+
+	// THEN
+	assert.ErrorIs(t, err, failure)
+	assert.Zero(t, purged, "nothing is purged once the transaction is rolled back")
+	assert.Equal(t, []string{"begin", "rollback"}, txLog.Entries())
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyService_CreateAPIKey_StoresOnlyTheHash(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAuthRepo := mocks.NewMockAuthRepository(ctrl)
+	apiKeyService := NewAPIKeyService(mockAuthRepo)
+
+	var stored *model.APIKey
+	mockAuthRepo.EXPECT().CreateAPIKey(gomock.Any()).DoAndReturn(func(key *model.APIKey) error {
+		stored = key
+		key.ID = 3
+		return nil
+	})
+
+	// WHEN
+	created, err := apiKeyService.CreateAPIKey(&model.User{ID: 7, Role: "staff"}, &request.CreateAPIKeyRequest{
+		Name: "Lobby kiosk", Scopes: []string{"queues:write"},
+	})
+
+	// THEN
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, uint(3), created.ID)
+	assert.Equal(t, uint(7), stored.UserID)
+	assert.Equal(t, []string{"queues:write"}, stored.Scopes)
+	assert.Equal(t, auth.HashToken(created.Key), stored.KeyHash)
+	assert.Equal(t, created.Key[:len(stored.Prefix)], stored.Prefix)
+}
+
+func TestAPIKeyService_RevokeAPIKey(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAuthRepo := mocks.NewMockAuthRepository(ctrl)
+	apiKeyService := NewAPIKeyService(mockAuthRepo)
+	revokedAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	mockAuthRepo.EXPECT().GetAPIKeyByID(uint(1)).Return(&model.APIKey{ID: 1, UserID: 7}, nil).Times(2)
+	mockAuthRepo.EXPECT().GetAPIKeyByID(uint(2)).Return(&model.APIKey{ID: 2, UserID: 7, RevokedAt: &revokedAt}, nil)
+	mockAuthRepo.EXPECT().GetAPIKeyByID(uint(9)).Return(nil, nil)
+	var revoked *model.APIKey
+	mockAuthRepo.EXPECT().UpdateAPIKey(gomock.Any()).DoAndReturn(func(key *model.APIKey) error {
+		revoked = key
+		return nil
+	})
+
+	// WHEN
+	someoneElse := apiKeyService.RevokeAPIKey(&model.User{ID: 8, Role: "staff"}, 1)
+	own := apiKeyService.RevokeAPIKey(&model.User{ID: 7, Role: "client"}, 1)
+	again := apiKeyService.RevokeAPIKey(&model.User{ID: 7, Role: "client"}, 2)
+	unknown := apiKeyService.RevokeAPIKey(&model.User{ID: 7, Role: "client"}, 9)
+
+	// THEN
+	assert.ErrorIs(t, someoneElse, ErrAPIKeyNotFound, "other users' keys are not revealed")
+	require.NoError(t, own)
+	require.NotNil(t, revoked)
+	assert.NotNil(t, revoked.RevokedAt)
+	assert.ErrorIs(t, again, ErrAPIKeyAlreadyRevoked)
+	assert.ErrorIs(t, unknown, ErrAPIKeyNotFound)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/enums"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// arrivalGracePeriod is how far either side of the start time an arrival
+	// still counts as on time.
+	arrivalGracePeriod = 5 * time.Minute
+	// checkInOpensBefore is how long before the start check-in is accepted.
+	checkInOpensBefore = 2 * time.Hour
+)
+
+var (
+	ErrAppointmentNotConfirmed = errors.New("appointment must be confirmed first")
+	ErrAlreadyCheckedIn        = errors.New("appointment is already checked in")
+	ErrOutsideCheckInWindow    = errors.New("check-in opens two hours before the start and closes when the appointment ends")
+	ErrWaitingLineEmpty        = errors.New("nobody is waiting for this participant")
+)
+
+// CheckInAppointment records that the attendee has arrived and places the
+// appointment into the participant's waiting line.
+func (as *appointmentService) CheckInAppointment(organizationID, id uint) (*response.WaitingLineEntry, error) {
+	tx := as.db.Begin()
+
+	appointment, err := as.appointmentRepository.GetByIDForUpdate(tx, organizationID, id)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error fetching appointment for check-in")
+		return nil, err
+	}
+	if appointment == nil {
+		tx.Rollback()
+		return nil, ErrAppointmentNotFound
+	}
+	if appointment.Status != string(enums.Confirmed) {
+		tx.Rollback()
+		return nil, ErrAppointmentNotConfirmed
+	}
+	if appointment.CheckedInAt != nil {
+		tx.Rollback()
+		return nil, ErrAlreadyCheckedIn
+	}
+
+	now := time.Now()
+	if now.Before(appointment.StartTime.Add(-checkInOpensBefore)) || now.After(appointment.EndTime) {
+		tx.Rollback()
+		return nil, ErrOutsideCheckInWindow
+	}
+	appointment.CheckedInAt = &now
+	appointment.ArrivalStatus = string(classifyArrival(appointment.StartTime, now))
+
+	if err := as.appointmentRepository.UpdateWithTx(tx, appointment, events.AppointmentCheckedIn); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error checking in appointment")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateAppointmentFailed
+	}
+
+	line, err := as.appointmentRepository.FindWaitingLine(appointment.ParticipantID)
+	if err != nil {
+		log.Error().Err(err).Uint("participantID", appointment.ParticipantID).Msg("Error fetching waiting line")
+		return nil, err
+	}
+	entry := &response.WaitingLineEntry{Appointment: *appointment}
+	for i := range line {
+		if line[i].ID == appointment.ID {
+			entry.Position = i + 1
+			break
+		}
+	}
+	return entry, nil
+}
+
+// GetWaitingLine lists who has checked in for the participant and has not
+// been called in yet, in the order they will be seen.
+func (as *appointmentService) GetWaitingLine(organizationID, participantID uint) (*response.WaitingLineResponse, error) {
+	if err := as.ensureUserExists(organizationID, participantID); err != nil {
+		return nil, err
+	}
+	line, err := as.appointmentRepository.FindWaitingLine(participantID)
+	if err != nil {
+		log.Error().Err(err).Uint("participantID", participantID).Msg("Error fetching waiting line")
+		return nil, err
+	}
+
+	entries := make([]response.WaitingLineEntry, 0, len(line))
+	for i, appointment := range line {
+		entries = append(entries, response.WaitingLineEntry{Position: i + 1, Appointment: appointment})
+	}
+	return &response.WaitingLineResponse{ParticipantID: participantID, Entries: entries}, nil
+}
+
+// CallNextInWaitingLine takes the head of the participant's waiting line and
+// marks it as called in.
+func (as *appointmentService) CallNextInWaitingLine(organizationID, participantID uint) (*model.Appointment, error) {
+	if err := as.ensureUserExists(organizationID, participantID); err != nil {
+		return nil, err
+	}
+
+	tx := as.db.Begin()
+
+	appointment, err := as.appointmentRepository.NextInWaitingLineForUpdate(tx, participantID)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("participantID", participantID).Msg("Error fetching next in waiting line")
+		return nil, err
+	}
+	if appointment == nil {
+		tx.Rollback()
+		return nil, ErrWaitingLineEmpty
+	}
+
+	now := time.Now()
+	appointment.CalledInAt = &now
+	if err := as.appointmentRepository.UpdateWithTx(tx, appointment, events.AppointmentCalledIn); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", appointment.ID).Msg("Error calling in appointment")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	return appointment, nil
+}
+
+func (as *appointmentService) ensureUserExists(organizationID, userID uint) error {
+	user, err := as.userRepository.GetById(organizationID, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error fetching user")
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func classifyArrival(start, arrivedAt time.Time) enums.ArrivalStatus {
+	switch {
+	case arrivedAt.Before(start.Add(-arrivalGracePeriod)):
+		return enums.ArrivedEarly
+	case arrivedAt.After(start.Add(arrivalGracePeriod)):
+		return enums.ArrivedLate
+	default:
+		return enums.ArrivedOnTime
+	}
+}
@@ -0,0 +1,486 @@
+package service
+
+import (
+	"errors"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/enums"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/recurrence"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	OccurrenceReasonConflict            = "conflict"
+	OccurrenceReasonBusy                = "busy"
+	OccurrenceReasonOutsideWorkingHours = "outside_working_hours"
+)
+
+var (
+	ErrInvalidRecurrenceRule   = recurrence.ErrInvalidRule
+	ErrOverlappingOccurrences  = errors.New("occurrences of the series overlap each other")
+	ErrAppointmentNotRecurring = errors.New("appointment is not part of a series")
+	ErrSeriesStatusChange      = errors.New("status of following occurrences can only be changed through the cancel action")
+)
+
+// SeriesConflictError reports every occurrence that blocked a series
+// operation. It unwraps to ErrAppointmentConflict.
+type SeriesConflictError struct {
+	Conflicts []response.OccurrenceConflict
+}
+
+func (e *SeriesConflictError) Error() string {
+	return ErrAppointmentConflict.Error()
+}
+
+func (e *SeriesConflictError) Unwrap() error {
+	return ErrAppointmentConflict
+}
+
+// CreateAppointmentSeries expands the recurrence rule and books every
+// occurrence atomically; if any occurrence cannot be booked nothing is
+// created and the returned SeriesConflictError lists the offenders.
+func (as *appointmentService) CreateAppointmentSeries(actor *model.User, req *request.RecurringAppointmentRequest) (*response.AppointmentSeriesResponse, error) {
+	if err := auth.CanBookFor(actor, req.UserID); err != nil {
+		return nil, err
+	}
+	if req.UserID == req.ParticipantID {
+		return nil, ErrCannotBookWithSelf
+	}
+	start_time, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		log.Warn().Err(err).Str("startTime", req.StartTime).Msg("Failed to parse start time")
+		return nil, ErrInvalidTimeFormat
+	}
+	end_time, err := time.Parse(time.RFC3339, req.EndTime)
+	if err != nil {
+		log.Warn().Err(err).Str("endTime", req.EndTime).Msg("Failed to parse end time")
+		return nil, ErrInvalidTimeFormat
+	}
+	if end_time.Before(start_time) {
+		return nil, ErrEndTimeBeforeStartTime
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	rule, err := recurrence.Parse(req.RRule)
+	if err != nil {
+		log.Warn().Err(err).Str("rrule", req.RRule).Msg("Failed to parse recurrence rule")
+		return nil, err
+	}
+	starts, err := rule.Occurrences(start_time.In(loc))
+	if err != nil {
+		return nil, err
+	}
+	if len(starts) == 0 {
+		return nil, ErrInvalidRecurrenceRule
+	}
+
+	duration := end_time.Sub(start_time)
+	appointments := make([]model.Appointment, 0, len(starts))
+	for i, start := range starts {
+		if i > 0 && start.Before(starts[i-1].Add(duration)) {
+			return nil, ErrOverlappingOccurrences
+		}
+		appointments = append(appointments, model.Appointment{
+			OrganizationID: actor.OrganizationID,
+			UserID:         req.UserID,
+			ParticipantID:  req.ParticipantID,
+			StartTime:      start,
+			EndTime:        start.Add(duration),
+			Description:    req.Description,
+			Status:         string(enums.Pending),
+		})
+	}
+
+	user, err := as.userRepository.GetById(actor.OrganizationID, req.UserID)
+	if err != nil || user == nil {
+		return nil, ErrUserOrParticipantNotFound
+	}
+	participant, err := as.userRepository.GetById(actor.OrganizationID, req.ParticipantID)
+	if err != nil || participant == nil {
+		return nil, ErrUserOrParticipantNotFound
+	}
+	if !user.Active() || !participant.Active() {
+		return nil, ErrUserDeactivated
+	}
+
+	tx := as.db.Begin()
+
+	if err := as.appointmentRepository.LockParticipants(tx, req.UserID, req.ParticipantID); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error locking participants")
+		return nil, ErrCreateAppointmentFailed
+	}
+	conflicts, err := as.findOccurrenceConflicts(tx, appointments)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		tx.Rollback()
+		log.Warn().Int("conflicts", len(conflicts)).Msg("Series occurrences cannot be booked")
+		return nil, &SeriesConflictError{Conflicts: conflicts}
+	}
+
+	series := &model.AppointmentSeries{
+		OrganizationID: actor.OrganizationID,
+		UserID:         req.UserID,
+		ParticipantID:  req.ParticipantID,
+		RRule:          req.RRule,
+		Timezone:       loc.String(),
+		Description:    req.Description,
+	}
+	if err := as.appointmentRepository.CreateSeriesWithTx(tx, series); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error creating appointment series")
+		return nil, ErrCreateAppointmentFailed
+	}
+	for i := range appointments {
+		appointments[i].SeriesID = &series.ID
+		if err := as.appointmentRepository.CreateWithTx(tx, &appointments[i]); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error creating series occurrence")
+			return nil, ErrCreateAppointmentFailed
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrCreateAppointmentFailed
+	}
+	return &response.AppointmentSeriesResponse{Series: *series, Appointments: appointments}, nil
+}
+
+// UpdateFollowingAppointments applies an edit of one occurrence to it and
+// every later pending or confirmed occurrence of its series, which move into
+// a series of their own. Time changes are applied as the same shift to each
+// occurrence.
+func (as *appointmentService) UpdateFollowingAppointments(organizationID, id, actorID uint, req *request.UpdateAppointmentRequest) ([]model.Appointment, error) {
+	if req.Status != nil {
+		return nil, ErrSeriesStatusChange
+	}
+	actor, err := as.userRepository.GetById(organizationID, actorID)
+	if err != nil {
+		log.Error().Err(err).Uint("actorID", actorID).Msg("Error fetching actor")
+		return nil, err
+	}
+	if actor == nil {
+		return nil, ErrUserNotFound
+	}
+
+	tx := as.db.Begin()
+
+	target, occurrences, err := as.lockFollowingOccurrences(tx, organizationID, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := auth.CanAccessAppointment(actor, target); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	newStart, newEnd := target.StartTime, target.EndTime
+	if req.StartTime != nil {
+		if newStart, err = time.Parse(time.RFC3339, *req.StartTime); err != nil {
+			tx.Rollback()
+			return nil, ErrInvalidTimeFormat
+		}
+	}
+	if req.EndTime != nil {
+		if newEnd, err = time.Parse(time.RFC3339, *req.EndTime); err != nil {
+			tx.Rollback()
+			return nil, ErrInvalidTimeFormat
+		}
+	}
+	if newEnd.Before(newStart) {
+		tx.Rollback()
+		return nil, ErrEndTimeBeforeStartTime
+	}
+	startShift, endShift := newStart.Sub(target.StartTime), newEnd.Sub(target.EndTime)
+	timesChanged := startShift != 0 || endShift != 0
+	eventType := events.AppointmentUpdated
+	if timesChanged {
+		eventType = events.AppointmentRescheduled
+	}
+
+	series, err := as.appointmentRepository.GetSeriesByIDForUpdate(tx, organizationID, *target.SeriesID)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("seriesID", *target.SeriesID).Msg("Error fetching appointment series")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	if series == nil {
+		tx.Rollback()
+		return nil, ErrAppointmentNotRecurring
+	}
+	last := occurrences[len(occurrences)-1]
+	following, err := as.splitSeries(tx, series, target, newStart, last.StartTime.Add(startShift), req.Description)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for i := range occurrences {
+		occurrences[i].SeriesID = &following.ID
+		occurrences[i].StartTime = occurrences[i].StartTime.Add(startShift)
+		occurrences[i].EndTime = occurrences[i].EndTime.Add(endShift)
+		if req.Description != nil {
+			occurrences[i].Description = *req.Description
+		}
+		occurrences[i].Sequence++
+		if err := as.appointmentRepository.UpdateWithTx(tx, &occurrences[i], eventType); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("appointmentID", occurrences[i].ID).Msg("Error updating series occurrence")
+			return nil, ErrUpdateAppointmentFailed
+		}
+	}
+
+	// Conflicts are checked after every occurrence has moved so they are
+	// compared against each other's new times rather than their old ones.
+	if timesChanged {
+		if err := as.appointmentRepository.LockParticipants(tx, target.UserID, target.ParticipantID); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error locking participants")
+			return nil, ErrUpdateAppointmentFailed
+		}
+		conflicts, err := as.findOccurrenceConflicts(tx, occurrences)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if len(conflicts) > 0 {
+			tx.Rollback()
+			return nil, &SeriesConflictError{Conflicts: conflicts}
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	return occurrences, nil
+}
+
+// CancelFollowingAppointments cancels an occurrence and every later pending
+// or confirmed occurrence of its series.
+func (as *appointmentService) CancelFollowingAppointments(organizationID, id, actorID uint) ([]model.Appointment, error) {
+	actor, err := as.userRepository.GetById(organizationID, actorID)
+	if err != nil {
+		log.Error().Err(err).Uint("actorID", actorID).Msg("Error fetching actor")
+		return nil, err
+	}
+	if actor == nil {
+		return nil, ErrUserNotFound
+	}
+
+	tx := as.db.Begin()
+
+	target, occurrences, err := as.lockFollowingOccurrences(tx, organizationID, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := auth.CanAccessAppointment(actor, target); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range occurrences {
+		if err := applyStatusTransition(&occurrences[i], enums.Cancelled, &actor.ID, now); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := as.appointmentRepository.UpdateWithTx(tx, &occurrences[i], events.AppointmentCancelled); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("appointmentID", occurrences[i].ID).Msg("Error cancelling series occurrence")
+			return nil, ErrUpdateAppointmentFailed
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	return occurrences, nil
+}
+
+// splitSeries ends series before target and returns the series target and
+// its following occurrences move into, whose rule runs from newStart until
+// lastStart. A series with nothing before target is rewritten instead of
+// split. The caller owns tx.
+func (as *appointmentService) splitSeries(tx *gorm.DB, series *model.AppointmentSeries, target *model.Appointment, newStart, lastStart time.Time, description *string) (*model.AppointmentSeries, error) {
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		log.Error().Err(err).Uint("seriesID", series.ID).Msg("Stored recurrence rule is invalid")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	loc, err := time.LoadLocation(series.Timezone)
+	if err != nil {
+		log.Error().Err(err).Uint("seriesID", series.ID).Msg("Stored series timezone is invalid")
+		return nil, ErrUpdateAppointmentFailed
+	}
+
+	// Moving an occurrence to another day moves the weekdays it recurs on.
+	followingRule := *rule
+	followingRule.ByDay = shiftWeekdays(rule.ByDay, daysBetween(target.StartTime.In(loc), newStart.In(loc)))
+	followingRule.Count = 0
+	until := lastStart.UTC()
+	followingRule.Until = &until
+
+	earlier, err := as.appointmentRepository.CountInSeriesBefore(tx, series.OrganizationID, series.ID, target.StartTime)
+	if err != nil {
+		log.Error().Err(err).Uint("seriesID", series.ID).Msg("Error counting earlier occurrences")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	if earlier == 0 {
+		series.RRule = followingRule.String()
+		if description != nil {
+			series.Description = *description
+		}
+		if err := as.appointmentRepository.UpdateSeriesWithTx(tx, series); err != nil {
+			log.Error().Err(err).Uint("seriesID", series.ID).Msg("Error updating appointment series")
+			return nil, ErrUpdateAppointmentFailed
+		}
+		return series, nil
+	}
+
+	following := &model.AppointmentSeries{
+		OrganizationID: series.OrganizationID,
+		UserID:         series.UserID,
+		ParticipantID:  series.ParticipantID,
+		RRule:          followingRule.String(),
+		Timezone:       series.Timezone,
+		Description:    series.Description,
+	}
+	if description != nil {
+		following.Description = *description
+	}
+	endedRule := *rule
+	endedRule.Count = 0
+	end := target.StartTime.Add(-time.Second).UTC()
+	endedRule.Until = &end
+	series.RRule = endedRule.String()
+
+	if err := as.appointmentRepository.UpdateSeriesWithTx(tx, series); err != nil {
+		log.Error().Err(err).Uint("seriesID", series.ID).Msg("Error ending appointment series")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	if err := as.appointmentRepository.CreateSeriesWithTx(tx, following); err != nil {
+		log.Error().Err(err).Uint("seriesID", series.ID).Msg("Error creating following appointment series")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	return following, nil
+}
+
+// daysBetween counts the calendar days from a's date to b's date.
+func daysBetween(a, b time.Time) int {
+	from := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func shiftWeekdays(days []time.Weekday, shift int) []time.Weekday {
+	if len(days) == 0 {
+		return nil
+	}
+	shifted := make([]time.Weekday, len(days))
+	for i, day := range days {
+		shifted[i] = time.Weekday(((int(day)+shift)%7 + 7) % 7)
+	}
+	return shifted
+}
+
+// lockFollowingOccurrences locks the appointment and the open occurrences
+// of its series from its start onwards. The caller owns tx.
+func (as *appointmentService) lockFollowingOccurrences(tx *gorm.DB, organizationID, id uint) (*model.Appointment, []model.Appointment, error) {
+	target, err := as.appointmentRepository.GetByIDForUpdate(tx, organizationID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error fetching appointment")
+		return nil, nil, err
+	}
+	if target == nil {
+		return nil, nil, ErrAppointmentNotFound
+	}
+	if target.SeriesID == nil {
+		return nil, nil, ErrAppointmentNotRecurring
+	}
+	if enums.AppointmentStatus(target.Status).IsTerminal() {
+		return nil, nil, ErrAppointmentClosed
+	}
+
+	occurrences, err := as.appointmentRepository.FindFollowingInSeriesForUpdate(tx, organizationID, *target.SeriesID, target.StartTime)
+	if err != nil {
+		log.Error().Err(err).Uint("seriesID", *target.SeriesID).Msg("Error fetching series occurrences")
+		return nil, nil, err
+	}
+	return target, occurrences, nil
+}
+
+// findOccurrenceConflicts checks each occurrence against working hours,
+// existing bookings and imported busy time. The caller must already hold the
+// participant locks.
+func (as *appointmentService) findOccurrenceConflicts(tx *gorm.DB, occurrences []model.Appointment) ([]response.OccurrenceConflict, error) {
+	var conflicts []response.OccurrenceConflict
+	for i := range occurrences {
+		occurrence := &occurrences[i]
+		err := ensureWithinWorkingHours(as.workingHoursRepository, occurrence.ParticipantID, occurrence.StartTime, occurrence.EndTime)
+		if errors.Is(err, ErrOutsideWorkingHours) {
+			conflicts = append(conflicts, response.OccurrenceConflict{
+				Start:  occurrence.StartTime,
+				End:    occurrence.EndTime,
+				Reason: OccurrenceReasonOutsideWorkingHours,
+			})
+			continue
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Error checking working hours")
+			return nil, err
+		}
+
+		existing, err := as.appointmentRepository.FindConflictingAppointments(tx, occurrence)
+		if err != nil {
+			log.Error().Err(err).Msg("Error checking for conflicting appointments")
+			return nil, err
+		}
+		if existing == nil {
+			continue
+		}
+		if len(existing.Appointments) == 0 {
+			conflicts = append(conflicts, response.OccurrenceConflict{
+				Start:  occurrence.StartTime,
+				End:    occurrence.EndTime,
+				Reason: OccurrenceReasonBusy,
+			})
+			continue
+		}
+		ids := make([]uint, 0, len(existing.Appointments))
+		for _, appointment := range existing.Appointments {
+			ids = append(ids, appointment.ID)
+		}
+		conflicts = append(conflicts, response.OccurrenceConflict{
+			Start:                     occurrence.StartTime,
+			End:                       occurrence.EndTime,
+			Reason:                    OccurrenceReasonConflict,
+			ConflictingAppointmentIDs: ids,
+		})
+	}
+	return conflicts, nil
+}
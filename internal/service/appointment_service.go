@@ -2,8 +2,11 @@ package service
 
 import (
 	"errors"
+	"queue_system/internal/auth"
 	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
 	"queue_system/internal/enums"
+	"queue_system/internal/events"
 	"queue_system/internal/model"
 	"queue_system/internal/repository"
 	"time"
@@ -17,33 +20,70 @@ var (
 	ErrInvalidTimeFormat         = errors.New("invalid time format, use RFC3339 (e.g., 2024-01-01T10:00:00Z)")
 	ErrEndTimeBeforeStartTime    = errors.New("end time must be after start time")
 	ErrAppointmentConflict       = errors.New("time slot conflicts with an existing appointment for one of the participants")
+	ErrBusyTimeConflict          = errors.New("time slot is blocked in an imported calendar of one of the participants")
 	ErrUserOrParticipantNotFound = errors.New("creator (user) or participant not found")
 	ErrCannotBookWithSelf        = errors.New("user cannot book an appointment with themselves")
 	ErrInvalidAppointmentStatus  = errors.New("invalid appointment status")
+	ErrInvalidStatusTransition   = errors.New("appointment status transition not allowed")
+	ErrAppointmentClosed         = errors.New("cancelled or completed appointments cannot be modified")
 	ErrCreateAppointmentFailed   = errors.New("failed to create appointment")
 	ErrUpdateAppointmentFailed   = errors.New("failed to update appointment")
+	ErrInvalidDateFormat         = errors.New("invalid date format, use YYYY-MM-DD (e.g., 2024-01-01)")
+	ErrInvalidTimezone           = errors.New("invalid timezone, use an IANA name (e.g., Asia/Ho_Chi_Minh)")
+	ErrCalendarRangeTooLarge     = errors.New("calendar range must not exceed 93 days")
+	ErrAppointmentAlreadyStarted = errors.New("appointment has already started")
+	ErrAppointmentNotDeletable   = errors.New("only pending, cancelled or completed appointments can be deleted")
+)
+
+const (
+	defaultAppointmentPageSize = 20
+	defaultCalendarDays        = 7
+	maxCalendarDays            = 93
+	calendarDateLayout         = "2006-01-02"
 )
 
 type AppointmentService interface {
-	CreateAppointment(req *request.AppointmentRequest) (*model.Appointment, error)
-	GetAppointmentByID(id uint) (*model.Appointment, error)
+	CreateAppointment(actor *model.User, req *request.AppointmentRequest) (*model.Appointment, error)
+	CreateAppointmentSeries(actor *model.User, req *request.RecurringAppointmentRequest) (*response.AppointmentSeriesResponse, error)
+	GetAppointmentByID(organizationID, id uint) (*model.Appointment, error)
+	ListAppointments(actor *model.User, req *request.ListAppointmentsRequest) ([]model.Appointment, int64, error)
+	GetUserCalendar(organizationID, userID uint, req *request.UserCalendarRequest) (*response.UserCalendarResponse, error)
+	UpdateAppointment(organizationID, id, actorID uint, req *request.UpdateAppointmentRequest) (*model.Appointment, error)
+	UpdateFollowingAppointments(organizationID, id, actorID uint, req *request.UpdateAppointmentRequest) ([]model.Appointment, error)
+	ConfirmAppointment(organizationID, id, actorID uint) (*model.Appointment, error)
+	CancelAppointment(organizationID, id, actorID uint) (*model.Appointment, error)
+	CancelFollowingAppointments(organizationID, id, actorID uint) ([]model.Appointment, error)
+	StartAppointment(organizationID, id, actorID uint) (*model.Appointment, error)
+	CompleteAppointment(organizationID, id, actorID uint) (*model.Appointment, error)
+	CheckInAppointment(organizationID, id uint) (*response.WaitingLineEntry, error)
+	GetWaitingLine(organizationID, participantID uint) (*response.WaitingLineResponse, error)
+	CallNextInWaitingLine(organizationID, participantID uint) (*model.Appointment, error)
+	DeleteAppointment(organizationID, id uint) error
+	RestoreAppointment(organizationID, id uint) (*model.Appointment, error)
 }
 
 type appointmentService struct {
-	appointmentRepository repository.AppointmentRepository
-	userRepository        repository.UserRepository
-	db                    *gorm.DB
+	appointmentRepository  repository.AppointmentRepository
+	userRepository         repository.UserRepository
+	workingHoursRepository repository.WorkingHoursRepository
+	db                     *gorm.DB
 }
 
-func NewAppointmentService(appointmentRepository repository.AppointmentRepository, userRepository repository.UserRepository, db *gorm.DB) AppointmentService {
+func NewAppointmentService(appointmentRepository repository.AppointmentRepository, userRepository repository.UserRepository, workingHoursRepository repository.WorkingHoursRepository, db *gorm.DB) AppointmentService {
 	return &appointmentService{
-		appointmentRepository: appointmentRepository,
-		userRepository:        userRepository,
-		db:                    db,
+		appointmentRepository:  appointmentRepository,
+		userRepository:         userRepository,
+		workingHoursRepository: workingHoursRepository,
+		db:                     db,
 	}
 }
 
-func (as *appointmentService) CreateAppointment(req *request.AppointmentRequest) (*model.Appointment, error) {
+// CreateAppointment books an appointment created by req.UserID, who must be
+// the actor unless the actor may manage all appointments.
+func (as *appointmentService) CreateAppointment(actor *model.User, req *request.AppointmentRequest) (*model.Appointment, error) {
+	if err := auth.CanBookFor(actor, req.UserID); err != nil {
+		return nil, err
+	}
 	if req.UserID == req.ParticipantID {
 		return nil, ErrCannotBookWithSelf
 	}
@@ -62,35 +102,53 @@ func (as *appointmentService) CreateAppointment(req *request.AppointmentRequest)
 	}
 
 	appointment := &model.Appointment{
-		UserID:        req.UserID,
-		ParticipantID: req.ParticipantID,
-		StartTime:     start_time,
-		EndTime:       end_time,
-		Description:   req.Description,
-		Status:        string(enums.Pending),
+		OrganizationID: actor.OrganizationID,
+		UserID:         req.UserID,
+		ParticipantID:  req.ParticipantID,
+		StartTime:      start_time,
+		EndTime:        end_time,
+		Description:    req.Description,
+		Status:         string(enums.Pending),
+		ExternalUID:    req.ExternalUID,
+		ResourceName:   req.ResourceName,
 	}
 	tx := as.db.Begin()
 
-	user, err := as.userRepository.GetById(req.UserID)
-	if err != nil && user == nil {
+	user, err := as.userRepository.GetById(actor.OrganizationID, req.UserID)
+	if err != nil || user == nil {
 		tx.Rollback()
 		return nil, ErrUserOrParticipantNotFound
 	}
 
-	participant, err := as.userRepository.GetById(req.ParticipantID)
-	if err != nil && participant == nil {
+	participant, err := as.userRepository.GetById(actor.OrganizationID, req.ParticipantID)
+	if err != nil || participant == nil {
 		tx.Rollback()
 		return nil, ErrUserOrParticipantNotFound
 	}
-	conflictingAppointments, err := as.appointmentRepository.FindConflictingAppointments(tx, appointment)
+	if !user.Active() || !participant.Active() {
+		tx.Rollback()
+		return nil, ErrUserDeactivated
+	}
+	if err := ensureWithinWorkingHours(as.workingHoursRepository, participant.ID, start_time, end_time); err != nil {
+		tx.Rollback()
+		log.Warn().Err(err).Uint("participantID", participant.ID).Msg("Booking outside participant working hours")
+		return nil, err
+	}
+	if err := as.appointmentRepository.LockParticipants(tx, appointment.UserID, appointment.ParticipantID); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error locking participants")
+		return nil, ErrCreateAppointmentFailed
+	}
+	conflicts, err := as.appointmentRepository.FindConflictingAppointments(tx, appointment)
 	if err != nil {
+		tx.Rollback()
 		log.Error().Err(err).Msg("Error checking for conflicting appointments")
 		return nil, err
 	}
-	if len(conflictingAppointments) > 0 {
+	if conflicts != nil {
 		tx.Rollback()
 		log.Warn().Msg("Conflicting appointments found")
-		return nil, ErrAppointmentConflict
+		return nil, conflictError(conflicts)
 	}
 
 	if err := as.appointmentRepository.CreateWithTx(tx, appointment); err != nil {
@@ -108,8 +166,8 @@ func (as *appointmentService) CreateAppointment(req *request.AppointmentRequest)
 
 }
 
-func (as *appointmentService) GetAppointmentByID(id uint) (*model.Appointment, error) {
-	appointment, err := as.appointmentRepository.GetByID(id)
+func (as *appointmentService) GetAppointmentByID(organizationID, id uint) (*model.Appointment, error) {
+	appointment, err := as.appointmentRepository.GetByID(organizationID, id)
 	if err != nil {
 		log.Error().Err(err).Uint("appointmentID", id).Msg("Error fetching appointment by ID")
 		return nil, err
@@ -119,3 +177,501 @@ func (as *appointmentService) GetAppointmentByID(id uint) (*model.Appointment, e
 	}
 	return appointment, nil
 }
+
+// ListAppointments lists the appointments matching req. Users who may not
+// manage all appointments only see the ones they take part in, and only
+// users who may restore them see deleted ones.
+func (as *appointmentService) ListAppointments(actor *model.User, req *request.ListAppointmentsRequest) ([]model.Appointment, int64, error) {
+	if req.IncludeDeleted && !auth.Can(actor, auth.RestoreDeleted) {
+		return nil, 0, auth.ErrPermissionDenied
+	}
+	filter := repository.AppointmentFilter{
+		OrganizationID: actor.OrganizationID,
+		UserID:         req.UserID,
+		ParticipantID:  req.ParticipantID,
+		Status:         req.Status,
+		IncludeDeleted: req.IncludeDeleted,
+		SortDesc:       req.Sort == "desc",
+	}
+	if !auth.Can(actor, auth.ManageAppointments) {
+		filter.PartyID = &actor.ID
+	}
+	if req.From != "" {
+		from, err := time.Parse(time.RFC3339, req.From)
+		if err != nil {
+			log.Warn().Err(err).Str("from", req.From).Msg("Failed to parse from time")
+			return nil, 0, ErrInvalidTimeFormat
+		}
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, err := time.Parse(time.RFC3339, req.To)
+		if err != nil {
+			log.Warn().Err(err).Str("to", req.To).Msg("Failed to parse to time")
+			return nil, 0, ErrInvalidTimeFormat
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		return nil, 0, ErrEndTimeBeforeStartTime
+	}
+
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultAppointmentPageSize
+	}
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+
+	appointments, total, err := as.appointmentRepository.List(filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing appointments")
+		return nil, 0, err
+	}
+	return appointments, total, nil
+}
+
+// GetUserCalendar groups the user's non-cancelled appointments by local day.
+// From and To are inclusive calendar dates in the requested timezone; an
+// appointment starting before the range is listed under its first day.
+func (as *appointmentService) GetUserCalendar(organizationID, userID uint, req *request.UserCalendarRequest) (*response.UserCalendarResponse, error) {
+	user, err := as.userRepository.GetById(organizationID, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error fetching user for calendar")
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		log.Warn().Err(err).Str("timezone", timezone).Msg("Failed to load timezone")
+		return nil, ErrInvalidTimezone
+	}
+
+	now := time.Now().In(loc)
+	firstDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if req.From != "" {
+		if firstDay, err = time.ParseInLocation(calendarDateLayout, req.From, loc); err != nil {
+			return nil, ErrInvalidDateFormat
+		}
+	}
+	lastDay := firstDay.AddDate(0, 0, defaultCalendarDays-1)
+	if req.To != "" {
+		if lastDay, err = time.ParseInLocation(calendarDateLayout, req.To, loc); err != nil {
+			return nil, ErrInvalidDateFormat
+		}
+	}
+	if lastDay.Before(firstDay) {
+		return nil, ErrEndTimeBeforeStartTime
+	}
+	if lastDay.After(firstDay.AddDate(0, 0, maxCalendarDays-1)) {
+		return nil, ErrCalendarRangeTooLarge
+	}
+
+	rangeEnd := lastDay.AddDate(0, 0, 1)
+	appointments, err := as.appointmentRepository.FindActiveForUser(userID, firstDay, rangeEnd)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error fetching appointments for calendar")
+		return nil, err
+	}
+
+	var days []response.CalendarDay
+	index := make(map[string]int)
+	for day := firstDay; day.Before(rangeEnd); day = day.AddDate(0, 0, 1) {
+		key := day.Format(calendarDateLayout)
+		index[key] = len(days)
+		days = append(days, response.CalendarDay{Date: key, Appointments: []model.Appointment{}})
+	}
+	for _, appointment := range appointments {
+		start := appointment.StartTime.In(loc)
+		if start.Before(firstDay) {
+			start = firstDay
+		}
+		i := index[start.Format(calendarDateLayout)]
+		days[i].Appointments = append(days[i].Appointments, appointment)
+	}
+
+	return &response.UserCalendarResponse{
+		UserID:   userID,
+		Timezone: loc.String(),
+		From:     firstDay.Format(calendarDateLayout),
+		To:       lastDay.Format(calendarDateLayout),
+		Days:     days,
+	}, nil
+}
+
+// UpdateAppointment changes the appointment on behalf of a user who may
+// access it. Confirming it this way needs a user who may host it.
+func (as *appointmentService) UpdateAppointment(organizationID, id, actorID uint, req *request.UpdateAppointmentRequest) (*model.Appointment, error) {
+	actor, err := as.userRepository.GetById(organizationID, actorID)
+	if err != nil {
+		log.Error().Err(err).Uint("actorID", actorID).Msg("Error fetching actor")
+		return nil, err
+	}
+	if actor == nil {
+		return nil, ErrUserNotFound
+	}
+
+	tx := as.db.Begin()
+
+	appointment, err := as.appointmentRepository.GetByIDForUpdate(tx, organizationID, id)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error fetching appointment for update")
+		return nil, err
+	}
+	if appointment == nil {
+		tx.Rollback()
+		return nil, ErrAppointmentNotFound
+	}
+	if err := auth.CanAccessAppointment(actor, appointment); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if enums.AppointmentStatus(appointment.Status).IsTerminal() {
+		tx.Rollback()
+		return nil, ErrAppointmentClosed
+	}
+
+	timesChanged := false
+	if req.StartTime != nil {
+		start_time, err := time.Parse(time.RFC3339, *req.StartTime)
+		if err != nil {
+			tx.Rollback()
+			log.Warn().Err(err).Str("startTime", *req.StartTime).Msg("Failed to parse start time")
+			return nil, ErrInvalidTimeFormat
+		}
+		appointment.StartTime = start_time
+		timesChanged = true
+	}
+	if req.EndTime != nil {
+		end_time, err := time.Parse(time.RFC3339, *req.EndTime)
+		if err != nil {
+			tx.Rollback()
+			log.Warn().Err(err).Str("endTime", *req.EndTime).Msg("Failed to parse end time")
+			return nil, ErrInvalidTimeFormat
+		}
+		appointment.EndTime = end_time
+		timesChanged = true
+	}
+	if appointment.EndTime.Before(appointment.StartTime) {
+		tx.Rollback()
+		return nil, ErrEndTimeBeforeStartTime
+	}
+	if req.Description != nil {
+		appointment.Description = *req.Description
+	}
+	if timesChanged || req.Description != nil {
+		appointment.Sequence++
+	}
+	previousStatus := appointment.Status
+	if req.Status != nil {
+		status := enums.AppointmentStatus(*req.Status)
+		if !status.IsValid() {
+			tx.Rollback()
+			return nil, ErrInvalidAppointmentStatus
+		}
+		if status != enums.AppointmentStatus(appointment.Status) {
+			if status == enums.Confirmed {
+				if err := auth.CanHostAppointment(actor, appointment); err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+			}
+			if err := applyStatusTransition(appointment, status, &actor.ID, time.Now()); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+
+	if timesChanged {
+		if err := ensureWithinWorkingHours(as.workingHoursRepository, appointment.ParticipantID, appointment.StartTime, appointment.EndTime); err != nil {
+			tx.Rollback()
+			log.Warn().Err(err).Uint("participantID", appointment.ParticipantID).Msg("Reschedule outside participant working hours")
+			return nil, err
+		}
+		if err := as.appointmentRepository.LockParticipants(tx, appointment.UserID, appointment.ParticipantID); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error locking participants")
+			return nil, ErrUpdateAppointmentFailed
+		}
+		conflicts, err := as.appointmentRepository.FindConflictingAppointments(tx, appointment)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error checking for conflicting appointments")
+			return nil, err
+		}
+		if conflicts != nil {
+			tx.Rollback()
+			log.Warn().Uint("appointmentID", id).Msg("Conflicting appointments found")
+			return nil, conflictError(conflicts)
+		}
+	}
+
+	eventType := events.AppointmentUpdated
+	if appointment.Status != previousStatus {
+		eventType = statusEventType(enums.AppointmentStatus(appointment.Status))
+	} else if timesChanged {
+		eventType = events.AppointmentRescheduled
+	}
+	if err := as.appointmentRepository.UpdateWithTx(tx, appointment, eventType); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error updating appointment")
+		return nil, ErrUpdateAppointmentFailed
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	return appointment, nil
+}
+
+func (as *appointmentService) ConfirmAppointment(organizationID, id, actorID uint) (*model.Appointment, error) {
+	return as.transitionAppointment(organizationID, id, enums.Confirmed, actorID, auth.CanHostAppointment)
+}
+
+func (as *appointmentService) CancelAppointment(organizationID, id, actorID uint) (*model.Appointment, error) {
+	return as.transitionAppointment(organizationID, id, enums.Cancelled, actorID, auth.CanAccessAppointment)
+}
+
+func (as *appointmentService) CompleteAppointment(organizationID, id, actorID uint) (*model.Appointment, error) {
+	return as.transitionAppointment(organizationID, id, enums.Completed, actorID, auth.CanHostAppointment)
+}
+
+// StartAppointment records when a confirmed appointment actually began.
+func (as *appointmentService) StartAppointment(organizationID, id, actorID uint) (*model.Appointment, error) {
+	return as.changeAppointment(organizationID, id, actorID, events.AppointmentStarted, auth.CanHostAppointment, func(appointment *model.Appointment, _ *uint, at time.Time) error {
+		if appointment.Status != string(enums.Confirmed) {
+			return ErrAppointmentNotConfirmed
+		}
+		if appointment.ActualStartAt != nil {
+			return ErrAppointmentAlreadyStarted
+		}
+		appointment.ActualStartAt = &at
+		return nil
+	})
+}
+
+func (as *appointmentService) transitionAppointment(organizationID, id uint, next enums.AppointmentStatus, actorID uint, authorize appointmentPolicy) (*model.Appointment, error) {
+	return as.changeAppointment(organizationID, id, actorID, statusEventType(next), authorize, func(appointment *model.Appointment, actorID *uint, at time.Time) error {
+		from := appointment.Status
+		if err := applyStatusTransition(appointment, next, actorID, at); err != nil {
+			log.Warn().Uint("appointmentID", id).Str("from", from).Str("to", string(next)).Msg("Rejected status transition")
+			return err
+		}
+		return nil
+	})
+}
+
+// appointmentPolicy decides whether a user may make a change to an
+// appointment.
+type appointmentPolicy func(user *model.User, appointment *model.Appointment) error
+
+// changeAppointment locks the appointment and applies change on behalf of
+// an existing actor that authorize allows, saving the result and recording
+// eventType if change succeeds.
+func (as *appointmentService) changeAppointment(organizationID, id, actorID uint, eventType string, authorize appointmentPolicy, change func(appointment *model.Appointment, actorID *uint, at time.Time) error) (*model.Appointment, error) {
+	actor, err := as.userRepository.GetById(organizationID, actorID)
+	if err != nil {
+		log.Error().Err(err).Uint("actorID", actorID).Msg("Error fetching actor")
+		return nil, err
+	}
+	if actor == nil {
+		return nil, ErrUserNotFound
+	}
+
+	tx := as.db.Begin()
+
+	appointment, err := as.appointmentRepository.GetByIDForUpdate(tx, organizationID, id)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error fetching appointment for change")
+		return nil, err
+	}
+	if appointment == nil {
+		tx.Rollback()
+		return nil, ErrAppointmentNotFound
+	}
+	if err := authorize(actor, appointment); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := change(appointment, &actor.ID, time.Now()); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := as.appointmentRepository.UpdateWithTx(tx, appointment, eventType); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error updating appointment")
+		return nil, ErrUpdateAppointmentFailed
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	return appointment, nil
+}
+
+// DeleteAppointment soft-deletes a pending, cancelled or completed
+// appointment; a confirmed one has to be cancelled first, so that one that
+// is checked in or under way does not disappear. Until it is purged it can
+// be restored with RestoreAppointment.
+func (as *appointmentService) DeleteAppointment(organizationID, id uint) error {
+	tx := as.db.Begin()
+
+	appointment, err := as.appointmentRepository.GetByIDForUpdate(tx, organizationID, id)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error fetching appointment for deletion")
+		return err
+	}
+	if appointment == nil {
+		tx.Rollback()
+		return ErrAppointmentNotFound
+	}
+	status := enums.AppointmentStatus(appointment.Status)
+	if status != enums.Pending && !status.IsTerminal() {
+		tx.Rollback()
+		return ErrAppointmentNotDeletable
+	}
+
+	if err := as.appointmentRepository.DeleteWithTx(tx, appointment); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error deleting appointment")
+		return ErrUpdateAppointmentFailed
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return ErrUpdateAppointmentFailed
+	}
+	return nil
+}
+
+// RestoreAppointment undeletes a deleted appointment. Both users must still
+// exist and be active, and a pending or confirmed appointment must not
+// conflict with what was booked since it was deleted.
+func (as *appointmentService) RestoreAppointment(organizationID, id uint) (*model.Appointment, error) {
+	tx := as.db.Begin()
+
+	appointment, err := as.appointmentRepository.GetDeletedByIDForUpdate(tx, organizationID, id)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error fetching deleted appointment")
+		return nil, err
+	}
+	if appointment == nil {
+		tx.Rollback()
+		return nil, ErrAppointmentNotFound
+	}
+
+	user, err := as.userRepository.GetById(organizationID, appointment.UserID)
+	if err != nil || user == nil {
+		tx.Rollback()
+		return nil, ErrUserOrParticipantNotFound
+	}
+	participant, err := as.userRepository.GetById(organizationID, appointment.ParticipantID)
+	if err != nil || participant == nil {
+		tx.Rollback()
+		return nil, ErrUserOrParticipantNotFound
+	}
+	if !user.Active() || !participant.Active() {
+		tx.Rollback()
+		return nil, ErrUserDeactivated
+	}
+
+	status := enums.AppointmentStatus(appointment.Status)
+	if status == enums.Pending || status == enums.Confirmed {
+		if err := as.appointmentRepository.LockParticipants(tx, appointment.UserID, appointment.ParticipantID); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error locking participants")
+			return nil, ErrUpdateAppointmentFailed
+		}
+		conflicts, err := as.appointmentRepository.FindConflictingAppointments(tx, appointment)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error checking for conflicting appointments")
+			return nil, err
+		}
+		if conflicts != nil {
+			tx.Rollback()
+			return nil, conflictError(conflicts)
+		}
+	}
+
+	if err := as.appointmentRepository.RestoreWithTx(tx, appointment); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error restoring appointment")
+		return nil, ErrUpdateAppointmentFailed
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateAppointmentFailed
+	}
+	return appointment, nil
+}
+
+// applyStatusTransition moves the appointment to next if the status machine
+// allows it, stamping who made the change and when.
+func applyStatusTransition(appointment *model.Appointment, next enums.AppointmentStatus, actorID *uint, at time.Time) error {
+	if !enums.AppointmentStatus(appointment.Status).CanTransitionTo(next) {
+		return ErrInvalidStatusTransition
+	}
+	appointment.Status = string(next)
+	appointment.Sequence++
+	switch next {
+	case enums.Confirmed:
+		appointment.ConfirmedByID, appointment.ConfirmedAt = actorID, &at
+	case enums.Cancelled:
+		appointment.CancelledByID, appointment.CancelledAt = actorID, &at
+	case enums.Completed:
+		appointment.CompletedByID, appointment.CompletedAt = actorID, &at
+		// Only appointments that were started have a measurable duration.
+		if appointment.ActualStartAt != nil {
+			appointment.ActualEndAt = &at
+		}
+	}
+	return nil
+}
+
+// statusEventType names the event emitted when an appointment enters status.
+func statusEventType(status enums.AppointmentStatus) string {
+	switch status {
+	case enums.Confirmed:
+		return events.AppointmentConfirmed
+	case enums.Cancelled:
+		return events.AppointmentCancelled
+	case enums.Completed:
+		return events.AppointmentCompleted
+	default:
+		return events.AppointmentUpdated
+	}
+}
+
+// conflictError reports a clash with another appointment before one with
+// an imported calendar.
+func conflictError(conflicts *repository.Conflicts) error {
+	if len(conflicts.Appointments) > 0 {
+		return ErrAppointmentConflict
+	}
+	return ErrBusyTimeConflict
+}
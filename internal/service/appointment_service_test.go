@@ -0,0 +1,480 @@
+package service
+
+import (
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/enums"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"queue_system/internal/repository/mocks"
+	"queue_system/test/txdb"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppointmentService_ListAppointments_AppliesFilterAndPaging(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+	staff := &model.User{ID: 1, Role: string(enums.RoleStaff)}
+
+	participantID := uint(7)
+	req := &request.ListAppointmentsRequest{
+		ParticipantID: &participantID,
+		Status:        "confirmed",
+		From:          "2030-01-01T00:00:00Z",
+		To:            "2030-01-02T00:00:00Z",
+		Sort:          "desc",
+		Page:          3,
+		PageSize:      10,
+	}
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	expected := []model.Appointment{{ID: 1, ParticipantID: participantID}}
+
+	mockAppointmentRepo.EXPECT().List(gomock.Any()).DoAndReturn(
+		func(filter repository.AppointmentFilter) ([]model.Appointment, int64, error) {
+			assert.Equal(t, &participantID, filter.ParticipantID)
+			assert.Equal(t, "confirmed", filter.Status)
+			assert.True(t, filter.From.Equal(from))
+			assert.True(t, filter.To.Equal(to))
+			assert.True(t, filter.SortDesc)
+			assert.Equal(t, 20, filter.Offset)
+			assert.Equal(t, 10, filter.Limit)
+			return expected, 21, nil
+		}).Times(1)
+
+	// WHEN
+	appointments, total, err := appointmentService.ListAppointments(staff, req)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, expected, appointments)
+	assert.Equal(t, int64(21), total)
+}
+
+func TestAppointmentService_ListAppointments_DefaultPaging(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+	staff := &model.User{ID: 1, Role: string(enums.RoleStaff)}
+
+	mockAppointmentRepo.EXPECT().List(repository.AppointmentFilter{Offset: 0, Limit: defaultAppointmentPageSize}).
+		Return([]model.Appointment{}, int64(0), nil).Times(1)
+
+	// WHEN
+	appointments, total, err := appointmentService.ListAppointments(staff, &request.ListAppointmentsRequest{})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Empty(t, appointments)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestAppointmentService_ListAppointments_InvalidWindow(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+	staff := &model.User{ID: 1, Role: string(enums.RoleStaff)}
+
+	// WHEN
+	_, _, errFormat := appointmentService.ListAppointments(staff, &request.ListAppointmentsRequest{From: "yesterday"})
+	_, _, errOrder := appointmentService.ListAppointments(staff, &request.ListAppointmentsRequest{
+		From: "2030-01-02T00:00:00Z",
+		To:   "2030-01-01T00:00:00Z",
+	})
+
+	// THEN
+	assert.Equal(t, ErrInvalidTimeFormat, errFormat)
+	assert.Equal(t, ErrEndTimeBeforeStartTime, errOrder)
+}
+
+func TestAppointmentService_ListAppointments_DeletedOnlyForAdmins(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+	staff := &model.User{ID: 1, Role: string(enums.RoleStaff)}
+	admin := &model.User{ID: 2, Role: string(enums.RoleAdmin)}
+	req := &request.ListAppointmentsRequest{IncludeDeleted: true}
+
+	mockAppointmentRepo.EXPECT().List(repository.AppointmentFilter{IncludeDeleted: true, Limit: defaultAppointmentPageSize}).
+		Return([]model.Appointment{}, int64(0), nil).Times(1)
+
+	// WHEN
+	_, _, staffErr := appointmentService.ListAppointments(staff, req)
+	_, _, adminErr := appointmentService.ListAppointments(admin, req)
+
+	// THEN
+	assert.ErrorIs(t, staffErr, auth.ErrPermissionDenied)
+	assert.NoError(t, adminErr)
+}
+
+func TestAppointmentService_GetUserCalendar_GroupsByLocalDay(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+
+	userID := uint(3)
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	assert.NoError(t, err)
+	rangeStart := time.Date(2030, 1, 1, 0, 0, 0, 0, loc)
+	rangeEnd := time.Date(2030, 1, 3, 0, 0, 0, 0, loc)
+
+	// 18:00 UTC on Jan 1st is already Jan 2nd in UTC+7.
+	lateEvening := model.Appointment{ID: 1, StartTime: time.Date(2030, 1, 1, 18, 0, 0, 0, time.UTC)}
+	morning := model.Appointment{ID: 2, StartTime: time.Date(2030, 1, 1, 2, 0, 0, 0, time.UTC)}
+
+	mockUserRepo.EXPECT().GetById(uint(1), userID).Return(&model.User{ID: userID}, nil).Times(1)
+	mockAppointmentRepo.EXPECT().FindActiveForUser(userID, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ uint, from, to time.Time) ([]model.Appointment, error) {
+			assert.True(t, from.Equal(rangeStart))
+			assert.True(t, to.Equal(rangeEnd))
+			return []model.Appointment{morning, lateEvening}, nil
+		}).Times(1)
+
+	// WHEN
+	calendar, err := appointmentService.GetUserCalendar(1, userID, &request.UserCalendarRequest{
+		From:     "2030-01-01",
+		To:       "2030-01-02",
+		Timezone: "Asia/Ho_Chi_Minh",
+	})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Ho_Chi_Minh", calendar.Timezone)
+	assert.Len(t, calendar.Days, 2)
+	assert.Equal(t, "2030-01-01", calendar.Days[0].Date)
+	assert.Equal(t, []model.Appointment{morning}, calendar.Days[0].Appointments)
+	assert.Equal(t, "2030-01-02", calendar.Days[1].Date)
+	assert.Equal(t, []model.Appointment{lateEvening}, calendar.Days[1].Appointments)
+}
+
+func TestAppointmentService_GetUserCalendar_UserNotFound(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(42)).Return(nil, nil).Times(1)
+
+	// WHEN
+	calendar, err := appointmentService.GetUserCalendar(1, 42, &request.UserCalendarRequest{})
+
+	// THEN
+	assert.Nil(t, calendar)
+	assert.Equal(t, ErrUserNotFound, err)
+}
+
+func TestAppointmentService_ListAppointments_ClientsSeeOwnAppointments(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+	client := &model.User{ID: 5, Role: string(enums.RoleClient)}
+
+	mockAppointmentRepo.EXPECT().List(gomock.Any()).DoAndReturn(
+		func(filter repository.AppointmentFilter) ([]model.Appointment, int64, error) {
+			assert.Equal(t, &client.ID, filter.PartyID)
+			return nil, 0, nil
+		}).Times(1)
+
+	// WHEN
+	_, _, err := appointmentService.ListAppointments(client, &request.ListAppointmentsRequest{})
+
+	// THEN
+	assert.NoError(t, err)
+}
+
+func TestAppointmentService_CreateAppointment_ClientsBookForThemselves(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+	client := &model.User{ID: 5, Role: string(enums.RoleClient)}
+
+	// WHEN
+	_, err := appointmentService.CreateAppointment(client, &request.AppointmentRequest{
+		UserID:        6,
+		ParticipantID: 7,
+		StartTime:     "2030-01-01T09:00:00Z",
+		EndTime:       "2030-01-01T10:00:00Z",
+	})
+
+	// THEN
+	assert.ErrorIs(t, err, auth.ErrBookingForOthers)
+}
+
+func TestAppointmentService_UpdateAppointment_UnknownActor(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(42)).Return(nil, nil).Times(2)
+
+	// WHEN
+	updated, err := appointmentService.UpdateAppointment(1, 7, 42, &request.UpdateAppointmentRequest{})
+	following, followingErr := appointmentService.UpdateFollowingAppointments(1, 7, 42, &request.UpdateAppointmentRequest{})
+
+	// THEN
+	assert.Nil(t, updated)
+	assert.Equal(t, ErrUserNotFound, err)
+	assert.Nil(t, following)
+	assert.Equal(t, ErrUserNotFound, followingErr)
+}
+
+func TestAppointmentService_DeleteAppointment_OnlyPendingOrClosed(t *testing.T) {
+	checkedIn := time.Date(2030, 1, 1, 8, 55, 0, 0, time.UTC)
+	for name, tc := range map[string]struct {
+		appointment *model.Appointment
+		expected    error
+	}{
+		"pending":     {&model.Appointment{ID: 5, Status: string(enums.Pending)}, nil},
+		"cancelled":   {&model.Appointment{ID: 5, Status: string(enums.Cancelled)}, nil},
+		"completed":   {&model.Appointment{ID: 5, Status: string(enums.Completed)}, nil},
+		"confirmed":   {&model.Appointment{ID: 5, Status: string(enums.Confirmed)}, ErrAppointmentNotDeletable},
+		"checked in":  {&model.Appointment{ID: 5, Status: string(enums.Confirmed), CheckedInAt: &checkedIn}, ErrAppointmentNotDeletable},
+		"in progress": {&model.Appointment{ID: 5, Status: string(enums.Confirmed), CheckedInAt: &checkedIn, ActualStartAt: &checkedIn}, ErrAppointmentNotDeletable},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db, txLog := txdb.Open(t)
+			mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+			appointmentService := NewAppointmentService(mockAppointmentRepo, nil, nil, db)
+
+			mockAppointmentRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint(1), uint(5)).Return(tc.appointment, nil)
+			if tc.expected == nil {
+				mockAppointmentRepo.EXPECT().DeleteWithTx(gomock.Any(), tc.appointment).Return(nil)
+			}
+
+			// WHEN
+			err := appointmentService.DeleteAppointment(1, 5)
+
+			// THEN
+			if tc.expected != nil {
+				assert.ErrorIs(t, err, tc.expected)
+				assert.Equal(t, []string{"begin", "rollback"}, txLog.Entries())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"begin", "commit"}, txLog.Entries())
+		})
+	}
+}
+
+func TestAppointmentService_RestoreAppointment_NeedsActiveUsers(t *testing.T) {
+	deactivatedAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	for name, tc := range map[string]struct {
+		participant *model.User
+		expected    error
+	}{
+		"participant deleted":     {nil, ErrUserOrParticipantNotFound},
+		"participant deactivated": {&model.User{ID: 3, DeactivatedAt: &deactivatedAt}, ErrUserDeactivated},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db, txLog := txdb.Open(t)
+			mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+			mockUserRepo := mocks.NewMockUserRepository(ctrl)
+			appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, nil, db)
+			deleted := &model.Appointment{ID: 5, UserID: 2, ParticipantID: 3, Status: string(enums.Confirmed)}
+
+			mockAppointmentRepo.EXPECT().GetDeletedByIDForUpdate(gomock.Any(), uint(1), uint(5)).Return(deleted, nil)
+			mockUserRepo.EXPECT().GetById(uint(1), uint(2)).Return(&model.User{ID: 2}, nil)
+			mockUserRepo.EXPECT().GetById(uint(1), uint(3)).Return(tc.participant, nil)
+
+			// WHEN
+			restored, err := appointmentService.RestoreAppointment(1, 5)
+
+			// THEN
+			assert.Nil(t, restored)
+			assert.ErrorIs(t, err, tc.expected)
+			assert.Equal(t, []string{"begin", "rollback"}, txLog.Entries())
+		})
+	}
+}
+
+func TestAppointmentService_CreateAppointmentSeries_RejectsInvalidRules(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+
+	client := &model.User{ID: 1, Role: string(enums.RoleClient)}
+	req := request.RecurringAppointmentRequest{
+		UserID:        1,
+		ParticipantID: 2,
+		StartTime:     "2030-01-01T09:00:00Z",
+		EndTime:       "2030-01-01T10:00:00Z",
+	}
+
+	// WHEN
+	unbounded := req
+	unbounded.RRule = "FREQ=DAILY"
+	_, errUnbounded := appointmentService.CreateAppointmentSeries(client, &unbounded)
+
+	overlapping := req
+	overlapping.RRule = "FREQ=DAILY;COUNT=3"
+	overlapping.EndTime = "2030-01-02T12:00:00Z"
+	_, errOverlapping := appointmentService.CreateAppointmentSeries(client, &overlapping)
+
+	// THEN
+	assert.ErrorIs(t, errUnbounded, ErrInvalidRecurrenceRule)
+	assert.Equal(t, ErrOverlappingOccurrences, errOverlapping)
+}
+
+func TestAppointmentService_UpdateFollowingAppointments_SplitsSeries(t *testing.T) {
+	wednesday := time.Date(2030, 1, 9, 9, 0, 0, 0, time.UTC)
+	for name, tc := range map[string]struct {
+		earlier        int64
+		expectedSeries uint
+		expectedRules  []string
+	}{
+		"later occurrence": {
+			earlier:        1,
+			expectedSeries: 10,
+			expectedRules:  []string{"FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20300109T085959Z"},
+		},
+		"first occurrence": {
+			earlier:        0,
+			expectedSeries: 9,
+			expectedRules:  []string{"FREQ=WEEKLY;BYDAY=TU,TH;UNTIL=20300117T090000Z"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db, txLog := txdb.Open(t)
+			mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+			mockUserRepo := mocks.NewMockUserRepository(ctrl)
+			mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+			appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, db)
+
+			seriesID := uint(9)
+			occurrence := func(id uint, start time.Time) model.Appointment {
+				return model.Appointment{ID: id, OrganizationID: 1, UserID: 42, ParticipantID: 3, SeriesID: &seriesID,
+					StartTime: start, EndTime: start.Add(time.Hour), Status: string(enums.Pending)}
+			}
+			target := occurrence(7, wednesday)
+			following := []model.Appointment{target, occurrence(8, wednesday.AddDate(0, 0, 5)), occurrence(11, wednesday.AddDate(0, 0, 7))}
+			series := &model.AppointmentSeries{ID: 9, OrganizationID: 1, UserID: 42, ParticipantID: 3,
+				RRule: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=6", Timezone: "UTC"}
+
+			mockUserRepo.EXPECT().GetById(uint(1), uint(42)).Return(&model.User{ID: 42, OrganizationID: 1, Role: string(enums.RoleClient)}, nil)
+			mockAppointmentRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint(1), uint(7)).Return(&target, nil)
+			mockAppointmentRepo.EXPECT().FindFollowingInSeriesForUpdate(gomock.Any(), uint(1), seriesID, wednesday).Return(following, nil)
+			mockAppointmentRepo.EXPECT().GetSeriesByIDForUpdate(gomock.Any(), uint(1), seriesID).Return(series, nil)
+			mockAppointmentRepo.EXPECT().CountInSeriesBefore(gomock.Any(), uint(1), seriesID, wednesday).Return(tc.earlier, nil)
+			var rules []string
+			mockAppointmentRepo.EXPECT().UpdateSeriesWithTx(gomock.Any(), series).DoAndReturn(func(_ any, updated *model.AppointmentSeries) error {
+				rules = append(rules, updated.RRule)
+				return nil
+			})
+			if tc.earlier > 0 {
+				mockAppointmentRepo.EXPECT().CreateSeriesWithTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, created *model.AppointmentSeries) error {
+					assert.Equal(t, "FREQ=WEEKLY;BYDAY=TU,TH;UNTIL=20300117T090000Z", created.RRule)
+					assert.Equal(t, uint(1), created.OrganizationID)
+					created.ID = 10
+					return nil
+				})
+			}
+			mockAppointmentRepo.EXPECT().UpdateWithTx(gomock.Any(), gomock.Any(), events.AppointmentRescheduled).Return(nil).Times(3)
+			mockAppointmentRepo.EXPECT().LockParticipants(gomock.Any(), uint(42), uint(3)).Return(nil)
+			mockAppointmentRepo.EXPECT().FindConflictingAppointments(gomock.Any(), gomock.Any()).Return(nil, nil).Times(3)
+			mockWorkingHoursRepo.EXPECT().ListOverridesBetween(uint(3), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+			mockWorkingHoursRepo.EXPECT().ListWindows(uint(3)).Return(nil, nil).AnyTimes()
+
+			// WHEN
+			thursday := wednesday.AddDate(0, 0, 1)
+			newStart, newEnd := thursday.Format(time.RFC3339), thursday.Add(time.Hour).Format(time.RFC3339)
+			moved, err := appointmentService.UpdateFollowingAppointments(1, 7, 42, &request.UpdateAppointmentRequest{StartTime: &newStart, EndTime: &newEnd})
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Len(t, moved, 3)
+			for _, appointment := range moved {
+				assert.Equal(t, tc.expectedSeries, *appointment.SeriesID)
+			}
+			assert.Equal(t, tc.expectedRules, rules)
+			assert.Equal(t, []string{"begin", "commit"}, txLog.Entries())
+		})
+	}
+}
+
+func TestAppointmentService_GetWaitingLine_NumbersPositions(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	appointmentService := NewAppointmentService(mockAppointmentRepo, mockUserRepo, mockWorkingHoursRepo, nil)
+
+	participantID := uint(7)
+	mockUserRepo.EXPECT().GetById(uint(1), participantID).Return(&model.User{ID: participantID}, nil).Times(1)
+	mockAppointmentRepo.EXPECT().FindWaitingLine(participantID).Return([]model.Appointment{{ID: 3}, {ID: 1}}, nil).Times(1)
+
+	// WHEN
+	line, err := appointmentService.GetWaitingLine(1, participantID)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, participantID, line.ParticipantID)
+	assert.Len(t, line.Entries, 2)
+	assert.Equal(t, 1, line.Entries[0].Position)
+	assert.Equal(t, uint(3), line.Entries[0].Appointment.ID)
+	assert.Equal(t, 2, line.Entries[1].Position)
+}
+
+func TestClassifyArrival(t *testing.T) {
+	start := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, enums.ArrivedEarly, classifyArrival(start, start.Add(-30*time.Minute)))
+	assert.Equal(t, enums.ArrivedOnTime, classifyArrival(start, start.Add(-5*time.Minute)))
+	assert.Equal(t, enums.ArrivedOnTime, classifyArrival(start, start.Add(3*time.Minute)))
+	assert.Equal(t, enums.ArrivedLate, classifyArrival(start, start.Add(10*time.Minute)))
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"queue_system/config"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/notification"
+	"queue_system/internal/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCredentials  = auth.ErrInvalidCredentials
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrInvalidMagicLink    = errors.New("invalid or expired sign-in link")
+)
+
+// AuthService signs users in with a password or a magic link and keeps them
+// signed in with rotating refresh tokens.
+type AuthService interface {
+	Login(organizationID uint, req *request.LoginRequest) (*response.TokenResponse, error)
+	RequestMagicLink(organizationID uint, req *request.MagicLinkRequest) error
+	SendMagicLink(ctx context.Context, event events.Event) error
+	VerifyMagicLink(req *request.VerifyMagicLinkRequest) (*response.TokenResponse, error)
+	Refresh(req *request.RefreshTokenRequest) (*response.TokenResponse, error)
+	Logout(req *request.RefreshTokenRequest) error
+}
+
+type authService struct {
+	authRepository  repository.AuthRepository
+	userRepository  repository.UserRepository
+	tokens          *auth.Tokens
+	sender          *notification.Sender
+	db              *gorm.DB
+	refreshTokenTTL time.Duration
+	magicLinkURL    string
+	magicLinkTTL    time.Duration
+	now             func() time.Time
+}
+
+func NewAuthService(authRepository repository.AuthRepository, userRepository repository.UserRepository, tokens *auth.Tokens, sender *notification.Sender, db *gorm.DB, cfg *config.Config) AuthService {
+	return &authService{
+		authRepository:  authRepository,
+		userRepository:  userRepository,
+		tokens:          tokens,
+		sender:          sender,
+		db:              db,
+		refreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+		magicLinkURL:    cfg.Auth.MagicLinkURL,
+		magicLinkTTL:    cfg.Auth.MagicLinkTTL,
+		now:             time.Now,
+	}
+}
+
+// Login signs in the organization's user with the email. Emails are only
+// unique within an organization, so the request must say which.
+func (as *authService) Login(organizationID uint, req *request.LoginRequest) (*response.TokenResponse, error) {
+	user, err := as.userRepository.GetByEmail(organizationID, req.Email)
+	if err != nil {
+		log.Error().Err(err).Msg("Error fetching user for login")
+		return nil, err
+	}
+	hash := ""
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	if !auth.CheckPassword(hash, req.Password) || !user.Active() {
+		return nil, ErrInvalidCredentials
+	}
+	return as.issueTokens(as.db, user.OrganizationID, user.ID, "")
+}
+
+// RequestMagicLink queues a login link for the user with the email. The
+// email is only looked up when the link is sent, so neither the answer nor
+// how long it takes tells who has an account.
+func (as *authService) RequestMagicLink(organizationID uint, req *request.MagicLinkRequest) error {
+	if err := as.authRepository.RecordMagicLinkRequest(organizationID, req.Email); err != nil {
+		log.Error().Err(err).Msg("Error queueing magic link")
+		return err
+	}
+	return nil
+}
+
+// SendMagicLink emails a login link for a MagicLinkRequested event if the
+// email belongs to an active user. It is registered on the event bus.
+func (as *authService) SendMagicLink(ctx context.Context, event events.Event) error {
+	var payload events.MagicLinkRequest
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	user, err := as.userRepository.GetByEmail(payload.OrganizationID, payload.Email)
+	if err != nil {
+		log.Error().Err(err).Msg("Error fetching user for magic link")
+		return err
+	}
+	if user == nil {
+		log.Info().Msg("Magic link requested for unknown email")
+		return nil
+	}
+	if !user.Active() {
+		log.Info().Uint("userID", user.ID).Msg("Magic link requested for deactivated user")
+		return nil
+	}
+
+	token, tokenHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+	link := &model.MagicLink{OrganizationID: user.OrganizationID, UserID: user.ID, TokenHash: tokenHash, ExpiresAt: as.now().Add(as.magicLinkTTL)}
+	if err := as.authRepository.CreateMagicLink(link); err != nil {
+		log.Error().Err(err).Uint("userID", user.ID).Msg("Error creating magic link")
+		return err
+	}
+	if err := as.sender.SendMagicLink(ctx, user, as.linkURL(token), as.magicLinkTTL); err != nil {
+		log.Error().Err(err).Uint("userID", user.ID).Msg("Error sending magic link")
+		return err
+	}
+	return nil
+}
+
+// VerifyMagicLink signs in with a magic link's token, which then cannot be
+// used again.
+func (as *authService) VerifyMagicLink(req *request.VerifyMagicLinkRequest) (*response.TokenResponse, error) {
+	now := as.now()
+	tx := as.db.Begin()
+	link, err := as.authRepository.GetMagicLinkForUpdate(tx, auth.HashToken(req.Token))
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error fetching magic link")
+		return nil, err
+	}
+	if link == nil || link.UsedAt != nil || !now.Before(link.ExpiresAt) {
+		tx.Rollback()
+		return nil, ErrInvalidMagicLink
+	}
+
+	link.UsedAt = &now
+	if err := as.authRepository.UpdateMagicLinkWithTx(tx, link); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("magicLinkID", link.ID).Msg("Error marking magic link used")
+		return nil, err
+	}
+	tokens, err := as.issueTokens(tx, link.OrganizationID, link.UserID, "")
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		log.Error().Err(err).Msg("Error committing magic link login")
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Refresh exchanges a refresh token for new tokens. A token that was
+// already exchanged has been copied, so its whole family is revoked and
+// every holder has to sign in again. The same happens when the user has
+// been deactivated or deleted since signing in.
+func (as *authService) Refresh(req *request.RefreshTokenRequest) (*response.TokenResponse, error) {
+	now := as.now()
+	tx := as.db.Begin()
+	stored, err := as.authRepository.GetRefreshTokenForUpdate(tx, auth.HashToken(req.RefreshToken))
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error fetching refresh token")
+		return nil, err
+	}
+	if stored == nil || !now.Before(stored.ExpiresAt) {
+		tx.Rollback()
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.RevokedAt != nil {
+		log.Warn().Uint("userID", stored.UserID).Str("familyID", stored.FamilyID).Msg("Revoked refresh token reused, revoking its family")
+		if err := as.authRepository.RevokeRefreshTokenFamilyWithTx(tx, stored.FamilyID, now); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error revoking refresh token family")
+			return nil, err
+		}
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Error committing refresh token family revocation")
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := as.userRepository.GetById(stored.OrganizationID, stored.UserID)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("userID", stored.UserID).Msg("Error fetching user for refresh")
+		return nil, err
+	}
+	if user == nil || !user.Active() {
+		if err := as.authRepository.RevokeRefreshTokenFamilyWithTx(tx, stored.FamilyID, now); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error revoking refresh token family")
+			return nil, err
+		}
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Error committing refresh token family revocation")
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	stored.RevokedAt = &now
+	if err := as.authRepository.UpdateRefreshTokenWithTx(tx, stored); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("refreshTokenID", stored.ID).Msg("Error revoking refresh token")
+		return nil, err
+	}
+	tokens, err := as.issueTokens(tx, stored.OrganizationID, stored.UserID, stored.FamilyID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		log.Error().Err(err).Msg("Error committing refresh token rotation")
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Logout revokes the refresh token's family, ending that session. Unknown
+// tokens are ignored.
+func (as *authService) Logout(req *request.RefreshTokenRequest) error {
+	tx := as.db.Begin()
+	stored, err := as.authRepository.GetRefreshTokenForUpdate(tx, auth.HashToken(req.RefreshToken))
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error fetching refresh token")
+		return err
+	}
+	if stored == nil {
+		tx.Rollback()
+		return nil
+	}
+	if err := as.authRepository.RevokeRefreshTokenFamilyWithTx(tx, stored.FamilyID, as.now()); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error revoking refresh token family")
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// issueTokens creates an access token and a refresh token for the user of
+// the organization. The refresh token joins familyID, or starts a new
+// family when it is empty.
+func (as *authService) issueTokens(tx *gorm.DB, organizationID, userID uint, familyID string) (*response.TokenResponse, error) {
+	now := as.now()
+	accessToken, accessExpiresAt, err := as.tokens.Issue(userID, organizationID, now)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, refreshHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	if familyID == "" {
+		familyID, _, err = auth.NewOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+	}
+	stored := &model.RefreshToken{
+		OrganizationID: organizationID,
+		UserID:         userID,
+		FamilyID:       familyID,
+		TokenHash:      refreshHash,
+		ExpiresAt:      now.Add(as.refreshTokenTTL),
+	}
+	if err := as.authRepository.CreateRefreshTokenWithTx(tx, stored); err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error storing refresh token")
+		return nil, err
+	}
+	return &response.TokenResponse{
+		AccessToken:           accessToken,
+		TokenType:             "Bearer",
+		ExpiresIn:             int(accessExpiresAt.Sub(now).Seconds()),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+func (as *authService) linkURL(token string) string {
+	link, err := url.Parse(as.magicLinkURL)
+	if err != nil {
+		return as.magicLinkURL + "?token=" + url.QueryEscape(token)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
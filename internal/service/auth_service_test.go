@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"queue_system/config"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuthService(authRepo *mocks.MockAuthRepository, userRepo *mocks.MockUserRepository) (AuthService, *auth.Tokens) {
+	cfg := &config.Config{Auth: config.Auth{
+		JWTSecret:       "0123456789abcdef0123456789abcdef",
+		Issuer:          "queue-system",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	}}
+	tokens := auth.NewTokens(cfg)
+	return NewAuthService(authRepo, userRepo, tokens, nil, nil, cfg), tokens
+}
+
+func TestAuthService_Login_Success(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAuthRepo := mocks.NewMockAuthRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	authService, tokens := newTestAuthService(mockAuthRepo, mockUserRepo)
+
+	hash, err := auth.HashPassword("correct horse")
+	require.NoError(t, err)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), "user@example.com").Return(&model.User{ID: 7, OrganizationID: 1, Email: "user@example.com", PasswordHash: hash}, nil)
+	var stored *model.RefreshToken
+	mockAuthRepo.EXPECT().CreateRefreshTokenWithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ interface{}, token *model.RefreshToken) error {
+			stored = token
+			return nil
+		})
+
+	// WHEN
+	result, err := authService.Login(1, &request.LoginRequest{Email: "user@example.com", Password: "correct horse"})
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", result.TokenType)
+	assert.Equal(t, 900, result.ExpiresIn)
+	claims, err := tokens.Verify(result.AccessToken, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "7", claims.Subject)
+	assert.Equal(t, uint(1), claims.OrganizationID)
+
+	require.NotNil(t, stored)
+	assert.Equal(t, uint(7), stored.UserID)
+	assert.Equal(t, uint(1), stored.OrganizationID)
+	assert.NotEmpty(t, stored.FamilyID)
+	assert.Equal(t, auth.HashToken(result.RefreshToken), stored.TokenHash, "only the hash is stored")
+	assert.Equal(t, stored.ExpiresAt, result.RefreshTokenExpiresAt)
+}
+
+func TestAuthService_Login_InvalidCredentials(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAuthRepo := mocks.NewMockAuthRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	authService, _ := newTestAuthService(mockAuthRepo, mockUserRepo)
+
+	hash, err := auth.HashPassword("correct horse")
+	require.NoError(t, err)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), "user@example.com").Return(&model.User{ID: 7, PasswordHash: hash}, nil)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), "nobody@example.com").Return(nil, nil)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), "linkonly@example.com").Return(&model.User{ID: 8}, nil)
+
+	// WHEN
+	_, wrongPassword := authService.Login(1, &request.LoginRequest{Email: "user@example.com", Password: "wrong horse"})
+	_, unknownUser := authService.Login(1, &request.LoginRequest{Email: "nobody@example.com", Password: "correct horse"})
+	_, noPassword := authService.Login(1, &request.LoginRequest{Email: "linkonly@example.com", Password: ""})
+
+	// THEN
+	assert.ErrorIs(t, wrongPassword, ErrInvalidCredentials)
+	assert.ErrorIs(t, unknownUser, ErrInvalidCredentials)
+	assert.ErrorIs(t, noPassword, ErrInvalidCredentials)
+}
+
+func TestAuthService_RequestMagicLink_OnlyQueuesTheRequest(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAuthRepo := mocks.NewMockAuthRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	authService, _ := newTestAuthService(mockAuthRepo, mockUserRepo)
+	mockAuthRepo.EXPECT().RecordMagicLinkRequest(uint(1), "nobody@example.com").Return(nil).Times(1)
+
+	// WHEN
+	err := authService.RequestMagicLink(1, &request.MagicLinkRequest{Email: "nobody@example.com"})
+
+	// THEN
+	assert.NoError(t, err)
+}
+
+func TestAuthService_SendMagicLink_IgnoresUnknownAndDeactivatedUsers(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAuthRepo := mocks.NewMockAuthRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	authService, _ := newTestAuthService(mockAuthRepo, mockUserRepo)
+	deactivatedAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), "nobody@example.com").Return(nil, nil).Times(1)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), "gone@example.com").Return(&model.User{ID: 3, DeactivatedAt: &deactivatedAt}, nil).Times(1)
+	event := func(email string) events.Event {
+		payload, err := json.Marshal(events.MagicLinkRequest{OrganizationID: 1, Email: email})
+		require.NoError(t, err)
+		return events.Event{Type: events.MagicLinkRequested, Payload: payload}
+	}
+
+	// WHEN
+	unknownErr := authService.SendMagicLink(context.Background(), event("nobody@example.com"))
+	deactivatedErr := authService.SendMagicLink(context.Background(), event("gone@example.com"))
+
+	// THEN
+	assert.NoError(t, unknownErr)
+	assert.NoError(t, deactivatedErr)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxAvailabilityUsers = 20
+	maxAvailabilityRange = 31 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidUserIDs            = errors.New("user_ids must be a comma-separated list of user IDs")
+	ErrTooManyUsers              = errors.New("availability can be searched for at most 20 users")
+	ErrInvalidDuration           = errors.New("invalid duration, use a positive Go duration (e.g., 30m, 1h)")
+	ErrAvailabilityRangeTooLarge = errors.New("availability range must not exceed 31 days")
+)
+
+type AvailabilityService interface {
+	FindAvailability(organizationID uint, req *request.AvailabilityRequest) (*response.AvailabilityResponse, error)
+}
+
+type availabilityService struct {
+	appointmentRepository  repository.AppointmentRepository
+	userRepository         repository.UserRepository
+	calendarRepository     repository.CalendarRepository
+	workingHoursRepository repository.WorkingHoursRepository
+}
+
+func NewAvailabilityService(appointmentRepository repository.AppointmentRepository, userRepository repository.UserRepository, calendarRepository repository.CalendarRepository, workingHoursRepository repository.WorkingHoursRepository) AvailabilityService {
+	return &availabilityService{
+		appointmentRepository:  appointmentRepository,
+		userRepository:         userRepository,
+		calendarRepository:     calendarRepository,
+		workingHoursRepository: workingHoursRepository,
+	}
+}
+
+// FindAvailability returns the windows within [from, to) of at least the
+// requested duration in which all users are working and none of them has a
+// pending or confirmed appointment or time blocked in an imported calendar.
+// All users must belong to the organization; if any of them is deactivated
+// there are no windows.
+func (avs *availabilityService) FindAvailability(organizationID uint, req *request.AvailabilityRequest) (*response.AvailabilityResponse, error) {
+	userIDs, err := parseUserIDs(req.UserIDs)
+	if err != nil {
+		return nil, err
+	}
+	from, err := time.Parse(time.RFC3339, req.From)
+	if err != nil {
+		log.Warn().Err(err).Str("from", req.From).Msg("Failed to parse from time")
+		return nil, ErrInvalidTimeFormat
+	}
+	to, err := time.Parse(time.RFC3339, req.To)
+	if err != nil {
+		log.Warn().Err(err).Str("to", req.To).Msg("Failed to parse to time")
+		return nil, ErrInvalidTimeFormat
+	}
+	if !to.After(from) {
+		return nil, ErrEndTimeBeforeStartTime
+	}
+	if to.Sub(from) > maxAvailabilityRange {
+		return nil, ErrAvailabilityRangeTooLarge
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		return nil, ErrInvalidDuration
+	}
+
+	availability := &response.AvailabilityResponse{
+		UserIDs:  userIDs,
+		From:     from,
+		To:       to,
+		Duration: duration.String(),
+		Slots:    []response.TimeSlot{},
+	}
+	active := true
+	for _, userID := range userIDs {
+		user, err := avs.userRepository.GetById(organizationID, userID)
+		if err != nil {
+			log.Error().Err(err).Uint("userID", userID).Msg("Error fetching user for availability")
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrUserNotFound
+		}
+		active = active && user.Active()
+	}
+	if !active {
+		return availability, nil
+	}
+
+	busy, err := avs.appointmentRepository.FindBusyForUsers(userIDs, from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("Error fetching busy appointments")
+		return nil, err
+	}
+	blocks, err := avs.calendarRepository.FindBusyBlocksForUsers(userIDs, from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("Error fetching busy blocks")
+		return nil, err
+	}
+
+	intervals := busyIntervals(busy, blocks)
+	for _, userID := range userIDs {
+		windows, err := workingWindows(avs.workingHoursRepository, userID, from, to)
+		if err != nil {
+			log.Error().Err(err).Uint("userID", userID).Msg("Error fetching working hours")
+			return nil, err
+		}
+		// Whatever lies outside the user's working windows is busy too.
+		intervals = append(intervals, freeSlots(windows, from, to, 0)...)
+	}
+
+	availability.Slots = freeSlots(intervals, from, to, duration)
+	return availability, nil
+}
+
+func parseUserIDs(raw string) ([]uint, error) {
+	seen := make(map[uint]bool)
+	var userIDs []uint
+	for _, part := range strings.Split(raw, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err != nil || id == 0 {
+			return nil, ErrInvalidUserIDs
+		}
+		if !seen[uint(id)] {
+			seen[uint(id)] = true
+			userIDs = append(userIDs, uint(id))
+		}
+	}
+	if len(userIDs) > maxAvailabilityUsers {
+		return nil, ErrTooManyUsers
+	}
+	return userIDs, nil
+}
+
+func busyIntervals(appointments []model.Appointment, blocks []model.BusyBlock) []response.TimeSlot {
+	intervals := make([]response.TimeSlot, 0, len(appointments)+len(blocks))
+	for _, appointment := range appointments {
+		intervals = append(intervals, response.TimeSlot{Start: appointment.StartTime, End: appointment.EndTime})
+	}
+	for _, block := range blocks {
+		intervals = append(intervals, response.TimeSlot{Start: block.StartTime, End: block.EndTime})
+	}
+	return intervals
+}
+
+// freeSlots subtracts the busy intervals from [from, to) and keeps the gaps
+// that are at least duration long.
+func freeSlots(busy []response.TimeSlot, from, to time.Time, duration time.Duration) []response.TimeSlot {
+	sort.Slice(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+
+	slots := []response.TimeSlot{}
+	cursor := from
+	for _, interval := range busy {
+		if interval.Start.After(cursor) {
+			gapEnd := interval.Start
+			if gapEnd.After(to) {
+				gapEnd = to
+			}
+			if gapEnd.Sub(cursor) >= duration {
+				slots = append(slots, response.TimeSlot{Start: cursor, End: gapEnd})
+			}
+		}
+		if interval.End.After(cursor) {
+			cursor = interval.End
+		}
+		if !cursor.Before(to) {
+			return slots
+		}
+	}
+	if to.Sub(cursor) >= duration {
+		slots = append(slots, response.TimeSlot{Start: cursor, End: to})
+	}
+	return slots
+}
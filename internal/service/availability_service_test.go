@@ -0,0 +1,162 @@
+package service
+
+import (
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvailabilityService_FindAvailability_SubtractsBusyTime(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockCalendarRepo := mocks.NewMockCalendarRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	availabilityService := NewAvailabilityService(mockAppointmentRepo, mockUserRepo, mockCalendarRepo, mockWorkingHoursRepo)
+
+	at := func(hour, minute int) time.Time { return time.Date(2030, 1, 1, hour, minute, 0, 0, time.UTC) }
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(1)).Return(&model.User{ID: 1}, nil).Times(1)
+	mockUserRepo.EXPECT().GetById(uint(1), uint(2)).Return(&model.User{ID: 2}, nil).Times(1)
+	mockAppointmentRepo.EXPECT().FindBusyForUsers([]uint{1, 2}, at(9, 0), at(17, 0)).Return([]model.Appointment{
+		{UserID: 1, StartTime: at(8, 30), EndTime: at(9, 30)},
+		{ParticipantID: 2, StartTime: at(10, 0), EndTime: at(11, 0)},
+		{UserID: 2, StartTime: at(10, 30), EndTime: at(12, 0)},
+		{ParticipantID: 1, StartTime: at(12, 15), EndTime: at(16, 0)},
+	}, nil).Times(1)
+	mockCalendarRepo.EXPECT().FindBusyBlocksForUsers([]uint{1, 2}, at(9, 0), at(17, 0)).Return([]model.BusyBlock{
+		{UserID: 2, StartTime: at(16, 30), EndTime: at(17, 30)},
+	}, nil).Times(1)
+	mockWorkingHoursRepo.EXPECT().ListOverridesBetween(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	mockWorkingHoursRepo.EXPECT().ListWindows(gomock.Any()).Return(nil, nil).Times(2)
+
+	// WHEN
+	availability, err := availabilityService.FindAvailability(1, &request.AvailabilityRequest{
+		UserIDs:  "1, 2,1",
+		From:     "2030-01-01T09:00:00Z",
+		To:       "2030-01-01T17:00:00Z",
+		Duration: "30m",
+	})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1, 2}, availability.UserIDs)
+	assert.Equal(t, []response.TimeSlot{
+		{Start: at(9, 30), End: at(10, 0)},
+		{Start: at(16, 0), End: at(16, 30)},
+	}, availability.Slots)
+}
+
+func TestAvailabilityService_FindAvailability_WithinWorkingHours(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockCalendarRepo := mocks.NewMockCalendarRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	availabilityService := NewAvailabilityService(mockAppointmentRepo, mockUserRepo, mockCalendarRepo, mockWorkingHoursRepo)
+
+	// 2030-01-07 is a Monday and 2030-01-08 a Tuesday.
+	at := func(day, hour int) time.Time { return time.Date(2030, 1, day, hour, 0, 0, 0, time.UTC) }
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(1)).Return(&model.User{ID: 1}, nil)
+	mockUserRepo.EXPECT().GetById(uint(1), uint(2)).Return(&model.User{ID: 2}, nil)
+	mockAppointmentRepo.EXPECT().FindBusyForUsers([]uint{1, 2}, at(7, 0), at(9, 0)).Return(nil, nil)
+	mockCalendarRepo.EXPECT().FindBusyBlocksForUsers([]uint{1, 2}, at(7, 0), at(9, 0)).Return(nil, nil)
+	mockWorkingHoursRepo.EXPECT().ListOverridesBetween(uint(1), "2030-01-06", "2030-01-10").Return(nil, nil)
+	mockWorkingHoursRepo.EXPECT().ListWindows(uint(1)).Return([]model.WorkingHours{
+		{UserID: 1, Weekday: int(time.Monday), StartTime: "09:00", EndTime: "17:00", Timezone: "UTC"},
+		{UserID: 1, Weekday: int(time.Tuesday), StartTime: "09:00", EndTime: "17:00", Timezone: "UTC"},
+	}, nil)
+	mockWorkingHoursRepo.EXPECT().ListOverridesBetween(uint(2), "2030-01-06", "2030-01-10").Return([]model.AvailabilityOverride{
+		{UserID: 2, Date: "2030-01-08", StartTime: "13:00", EndTime: "15:00", Timezone: "UTC"},
+	}, nil)
+	mockWorkingHoursRepo.EXPECT().ListWindows(uint(2)).Return([]model.WorkingHours{
+		{UserID: 2, Weekday: int(time.Monday), StartTime: "12:00", EndTime: "20:00", Timezone: "UTC"},
+		{UserID: 2, Weekday: int(time.Tuesday), StartTime: "12:00", EndTime: "20:00", Timezone: "UTC"},
+	}, nil)
+
+	// WHEN
+	availability, err := availabilityService.FindAvailability(1, &request.AvailabilityRequest{
+		UserIDs:  "1,2",
+		From:     "2030-01-07T00:00:00Z",
+		To:       "2030-01-09T00:00:00Z",
+		Duration: "1h",
+	})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, []response.TimeSlot{
+		{Start: at(7, 12), End: at(7, 17)},
+		{Start: at(8, 13), End: at(8, 15)},
+	}, availability.Slots)
+}
+
+func TestAvailabilityService_FindAvailability_InactiveUser(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	availabilityService := NewAvailabilityService(mocks.NewMockAppointmentRepository(ctrl), mockUserRepo, mocks.NewMockCalendarRepository(ctrl), mocks.NewMockWorkingHoursRepository(ctrl))
+	deactivatedAt := time.Date(2029, 12, 1, 9, 0, 0, 0, time.UTC)
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(1)).Return(&model.User{ID: 1}, nil)
+	mockUserRepo.EXPECT().GetById(uint(1), uint(2)).Return(&model.User{ID: 2, DeactivatedAt: &deactivatedAt}, nil)
+
+	// WHEN
+	availability, err := availabilityService.FindAvailability(1, &request.AvailabilityRequest{
+		UserIDs:  "1,2",
+		From:     "2030-01-07T09:00:00Z",
+		To:       "2030-01-07T17:00:00Z",
+		Duration: "30m",
+	})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Empty(t, availability.Slots)
+}
+
+func TestAvailabilityService_FindAvailability_InvalidInput(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockCalendarRepo := mocks.NewMockCalendarRepository(ctrl)
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	availabilityService := NewAvailabilityService(mockAppointmentRepo, mockUserRepo, mockCalendarRepo, mockWorkingHoursRepo)
+
+	valid := request.AvailabilityRequest{
+		UserIDs:  "1",
+		From:     "2030-01-01T09:00:00Z",
+		To:       "2030-01-01T17:00:00Z",
+		Duration: "30m",
+	}
+	cases := map[error]func(req *request.AvailabilityRequest){
+		ErrInvalidUserIDs:            func(req *request.AvailabilityRequest) { req.UserIDs = "1,abc" },
+		ErrInvalidDuration:           func(req *request.AvailabilityRequest) { req.Duration = "-5m" },
+		ErrEndTimeBeforeStartTime:    func(req *request.AvailabilityRequest) { req.To = req.From },
+		ErrAvailabilityRangeTooLarge: func(req *request.AvailabilityRequest) { req.To = "2030-03-01T00:00:00Z" },
+	}
+
+	for expected, mutate := range cases {
+		req := valid
+		mutate(&req)
+
+		// WHEN
+		availability, err := availabilityService.FindAvailability(1, &req)
+
+		// THEN
+		assert.Nil(t, availability)
+		assert.Equal(t, expected, err)
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"queue_system/internal/calendarsync"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/ical"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBusyCalendarNotFound     = errors.New("imported calendar not found")
+	ErrInvalidCalendarFile      = errors.New("file is not an iCalendar (.ics) file")
+	ErrCalendarURLNotAllowed    = errors.New("calendar url must be a public http, https or webcal url")
+	ErrCalendarFetchFailed      = errors.New("failed to fetch calendar")
+	ErrImportBusyCalendarFailed = errors.New("failed to import calendar")
+)
+
+// BusyCalendarService manages the calendars users import so that their
+// events block time that could otherwise be booked.
+type BusyCalendarService interface {
+	ImportBusyCalendar(organizationID, userID uint, name string, data []byte) (*model.BusyCalendar, error)
+	SubscribeBusyCalendar(ctx context.Context, organizationID, userID uint, req *request.SubscribeBusyCalendarRequest) (*model.BusyCalendar, error)
+	ListBusyCalendars(organizationID, userID uint) ([]model.BusyCalendar, error)
+	DeleteBusyCalendar(organizationID, userID, calendarID uint) error
+}
+
+type busyCalendarService struct {
+	calendarRepository repository.CalendarRepository
+	userRepository     repository.UserRepository
+	syncer             *calendarsync.Syncer
+	db                 *gorm.DB
+	now                func() time.Time
+}
+
+func NewBusyCalendarService(calendarRepository repository.CalendarRepository, userRepository repository.UserRepository, syncer *calendarsync.Syncer, db *gorm.DB) BusyCalendarService {
+	return &busyCalendarService{
+		calendarRepository: calendarRepository,
+		userRepository:     userRepository,
+		syncer:             syncer,
+		db:                 db,
+		now:                time.Now,
+	}
+}
+
+// ImportBusyCalendar stores an uploaded calendar and blocks the time of its
+// events.
+func (bs *busyCalendarService) ImportBusyCalendar(organizationID, userID uint, name string, data []byte) (*model.BusyCalendar, error) {
+	if err := bs.ensureUserExists(organizationID, userID); err != nil {
+		return nil, err
+	}
+	calendar := &model.BusyCalendar{UserID: userID, Name: name, Data: string(data)}
+	if err := bs.importCalendar(calendar, data); err != nil {
+		return nil, err
+	}
+	return calendar, nil
+}
+
+// SubscribeBusyCalendar fetches the calendar at req.URL once to check it,
+// then keeps it refreshed in the background. Why a fetch failed is only
+// logged, so that the endpoint cannot be used to probe other servers.
+func (bs *busyCalendarService) SubscribeBusyCalendar(ctx context.Context, organizationID, userID uint, req *request.SubscribeBusyCalendarRequest) (*model.BusyCalendar, error) {
+	if err := bs.ensureUserExists(organizationID, userID); err != nil {
+		return nil, err
+	}
+	if err := bs.syncer.CheckURL(ctx, req.URL); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCalendarURLNotAllowed, err)
+	}
+	data, err := bs.syncer.Fetch(ctx, req.URL)
+	if err != nil {
+		log.Warn().Err(err).Uint("userID", userID).Msg("Error fetching calendar to subscribe to")
+		return nil, ErrCalendarFetchFailed
+	}
+
+	calendar := &model.BusyCalendar{UserID: userID, Name: req.Name, URL: req.URL}
+	if err := bs.importCalendar(calendar, data); err != nil {
+		return nil, err
+	}
+	return calendar, nil
+}
+
+func (bs *busyCalendarService) importCalendar(calendar *model.BusyCalendar, data []byte) error {
+	tx := bs.db.Begin()
+	if err := bs.syncer.Import(tx, calendar, data, bs.now()); err != nil {
+		tx.Rollback()
+		if errors.Is(err, ical.ErrInvalidCalendar) {
+			return ErrInvalidCalendarFile
+		}
+		log.Error().Err(err).Uint("userID", calendar.UserID).Msg("Error importing calendar")
+		return ErrImportBusyCalendarFailed
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return ErrImportBusyCalendarFailed
+	}
+	return nil
+}
+
+func (bs *busyCalendarService) ListBusyCalendars(organizationID, userID uint) ([]model.BusyCalendar, error) {
+	if err := bs.ensureUserExists(organizationID, userID); err != nil {
+		return nil, err
+	}
+	calendars, err := bs.calendarRepository.ListBusyCalendars(userID)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error listing imported calendars")
+		return nil, err
+	}
+	return calendars, nil
+}
+
+// DeleteBusyCalendar removes the calendar and frees the time it blocked.
+func (bs *busyCalendarService) DeleteBusyCalendar(organizationID, userID, calendarID uint) error {
+	if err := bs.ensureUserExists(organizationID, userID); err != nil {
+		return err
+	}
+	calendar, err := bs.calendarRepository.GetBusyCalendar(calendarID)
+	if err != nil {
+		log.Error().Err(err).Uint("calendarID", calendarID).Msg("Error fetching imported calendar")
+		return err
+	}
+	if calendar == nil || calendar.UserID != userID {
+		return ErrBusyCalendarNotFound
+	}
+	deleted, err := bs.calendarRepository.DeleteBusyCalendar(calendarID)
+	if err != nil {
+		log.Error().Err(err).Uint("calendarID", calendarID).Msg("Error deleting imported calendar")
+		return err
+	}
+	if !deleted {
+		return ErrBusyCalendarNotFound
+	}
+	return nil
+}
+
+func (bs *busyCalendarService) ensureUserExists(organizationID, userID uint) error {
+	user, err := bs.userRepository.GetById(organizationID, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error fetching user")
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"queue_system/config"
+	"queue_system/internal/calendarsync"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusyCalendarService_DeleteBusyCalendar_OnlyOwnCalendars(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockCalendarRepo := mocks.NewMockCalendarRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	busyCalendarService := NewBusyCalendarService(mockCalendarRepo, mockUserRepo, nil, nil)
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(1)).Return(&model.User{ID: 1}, nil).Times(2)
+	mockUserRepo.EXPECT().GetById(uint(1), uint(2)).Return(&model.User{ID: 2}, nil).Times(1)
+	mockCalendarRepo.EXPECT().GetBusyCalendar(uint(5)).Return(&model.BusyCalendar{ID: 5, UserID: 1}, nil).Times(2)
+	mockCalendarRepo.EXPECT().GetBusyCalendar(uint(6)).Return(nil, nil).Times(1)
+	mockCalendarRepo.EXPECT().DeleteBusyCalendar(uint(5)).Return(true, nil).Times(1)
+
+	// WHEN
+	otherUserErr := busyCalendarService.DeleteBusyCalendar(1, 2, 5)
+	missingErr := busyCalendarService.DeleteBusyCalendar(1, 1, 6)
+	ownerErr := busyCalendarService.DeleteBusyCalendar(1, 1, 5)
+
+	// THEN
+	assert.Equal(t, ErrBusyCalendarNotFound, otherUserErr)
+	assert.Equal(t, ErrBusyCalendarNotFound, missingErr)
+	assert.NoError(t, ownerErr)
+}
+
+func TestBusyCalendarService_ImportBusyCalendar_UnknownUser(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockCalendarRepo := mocks.NewMockCalendarRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	busyCalendarService := NewBusyCalendarService(mockCalendarRepo, mockUserRepo, nil, nil)
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(9)).Return(nil, nil).Times(1)
+
+	// WHEN
+	calendar, err := busyCalendarService.ImportBusyCalendar(1, 9, "personal.ics", []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"))
+
+	// THEN
+	assert.Nil(t, calendar)
+	assert.Equal(t, ErrUserNotFound, err)
+}
+
+func TestBusyCalendarService_SubscribeBusyCalendar_PrivateAddress(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockCalendarRepo := mocks.NewMockCalendarRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	syncer := calendarsync.NewSyncer(nil, mockCalendarRepo, &config.Config{})
+	busyCalendarService := NewBusyCalendarService(mockCalendarRepo, mockUserRepo, syncer, nil)
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(1)).Return(&model.User{ID: 1}, nil).Times(1)
+
+	// WHEN
+	calendar, err := busyCalendarService.SubscribeBusyCalendar(context.Background(), 1, 1, &request.SubscribeBusyCalendarRequest{URL: "http://169.254.169.254/latest/meta-data"})
+
+	// THEN
+	assert.Nil(t, calendar)
+	assert.ErrorIs(t, err, ErrCalendarURLNotAllowed)
+}
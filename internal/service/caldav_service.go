@@ -0,0 +1,338 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"queue_system/config"
+	"queue_system/internal/auth"
+	"queue_system/internal/caldav"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/enums"
+	"queue_system/internal/ical"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrCalendarObjectNotFound     = errors.New("calendar object not found")
+	ErrCalendarPreconditionFailed = errors.New("calendar object has changed")
+	ErrInvalidCalendarObject      = errors.New("calendar object must hold a single event with a start and end time")
+	ErrCalendarAttendeeRequired   = errors.New("exactly one attendee must be a registered user")
+)
+
+// CalDAVService serves a user's appointments as the objects of a CalDAV
+// collection. Objects are named appointment-<id>.ics unless a calendar
+// client created them under another name. Bookings and changes go through
+// AppointmentService, so they are validated like any other.
+type CalDAVService interface {
+	GetCalendarOwner(organizationID, userID uint) (*model.User, error)
+	ListObjects(organizationID, userID uint) ([]caldav.Object, error)
+	GetObject(organizationID, userID uint, name string) (*caldav.Object, error)
+	PutObject(organizationID, userID uint, name string, data []byte, ifMatch, ifNoneMatch string) (bool, error)
+	DeleteObject(organizationID, userID uint, name, ifMatch string) error
+}
+
+type caldavService struct {
+	appointmentService    AppointmentService
+	appointmentRepository repository.AppointmentRepository
+	userRepository        repository.UserRepository
+	uidDomain             string
+	now                   func() time.Time
+}
+
+func NewCalDAVService(appointmentService AppointmentService, appointmentRepository repository.AppointmentRepository, userRepository repository.UserRepository, cfg *config.Config) CalDAVService {
+	return &caldavService{
+		appointmentService:    appointmentService,
+		appointmentRepository: appointmentRepository,
+		userRepository:        userRepository,
+		uidDomain:             cfg.Calendar.UIDDomain,
+		now:                   time.Now,
+	}
+}
+
+func (cs *caldavService) GetCalendarOwner(organizationID, userID uint) (*model.User, error) {
+	user, err := cs.userRepository.GetById(organizationID, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error fetching user")
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// ListObjects returns the user's open appointments that ended within
+// feedHistory. Cancelled appointments are left out, so clients remove them.
+func (cs *caldavService) ListObjects(organizationID, userID uint) ([]caldav.Object, error) {
+	if _, err := cs.GetCalendarOwner(organizationID, userID); err != nil {
+		return nil, err
+	}
+	appointments, err := cs.appointmentRepository.FindForUserEndingAfter(userID, cs.now().Add(-feedHistory))
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error fetching appointments for CalDAV")
+		return nil, err
+	}
+	open := appointments[:0]
+	for _, appointment := range appointments {
+		if appointment.Status != string(enums.Cancelled) {
+			open = append(open, appointment)
+		}
+	}
+	return cs.objects(organizationID, open)
+}
+
+func (cs *caldavService) GetObject(organizationID, userID uint, name string) (*caldav.Object, error) {
+	appointment, err := cs.findAppointment(organizationID, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if appointment == nil {
+		return nil, ErrCalendarObjectNotFound
+	}
+	objects, err := cs.objects(organizationID, []model.Appointment{*appointment})
+	if err != nil {
+		return nil, err
+	}
+	return &objects[0], nil
+}
+
+// PutObject books the event in data as an appointment organized by the
+// user with the attendee who is a registered user, or applies it to the
+// appointment already stored under name. Only the time, summary and status
+// of an existing appointment can be changed this way. STATUS:CONFIRMED
+// confirms a pending appointment; a new one stays pending unless the user
+// may host it. It reports whether an appointment was created.
+func (cs *caldavService) PutObject(organizationID, userID uint, name string, data []byte, ifMatch, ifNoneMatch string) (bool, error) {
+	event, err := ical.ReadEvent(data)
+	if err != nil {
+		return false, ErrInvalidCalendarObject
+	}
+	owner, err := cs.GetCalendarOwner(organizationID, userID)
+	if err != nil {
+		return false, err
+	}
+	appointment, err := cs.findAppointment(organizationID, userID, name)
+	if err != nil {
+		return false, err
+	}
+
+	if appointment == nil {
+		if ifMatch != "" {
+			return false, ErrCalendarPreconditionFailed
+		}
+		participant, err := cs.findParticipant(owner, event)
+		if err != nil {
+			return false, err
+		}
+		created, err := cs.appointmentService.CreateAppointment(owner, &request.AppointmentRequest{
+			UserID:        owner.ID,
+			ParticipantID: participant.ID,
+			StartTime:     event.Start.Format(time.RFC3339),
+			EndTime:       event.End.Format(time.RFC3339),
+			Description:   eventDescription(event, owner, participant),
+			ExternalUID:   event.UID,
+			ResourceName:  name,
+		})
+		if err != nil {
+			return false, err
+		}
+		if event.Status == "CONFIRMED" && auth.CanHostAppointment(owner, created) == nil {
+			_, err = cs.appointmentService.ConfirmAppointment(organizationID, created.ID, owner.ID)
+		}
+		return true, err
+	}
+
+	if ifNoneMatch == "*" || (ifMatch != "" && ifMatch != objectETag(appointment)) {
+		return false, ErrCalendarPreconditionFailed
+	}
+	confirm := event.Status == "CONFIRMED" && appointment.Status == string(enums.Pending)
+	if confirm {
+		if err := auth.CanHostAppointment(owner, appointment); err != nil {
+			return false, err
+		}
+	}
+	if event.Status == "CANCELLED" {
+		_, err := cs.appointmentService.CancelAppointment(organizationID, appointment.ID, owner.ID)
+		return false, err
+	}
+
+	var update request.UpdateAppointmentRequest
+	if !event.Start.Equal(appointment.StartTime) {
+		start := event.Start.Format(time.RFC3339)
+		update.StartTime = &start
+	}
+	if !event.End.Equal(appointment.EndTime) {
+		end := event.End.Format(time.RFC3339)
+		update.EndTime = &end
+	}
+	organizer, participant, err := cs.appointmentUsers(appointment)
+	if err != nil {
+		return false, err
+	}
+	if description := eventDescription(event, organizer, participant); description != appointment.Description {
+		update.Description = &description
+	}
+	if update.StartTime != nil || update.EndTime != nil || update.Description != nil {
+		if _, err := cs.appointmentService.UpdateAppointment(organizationID, appointment.ID, owner.ID, &update); err != nil {
+			return false, err
+		}
+	}
+	if confirm {
+		_, err = cs.appointmentService.ConfirmAppointment(organizationID, appointment.ID, owner.ID)
+	}
+	return false, err
+}
+
+// DeleteObject cancels the appointment stored under name.
+func (cs *caldavService) DeleteObject(organizationID, userID uint, name, ifMatch string) error {
+	appointment, err := cs.findAppointment(organizationID, userID, name)
+	if err != nil {
+		return err
+	}
+	if appointment == nil {
+		return ErrCalendarObjectNotFound
+	}
+	if ifMatch != "" && ifMatch != objectETag(appointment) {
+		return ErrCalendarPreconditionFailed
+	}
+	_, err = cs.appointmentService.CancelAppointment(organizationID, appointment.ID, userID)
+	return err
+}
+
+// findAppointment returns the open appointment of userID stored under name,
+// or nil if there is none.
+func (cs *caldavService) findAppointment(organizationID, userID uint, name string) (*model.Appointment, error) {
+	appointment, err := cs.appointmentRepository.FindByResourceName(userID, name)
+	if err != nil {
+		log.Error().Err(err).Str("name", name).Msg("Error fetching appointment by resource name")
+		return nil, err
+	}
+	if appointment == nil {
+		id, ok := appointmentIDFromName(name)
+		if !ok {
+			return nil, nil
+		}
+		appointment, err = cs.appointmentRepository.GetByID(organizationID, id)
+		if err != nil {
+			log.Error().Err(err).Uint("appointmentID", id).Msg("Error fetching appointment")
+			return nil, err
+		}
+		if appointment == nil || appointment.ResourceName != "" ||
+			(appointment.UserID != userID && appointment.ParticipantID != userID) {
+			return nil, nil
+		}
+	}
+	if appointment.Status == string(enums.Cancelled) {
+		return nil, nil
+	}
+	return appointment, nil
+}
+
+// findParticipant returns the one attendee of event, other than owner, who
+// is a registered user.
+func (cs *caldavService) findParticipant(owner *model.User, event *ical.Incoming) (*model.User, error) {
+	var participant *model.User
+	for _, email := range event.Attendees {
+		if strings.EqualFold(email, owner.Email) {
+			continue
+		}
+		user, err := cs.userRepository.GetByEmail(owner.OrganizationID, email)
+		if err != nil {
+			log.Error().Err(err).Msg("Error fetching attendee")
+			return nil, err
+		}
+		if user == nil {
+			continue
+		}
+		if participant != nil && participant.ID != user.ID {
+			return nil, ErrCalendarAttendeeRequired
+		}
+		participant = user
+	}
+	if participant == nil {
+		return nil, ErrCalendarAttendeeRequired
+	}
+	if !participant.Active() {
+		return nil, ErrUserDeactivated
+	}
+	return participant, nil
+}
+
+func (cs *caldavService) appointmentUsers(appointment *model.Appointment) (*model.User, *model.User, error) {
+	users, err := cs.userRepository.GetByIds(appointment.OrganizationID, []uint{appointment.UserID, appointment.ParticipantID})
+	if err != nil {
+		log.Error().Err(err).Uint("appointmentID", appointment.ID).Msg("Error fetching appointment users")
+		return nil, nil, err
+	}
+	organizer, participant := &model.User{}, &model.User{}
+	for i := range users {
+		if users[i].ID == appointment.UserID {
+			organizer = &users[i]
+		}
+		if users[i].ID == appointment.ParticipantID {
+			participant = &users[i]
+		}
+	}
+	return organizer, participant, nil
+}
+
+func (cs *caldavService) objects(organizationID uint, appointments []model.Appointment) ([]caldav.Object, error) {
+	calendarEvents, err := appointmentEvents(cs.userRepository, organizationID, appointments, cs.uidDomain)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]caldav.Object, 0, len(appointments))
+	for i := range appointments {
+		// Calendar object resources hold one event and no METHOD.
+		calendar := ical.Calendar{Events: calendarEvents[i : i+1]}
+		objects = append(objects, caldav.Object{
+			Name:  objectName(&appointments[i]),
+			ETag:  objectETag(&appointments[i]),
+			Start: appointments[i].StartTime,
+			End:   appointments[i].EndTime,
+			Data:  calendar.Encode(),
+		})
+	}
+	return objects, nil
+}
+
+func objectName(appointment *model.Appointment) string {
+	if appointment.ResourceName != "" {
+		return appointment.ResourceName
+	}
+	return fmt.Sprintf("appointment-%d.ics", appointment.ID)
+}
+
+func appointmentIDFromName(name string) (uint, bool) {
+	rest, ok := strings.CutPrefix(name, "appointment-")
+	if !ok {
+		return 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, ".ics")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(rest, 10, 32)
+	return uint(id), err == nil
+}
+
+// objectETag changes with every update of the appointment.
+func objectETag(appointment *model.Appointment) string {
+	return fmt.Sprintf(`"%d-%d"`, appointment.ID, appointment.UpdatedAt.UnixMicro())
+}
+
+// eventDescription is what the appointment's description should be after
+// event: its summary, unless that is the one generated for appointments
+// without a description.
+func eventDescription(event *ical.Incoming, organizer, participant *model.User) string {
+	if event.Summary != "" && event.Summary != ical.DefaultSummary(organizer, participant) {
+		return event.Summary
+	}
+	return event.Description
+}
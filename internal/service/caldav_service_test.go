@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"queue_system/config"
+	"queue_system/internal/auth"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCalDAVService(ctrl *gomock.Controller) (CalDAVService, *mocks.MockAppointmentRepository, *mocks.MockUserRepository) {
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	cfg := &config.Config{}
+	cfg.Calendar.UIDDomain = "example.com"
+	return NewCalDAVService(nil, mockAppointmentRepo, mockUserRepo, cfg), mockAppointmentRepo, mockUserRepo
+}
+
+func calendarObject(lines ...string) []byte {
+	return []byte(strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0", "BEGIN:VEVENT"}, lines...), "END:VEVENT", "END:VCALENDAR"), "\r\n"))
+}
+
+func TestCalDAVService_GetObject_ByGeneratedName(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	caldavService, mockAppointmentRepo, mockUserRepo := newTestCalDAVService(ctrl)
+
+	start := time.Date(2030, 6, 3, 14, 0, 0, 0, time.UTC)
+	updated := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	mockAppointmentRepo.EXPECT().FindByResourceName(uint(2), "appointment-7.ics").Return(nil, nil).Times(1)
+	mockAppointmentRepo.EXPECT().GetByID(uint(1), uint(7)).Return(&model.Appointment{
+		ID: 7, UserID: 1, ParticipantID: 2, StartTime: start, EndTime: start.Add(time.Hour), Status: "confirmed", UpdatedAt: updated,
+	}, nil).Times(1)
+	mockUserRepo.EXPECT().GetByIds(uint(1), []uint{1, 2}).Return([]model.User{
+		{ID: 1, Name: "Dr. Smith", Email: "smith@example.com"},
+		{ID: 2, Name: "Ana", Email: "ana@example.com"},
+	}, nil).Times(1)
+
+	// WHEN
+	object, err := caldavService.GetObject(1, 2, "appointment-7.ics")
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "appointment-7.ics", object.Name)
+	assert.Equal(t, fmt.Sprintf(`"7-%d"`, updated.UnixMicro()), object.ETag)
+	assert.Contains(t, string(object.Data), "UID:appointment-7@example.com\r\n")
+	assert.NotContains(t, string(object.Data), "METHOD:")
+}
+
+func TestCalDAVService_GetObject_HidesOtherUsersAndCancelledAppointments(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	caldavService, mockAppointmentRepo, _ := newTestCalDAVService(ctrl)
+
+	mockAppointmentRepo.EXPECT().FindByResourceName(uint(3), "appointment-7.ics").Return(nil, nil).Times(1)
+	mockAppointmentRepo.EXPECT().GetByID(uint(1), uint(7)).Return(&model.Appointment{ID: 7, UserID: 1, ParticipantID: 2, Status: "confirmed"}, nil).Times(1)
+	mockAppointmentRepo.EXPECT().FindByResourceName(uint(1), "client.ics").Return(&model.Appointment{ID: 8, UserID: 1, ParticipantID: 2, Status: "cancelled"}, nil).Times(1)
+
+	// WHEN
+	_, otherUserErr := caldavService.GetObject(1, 3, "appointment-7.ics")
+	_, cancelledErr := caldavService.GetObject(1, 1, "client.ics")
+
+	// THEN
+	assert.Equal(t, ErrCalendarObjectNotFound, otherUserErr)
+	assert.Equal(t, ErrCalendarObjectNotFound, cancelledErr)
+}
+
+func TestCalDAVService_PutObject_RejectsInvalidData(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	caldavService, _, _ := newTestCalDAVService(ctrl)
+
+	// WHEN
+	created, err := caldavService.PutObject(1, 1, "client.ics", calendarObject("DTSTART;VALUE=DATE:20300603"), "", "")
+
+	// THEN
+	assert.Equal(t, ErrInvalidCalendarObject, err)
+	assert.False(t, created)
+}
+
+func TestCalDAVService_PutObject_RequiresRegisteredAttendee(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	caldavService, mockAppointmentRepo, mockUserRepo := newTestCalDAVService(ctrl)
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(1)).Return(&model.User{ID: 1, OrganizationID: 1, Email: "smith@example.com"}, nil).Times(1)
+	mockAppointmentRepo.EXPECT().FindByResourceName(uint(1), "client.ics").Return(nil, nil).Times(1)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), "stranger@example.com").Return(nil, nil).Times(1)
+	data := calendarObject(
+		"UID:client-1",
+		"DTSTART:20300603T140000Z",
+		"DTEND:20300603T150000Z",
+		"ATTENDEE:mailto:smith@example.com",
+		"ATTENDEE:mailto:stranger@example.com",
+	)
+
+	// WHEN
+	created, err := caldavService.PutObject(1, 1, "client.ics", data, "", "")
+
+	// THEN
+	assert.Equal(t, ErrCalendarAttendeeRequired, err)
+	assert.False(t, created)
+}
+
+func TestCalDAVService_PutObject_ChecksPreconditions(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	caldavService, mockAppointmentRepo, mockUserRepo := newTestCalDAVService(ctrl)
+
+	data := calendarObject("UID:client-1", "DTSTART:20300603T140000Z", "DTEND:20300603T150000Z")
+	existing := &model.Appointment{ID: 8, UserID: 1, ParticipantID: 2, Status: "confirmed", ResourceName: "client.ics"}
+	mockUserRepo.EXPECT().GetById(uint(1), uint(1)).Return(&model.User{ID: 1}, nil).Times(3)
+	mockAppointmentRepo.EXPECT().FindByResourceName(uint(1), "client.ics").Return(existing, nil).Times(2)
+	mockAppointmentRepo.EXPECT().FindByResourceName(uint(1), "new.ics").Return(nil, nil).Times(1)
+
+	// WHEN
+	_, staleErr := caldavService.PutObject(1, 1, "client.ics", data, `"8-0"`, "")
+	_, existsErr := caldavService.PutObject(1, 1, "client.ics", data, "", "*")
+	_, missingErr := caldavService.PutObject(1, 1, "new.ics", data, `"8-0"`, "")
+
+	// THEN
+	assert.Equal(t, ErrCalendarPreconditionFailed, staleErr)
+	assert.Equal(t, ErrCalendarPreconditionFailed, existsErr)
+	assert.Equal(t, ErrCalendarPreconditionFailed, missingErr)
+}
+
+func TestCalDAVService_PutObject_OnlyHostsConfirm(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	caldavService, mockAppointmentRepo, mockUserRepo := newTestCalDAVService(ctrl)
+
+	data := calendarObject("UID:client-1", "DTSTART:20300603T140000Z", "DTEND:20300603T150000Z", "STATUS:CONFIRMED")
+	pending := &model.Appointment{ID: 8, UserID: 1, ParticipantID: 2, Status: "pending", ResourceName: "client.ics"}
+	mockUserRepo.EXPECT().GetById(uint(1), uint(1)).Return(&model.User{ID: 1, Role: "client"}, nil).Times(1)
+	mockAppointmentRepo.EXPECT().FindByResourceName(uint(1), "client.ics").Return(pending, nil).Times(1)
+
+	// WHEN
+	created, err := caldavService.PutObject(1, 1, "client.ics", data, "", "")
+
+	// THEN
+	assert.False(t, created)
+	assert.Equal(t, auth.ErrNotAppointmentHost, err)
+}
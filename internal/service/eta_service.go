@@ -0,0 +1,128 @@
+package service
+
+import (
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/enums"
+	"queue_system/internal/eta"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type ETAService interface {
+	GetAppointmentETA(organizationID, id uint, req *request.AppointmentETARequest) (*response.AppointmentETAResponse, error)
+}
+
+type etaService struct {
+	appointmentRepository repository.AppointmentRepository
+	estimator             eta.Estimator
+	now                   func() time.Time
+}
+
+func NewETAService(appointmentRepository repository.AppointmentRepository, estimator eta.Estimator) ETAService {
+	return &etaService{
+		appointmentRepository: appointmentRepository,
+		estimator:             estimator,
+		now:                   time.Now,
+	}
+}
+
+// GetAppointmentETA predicts when the appointment will actually start by
+// replaying the participant's earlier appointments that day, stretching
+// those not yet finished by the overrun the estimator expects.
+func (es *etaService) GetAppointmentETA(organizationID, id uint, req *request.AppointmentETARequest) (*response.AppointmentETAResponse, error) {
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	appointment, err := es.appointmentRepository.GetByID(organizationID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error fetching appointment for ETA")
+		return nil, err
+	}
+	if appointment == nil {
+		return nil, ErrAppointmentNotFound
+	}
+	if enums.AppointmentStatus(appointment.Status).IsTerminal() {
+		return nil, ErrAppointmentClosed
+	}
+
+	result := &response.AppointmentETAResponse{
+		AppointmentID:  appointment.ID,
+		ScheduledStart: appointment.StartTime,
+	}
+	if appointment.ActualStartAt != nil {
+		result.EstimatedStart = *appointment.ActualStartAt
+		result.DelayMinutes = int(result.EstimatedStart.Sub(appointment.StartTime).Minutes())
+		return result, nil
+	}
+
+	local := appointment.StartTime.In(loc)
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	earlier, err := es.appointmentRepository.FindActiveForUser(appointment.ParticipantID, dayStart, appointment.StartTime)
+	if err != nil {
+		log.Error().Err(err).Uint("participantID", appointment.ParticipantID).Msg("Error fetching earlier appointments")
+		return nil, err
+	}
+
+	result.EstimatedStart, result.Samples = predictStart(appointment, earlier, es.estimator, es.now())
+	result.DelayMinutes = int(result.EstimatedStart.Sub(appointment.StartTime).Minutes())
+	return result, nil
+}
+
+// predictStart walks the earlier appointments in start order to find when
+// the participant becomes free. Finished appointments end when they did,
+// the rest take their scheduled length plus the estimated overrun and never
+// end before now.
+func predictStart(target *model.Appointment, earlier []model.Appointment, estimator eta.Estimator, now time.Time) (time.Time, int) {
+	var samples []time.Duration
+	for _, appointment := range earlier {
+		if appointment.ActualStartAt != nil && appointment.ActualEndAt != nil {
+			actual := appointment.ActualEndAt.Sub(*appointment.ActualStartAt)
+			samples = append(samples, actual-appointment.EndTime.Sub(appointment.StartTime))
+		}
+	}
+	overrun := estimator.EstimateOverrun(samples)
+
+	var freeAt time.Time
+	for _, appointment := range earlier {
+		if appointment.ID == target.ID || !appointment.StartTime.Before(target.StartTime) {
+			continue
+		}
+		expected := appointment.EndTime.Sub(appointment.StartTime) + overrun
+		if expected < 0 {
+			expected = 0
+		}
+
+		var end time.Time
+		switch {
+		case appointment.ActualEndAt != nil:
+			end = *appointment.ActualEndAt
+		case appointment.Status == string(enums.Completed) && appointment.CompletedAt != nil:
+			end = *appointment.CompletedAt
+		case appointment.ActualStartAt != nil:
+			end = latest(appointment.ActualStartAt.Add(expected), now)
+		default:
+			end = latest(appointment.StartTime, freeAt, now).Add(expected)
+		}
+		freeAt = latest(freeAt, end)
+	}
+	return latest(target.StartTime, freeAt, now), len(samples)
+}
+
+func latest(first time.Time, rest ...time.Time) time.Time {
+	for _, t := range rest {
+		if t.After(first) {
+			first = t
+		}
+	}
+	return first
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"queue_system/internal/dto/request"
+	"queue_system/internal/eta"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETAService_GetAppointmentETA_CarriesOverrunForward(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+
+	at := func(hour, minute int) time.Time { return time.Date(2030, 1, 7, hour, minute, 0, 0, time.UTC) }
+	ptr := func(t time.Time) *time.Time { return &t }
+	participantID := uint(9)
+
+	// The 09:00 ran 15 minutes over and the 09:30 started late at 09:45.
+	earlier := []model.Appointment{
+		{ID: 1, ParticipantID: participantID, Status: "completed", StartTime: at(9, 0), EndTime: at(9, 30),
+			ActualStartAt: ptr(at(9, 0)), ActualEndAt: ptr(at(9, 45)), CompletedAt: ptr(at(9, 45))},
+		{ID: 2, ParticipantID: participantID, Status: "confirmed", StartTime: at(9, 30), EndTime: at(10, 0),
+			ActualStartAt: ptr(at(9, 45))},
+	}
+	target := &model.Appointment{ID: 3, ParticipantID: participantID, Status: "confirmed", StartTime: at(10, 0), EndTime: at(10, 30)}
+
+	mockAppointmentRepo.EXPECT().GetByID(uint(1), target.ID).Return(target, nil).Times(1)
+	mockAppointmentRepo.EXPECT().FindActiveForUser(participantID, at(0, 0), at(10, 0)).Return(earlier, nil).Times(1)
+
+	etaService := &etaService{
+		appointmentRepository: mockAppointmentRepo,
+		estimator:             eta.MovingAverage{Window: 3},
+		now:                   func() time.Time { return at(10, 0) },
+	}
+
+	// WHEN
+	estimate, err := etaService.GetAppointmentETA(1, target.ID, &request.AppointmentETARequest{})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, at(10, 30), estimate.EstimatedStart)
+	assert.Equal(t, 30, estimate.DelayMinutes)
+	assert.Equal(t, 1, estimate.Samples)
+}
+
+func TestPredictStart_NeverBeforeScheduleOrNow(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2030, 1, 7, hour, minute, 0, 0, time.UTC) }
+	ptr := func(t time.Time) *time.Time { return &t }
+	estimator := eta.Percentile{Percentile: 80}
+
+	// An earlier appointment finished early, so the target keeps its slot.
+	finishedEarly := []model.Appointment{
+		{ID: 1, Status: "completed", StartTime: at(9, 0), EndTime: at(10, 0), ActualStartAt: ptr(at(9, 0)), ActualEndAt: ptr(at(9, 40))},
+	}
+	target := &model.Appointment{ID: 2, StartTime: at(10, 0), EndTime: at(10, 30)}
+	start, samples := predictStart(target, finishedEarly, estimator, at(9, 50))
+	assert.Equal(t, at(10, 0), start)
+	assert.Equal(t, 1, samples)
+
+	// With nothing ahead but the target not yet started, it cannot start
+	// before now.
+	start, samples = predictStart(target, nil, estimator, at(10, 20))
+	assert.Equal(t, at(10, 20), start)
+	assert.Equal(t, 0, samples)
+}
+
+func TestETAService_GetAppointmentETA_ClosedAppointment(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	etaService := NewETAService(mockAppointmentRepo, eta.MovingAverage{Window: 3})
+
+	mockAppointmentRepo.EXPECT().GetByID(uint(1), uint(4)).Return(&model.Appointment{ID: 4, Status: "cancelled"}, nil).Times(1)
+
+	// WHEN
+	estimate, err := etaService.GetAppointmentETA(1, 4, &request.AppointmentETARequest{})
+
+	// THEN
+	assert.Nil(t, estimate)
+	assert.Equal(t, ErrAppointmentClosed, err)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"queue_system/config"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/ical"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// feedHistory is how far back a calendar feed reaches. Older appointments
+// stay in clients that already have them.
+const feedHistory = 90 * 24 * time.Hour
+
+var ErrInvalidCalendarToken = errors.New("invalid calendar token")
+
+type ICalService interface {
+	ExportAppointment(organizationID, id uint) ([]byte, error)
+	IssueFeedToken(organizationID, userID uint) (*response.CalendarFeedResponse, error)
+	ExportUserFeed(userID uint, token string) ([]byte, error)
+}
+
+type icalService struct {
+	appointmentRepository repository.AppointmentRepository
+	userRepository        repository.UserRepository
+	calendarRepository    repository.CalendarRepository
+	uidDomain             string
+	now                   func() time.Time
+}
+
+func NewICalService(appointmentRepository repository.AppointmentRepository, userRepository repository.UserRepository, calendarRepository repository.CalendarRepository, cfg *config.Config) ICalService {
+	return &icalService{
+		appointmentRepository: appointmentRepository,
+		userRepository:        userRepository,
+		calendarRepository:    calendarRepository,
+		uidDomain:             cfg.Calendar.UIDDomain,
+		now:                   time.Now,
+	}
+}
+
+func (is *icalService) ExportAppointment(organizationID, id uint) ([]byte, error) {
+	appointment, err := is.appointmentRepository.GetByID(organizationID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("appointmentID", id).Msg("Error fetching appointment for export")
+		return nil, err
+	}
+	if appointment == nil {
+		return nil, ErrAppointmentNotFound
+	}
+
+	calendarEvents, err := appointmentEvents(is.userRepository, organizationID, []model.Appointment{*appointment}, is.uidDomain)
+	if err != nil {
+		return nil, err
+	}
+	calendar := ical.Calendar{Method: ical.MethodPublish, Events: calendarEvents}
+	return calendar.Encode(), nil
+}
+
+// IssueFeedToken gives the user a new feed token, revoking the previous one.
+func (is *icalService) IssueFeedToken(organizationID, userID uint) (*response.CalendarFeedResponse, error) {
+	user, err := is.userRepository.GetById(organizationID, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error fetching user")
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	token, tokenHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	feed := &model.CalendarFeed{UserID: userID, OrganizationID: organizationID, TokenHash: tokenHash}
+	if err := is.calendarRepository.SaveFeed(feed); err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error saving calendar feed token")
+		return nil, err
+	}
+	return &response.CalendarFeedResponse{
+		UserID: userID,
+		Token:  token,
+		URL:    fmt.Sprintf("/api/v1/users/%d/calendar.ics?token=%s", userID, token),
+	}, nil
+}
+
+// ExportUserFeed returns every appointment the user organizes or attends
+// that ended within feedHistory, cancelled ones included so that clients
+// drop them. The token determines the organization, as calendar clients
+// cannot send the tenancy header.
+func (is *icalService) ExportUserFeed(userID uint, token string) ([]byte, error) {
+	feed, err := is.calendarRepository.GetFeedByTokenHash(auth.HashToken(token))
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error fetching calendar feed")
+		return nil, err
+	}
+	if feed == nil || feed.UserID != userID {
+		return nil, ErrInvalidCalendarToken
+	}
+	organizationID := feed.OrganizationID
+
+	user, err := is.userRepository.GetById(organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	appointments, err := is.appointmentRepository.FindForUserEndingAfter(userID, is.now().Add(-feedHistory))
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error fetching appointments for calendar feed")
+		return nil, err
+	}
+
+	calendarEvents, err := appointmentEvents(is.userRepository, organizationID, appointments, is.uidDomain)
+	if err != nil {
+		return nil, err
+	}
+	calendar := ical.Calendar{
+		Method: ical.MethodPublish,
+		Name:   "Appointments - " + user.Name,
+		Events: calendarEvents,
+	}
+	return calendar.Encode(), nil
+}
+
+// appointmentEvents converts the organization's appointments to events,
+// loading the people on them in one query.
+func appointmentEvents(userRepository repository.UserRepository, organizationID uint, appointments []model.Appointment, uidDomain string) ([]ical.Event, error) {
+	var userIDs []uint
+	for i := range appointments {
+		userIDs = append(userIDs, appointments[i].UserID, appointments[i].ParticipantID)
+	}
+	users := make(map[uint]*model.User)
+	if len(userIDs) > 0 {
+		found, err := userRepository.GetByIds(organizationID, userIDs)
+		if err != nil {
+			log.Error().Err(err).Msg("Error fetching appointment users for export")
+			return nil, err
+		}
+		for i := range found {
+			users[found[i].ID] = &found[i]
+		}
+	}
+
+	calendarEvents := make([]ical.Event, 0, len(appointments))
+	for i := range appointments {
+		organizer, participant := users[appointments[i].UserID], users[appointments[i].ParticipantID]
+		if organizer == nil {
+			organizer = &model.User{}
+		}
+		if participant == nil {
+			participant = &model.User{}
+		}
+		calendarEvents = append(calendarEvents, ical.AppointmentEvent(&appointments[i], organizer, participant, uidDomain))
+	}
+	return calendarEvents, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"queue_system/config"
+	"queue_system/internal/auth"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestICalService(ctrl *gomock.Controller) (ICalService, *mocks.MockAppointmentRepository, *mocks.MockUserRepository, *mocks.MockCalendarRepository) {
+	mockAppointmentRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockCalendarRepo := mocks.NewMockCalendarRepository(ctrl)
+	cfg := &config.Config{}
+	cfg.Calendar.UIDDomain = "example.com"
+	return NewICalService(mockAppointmentRepo, mockUserRepo, mockCalendarRepo, cfg), mockAppointmentRepo, mockUserRepo, mockCalendarRepo
+}
+
+func TestICalService_ExportAppointment_Success(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	icalService, mockAppointmentRepo, mockUserRepo, _ := newTestICalService(ctrl)
+
+	start := time.Date(2030, 6, 3, 14, 0, 0, 0, time.UTC)
+	mockAppointmentRepo.EXPECT().GetByID(uint(1), uint(7)).Return(&model.Appointment{
+		ID: 7, UserID: 1, ParticipantID: 2, StartTime: start, EndTime: start.Add(time.Hour), Status: "confirmed", Sequence: 2,
+	}, nil).Times(1)
+	mockUserRepo.EXPECT().GetByIds(uint(1), []uint{1, 2}).Return([]model.User{
+		{ID: 1, Name: "Dr. Smith", Email: "smith@example.com"},
+		{ID: 2, Name: "Ana", Email: "ana@example.com"},
+	}, nil).Times(1)
+
+	// WHEN
+	data, err := icalService.ExportAppointment(1, 7)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "UID:appointment-7@example.com\r\n")
+	assert.Contains(t, string(data), "SEQUENCE:2\r\n")
+	assert.Contains(t, string(data), "mailto:ana@example.com\r\n")
+}
+
+func TestICalService_ExportAppointment_NotFound(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	icalService, mockAppointmentRepo, _, _ := newTestICalService(ctrl)
+	mockAppointmentRepo.EXPECT().GetByID(uint(1), uint(7)).Return(nil, nil).Times(1)
+
+	// WHEN
+	data, err := icalService.ExportAppointment(1, 7)
+
+	// THEN
+	assert.Equal(t, ErrAppointmentNotFound, err)
+	assert.Nil(t, data)
+}
+
+func TestICalService_IssueFeedToken_StoresOnlyTheHash(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	icalService, _, mockUserRepo, mockCalendarRepo := newTestICalService(ctrl)
+	mockUserRepo.EXPECT().GetById(uint(3), uint(1)).Return(&model.User{ID: 1, OrganizationID: 3}, nil).Times(1)
+	var saved *model.CalendarFeed
+	mockCalendarRepo.EXPECT().SaveFeed(gomock.Any()).DoAndReturn(func(feed *model.CalendarFeed) error {
+		saved = feed
+		return nil
+	}).Times(1)
+
+	// WHEN
+	feed, err := icalService.IssueFeedToken(3, 1)
+
+	// THEN
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.NotEmpty(t, feed.Token)
+	assert.Equal(t, auth.HashToken(feed.Token), saved.TokenHash)
+	assert.NotContains(t, saved.TokenHash, feed.Token)
+	assert.Equal(t, uint(3), saved.OrganizationID)
+}
+
+func TestICalService_ExportUserFeed_RejectsWrongToken(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	icalService, _, _, mockCalendarRepo := newTestICalService(ctrl)
+	mockCalendarRepo.EXPECT().GetFeedByTokenHash(auth.HashToken("wrong")).Return(nil, nil).Times(1)
+	mockCalendarRepo.EXPECT().GetFeedByTokenHash(auth.HashToken("other")).Return(&model.CalendarFeed{UserID: 2, TokenHash: auth.HashToken("other")}, nil).Times(1)
+
+	// WHEN
+	_, wrongTokenErr := icalService.ExportUserFeed(1, "wrong")
+	_, otherUsersTokenErr := icalService.ExportUserFeed(1, "other")
+
+	// THEN
+	assert.Equal(t, ErrInvalidCalendarToken, wrongTokenErr)
+	assert.Equal(t, ErrInvalidCalendarToken, otherUsersTokenErr)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"queue_system/config"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/enums"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"regexp"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidOrganizationSlug  = errors.New("slug must be lowercase letters, digits and hyphens, and start and end with a letter or digit")
+	ErrOrganizationExists       = errors.New("organization already exists")
+	ErrCreateOrganizationFailed = errors.New("failed to create organization")
+)
+
+// organizationSlug matches slugs that can also be used as a subdomain.
+var organizationSlug = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+type OrganizationService interface {
+	CreateOrganization(actor *model.User, req *request.CreateOrganizationRequest) (*response.OrganizationResponse, error)
+}
+
+type organizationService struct {
+	organizationRepository repository.OrganizationRepository
+	userRepository         repository.UserRepository
+	db                     *gorm.DB
+	defaultOrganization    string
+}
+
+func NewOrganizationService(organizationRepository repository.OrganizationRepository, userRepository repository.UserRepository, db *gorm.DB, cfg *config.Config) OrganizationService {
+	return &organizationService{
+		organizationRepository: organizationRepository,
+		userRepository:         userRepository,
+		db:                     db,
+		defaultOrganization:    cfg.Tenancy.DefaultOrganization,
+	}
+}
+
+// CreateOrganization adds an organization and its first admin on behalf of
+// an admin of the default organization.
+func (os *organizationService) CreateOrganization(actor *model.User, req *request.CreateOrganizationRequest) (*response.OrganizationResponse, error) {
+	if !auth.Can(actor, auth.ManageOrganizations) {
+		return nil, auth.ErrPermissionDenied
+	}
+	operators, err := os.organizationRepository.GetBySlug(os.defaultOrganization)
+	if err != nil {
+		log.Error().Err(err).Msg("Error fetching default organization")
+		return nil, err
+	}
+	if operators == nil || operators.ID != actor.OrganizationID {
+		return nil, auth.ErrOperatorsOnly
+	}
+	if !organizationSlug.MatchString(req.Slug) {
+		return nil, ErrInvalidOrganizationSlug
+	}
+	existing, err := os.organizationRepository.GetBySlug(req.Slug)
+	if err != nil {
+		log.Error().Err(err).Str("slug", req.Slug).Msg("Error checking existing organization")
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrOrganizationExists
+	}
+
+	organization := model.Organization{Name: req.Name, Slug: req.Slug}
+	admin := model.User{
+		Name:  req.Admin.Name,
+		Email: req.Admin.Email,
+		Role:  string(enums.RoleAdmin),
+	}
+	if req.Admin.Password != "" {
+		admin.PasswordHash, err = auth.HashPassword(req.Admin.Password)
+		if err != nil {
+			log.Error().Err(err).Msg("Error hashing password")
+			return nil, err
+		}
+	}
+
+	tx := os.db.Begin()
+
+	if err := os.organizationRepository.CreateOrganizationWithTx(tx, &organization); err != nil {
+		tx.Rollback()
+		if repository.IsUniqueViolation(err) {
+			return nil, ErrOrganizationExists
+		}
+		log.Error().Err(err).Str("slug", req.Slug).Msg("Error creating organization")
+		return nil, ErrCreateOrganizationFailed
+	}
+	admin.OrganizationID = organization.ID
+	if err := os.userRepository.CreateUserWithTx(tx, &admin); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Str("slug", req.Slug).Msg("Error creating organization admin")
+		return nil, ErrCreateOrganizationFailed
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		if repository.IsUniqueViolation(err) {
+			return nil, ErrOrganizationExists
+		}
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrCreateOrganizationFailed
+	}
+	return &response.OrganizationResponse{Organization: organization, Admin: admin}, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"queue_system/config"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"queue_system/test/txdb"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationService_CreateOrganization_Rejections(t *testing.T) {
+	operators := &model.Organization{ID: 1, Slug: "default"}
+	validRequest := request.CreateOrganizationRequest{
+		Name: "Acme", Slug: "acme",
+		Admin: request.OrganizationAdminRequest{Name: "Ada", Email: "ada@acme.test"},
+	}
+	tests := map[string]struct {
+		actor   *model.User
+		slug    string
+		taken   bool
+		wantErr error
+	}{
+		"staff of the default organization": {
+			actor:   &model.User{ID: 2, OrganizationID: 1, Role: "staff"},
+			slug:    "acme",
+			wantErr: auth.ErrPermissionDenied,
+		},
+		"admin of another organization": {
+			actor:   &model.User{ID: 3, OrganizationID: 2, Role: "admin"},
+			slug:    "acme",
+			wantErr: auth.ErrOperatorsOnly,
+		},
+		"invalid slug": {
+			actor:   &model.User{ID: 1, OrganizationID: 1, Role: "admin"},
+			slug:    "Acme Corp",
+			wantErr: ErrInvalidOrganizationSlug,
+		},
+		"slug taken": {
+			actor:   &model.User{ID: 1, OrganizationID: 1, Role: "admin"},
+			slug:    "acme",
+			taken:   true,
+			wantErr: ErrOrganizationExists,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockOrgRepo := mocks.NewMockOrganizationRepository(ctrl)
+			mockUserRepo := mocks.NewMockUserRepository(ctrl)
+			db, txLog := txdb.Open(t)
+			cfg := &config.Config{Tenancy: config.Tenancy{DefaultOrganization: "default"}}
+			organizationService := NewOrganizationService(mockOrgRepo, mockUserRepo, db, cfg)
+
+			mockOrgRepo.EXPECT().GetBySlug("default").Return(operators, nil).AnyTimes()
+			if tc.taken {
+				mockOrgRepo.EXPECT().GetBySlug(tc.slug).Return(&model.Organization{ID: 5, Slug: tc.slug}, nil)
+			}
+			req := validRequest
+			req.Slug = tc.slug
+
+			// WHEN
+			organization, err := organizationService.CreateOrganization(tc.actor, &req)
+
+			// THEN
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Nil(t, organization)
+			assert.Empty(t, txLog.Entries())
+		})
+	}
+}
+
+func TestOrganizationService_CreateOrganization_AddsFirstAdmin(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockOrgRepo := mocks.NewMockOrganizationRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	db, txLog := txdb.Open(t)
+	cfg := &config.Config{Tenancy: config.Tenancy{DefaultOrganization: "default"}}
+	organizationService := NewOrganizationService(mockOrgRepo, mockUserRepo, db, cfg)
+
+	mockOrgRepo.EXPECT().GetBySlug("default").Return(&model.Organization{ID: 1, Slug: "default"}, nil)
+	mockOrgRepo.EXPECT().GetBySlug("acme").Return(nil, nil)
+	mockOrgRepo.EXPECT().CreateOrganizationWithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ any, organization *model.Organization) error {
+			organization.ID = 4
+			return nil
+		})
+	var admin *model.User
+	mockUserRepo.EXPECT().CreateUserWithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ any, user *model.User) error {
+			admin = user
+			return nil
+		})
+
+	// WHEN
+	created, err := organizationService.CreateOrganization(&model.User{ID: 1, OrganizationID: 1, Role: "admin"}, &request.CreateOrganizationRequest{
+		Name: "Acme", Slug: "acme",
+		Admin: request.OrganizationAdminRequest{Name: "Ada", Email: "ada@acme.test", Password: "correct horse"},
+	})
+
+	// THEN
+	require.NoError(t, err)
+	require.NotNil(t, admin)
+	assert.Equal(t, uint(4), created.ID)
+	assert.Equal(t, uint(4), admin.OrganizationID)
+	assert.Equal(t, "admin", admin.Role)
+	assert.True(t, auth.CheckPassword(admin.PasswordHash, "correct horse"))
+	assert.Equal(t, []string{"begin", "commit"}, txLog.Entries())
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"errors"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/enums"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrQueueNotFound           = errors.New("queue not found")
+	ErrQueueAlreadyExists      = errors.New("a queue with this name already exists at the service point")
+	ErrQueueEmpty              = errors.New("no tickets are waiting in this queue")
+	ErrTicketNotFound          = errors.New("ticket not found")
+	ErrInvalidTicketTransition = errors.New("ticket cannot move to the requested status")
+	ErrCreateQueueFailed       = errors.New("failed to create queue")
+	ErrTakeTicketFailed        = errors.New("failed to take ticket")
+	ErrUpdateTicketFailed      = errors.New("failed to update ticket")
+)
+
+type QueueService interface {
+	CreateQueue(organizationID uint, req *request.CreateQueueRequest) (*model.Queue, error)
+	ListQueues(organizationID uint) ([]model.Queue, error)
+	GetQueueByID(organizationID, id uint) (*model.Queue, error)
+	TakeTicket(actor *model.User, queueID uint, req *request.TakeTicketRequest) (*model.QueueTicket, error)
+	ListTickets(organizationID, queueID uint, req *request.ListTicketsRequest) ([]model.QueueTicket, error)
+	CallNextTicket(organizationID, queueID uint, req *request.CallNextTicketRequest) (*model.QueueTicket, error)
+	StartServingTicket(organizationID, queueID, ticketID uint) (*model.QueueTicket, error)
+	CompleteTicket(organizationID, queueID, ticketID uint) (*model.QueueTicket, error)
+	MarkTicketNoShow(organizationID, queueID, ticketID uint) (*model.QueueTicket, error)
+}
+
+type queueService struct {
+	queueRepository repository.QueueRepository
+	userRepository  repository.UserRepository
+	db              *gorm.DB
+}
+
+func NewQueueService(queueRepository repository.QueueRepository, userRepository repository.UserRepository, db *gorm.DB) QueueService {
+	return &queueService{
+		queueRepository: queueRepository,
+		userRepository:  userRepository,
+		db:              db,
+	}
+}
+
+func (qs *queueService) CreateQueue(organizationID uint, req *request.CreateQueueRequest) (*model.Queue, error) {
+	existing, err := qs.queueRepository.GetQueueByName(organizationID, req.Name, req.ServicePoint)
+	if err != nil {
+		log.Error().Err(err).Msg("Error checking for existing queue")
+		return nil, ErrCreateQueueFailed
+	}
+	if existing != nil {
+		return nil, ErrQueueAlreadyExists
+	}
+
+	queue := &model.Queue{
+		OrganizationID: organizationID,
+		Name:           req.Name,
+		ServicePoint:   req.ServicePoint,
+		Description:    req.Description,
+		NextNumber:     1,
+	}
+	if err := qs.queueRepository.CreateQueue(queue); err != nil {
+		if repository.IsUniqueViolation(err) {
+			return nil, ErrQueueAlreadyExists
+		}
+		log.Error().Err(err).Msg("Error creating queue")
+		return nil, ErrCreateQueueFailed
+	}
+	return queue, nil
+}
+
+func (qs *queueService) ListQueues(organizationID uint) ([]model.Queue, error) {
+	queues, err := qs.queueRepository.ListQueues(organizationID)
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing queues")
+		return nil, err
+	}
+	return queues, nil
+}
+
+func (qs *queueService) GetQueueByID(organizationID, id uint) (*model.Queue, error) {
+	queue, err := qs.queueRepository.GetQueueByID(organizationID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("queueID", id).Msg("Error fetching queue")
+		return nil, err
+	}
+	if queue == nil {
+		return nil, ErrQueueNotFound
+	}
+	return queue, nil
+}
+
+// TakeTicket issues the queue's next number. The queue row stays locked
+// until the ticket is stored so concurrent walk-ins never share a number.
+// Staff can link a ticket to any user of the organization; everyone else
+// only takes tickets for themselves.
+func (qs *queueService) TakeTicket(actor *model.User, queueID uint, req *request.TakeTicketRequest) (*model.QueueTicket, error) {
+	organizationID := actor.OrganizationID
+	userID := req.UserID
+	if !auth.Can(actor, auth.ManageQueues) {
+		userID = &actor.ID
+	}
+	if userID != nil {
+		user, err := qs.userRepository.GetById(organizationID, *userID)
+		if err != nil || user == nil {
+			return nil, ErrUserNotFound
+		}
+	}
+
+	tx := qs.db.Begin()
+
+	queue, err := qs.queueRepository.GetQueueForUpdate(tx, organizationID, queueID)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("queueID", queueID).Msg("Error locking queue")
+		return nil, ErrTakeTicketFailed
+	}
+	if queue == nil {
+		tx.Rollback()
+		return nil, ErrQueueNotFound
+	}
+
+	ticket := &model.QueueTicket{
+		OrganizationID: organizationID,
+		QueueID:        queue.ID,
+		Number:         queue.NextNumber,
+		UserID:         userID,
+		CustomerName:   req.CustomerName,
+		Status:         string(enums.TicketWaiting),
+	}
+	if err := qs.queueRepository.CreateTicketWithTx(tx, ticket); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("queueID", queueID).Msg("Error creating ticket")
+		return nil, ErrTakeTicketFailed
+	}
+	queue.NextNumber++
+	if err := qs.queueRepository.UpdateQueueWithTx(tx, queue); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("queueID", queueID).Msg("Error advancing queue number")
+		return nil, ErrTakeTicketFailed
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrTakeTicketFailed
+	}
+	return ticket, nil
+}
+
+func (qs *queueService) ListTickets(organizationID, queueID uint, req *request.ListTicketsRequest) ([]model.QueueTicket, error) {
+	if _, err := qs.GetQueueByID(organizationID, queueID); err != nil {
+		return nil, err
+	}
+	tickets, err := qs.queueRepository.ListTickets(queueID, req.Status)
+	if err != nil {
+		log.Error().Err(err).Uint("queueID", queueID).Msg("Error listing tickets")
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// CallNextTicket calls the lowest-numbered waiting ticket to the given
+// counter. Calls hold the queue lock, so concurrent counters receive
+// tickets strictly in the order they were taken.
+func (qs *queueService) CallNextTicket(organizationID, queueID uint, req *request.CallNextTicketRequest) (*model.QueueTicket, error) {
+	tx := qs.db.Begin()
+
+	queue, err := qs.queueRepository.GetQueueForUpdate(tx, organizationID, queueID)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("queueID", queueID).Msg("Error locking queue")
+		return nil, ErrUpdateTicketFailed
+	}
+	if queue == nil {
+		tx.Rollback()
+		return nil, ErrQueueNotFound
+	}
+
+	ticket, err := qs.queueRepository.NextWaitingTicket(tx, queueID)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("queueID", queueID).Msg("Error fetching next ticket")
+		return nil, ErrUpdateTicketFailed
+	}
+	if ticket == nil {
+		tx.Rollback()
+		return nil, ErrQueueEmpty
+	}
+
+	ticket.Counter = req.Counter
+	if err := applyTicketTransition(ticket, enums.TicketCalled, time.Now()); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := qs.queueRepository.UpdateTicketWithTx(tx, ticket); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("ticketID", ticket.ID).Msg("Error calling ticket")
+		return nil, ErrUpdateTicketFailed
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateTicketFailed
+	}
+	return ticket, nil
+}
+
+func (qs *queueService) StartServingTicket(organizationID, queueID, ticketID uint) (*model.QueueTicket, error) {
+	return qs.transitionTicket(organizationID, queueID, ticketID, enums.TicketServing)
+}
+
+func (qs *queueService) CompleteTicket(organizationID, queueID, ticketID uint) (*model.QueueTicket, error) {
+	return qs.transitionTicket(organizationID, queueID, ticketID, enums.TicketDone)
+}
+
+func (qs *queueService) MarkTicketNoShow(organizationID, queueID, ticketID uint) (*model.QueueTicket, error) {
+	return qs.transitionTicket(organizationID, queueID, ticketID, enums.TicketNoShow)
+}
+
+func (qs *queueService) transitionTicket(organizationID, queueID, ticketID uint, next enums.TicketStatus) (*model.QueueTicket, error) {
+	tx := qs.db.Begin()
+
+	ticket, err := qs.queueRepository.GetTicketForUpdate(tx, organizationID, queueID, ticketID)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("ticketID", ticketID).Msg("Error fetching ticket for status change")
+		return nil, ErrUpdateTicketFailed
+	}
+	if ticket == nil {
+		tx.Rollback()
+		return nil, ErrTicketNotFound
+	}
+
+	if err := applyTicketTransition(ticket, next, time.Now()); err != nil {
+		tx.Rollback()
+		log.Warn().Uint("ticketID", ticketID).Str("from", ticket.Status).Str("to", string(next)).Msg("Rejected ticket transition")
+		return nil, err
+	}
+	if err := qs.queueRepository.UpdateTicketWithTx(tx, ticket); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("ticketID", ticketID).Msg("Error updating ticket status")
+		return nil, ErrUpdateTicketFailed
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateTicketFailed
+	}
+	return ticket, nil
+}
+
+// applyTicketTransition moves the ticket to next if allowed and stamps the
+// matching timestamp.
+func applyTicketTransition(ticket *model.QueueTicket, next enums.TicketStatus, at time.Time) error {
+	if !enums.TicketStatus(ticket.Status).CanTransitionTo(next) {
+		return ErrInvalidTicketTransition
+	}
+	ticket.Status = string(next)
+	switch next {
+	case enums.TicketCalled:
+		ticket.CalledAt = &at
+	case enums.TicketServing:
+		ticket.ServingAt = &at
+	case enums.TicketDone, enums.TicketNoShow:
+		ticket.FinishedAt = &at
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"queue_system/internal/dto/request"
+	"queue_system/internal/enums"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"queue_system/test/txdb"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueService_CreateQueue_Duplicate(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockQueueRepo := mocks.NewMockQueueRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	queueService := NewQueueService(mockQueueRepo, mockUserRepo, nil)
+
+	mockQueueRepo.EXPECT().GetQueueByName(uint(1), "General", "Front desk").Return(&model.Queue{ID: 1}, nil).Times(1)
+
+	// WHEN
+	queue, err := queueService.CreateQueue(1, &request.CreateQueueRequest{Name: "General", ServicePoint: "Front desk"})
+
+	// THEN
+	assert.Nil(t, queue)
+	assert.Equal(t, ErrQueueAlreadyExists, err)
+}
+
+func TestQueueService_CreateQueue_CreatedConcurrently(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockQueueRepo := mocks.NewMockQueueRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	queueService := NewQueueService(mockQueueRepo, mockUserRepo, nil)
+
+	mockQueueRepo.EXPECT().GetQueueByName(uint(1), "General", "Front desk").Return(nil, nil).Times(1)
+	mockQueueRepo.EXPECT().CreateQueue(gomock.Any()).Return(sqlStateError("23505")).Times(1)
+
+	// WHEN
+	queue, err := queueService.CreateQueue(1, &request.CreateQueueRequest{Name: "General", ServicePoint: "Front desk"})
+
+	// THEN
+	assert.Nil(t, queue)
+	assert.Equal(t, ErrQueueAlreadyExists, err)
+}
+
+func TestQueueService_TakeTicket_UnknownUser(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockQueueRepo := mocks.NewMockQueueRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	queueService := NewQueueService(mockQueueRepo, mockUserRepo, nil)
+
+	userID := uint(42)
+	mockUserRepo.EXPECT().GetById(uint(1), userID).Return(nil, nil).Times(1)
+
+	// WHEN
+	ticket, err := queueService.TakeTicket(&model.User{ID: 7, OrganizationID: 1, Role: "staff"}, 1, &request.TakeTicketRequest{UserID: &userID})
+
+	// THEN
+	assert.Nil(t, ticket)
+	assert.Equal(t, ErrUserNotFound, err)
+}
+
+func TestQueueService_TakeTicket_ClientsTakeTicketsForThemselves(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockQueueRepo := mocks.NewMockQueueRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	db, _ := txdb.Open(t)
+	queueService := NewQueueService(mockQueueRepo, mockUserRepo, db)
+
+	client := &model.User{ID: 7, OrganizationID: 1, Role: "client"}
+	otherUserID := uint(42)
+	mockUserRepo.EXPECT().GetById(uint(1), client.ID).Return(client, nil).Times(1)
+	mockQueueRepo.EXPECT().GetQueueForUpdate(gomock.Any(), uint(1), uint(3)).Return(&model.Queue{ID: 3, OrganizationID: 1, NextNumber: 5}, nil)
+	mockQueueRepo.EXPECT().CreateTicketWithTx(gomock.Any(), gomock.Any()).Return(nil)
+	mockQueueRepo.EXPECT().UpdateQueueWithTx(gomock.Any(), gomock.Any()).Return(nil)
+
+	// WHEN
+	ticket, err := queueService.TakeTicket(client, 3, &request.TakeTicketRequest{UserID: &otherUserID})
+
+	// THEN
+	require.NoError(t, err)
+	require.NotNil(t, ticket.UserID)
+	assert.Equal(t, client.ID, *ticket.UserID)
+	assert.Equal(t, 5, ticket.Number)
+}
+
+func TestApplyTicketTransition(t *testing.T) {
+	// GIVEN
+	at := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	ticket := &model.QueueTicket{Status: string(enums.TicketWaiting)}
+
+	// WHEN / THEN
+	assert.Equal(t, ErrInvalidTicketTransition, applyTicketTransition(ticket, enums.TicketServing, at))
+	assert.NoError(t, applyTicketTransition(ticket, enums.TicketCalled, at))
+	assert.Equal(t, &at, ticket.CalledAt)
+	assert.NoError(t, applyTicketTransition(ticket, enums.TicketServing, at))
+	assert.NoError(t, applyTicketTransition(ticket, enums.TicketDone, at))
+	assert.Equal(t, &at, ticket.FinishedAt)
+	assert.Equal(t, ErrInvalidTicketTransition, applyTicketTransition(ticket, enums.TicketNoShow, at))
+}
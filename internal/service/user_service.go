@@ -2,9 +2,15 @@ package service
 
 import (
 	"errors"
+	"queue_system/internal/auth"
 	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/enums"
+	"queue_system/internal/events"
 	"queue_system/internal/model"
 	"queue_system/internal/repository"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
@@ -16,25 +22,62 @@ var (
 	ErrEmailExists      = errors.New("email already exists")
 	ErrUpdateFailed     = errors.New("failed to update user")
 	ErrCreateUserFailed = errors.New("failed to create user")
+	// ErrOrganizationRequired is returned when a user is created by a
+	// request that resolved to no organization.
+	ErrOrganizationRequired   = errors.New("organization required")
+	ErrUserDeactivated        = errors.New("user is deactivated")
+	ErrUserAlreadyDeactivated = errors.New("user is already deactivated")
+	ErrUserNotDeactivated     = errors.New("user is not deactivated")
+	ErrCannotDeactivateSelf   = errors.New("users cannot deactivate themselves")
 )
 
+const defaultUserPageSize = 20
+
 type UserService interface {
-	CreateUser(req *request.CreateUserRequest) (*model.User, error)
-	GetUserById(id uint) (*model.User, error)
+	CreateUser(organizationID uint, actor *model.User, req *request.CreateUserRequest) (*model.User, error)
+	GetUserById(organizationID, id uint) (*model.User, error)
+	ListUsers(organizationID uint, actor *model.User, req *request.ListUsersRequest) ([]model.User, int64, error)
+	UpdateUser(organizationID uint, actor *model.User, id uint, req *request.UpdateUserRequest) (*model.User, error)
+	DeactivateUser(organizationID uint, actor *model.User, id uint, req *request.DeactivateUserRequest) (*response.DeactivatedUserResponse, error)
+	ReactivateUser(organizationID, id uint) (*model.User, error)
+	DeleteUser(organizationID, id uint) error
+	RestoreUser(organizationID, id uint) (*model.User, error)
 }
 
 type userService struct {
-	userRepository repository.UserRepository
+	userRepository        repository.UserRepository
+	appointmentRepository repository.AppointmentRepository
+	authRepository        repository.AuthRepository
+	db                    *gorm.DB
+	now                   func() time.Time
 }
 
-func NewUserService(userRepository repository.UserRepository) UserService {
+func NewUserService(userRepository repository.UserRepository, appointmentRepository repository.AppointmentRepository, authRepository repository.AuthRepository, db *gorm.DB) UserService {
 	return &userService{
-		userRepository: userRepository,
+		userRepository:        userRepository,
+		appointmentRepository: appointmentRepository,
+		authRepository:        authRepository,
+		db:                    db,
+		now:                   time.Now,
 	}
 }
 
-func (us *userService) CreateUser(req *request.CreateUserRequest) (*model.User, error) {
-	existingUser, err := us.userRepository.GetByEmail(req.Email)
+// CreateUser signs up a client of the organization, or creates a user with
+// any role on behalf of an admin. actor is nil for sign-ups. Emails only
+// need to be unique within the organization.
+func (us *userService) CreateUser(organizationID uint, actor *model.User, req *request.CreateUserRequest) (*model.User, error) {
+	if organizationID == 0 {
+		return nil, ErrOrganizationRequired
+	}
+	role := enums.Role(req.Role)
+	if role == "" {
+		role = enums.RoleClient
+	}
+	if role != enums.RoleClient && !auth.Can(actor, auth.ManageUsers) {
+		return nil, auth.ErrRoleNotAssignable
+	}
+
+	existingUser, err := us.userRepository.GetByEmail(organizationID, req.Email)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		log.Error().Err(err).Msg("Error checking existing email")
 		return nil, err
@@ -44,20 +87,33 @@ func (us *userService) CreateUser(req *request.CreateUserRequest) (*model.User,
 		return nil, ErrEmailExists
 	}
 	user := &model.User{
-		Name:  req.Name,
-		Email: req.Email,
-		Role:  req.Role,
+		OrganizationID: organizationID,
+		Name:           req.Name,
+		Email:          req.Email,
+		Role:           string(role),
+		Phone:          req.Phone,
+		Timezone:       req.Timezone,
+	}
+	if req.Password != "" {
+		user.PasswordHash, err = auth.HashPassword(req.Password)
+		if err != nil {
+			log.Error().Err(err).Msg("Error hashing password")
+			return nil, err
+		}
 	}
 	createdUser, err := us.userRepository.CreateUser(user)
 	if err != nil {
+		if repository.IsUniqueViolation(err) {
+			return nil, ErrEmailExists
+		}
 		log.Error().Err(err).Msg("Error creating user")
 		return nil, ErrCreateUserFailed
 	}
 	return createdUser, nil
 }
 
-func (us *userService) GetUserById(id uint) (*model.User, error) {
-	user, err := us.userRepository.GetById(id)
+func (us *userService) GetUserById(organizationID, id uint) (*model.User, error) {
+	user, err := us.userRepository.GetById(organizationID, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Error().Err(ErrUserNotFound).Msg("User not found")
@@ -68,3 +124,259 @@ func (us *userService) GetUserById(id uint) (*model.User, error) {
 	}
 	return user, nil
 }
+
+// ListUsers returns a page of the organization's users matching req,
+// ordered by name, and how many match in total. Only users who may restore
+// them see deleted users.
+func (us *userService) ListUsers(organizationID uint, actor *model.User, req *request.ListUsersRequest) ([]model.User, int64, error) {
+	if req.IncludeDeleted && !auth.Can(actor, auth.RestoreDeleted) {
+		return nil, 0, auth.ErrPermissionDenied
+	}
+	filter := repository.UserFilter{
+		OrganizationID: organizationID,
+		Search:         strings.TrimSpace(req.Search),
+		Role:           req.Role,
+		IncludeDeleted: req.IncludeDeleted,
+	}
+	if req.Status != "" {
+		active := req.Status == "active"
+		filter.Active = &active
+	}
+
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultUserPageSize
+	}
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+
+	users, total, err := us.userRepository.List(filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing users")
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// UpdateUser changes the fields set in req. A new email must still be
+// unique within the organization, and only admins may change roles.
+func (us *userService) UpdateUser(organizationID uint, actor *model.User, id uint, req *request.UpdateUserRequest) (*model.User, error) {
+	user, err := us.userRepository.GetById(organizationID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", id).Msg("Error fetching user for update")
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if req.Role != nil && *req.Role != user.Role {
+		if !auth.Can(actor, auth.ManageUsers) {
+			return nil, auth.ErrRoleNotAssignable
+		}
+		user.Role = *req.Role
+	}
+	if req.Email != nil && *req.Email != user.Email {
+		existingUser, err := us.userRepository.GetByEmail(organizationID, *req.Email)
+		if err != nil {
+			log.Error().Err(err).Msg("Error checking existing email")
+			return nil, err
+		}
+		if existingUser != nil && existingUser.ID != user.ID {
+			return nil, ErrEmailExists
+		}
+		user.Email = *req.Email
+	}
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	if req.Phone != nil {
+		user.Phone = *req.Phone
+	}
+	if req.Timezone != nil {
+		user.Timezone = *req.Timezone
+	}
+
+	if err := us.userRepository.UpdateUser(user); err != nil {
+		if repository.IsUniqueViolation(err) {
+			return nil, ErrEmailExists
+		}
+		log.Error().Err(err).Uint("userID", id).Msg("Error updating user")
+		return nil, ErrUpdateFailed
+	}
+	return user, nil
+}
+
+// DeactivateUser stops the user from signing in and being booked, and
+// signs them out by revoking their refresh tokens. Their upcoming pending
+// and confirmed appointments are cancelled on the actor's behalf unless req
+// asks to keep them.
+func (us *userService) DeactivateUser(organizationID uint, actor *model.User, id uint, req *request.DeactivateUserRequest) (*response.DeactivatedUserResponse, error) {
+	if actor.ID == id {
+		return nil, ErrCannotDeactivateSelf
+	}
+
+	tx := us.db.Begin()
+
+	user, err := us.userRepository.GetByIdForUpdate(tx, organizationID, id)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("userID", id).Msg("Error fetching user for deactivation")
+		return nil, err
+	}
+	if user == nil {
+		tx.Rollback()
+		return nil, ErrUserNotFound
+	}
+	if !user.Active() {
+		tx.Rollback()
+		return nil, ErrUserAlreadyDeactivated
+	}
+
+	now := us.now()
+	user.DeactivatedAt = &now
+	if err := us.userRepository.UpdateUserWithTx(tx, user, events.UserDeactivated); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("userID", id).Msg("Error deactivating user")
+		return nil, ErrUpdateFailed
+	}
+	if err := us.authRepository.RevokeUserRefreshTokensWithTx(tx, user.ID, now); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("userID", id).Msg("Error revoking refresh tokens of deactivated user")
+		return nil, ErrUpdateFailed
+	}
+
+	cancelled := []model.Appointment{}
+	if req.Appointments != "keep" {
+		cancelled, err = us.appointmentRepository.FindUpcomingForUserForUpdate(tx, user.ID, now)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("userID", id).Msg("Error fetching upcoming appointments")
+			return nil, ErrUpdateFailed
+		}
+		for i := range cancelled {
+			if err := applyStatusTransition(&cancelled[i], enums.Cancelled, &actor.ID, now); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			if err := us.appointmentRepository.UpdateWithTx(tx, &cancelled[i], events.AppointmentCancelled); err != nil {
+				tx.Rollback()
+				log.Error().Err(err).Uint("appointmentID", cancelled[i].ID).Msg("Error cancelling appointment of deactivated user")
+				return nil, ErrUpdateFailed
+			}
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateFailed
+	}
+	log.Info().Uint("userID", id).Uint("actorID", actor.ID).Int("cancelled", len(cancelled)).Msg("User deactivated")
+	return &response.DeactivatedUserResponse{User: *user, CancelledAppointments: cancelled}, nil
+}
+
+// ReactivateUser lets a deactivated user sign in and be booked again.
+// Appointments cancelled on deactivation stay cancelled.
+func (us *userService) ReactivateUser(organizationID, id uint) (*model.User, error) {
+	user, err := us.userRepository.GetById(organizationID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", id).Msg("Error fetching user for reactivation")
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Active() {
+		return nil, ErrUserNotDeactivated
+	}
+
+	user.DeactivatedAt = nil
+	if err := us.userRepository.UpdateUser(user); err != nil {
+		log.Error().Err(err).Uint("userID", id).Msg("Error reactivating user")
+		return nil, ErrUpdateFailed
+	}
+	return user, nil
+}
+
+// DeleteUser soft-deletes a deactivated user, so that only users who can no
+// longer sign in or be booked disappear. Until the user is purged they can
+// be restored with RestoreUser.
+func (us *userService) DeleteUser(organizationID, id uint) error {
+	tx := us.db.Begin()
+
+	user, err := us.userRepository.GetByIdForUpdate(tx, organizationID, id)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("userID", id).Msg("Error fetching user for deletion")
+		return err
+	}
+	if user == nil {
+		tx.Rollback()
+		return ErrUserNotFound
+	}
+	if user.Active() {
+		tx.Rollback()
+		return ErrUserNotDeactivated
+	}
+
+	if err := us.userRepository.DeleteUserWithTx(tx, user); err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("userID", id).Msg("Error deleting user")
+		return ErrUpdateFailed
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error committing transaction")
+		return ErrUpdateFailed
+	}
+	return nil
+}
+
+// RestoreUser undeletes a deleted user, who stays deactivated until
+// reactivated. It fails if their email has been taken since.
+func (us *userService) RestoreUser(organizationID, id uint) (*model.User, error) {
+	tx := us.db.Begin()
+
+	user, err := us.userRepository.GetDeletedByIdForUpdate(tx, organizationID, id)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Uint("userID", id).Msg("Error fetching deleted user")
+		return nil, err
+	}
+	if user == nil {
+		tx.Rollback()
+		return nil, ErrUserNotFound
+	}
+	existingUser, err := us.userRepository.GetByEmailWithTx(tx, organizationID, user.Email)
+	if err != nil {
+		tx.Rollback()
+		log.Error().Err(err).Msg("Error checking existing email")
+		return nil, err
+	}
+	if existingUser != nil {
+		tx.Rollback()
+		return nil, ErrEmailExists
+	}
+
+	if err := us.userRepository.RestoreUserWithTx(tx, user); err != nil {
+		tx.Rollback()
+		if repository.IsUniqueViolation(err) {
+			return nil, ErrEmailExists
+		}
+		log.Error().Err(err).Uint("userID", id).Msg("Error restoring user")
+		return nil, ErrUpdateFailed
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		if repository.IsUniqueViolation(err) {
+			return nil, ErrEmailExists
+		}
+		log.Error().Err(err).Msg("Error committing transaction")
+		return nil, ErrUpdateFailed
+	}
+	return user, nil
+}
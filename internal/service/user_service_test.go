@@ -1,14 +1,19 @@
 package service
 
 import (
+	"fmt"
+	"queue_system/internal/auth"
 	"queue_system/internal/dto/request"
 	"queue_system/internal/model"
+	"queue_system/internal/repository"
 	"queue_system/internal/repository/mocks"
+	"queue_system/test/txdb"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func TestUserService_CreateUser_Success(t *testing.T) {
@@ -17,12 +22,12 @@ func TestUserService_CreateUser_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
-	userService := NewUserService(mockUserRepo)
+	userService := NewUserService(mockUserRepo, nil, nil, nil)
 
 	req := &request.CreateUserRequest{
 		Name:  "Test User GoMock",
 		Email: "test.gomock@example.com",
-		Role:  "member",
+		Role:  "client",
 	}
 	expectedUser := &model.User{
 		ID:    1,
@@ -30,7 +35,7 @@ func TestUserService_CreateUser_Success(t *testing.T) {
 		Email: req.Email,
 		Role:  req.Role,
 	}
-	mockUserRepo.EXPECT().GetByEmail(req.Email).Return(nil, nil).Times(1)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), req.Email).Return(nil, nil).Times(1)
 	mockUserRepo.EXPECT().CreateUser(gomock.Any()).DoAndReturn(
 		func(userArg *model.User) (*model.User, error) {
 			userArg.ID = 1
@@ -40,7 +45,7 @@ func TestUserService_CreateUser_Success(t *testing.T) {
 		}).Times(1)
 
 	// WHEN
-	createdUser, err := userService.CreateUser(req)
+	createdUser, err := userService.CreateUser(1, nil, req)
 
 	//THEN
 	assert.NoError(t, err)
@@ -55,12 +60,12 @@ func TestUserService_CreateUser_EmailExists(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
-	userService := NewUserService(mockUserRepo)
+	userService := NewUserService(mockUserRepo, nil, nil, nil)
 
 	request := &request.CreateUserRequest{
 		Name:  "Test User GoMock",
 		Email: "exists.gomock@example.com",
-		Role:  "member",
+		Role:  "client",
 	}
 	exsistingUser := &model.User{
 		ID:    1,
@@ -68,9 +73,9 @@ func TestUserService_CreateUser_EmailExists(t *testing.T) {
 		Name:  "Existing User",
 		Role:  request.Role,
 	}
-	mockUserRepo.EXPECT().GetByEmail(request.Email).Return(exsistingUser, nil).Times(1)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), request.Email).Return(exsistingUser, nil).Times(1)
 	// WHEN
-	createdUser, err := userService.CreateUser(request)
+	createdUser, err := userService.CreateUser(1, nil, request)
 
 	// THEN
 	assert.Error(t, err)
@@ -78,12 +83,76 @@ func TestUserService_CreateUser_EmailExists(t *testing.T) {
 	assert.Equal(t, ErrEmailExists, err)
 }
 
+func TestUserService_CreateUser_RoleNeedsAdmin(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(mockUserRepo, nil, nil, nil)
+
+	req := &request.CreateUserRequest{Name: "New Staff", Email: "staff@example.com", Role: "staff"}
+	admin := &model.User{ID: 1, Role: "admin"}
+	mockUserRepo.EXPECT().GetByEmail(uint(1), req.Email).Return(nil, nil).Times(1)
+	mockUserRepo.EXPECT().CreateUser(gomock.Any()).DoAndReturn(
+		func(userArg *model.User) (*model.User, error) {
+			userArg.ID = 2
+			return userArg, nil
+		}).Times(1)
+
+	// WHEN
+	_, signUpErr := userService.CreateUser(1, nil, req)
+	_, providerErr := userService.CreateUser(1, &model.User{ID: 3, Role: "provider"}, req)
+	createdUser, err := userService.CreateUser(1, admin, req)
+
+	// THEN
+	assert.ErrorIs(t, signUpErr, auth.ErrRoleNotAssignable)
+	assert.ErrorIs(t, providerErr, auth.ErrRoleNotAssignable)
+	assert.NoError(t, err)
+	assert.Equal(t, "staff", createdUser.Role)
+}
+
+func TestUserService_CreateUser_DefaultsToClient(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(mockUserRepo, nil, nil, nil)
+
+	req := &request.CreateUserRequest{Name: "New Client", Email: "client@example.com"}
+	mockUserRepo.EXPECT().GetByEmail(uint(1), req.Email).Return(nil, nil).Times(1)
+	mockUserRepo.EXPECT().CreateUser(gomock.Any()).DoAndReturn(
+		func(userArg *model.User) (*model.User, error) {
+			return userArg, nil
+		}).Times(1)
+
+	// WHEN
+	createdUser, err := userService.CreateUser(1, nil, req)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "client", createdUser.Role)
+	assert.Equal(t, uint(1), createdUser.OrganizationID)
+}
+
+func TestUserService_CreateUser_RequiresOrganization(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	userService := NewUserService(mocks.NewMockUserRepository(ctrl), nil, nil, nil)
+
+	// WHEN
+	_, err := userService.CreateUser(0, nil, &request.CreateUserRequest{Name: "Nobody", Email: "nobody@example.com"})
+
+	// THEN
+	assert.ErrorIs(t, err, ErrOrganizationRequired)
+}
+
 func TestUserService_GetUserById_Success(t *testing.T) {
 	// GIVEN
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	mockUserRepository := mocks.NewMockUserRepository(ctrl)
-	userService := NewUserService(mockUserRepository)
+	userService := NewUserService(mockUserRepository, nil, nil, nil)
 	userID := uint(1)
 
 	expectedUser := &model.User{
@@ -93,13 +162,219 @@ func TestUserService_GetUserById_Success(t *testing.T) {
 		Role:  "admin",
 	}
 
-	mockUserRepository.EXPECT().GetById(userID).Return(expectedUser, nil).Times(1)
+	mockUserRepository.EXPECT().GetById(uint(1), userID).Return(expectedUser, nil).Times(1)
 
 	//WHEN
-	user, err := userService.GetUserById(userID)
+	user, err := userService.GetUserById(1, userID)
 
 	//THEN
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, expectedUser, user)
 }
+
+func TestUserService_ListUsers_BuildsFilter(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(mockUserRepo, nil, nil, nil)
+	active := false
+	users := []model.User{{ID: 4, Name: "Ann", DeactivatedAt: &time.Time{}}}
+
+	mockUserRepo.EXPECT().List(repository.UserFilter{
+		OrganizationID: 1,
+		Search:         "ann",
+		Role:           "provider",
+		Active:         &active,
+		Offset:         20,
+		Limit:          10,
+	}).Return(users, int64(21), nil)
+
+	// WHEN
+	result, total, err := userService.ListUsers(1, &model.User{ID: 9, Role: "admin"}, &request.ListUsersRequest{Search: " ann ", Role: "provider", Status: "deactivated", Page: 3, PageSize: 10})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, users, result)
+	assert.Equal(t, int64(21), total)
+}
+
+func TestUserService_UpdateUser_EmailTaken(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(mockUserRepo, nil, nil, nil)
+	user := &model.User{ID: 2, Name: "Pat", Email: "pat@example.com", Role: "client"}
+	email := "sam@example.com"
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(2)).Return(user, nil)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), email).Return(&model.User{ID: 3, Email: email}, nil)
+	mockUserRepo.EXPECT().UpdateUser(gomock.Any()).Times(0)
+
+	// WHEN
+	_, err := userService.UpdateUser(1, user, 2, &request.UpdateUserRequest{Email: &email})
+
+	// THEN
+	assert.ErrorIs(t, err, ErrEmailExists)
+}
+
+// sqlStateError stands in for a driver error carrying a SQLSTATE code.
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestUserService_UpdateUser_EmailTakenConcurrently(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(mockUserRepo, nil, nil, nil)
+	user := &model.User{ID: 2, Name: "Pat", Email: "pat@example.com", Role: "client"}
+	email := "sam@example.com"
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(2)).Return(user, nil)
+	mockUserRepo.EXPECT().GetByEmail(uint(1), email).Return(nil, nil)
+	mockUserRepo.EXPECT().UpdateUser(gomock.Any()).Return(fmt.Errorf("save user: %w", sqlStateError("23505")))
+
+	// WHEN
+	_, err := userService.UpdateUser(1, user, 2, &request.UpdateUserRequest{Email: &email})
+
+	// THEN
+	assert.ErrorIs(t, err, ErrEmailExists)
+}
+
+func TestUserService_UpdateUser_RoleChange(t *testing.T) {
+	for name, tc := range map[string]struct {
+		actorRole string
+		expected  error
+	}{
+		"admin":    {"admin", nil},
+		"client":   {"client", auth.ErrRoleNotAssignable},
+		"provider": {"provider", auth.ErrRoleNotAssignable},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockUserRepo := mocks.NewMockUserRepository(ctrl)
+			userService := NewUserService(mockUserRepo, nil, nil, nil)
+			actor := &model.User{ID: 9, Role: tc.actorRole}
+			name, role := "Pat Doe", "provider"
+
+			mockUserRepo.EXPECT().GetById(uint(1), uint(2)).Return(&model.User{ID: 2, Name: "Pat", Role: "client"}, nil)
+			if tc.expected == nil {
+				mockUserRepo.EXPECT().UpdateUser(gomock.Any()).Return(nil)
+			}
+
+			// WHEN
+			updated, err := userService.UpdateUser(1, actor, 2, &request.UpdateUserRequest{Name: &name, Role: &role})
+
+			// THEN
+			if tc.expected != nil {
+				assert.ErrorIs(t, err, tc.expected)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "Pat Doe", updated.Name)
+			assert.Equal(t, "provider", updated.Role)
+		})
+	}
+}
+
+func TestUserService_DeactivateUser_Self(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	userService := NewUserService(mocks.NewMockUserRepository(ctrl), nil, nil, nil)
+
+	// WHEN
+	_, err := userService.DeactivateUser(1, &model.User{ID: 2, Role: "admin"}, 2, &request.DeactivateUserRequest{})
+
+	// THEN
+	assert.ErrorIs(t, err, ErrCannotDeactivateSelf)
+}
+
+func TestUserService_ReactivateUser(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(mockUserRepo, nil, nil, nil)
+	deactivatedAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	mockUserRepo.EXPECT().GetById(uint(1), uint(2)).Return(&model.User{ID: 2, DeactivatedAt: &deactivatedAt}, nil)
+	mockUserRepo.EXPECT().UpdateUser(gomock.Any()).Return(nil)
+	mockUserRepo.EXPECT().GetById(uint(1), uint(3)).Return(&model.User{ID: 3}, nil)
+
+	// WHEN
+	reactivated, err := userService.ReactivateUser(1, 2)
+	_, activeErr := userService.ReactivateUser(1, 3)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.True(t, reactivated.Active())
+	assert.ErrorIs(t, activeErr, ErrUserNotDeactivated)
+}
+
+func TestUserService_ListUsers_DeletedOnlyForAdmins(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(mockUserRepo, nil, nil, nil)
+	req := &request.ListUsersRequest{IncludeDeleted: true}
+
+	mockUserRepo.EXPECT().List(repository.UserFilter{OrganizationID: 1, IncludeDeleted: true, Limit: defaultUserPageSize}).Return(nil, int64(0), nil)
+
+	// WHEN
+	_, _, staffErr := userService.ListUsers(1, &model.User{ID: 8, Role: "staff"}, req)
+	_, _, adminErr := userService.ListUsers(1, &model.User{ID: 9, Role: "admin"}, req)
+
+	// THEN
+	assert.ErrorIs(t, staffErr, auth.ErrPermissionDenied)
+	assert.NoError(t, adminErr)
+}
+
+func TestUserService_RestoreUser(t *testing.T) {
+	for name, tc := range map[string]struct {
+		taken      *model.User
+		restoreErr error
+		expected   error
+		txLog      []string
+	}{
+		"restored":                 {nil, nil, nil, []string{"begin", "commit"}},
+		"email taken":              {&model.User{ID: 3, Email: "pat@example.com"}, nil, ErrEmailExists, []string{"begin", "rollback"}},
+		"email taken concurrently": {nil, sqlStateError("23505"), ErrEmailExists, []string{"begin", "rollback"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db, txLog := txdb.Open(t)
+			mockUserRepo := mocks.NewMockUserRepository(ctrl)
+			userService := NewUserService(mockUserRepo, nil, nil, db)
+			deleted := &model.User{ID: 2, Email: "pat@example.com", DeletedAt: gorm.DeletedAt{Time: time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC), Valid: true}}
+
+			mockUserRepo.EXPECT().GetDeletedByIdForUpdate(gomock.Any(), uint(1), uint(2)).Return(deleted, nil)
+			mockUserRepo.EXPECT().GetByEmailWithTx(gomock.Any(), uint(1), "pat@example.com").Return(tc.taken, nil)
+			if tc.taken == nil {
+				mockUserRepo.EXPECT().RestoreUserWithTx(gomock.Any(), deleted).Return(tc.restoreErr)
+			}
+
+			// WHEN
+			restored, err := userService.RestoreUser(1, 2)
+
+			// THEN
+			assert.Equal(t, tc.txLog, txLog.Entries())
+			if tc.expected != nil {
+				assert.ErrorIs(t, err, tc.expected)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, uint(2), restored.ID)
+		})
+	}
+}
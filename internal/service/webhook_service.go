@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"queue_system/config"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/enums"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/netguard"
+	"queue_system/internal/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookInactive         = errors.New("webhook is disabled")
+	ErrUnknownEventType        = errors.New("unknown event type")
+	ErrWebhookURLNotAllowed    = errors.New("webhook url must be a public http or https url")
+	ErrCreateWebhookFailed     = errors.New("failed to create webhook")
+	ErrUpdateWebhookFailed     = errors.New("failed to update webhook")
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, organizationID uint, req *request.CreateWebhookRequest) (*model.Webhook, error)
+	ListWebhooks(organizationID uint) ([]model.Webhook, error)
+	GetWebhookByID(organizationID, id uint) (*model.Webhook, error)
+	UpdateWebhook(ctx context.Context, organizationID, id uint, req *request.UpdateWebhookRequest) (*model.Webhook, error)
+	DeleteWebhook(organizationID, id uint) error
+	ListDeliveries(organizationID, webhookID uint, req *request.ListWebhookDeliveriesRequest) ([]model.WebhookDelivery, error)
+	GetDelivery(organizationID, webhookID, deliveryID uint) (*model.WebhookDelivery, error)
+	ReplayDelivery(organizationID, webhookID, deliveryID uint) (*model.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	webhookRepository repository.WebhookRepository
+	guard             *netguard.Guard
+}
+
+func NewWebhookService(webhookRepository repository.WebhookRepository, cfg *config.Config) WebhookService {
+	return &webhookService{
+		webhookRepository: webhookRepository,
+		guard:             netguard.New(cfg.Webhook.AllowPrivateTargets),
+	}
+}
+
+func (ws *webhookService) CreateWebhook(ctx context.Context, organizationID uint, req *request.CreateWebhookRequest) (*model.Webhook, error) {
+	if err := validateEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+	if err := ws.validateURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+
+	webhook := &model.Webhook{
+		OrganizationID: organizationID,
+		URL:            req.URL,
+		Secret:         req.Secret,
+		EventTypes:     req.EventTypes,
+		Description:    req.Description,
+		Active:         true,
+	}
+	if err := ws.webhookRepository.CreateWebhook(webhook); err != nil {
+		log.Error().Err(err).Msg("Error creating webhook")
+		return nil, ErrCreateWebhookFailed
+	}
+	return webhook, nil
+}
+
+func (ws *webhookService) ListWebhooks(organizationID uint) ([]model.Webhook, error) {
+	webhooks, err := ws.webhookRepository.ListWebhooks(organizationID)
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing webhooks")
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (ws *webhookService) GetWebhookByID(organizationID, id uint) (*model.Webhook, error) {
+	webhook, err := ws.webhookRepository.GetWebhookByID(organizationID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("webhookID", id).Msg("Error fetching webhook")
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+func (ws *webhookService) UpdateWebhook(ctx context.Context, organizationID, id uint, req *request.UpdateWebhookRequest) (*model.Webhook, error) {
+	webhook, err := ws.GetWebhookByID(organizationID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := ws.validateURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *req.URL
+	}
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+	}
+	if req.EventTypes != nil {
+		if err := validateEventTypes(*req.EventTypes); err != nil {
+			return nil, err
+		}
+		webhook.EventTypes = *req.EventTypes
+	}
+	if req.Description != nil {
+		webhook.Description = *req.Description
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := ws.webhookRepository.UpdateWebhook(webhook); err != nil {
+		log.Error().Err(err).Uint("webhookID", id).Msg("Error updating webhook")
+		return nil, ErrUpdateWebhookFailed
+	}
+	return webhook, nil
+}
+
+func (ws *webhookService) DeleteWebhook(organizationID, id uint) error {
+	deleted, err := ws.webhookRepository.DeleteWebhook(organizationID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("webhookID", id).Msg("Error deleting webhook")
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (ws *webhookService) ListDeliveries(organizationID, webhookID uint, req *request.ListWebhookDeliveriesRequest) ([]model.WebhookDelivery, error) {
+	if _, err := ws.GetWebhookByID(organizationID, webhookID); err != nil {
+		return nil, err
+	}
+	deliveries, err := ws.webhookRepository.ListDeliveries(organizationID, webhookID, req.Status)
+	if err != nil {
+		log.Error().Err(err).Uint("webhookID", webhookID).Msg("Error listing webhook deliveries")
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (ws *webhookService) GetDelivery(organizationID, webhookID, deliveryID uint) (*model.WebhookDelivery, error) {
+	delivery, err := ws.webhookRepository.GetDelivery(organizationID, webhookID, deliveryID)
+	if err != nil {
+		log.Error().Err(err).Uint("deliveryID", deliveryID).Msg("Error fetching webhook delivery")
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+// ReplayDelivery queues the event of an earlier delivery to be sent again,
+// whatever the outcome of the original. The replay is a new delivery so the
+// original's log entry is kept.
+func (ws *webhookService) ReplayDelivery(organizationID, webhookID, deliveryID uint) (*model.WebhookDelivery, error) {
+	webhook, err := ws.GetWebhookByID(organizationID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.Active {
+		return nil, ErrWebhookInactive
+	}
+	original, err := ws.GetDelivery(organizationID, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	replay := &model.WebhookDelivery{
+		OrganizationID: original.OrganizationID,
+		WebhookID:      original.WebhookID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		OccurredAt:     original.OccurredAt,
+		Status:         string(enums.WebhookDeliveryPending),
+		NextAttemptAt:  time.Now(),
+		ReplayOfID:     &original.ID,
+	}
+	if err := ws.webhookRepository.CreateDelivery(replay); err != nil {
+		log.Error().Err(err).Uint("deliveryID", deliveryID).Msg("Error queueing webhook replay")
+		return nil, err
+	}
+	return replay, nil
+}
+
+func validateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !events.IsKnownType(eventType) {
+			return fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+		}
+	}
+	return nil
+}
+
+// validateURL refuses endpoints on loopback, private or link-local
+// addresses, which would let integrators probe the internal network. The
+// dispatcher checks the address again when it connects.
+func (ws *webhookService) validateURL(ctx context.Context, url string) error {
+	if err := ws.guard.CheckURL(ctx, url); err != nil {
+		return fmt.Errorf("%w: %v", ErrWebhookURLNotAllowed, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"queue_system/config"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookService_CreateWebhook_UnknownEventType(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWebhookRepo := mocks.NewMockWebhookRepository(ctrl)
+	webhookService := NewWebhookService(mockWebhookRepo, &config.Config{})
+
+	req := &request.CreateWebhookRequest{
+		URL:        "https://example.com/hooks",
+		Secret:     "super-secret-value",
+		EventTypes: []string{events.UserCreated, "user.exploded"},
+	}
+
+	// WHEN
+	webhook, err := webhookService.CreateWebhook(context.Background(), 1, req)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrUnknownEventType)
+	assert.Contains(t, err.Error(), "user.exploded")
+	assert.Nil(t, webhook)
+}
+
+func TestWebhookService_CreateWebhook_PrivateAddress(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWebhookRepo := mocks.NewMockWebhookRepository(ctrl)
+	webhookService := NewWebhookService(mockWebhookRepo, &config.Config{})
+	mockWebhookRepo.EXPECT().CreateWebhook(gomock.Any()).Times(0)
+
+	for _, url := range []string{"http://127.0.0.1:8080/hooks", "http://169.254.169.254/latest/meta-data", "http://10.0.0.5/hooks"} {
+		// WHEN
+		webhook, err := webhookService.CreateWebhook(context.Background(), 1, &request.CreateWebhookRequest{URL: url, Secret: "super-secret-value"})
+
+		// THEN
+		assert.ErrorIs(t, err, ErrWebhookURLNotAllowed, url)
+		assert.Nil(t, webhook)
+	}
+}
+
+func TestWebhookService_ReplayDelivery_QueuesCopy(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWebhookRepo := mocks.NewMockWebhookRepository(ctrl)
+	webhookService := NewWebhookService(mockWebhookRepo, &config.Config{})
+
+	original := &model.WebhookDelivery{ID: 4, OrganizationID: 2, WebhookID: 1, EventID: 9, EventType: events.UserCreated,
+		Payload: `{"id":5}`, Status: "failed", Attempts: 8, ResponseCode: 500}
+	mockWebhookRepo.EXPECT().GetWebhookByID(uint(2), uint(1)).Return(&model.Webhook{ID: 1, OrganizationID: 2, Active: true}, nil).Times(1)
+	mockWebhookRepo.EXPECT().GetDelivery(uint(2), uint(1), uint(4)).Return(original, nil).Times(1)
+	mockWebhookRepo.EXPECT().CreateDelivery(gomock.Any()).Return(nil).Times(1)
+
+	// WHEN
+	replay, err := webhookService.ReplayDelivery(2, 1, 4)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "pending", replay.Status)
+	assert.Equal(t, 0, replay.Attempts)
+	assert.Equal(t, original.EventID, replay.EventID)
+	assert.Equal(t, original.Payload, replay.Payload)
+	assert.Equal(t, uint(2), replay.OrganizationID)
+	require.NotNil(t, replay.ReplayOfID)
+	assert.Equal(t, uint(4), *replay.ReplayOfID)
+}
+
+func TestWebhookService_ReplayDelivery_InactiveWebhook(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWebhookRepo := mocks.NewMockWebhookRepository(ctrl)
+	webhookService := NewWebhookService(mockWebhookRepo, &config.Config{})
+
+	mockWebhookRepo.EXPECT().GetWebhookByID(uint(2), uint(1)).Return(&model.Webhook{ID: 1, OrganizationID: 2, Active: false}, nil).Times(1)
+
+	// WHEN
+	replay, err := webhookService.ReplayDelivery(2, 1, 4)
+
+	// THEN
+	assert.Equal(t, ErrWebhookInactive, err)
+	assert.Nil(t, replay)
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"errors"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const clockLayout = "15:04"
+
+var (
+	ErrWorkingHoursNotFound   = errors.New("working hours not found")
+	ErrOverrideNotFound       = errors.New("availability override not found")
+	ErrInvalidClockTime       = errors.New("invalid time of day, use HH:MM (e.g., 09:00)")
+	ErrOverrideHoursMismatch  = errors.New("open overrides need start_time and end_time, closed overrides must not have them")
+	ErrOutsideWorkingHours    = errors.New("participant is not available at the requested time")
+	ErrSaveWorkingHoursFailed = errors.New("failed to save working hours")
+)
+
+type WorkingHoursService interface {
+	ListWorkingHours(organizationID, userID uint) ([]model.WorkingHours, error)
+	CreateWorkingHours(organizationID, userID uint, req *request.WorkingHoursRequest) (*model.WorkingHours, error)
+	UpdateWorkingHours(organizationID, userID, id uint, req *request.WorkingHoursRequest) (*model.WorkingHours, error)
+	DeleteWorkingHours(organizationID, userID, id uint) error
+	ListOverrides(organizationID, userID uint) ([]model.AvailabilityOverride, error)
+	CreateOverride(organizationID, userID uint, req *request.AvailabilityOverrideRequest) (*model.AvailabilityOverride, error)
+	DeleteOverride(organizationID, userID, id uint) error
+}
+
+type workingHoursService struct {
+	workingHoursRepository repository.WorkingHoursRepository
+	userRepository         repository.UserRepository
+}
+
+func NewWorkingHoursService(workingHoursRepository repository.WorkingHoursRepository, userRepository repository.UserRepository) WorkingHoursService {
+	return &workingHoursService{
+		workingHoursRepository: workingHoursRepository,
+		userRepository:         userRepository,
+	}
+}
+
+func (ws *workingHoursService) ListWorkingHours(organizationID, userID uint) ([]model.WorkingHours, error) {
+	if err := ws.ensureUserExists(organizationID, userID); err != nil {
+		return nil, err
+	}
+	windows, err := ws.workingHoursRepository.ListWindows(userID)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error listing working hours")
+		return nil, err
+	}
+	return windows, nil
+}
+
+func (ws *workingHoursService) CreateWorkingHours(organizationID, userID uint, req *request.WorkingHoursRequest) (*model.WorkingHours, error) {
+	if err := ws.ensureUserExists(organizationID, userID); err != nil {
+		return nil, err
+	}
+	window := &model.WorkingHours{UserID: userID}
+	if err := applyWorkingHoursRequest(window, req); err != nil {
+		return nil, err
+	}
+	if err := ws.workingHoursRepository.CreateWindow(window); err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error creating working hours")
+		return nil, ErrSaveWorkingHoursFailed
+	}
+	return window, nil
+}
+
+func (ws *workingHoursService) UpdateWorkingHours(organizationID, userID, id uint, req *request.WorkingHoursRequest) (*model.WorkingHours, error) {
+	if err := ws.ensureUserExists(organizationID, userID); err != nil {
+		return nil, err
+	}
+	window, err := ws.workingHoursRepository.GetWindow(userID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("workingHoursID", id).Msg("Error fetching working hours")
+		return nil, err
+	}
+	if window == nil {
+		return nil, ErrWorkingHoursNotFound
+	}
+	if err := applyWorkingHoursRequest(window, req); err != nil {
+		return nil, err
+	}
+	if err := ws.workingHoursRepository.UpdateWindow(window); err != nil {
+		log.Error().Err(err).Uint("workingHoursID", id).Msg("Error updating working hours")
+		return nil, ErrSaveWorkingHoursFailed
+	}
+	return window, nil
+}
+
+func (ws *workingHoursService) DeleteWorkingHours(organizationID, userID, id uint) error {
+	if err := ws.ensureUserExists(organizationID, userID); err != nil {
+		return err
+	}
+	deleted, err := ws.workingHoursRepository.DeleteWindow(userID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("workingHoursID", id).Msg("Error deleting working hours")
+		return err
+	}
+	if !deleted {
+		return ErrWorkingHoursNotFound
+	}
+	return nil
+}
+
+func (ws *workingHoursService) ListOverrides(organizationID, userID uint) ([]model.AvailabilityOverride, error) {
+	if err := ws.ensureUserExists(organizationID, userID); err != nil {
+		return nil, err
+	}
+	overrides, err := ws.workingHoursRepository.ListOverrides(userID)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error listing availability overrides")
+		return nil, err
+	}
+	return overrides, nil
+}
+
+func (ws *workingHoursService) CreateOverride(organizationID, userID uint, req *request.AvailabilityOverrideRequest) (*model.AvailabilityOverride, error) {
+	if err := ws.ensureUserExists(organizationID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := time.Parse(calendarDateLayout, req.Date); err != nil {
+		return nil, ErrInvalidDateFormat
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	hasHours := req.StartTime != "" || req.EndTime != ""
+	if req.Closed == hasHours {
+		return nil, ErrOverrideHoursMismatch
+	}
+	if !req.Closed {
+		if err := validateClockRange(req.StartTime, req.EndTime); err != nil {
+			return nil, err
+		}
+	}
+
+	override := &model.AvailabilityOverride{
+		UserID:    userID,
+		Date:      req.Date,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Closed:    req.Closed,
+		Timezone:  req.Timezone,
+		Reason:    req.Reason,
+	}
+	if err := ws.workingHoursRepository.CreateOverride(override); err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error creating availability override")
+		return nil, ErrSaveWorkingHoursFailed
+	}
+	return override, nil
+}
+
+func (ws *workingHoursService) DeleteOverride(organizationID, userID, id uint) error {
+	if err := ws.ensureUserExists(organizationID, userID); err != nil {
+		return err
+	}
+	deleted, err := ws.workingHoursRepository.DeleteOverride(userID, id)
+	if err != nil {
+		log.Error().Err(err).Uint("overrideID", id).Msg("Error deleting availability override")
+		return err
+	}
+	if !deleted {
+		return ErrOverrideNotFound
+	}
+	return nil
+}
+
+func (ws *workingHoursService) ensureUserExists(organizationID, userID uint) error {
+	user, err := ws.userRepository.GetById(organizationID, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("userID", userID).Msg("Error fetching user")
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func applyWorkingHoursRequest(window *model.WorkingHours, req *request.WorkingHoursRequest) error {
+	if err := validateClockRange(req.StartTime, req.EndTime); err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+	window.Weekday = *req.Weekday
+	window.StartTime = req.StartTime
+	window.EndTime = req.EndTime
+	window.Timezone = req.Timezone
+	return nil
+}
+
+func validateClockRange(start, end string) error {
+	startClock, err := time.Parse(clockLayout, start)
+	if err != nil {
+		return ErrInvalidClockTime
+	}
+	endClock, err := time.Parse(clockLayout, end)
+	if err != nil {
+		return ErrInvalidClockTime
+	}
+	if !endClock.After(startClock) {
+		return ErrEndTimeBeforeStartTime
+	}
+	return nil
+}
+
+// ensureWithinWorkingHours rejects [start, end) unless it fits inside one of
+// the user's working windows.
+func ensureWithinWorkingHours(workingHoursRepository repository.WorkingHoursRepository, userID uint, start, end time.Time) error {
+	windows, err := workingWindows(workingHoursRepository, userID, start, end)
+	if err != nil {
+		return err
+	}
+	for _, window := range windows {
+		if !start.Before(window.Start) && !end.After(window.End) {
+			return nil
+		}
+	}
+	return ErrOutsideWorkingHours
+}
+
+// workingWindows returns the windows within [from, to) in which the user can
+// be booked, in start order. An override for a local date replaces the
+// weekly windows on that date, and a closed one leaves none; users without
+// any weekly windows are always bookable except on override dates.
+func workingWindows(workingHoursRepository repository.WorkingHoursRepository, userID uint, from, to time.Time) ([]response.TimeSlot, error) {
+	// Widen the UTC dates by a day on each side so every timezone's local
+	// dates are covered.
+	overrides, err := workingHoursRepository.ListOverridesBetween(userID,
+		from.UTC().AddDate(0, 0, -1).Format(calendarDateLayout),
+		to.UTC().AddDate(0, 0, 1).Format(calendarDateLayout))
+	if err != nil {
+		return nil, err
+	}
+	weekly, err := workingHoursRepository.ListWindows(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var windows []response.TimeSlot
+	if len(weekly) == 0 {
+		windows = []response.TimeSlot{{Start: from, End: to}}
+	}
+	for _, window := range weekly {
+		loc, err := time.LoadLocation(window.Timezone)
+		if err != nil {
+			continue
+		}
+		last := to.In(loc).Format(calendarDateLayout)
+		for day := from.In(loc).AddDate(0, 0, -1); day.Format(calendarDateLayout) <= last; day = day.AddDate(0, 0, 1) {
+			if int(day.Weekday()) != window.Weekday {
+				continue
+			}
+			if slot, ok := clockWindow(window.Timezone, day.Format(calendarDateLayout), window.StartTime, window.EndTime); ok {
+				windows = append(windows, slot)
+			}
+		}
+	}
+
+	closed := make(map[string]bool)
+	for _, override := range overrides {
+		if override.Closed {
+			closed[override.Timezone+" "+override.Date] = true
+		}
+	}
+	var overridden []response.TimeSlot
+	for _, override := range overrides {
+		// The override replaces the weekly windows for the whole local date.
+		day, ok := clockWindow(override.Timezone, override.Date, "00:00", "00:00")
+		if !ok {
+			continue
+		}
+		day.End = day.End.AddDate(0, 0, 1)
+		windows = subtractSlot(windows, day)
+		if override.Closed || closed[override.Timezone+" "+override.Date] {
+			continue
+		}
+		if slot, ok := clockWindow(override.Timezone, override.Date, override.StartTime, override.EndTime); ok {
+			overridden = append(overridden, slot)
+		}
+	}
+	windows = append(windows, overridden...)
+
+	clipped := windows[:0]
+	for _, window := range windows {
+		if window.Start.Before(from) {
+			window.Start = from
+		}
+		if window.End.After(to) {
+			window.End = to
+		}
+		if window.End.After(window.Start) {
+			clipped = append(clipped, window)
+		}
+	}
+	sort.Slice(clipped, func(i, j int) bool { return clipped[i].Start.Before(clipped[j].Start) })
+	return clipped, nil
+}
+
+// subtractSlot removes cut from each of the windows.
+func subtractSlot(windows []response.TimeSlot, cut response.TimeSlot) []response.TimeSlot {
+	var rest []response.TimeSlot
+	for _, window := range windows {
+		if !window.Start.Before(cut.End) || !window.End.After(cut.Start) {
+			rest = append(rest, window)
+			continue
+		}
+		if window.Start.Before(cut.Start) {
+			rest = append(rest, response.TimeSlot{Start: window.Start, End: cut.Start})
+		}
+		if window.End.After(cut.End) {
+			rest = append(rest, response.TimeSlot{Start: cut.End, End: window.End})
+		}
+	}
+	return rest
+}
+
+// clockWindow returns the wall-clock window from windowStart to windowEnd
+// on date in timezone.
+func clockWindow(timezone, date, windowStart, windowEnd string) (response.TimeSlot, bool) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return response.TimeSlot{}, false
+	}
+	from, err := time.ParseInLocation(calendarDateLayout+" "+clockLayout, date+" "+windowStart, loc)
+	if err != nil {
+		return response.TimeSlot{}, false
+	}
+	to, err := time.ParseInLocation(calendarDateLayout+" "+clockLayout, date+" "+windowEnd, loc)
+	if err != nil {
+		return response.TimeSlot{}, false
+	}
+	return response.TimeSlot{Start: from, End: to}, true
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"queue_system/internal/dto/request"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureWithinWorkingHours_WeeklyWindow(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+
+	userID := uint(5)
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	assert.NoError(t, err)
+	// 2030-01-07 is a Monday.
+	windows := []model.WorkingHours{{UserID: userID, Weekday: int(time.Monday), StartTime: "09:00", EndTime: "17:00", Timezone: "Asia/Ho_Chi_Minh"}}
+
+	mockWorkingHoursRepo.EXPECT().ListOverridesBetween(userID, gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	mockWorkingHoursRepo.EXPECT().ListWindows(userID).Return(windows, nil).AnyTimes()
+
+	inside := time.Date(2030, 1, 7, 10, 0, 0, 0, loc)
+	threeAM := time.Date(2030, 1, 7, 3, 0, 0, 0, loc)
+	spillsOver := time.Date(2030, 1, 7, 16, 30, 0, 0, loc)
+	tuesday := time.Date(2030, 1, 8, 10, 0, 0, 0, loc)
+
+	// WHEN / THEN
+	assert.NoError(t, ensureWithinWorkingHours(mockWorkingHoursRepo, userID, inside, inside.Add(time.Hour)))
+	assert.Equal(t, ErrOutsideWorkingHours, ensureWithinWorkingHours(mockWorkingHoursRepo, userID, threeAM, threeAM.Add(time.Hour)))
+	assert.Equal(t, ErrOutsideWorkingHours, ensureWithinWorkingHours(mockWorkingHoursRepo, userID, spillsOver, spillsOver.Add(time.Hour)))
+	assert.Equal(t, ErrOutsideWorkingHours, ensureWithinWorkingHours(mockWorkingHoursRepo, userID, tuesday, tuesday.Add(time.Hour)))
+}
+
+func TestEnsureWithinWorkingHours_OverridesReplaceWeeklyWindows(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+
+	userID := uint(5)
+	holiday := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	saturday := time.Date(2030, 1, 5, 10, 0, 0, 0, time.UTC)
+
+	mockWorkingHoursRepo.EXPECT().ListOverridesBetween(userID, "2029-12-31", "2030-01-02").Return([]model.AvailabilityOverride{
+		{UserID: userID, Date: "2030-01-01", Closed: true, Timezone: "UTC"},
+	}, nil).Times(1)
+	mockWorkingHoursRepo.EXPECT().ListOverridesBetween(userID, "2030-01-04", "2030-01-06").Return([]model.AvailabilityOverride{
+		{UserID: userID, Date: "2030-01-05", StartTime: "09:00", EndTime: "12:00", Timezone: "UTC"},
+	}, nil).Times(1)
+	mockWorkingHoursRepo.EXPECT().ListWindows(userID).Return(nil, nil).AnyTimes()
+
+	// WHEN / THEN
+	assert.Equal(t, ErrOutsideWorkingHours, ensureWithinWorkingHours(mockWorkingHoursRepo, userID, holiday, holiday.Add(time.Hour)))
+	assert.NoError(t, ensureWithinWorkingHours(mockWorkingHoursRepo, userID, saturday, saturday.Add(time.Hour)))
+}
+
+func TestEnsureWithinWorkingHours_NoRulesIsUnrestricted(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+
+	userID := uint(5)
+	start := time.Date(2030, 1, 7, 3, 0, 0, 0, time.UTC)
+	mockWorkingHoursRepo.EXPECT().ListOverridesBetween(userID, gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	mockWorkingHoursRepo.EXPECT().ListWindows(userID).Return(nil, nil).Times(1)
+
+	// WHEN
+	err := ensureWithinWorkingHours(mockWorkingHoursRepo, userID, start, start.Add(time.Hour))
+
+	// THEN
+	assert.NoError(t, err)
+}
+
+func TestWorkingHoursService_CreateOverride_Validation(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWorkingHoursRepo := mocks.NewMockWorkingHoursRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	workingHoursService := NewWorkingHoursService(mockWorkingHoursRepo, mockUserRepo)
+
+	userID := uint(5)
+	mockUserRepo.EXPECT().GetById(uint(1), userID).Return(&model.User{ID: userID}, nil).AnyTimes()
+
+	// WHEN
+	_, errClosedWithHours := workingHoursService.CreateOverride(1, userID, &request.AvailabilityOverrideRequest{
+		Date: "2030-01-01", Closed: true, StartTime: "09:00", EndTime: "12:00", Timezone: "UTC",
+	})
+	_, errOpenWithoutHours := workingHoursService.CreateOverride(1, userID, &request.AvailabilityOverrideRequest{
+		Date: "2030-01-01", Timezone: "UTC",
+	})
+	_, errBadTimezone := workingHoursService.CreateOverride(1, userID, &request.AvailabilityOverrideRequest{
+		Date: "2030-01-01", Closed: true, Timezone: "Mars/Olympus",
+	})
+
+	// THEN
+	assert.Equal(t, ErrOverrideHoursMismatch, errClosedWithHours)
+	assert.Equal(t, ErrOverrideHoursMismatch, errOpenWithoutHours)
+	assert.Equal(t, ErrInvalidTimezone, errBadTimezone)
+}
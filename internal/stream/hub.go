@@ -0,0 +1,204 @@
+// Package stream fans appointment and user change events out to live
+// subscribers and keeps a short history so reconnecting clients can resume
+// from the last event they saw.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// historySize is how many recent events are kept for resuming.
+	historySize = 1024
+	// subscriberBuffer is how many events a slow subscriber may fall behind
+	// before it is disconnected.
+	subscriberBuffer = 64
+)
+
+type Event struct {
+	ID             uint64      `json:"id"`
+	OrganizationID uint        `json:"-"`
+	Type           string      `json:"type"`
+	UserIDs        []uint      `json:"user_ids"`
+	Status         string      `json:"status,omitempty"`
+	Data           interface{} `json:"data"`
+	OccurredAt     time.Time   `json:"occurred_at"`
+}
+
+// Filter selects the events a subscriber receives. Only events of
+// OrganizationID match. UserID matches events involving that user.
+// Statuses match appointment events in one of the statuses; events without
+// a status are skipped while it is set.
+type Filter struct {
+	OrganizationID uint
+	UserID         *uint
+	Statuses       []string
+}
+
+func (f Filter) Matches(event Event) bool {
+	if event.OrganizationID != f.OrganizationID {
+		return false
+	}
+	if f.UserID != nil {
+		involved := false
+		for _, id := range event.UserIDs {
+			if id == *f.UserID {
+				involved = true
+				break
+			}
+		}
+		if !involved {
+			return false
+		}
+	}
+	if len(f.Statuses) > 0 {
+		for _, status := range f.Statuses {
+			if event.Status == status {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+type Subscription struct {
+	Events <-chan Event
+	events chan Event
+	filter Filter
+}
+
+type Hub struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []Event
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		nextID:      1,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns the event its ID and delivers it to every matching
+// subscriber. Subscribers whose buffer is full are dropped; they can
+// reconnect and resume from history.
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+
+	event.ID = h.nextID
+	h.nextID++
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	h.history = append(h.history, event)
+	if len(h.history) > historySize {
+		h.history = h.history[len(h.history)-historySize:]
+	}
+
+	for sub := range h.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// HandleEvent is the event bus subscriber that feeds the stream. The bus
+// delivers at least once, so a retried event may be streamed twice.
+func (h *Hub) HandleEvent(_ context.Context, event events.Event) error {
+	streamEvent := Event{OrganizationID: event.OrganizationID, Type: event.Type, OccurredAt: event.OccurredAt}
+	switch {
+	case strings.HasPrefix(event.Type, "appointment."):
+		var appointment model.Appointment
+		if err := json.Unmarshal(event.Payload, &appointment); err != nil {
+			return err
+		}
+		streamEvent.UserIDs = []uint{appointment.UserID, appointment.ParticipantID}
+		streamEvent.Status = appointment.Status
+		streamEvent.Data = appointment
+	case strings.HasPrefix(event.Type, "user."):
+		var user model.User
+		if err := json.Unmarshal(event.Payload, &user); err != nil {
+			return err
+		}
+		streamEvent.UserIDs = []uint{user.ID}
+		streamEvent.Data = user
+	default:
+		return nil
+	}
+	h.Publish(streamEvent)
+	return nil
+}
+
+// Subscribe registers a subscriber. When lastEventID is non-zero the
+// matching events published after it are returned for replay; complete is
+// false if some of them have already left the history.
+func (h *Hub) Subscribe(filter Filter, lastEventID uint64) (sub *Subscription, replay []Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events := make(chan Event, subscriberBuffer)
+	sub = &Subscription{Events: events, events: events, filter: filter}
+	if h.closed {
+		close(events)
+		return sub, nil, true
+	}
+	h.subscribers[sub] = struct{}{}
+
+	complete = true
+	if lastEventID > 0 {
+		// Either events were evicted from the history, or the ID comes from
+		// before a restart and no longer lines up with ours.
+		if len(h.history) > 0 && h.history[0].ID > lastEventID+1 || lastEventID >= h.nextID {
+			complete = false
+		}
+		for _, event := range h.history {
+			if event.ID > lastEventID && filter.Matches(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+	return sub, replay, complete
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		h.remove(sub)
+	}
+}
+
+// Close disconnects every subscriber so open streams end, e.g. on
+// shutdown. Later publishes are ignored.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		h.remove(sub)
+	}
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	delete(h.subscribers, sub)
+	close(sub.events)
+}
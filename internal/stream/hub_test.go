@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"context"
+	"queue_system/internal/events"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub_DeliversMatchingEvents(t *testing.T) {
+	hub := NewHub()
+	userID := uint(7)
+	sub, replay, complete := hub.Subscribe(Filter{UserID: &userID, Statuses: []string{"confirmed"}}, 0)
+	assert.Empty(t, replay)
+	assert.True(t, complete)
+
+	hub.Publish(Event{Type: events.AppointmentConfirmed, UserIDs: []uint{1, 2}, Status: "confirmed"})
+	hub.Publish(Event{Type: events.AppointmentCreated, UserIDs: []uint{1, 7}, Status: "pending"})
+	hub.Publish(Event{Type: events.UserCreated, UserIDs: []uint{7}})
+	hub.Publish(Event{Type: events.AppointmentConfirmed, UserIDs: []uint{1, 7}, Status: "confirmed"})
+
+	event := <-sub.Events
+	assert.Equal(t, uint64(4), event.ID)
+	assert.Equal(t, events.AppointmentConfirmed, event.Type)
+	assert.Len(t, sub.Events, 0)
+}
+
+func TestHub_ReplaysAfterLastEventID(t *testing.T) {
+	hub := NewHub()
+	for i := 0; i < 3; i++ {
+		hub.Publish(Event{Type: events.UserCreated, UserIDs: []uint{uint(i)}})
+	}
+
+	_, replay, complete := hub.Subscribe(Filter{}, 1)
+	assert.True(t, complete)
+	assert.Len(t, replay, 2)
+	assert.Equal(t, uint64(2), replay[0].ID)
+
+	// An ID the hub has not issued, e.g. from before a restart.
+	_, replay, complete = hub.Subscribe(Filter{}, 50)
+	assert.False(t, complete)
+	assert.Empty(t, replay)
+}
+
+func TestHub_ReportsEvictedHistory(t *testing.T) {
+	hub := NewHub()
+	for i := 0; i < historySize+10; i++ {
+		hub.Publish(Event{Type: events.UserCreated})
+	}
+
+	_, replay, complete := hub.Subscribe(Filter{}, 5)
+	assert.False(t, complete)
+	assert.Len(t, replay, historySize)
+}
+
+func TestHub_DropsSlowSubscribersAndCloses(t *testing.T) {
+	hub := NewHub()
+	slow, _, _ := hub.Subscribe(Filter{}, 0)
+	for i := 0; i < subscriberBuffer+1; i++ {
+		hub.Publish(Event{Type: events.UserCreated})
+	}
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+
+	open, _, _ := hub.Subscribe(Filter{}, 0)
+	hub.Close()
+	_, ok := <-open.Events
+	assert.False(t, ok)
+}
+
+func TestHub_HandleEvent_MapsDomainEvents(t *testing.T) {
+	hub := NewHub()
+	sub, _, _ := hub.Subscribe(Filter{}, 0)
+
+	err := hub.HandleEvent(context.Background(), events.Event{
+		Type:    events.AppointmentConfirmed,
+		Payload: []byte(`{"ID":3,"UserID":1,"ParticipantID":2,"Status":"confirmed"}`),
+	})
+	assert.NoError(t, err)
+	err = hub.HandleEvent(context.Background(), events.Event{
+		Type:    events.UserCreated,
+		Payload: []byte(`{"id":5}`),
+	})
+	assert.NoError(t, err)
+
+	appointment := <-sub.Events
+	assert.Equal(t, events.AppointmentConfirmed, appointment.Type)
+	assert.Equal(t, []uint{1, 2}, appointment.UserIDs)
+	assert.Equal(t, "confirmed", appointment.Status)
+
+	user := <-sub.Events
+	assert.Equal(t, events.UserCreated, user.Type)
+	assert.Equal(t, []uint{5}, user.UserIDs)
+}
+
+func TestHub_OnlyDeliversEventsOfTheSubscribersOrganization(t *testing.T) {
+	hub := NewHub()
+	sub, _, _ := hub.Subscribe(Filter{OrganizationID: 1}, 0)
+
+	err := hub.HandleEvent(context.Background(), events.Event{OrganizationID: 2, Type: events.UserCreated, Payload: []byte(`{"id":5}`)})
+	assert.NoError(t, err)
+	err = hub.HandleEvent(context.Background(), events.Event{OrganizationID: 1, Type: events.UserCreated, Payload: []byte(`{"id":6}`)})
+	assert.NoError(t, err)
+
+	user := <-sub.Events
+	assert.Equal(t, []uint{6}, user.UserIDs)
+	assert.Len(t, sub.Events, 0)
+
+	_, replay, _ := hub.Subscribe(Filter{OrganizationID: 2}, 1)
+	assert.Empty(t, replay)
+}
@@ -0,0 +1,106 @@
+// Package tenant works out which organization a request is for. The
+// resolver middleware reads the request's header or host; the auth
+// middleware then holds signed-in users to the organization in their
+// credentials.
+package tenant
+
+import (
+	"net"
+	"net/http"
+	"queue_system/config"
+	"queue_system/internal/repository"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	organizationKey = "tenant.organization"
+	explicitKey     = "tenant.explicit"
+)
+
+// Resolver matches requests to organizations as configured in
+// config.Tenancy.
+type Resolver struct {
+	organizationRepository repository.OrganizationRepository
+	header                 string
+	baseDomain             string
+	defaultOrganization    string
+}
+
+func NewResolver(organizationRepository repository.OrganizationRepository, cfg *config.Config) *Resolver {
+	return &Resolver{
+		organizationRepository: organizationRepository,
+		header:                 cfg.Tenancy.Header,
+		baseDomain:             cfg.Tenancy.BaseDomain,
+		defaultOrganization:    cfg.Tenancy.DefaultOrganization,
+	}
+}
+
+// Middleware resolves the organization named by the header or subdomain,
+// falling back to the default one. Naming an unknown organization is a
+// 404; without a default, requests naming none have no organization.
+func (r *Resolver) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		slug, explicit := r.requestedSlug(ctx.Request)
+		if !explicit {
+			slug = r.defaultOrganization
+		}
+		if slug == "" {
+			ctx.Next()
+			return
+		}
+
+		organization, err := r.organizationRepository.GetBySlug(slug)
+		if err != nil {
+			log.Error().Err(err).Str("slug", slug).Msg("Failed to resolve organization")
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve organization"})
+			return
+		}
+		if organization == nil {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			return
+		}
+		Set(ctx, organization.ID, explicit)
+		ctx.Next()
+	}
+}
+
+// requestedSlug returns the organization the request names, if any.
+func (r *Resolver) requestedSlug(req *http.Request) (string, bool) {
+	if r.header != "" {
+		if slug := strings.TrimSpace(req.Header.Get(r.header)); slug != "" {
+			return slug, true
+		}
+	}
+	if r.baseDomain == "" {
+		return "", false
+	}
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+r.baseDomain)
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return "", false
+	}
+	return label, true
+}
+
+// Set records the request's organization. Explicit is whether the request
+// named it rather than falling back to the default.
+func Set(ctx *gin.Context, organizationID uint, explicit bool) {
+	ctx.Set(organizationKey, organizationID)
+	ctx.Set(explicitKey, explicit)
+}
+
+// OrganizationID returns the request's organization, or 0 if it has none.
+func OrganizationID(ctx *gin.Context) uint {
+	return ctx.GetUint(organizationKey)
+}
+
+// Explicit reports whether the request named its organization.
+func Explicit(ctx *gin.Context) bool {
+	return ctx.GetBool(explicitKey)
+}
@@ -0,0 +1,80 @@
+package tenant
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"queue_system/config"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolver_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	organizations := map[string]*model.Organization{
+		"default": {ID: 1, Slug: "default"},
+		"acme":    {ID: 2, Slug: "acme"},
+	}
+
+	for name, tc := range map[string]struct {
+		host             string
+		header           string
+		defaultSlug      string
+		expectedCode     int
+		expectedID       uint
+		expectedExplicit bool
+	}{
+		"default organization":   {"localhost:8080", "", "default", http.StatusOK, 1, false},
+		"header":                 {"localhost:8080", "acme", "default", http.StatusOK, 2, true},
+		"subdomain":              {"acme.example.com:8080", "", "default", http.StatusOK, 2, true},
+		"header over subdomain":  {"default.example.com", "acme", "default", http.StatusOK, 2, true},
+		"nested subdomain":       {"a.acme.example.com", "", "default", http.StatusOK, 1, false},
+		"unknown organization":   {"localhost:8080", "globex", "default", http.StatusNotFound, 0, false},
+		"no default":             {"example.com", "", "", http.StatusOK, 0, false},
+		"unknown subdomain":      {"globex.example.com", "", "default", http.StatusNotFound, 0, false},
+		"repository unavailable": {"localhost:8080", "broken", "default", http.StatusInternalServerError, 0, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockOrganizationRepo := mocks.NewMockOrganizationRepository(ctrl)
+			mockOrganizationRepo.EXPECT().GetBySlug(gomock.Any()).DoAndReturn(func(slug string) (*model.Organization, error) {
+				if slug == "broken" {
+					return nil, errors.New("connection refused")
+				}
+				return organizations[slug], nil
+			}).AnyTimes()
+			cfg := &config.Config{Tenancy: config.Tenancy{Header: "X-Organization", BaseDomain: "example.com", DefaultOrganization: tc.defaultSlug}}
+			resolver := NewResolver(mockOrganizationRepo, cfg)
+
+			var organizationID uint
+			var explicit bool
+			router := gin.New()
+			router.Use(resolver.Middleware())
+			router.GET("/", func(ctx *gin.Context) {
+				organizationID, explicit = OrganizationID(ctx), Explicit(ctx)
+				ctx.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tc.host
+			if tc.header != "" {
+				req.Header.Set("X-Organization", tc.header)
+			}
+
+			// WHEN
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// THEN
+			assert.Equal(t, tc.expectedCode, rr.Code, rr.Body.String())
+			assert.Equal(t, tc.expectedID, organizationID)
+			assert.Equal(t, tc.expectedExplicit, explicit)
+		})
+	}
+}
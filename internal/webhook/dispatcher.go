@@ -0,0 +1,307 @@
+// Package webhook sends domain events to the endpoints integrators have
+// registered. Events from the bus are queued as deliveries, which a
+// background worker sends, signs and retries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"queue_system/config"
+	"queue_system/internal/enums"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/netguard"
+	"queue_system/internal/repository"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// baseRetryDelay is the wait after the first failed attempt; it doubles
+	// with every further failure up to maxRetryDelay.
+	baseRetryDelay      = 10 * time.Second
+	maxRetryDelay       = time.Hour
+	defaultTimeout      = 10 * time.Second
+	defaultMaxAttempts  = 8
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 50
+)
+
+// Payload is the JSON body of a delivery request. Data is the entity the
+// event is about, as recorded in the outbox.
+type Payload struct {
+	DeliveryID uint            `json:"delivery_id"`
+	EventID    uint            `json:"event_id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type Dispatcher struct {
+	db                *gorm.DB
+	webhookRepository repository.WebhookRepository
+	client            *http.Client
+	maxAttempts       int
+	pollInterval      time.Duration
+	batchSize         int
+	lease             time.Duration
+
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+func NewDispatcher(db *gorm.DB, webhookRepository repository.WebhookRepository, cfg *config.Config) *Dispatcher {
+	timeout := cfg.Webhook.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	dispatcher := &Dispatcher{
+		db:                db,
+		webhookRepository: webhookRepository,
+		client:            netguard.New(cfg.Webhook.AllowPrivateTargets).Client(timeout),
+		maxAttempts:       cfg.Webhook.MaxAttempts,
+		pollInterval:      cfg.Webhook.PollInterval,
+		batchSize:         cfg.Webhook.BatchSize,
+	}
+	if dispatcher.maxAttempts <= 0 {
+		dispatcher.maxAttempts = defaultMaxAttempts
+	}
+	if dispatcher.pollInterval <= 0 {
+		dispatcher.pollInterval = defaultPollInterval
+	}
+	if dispatcher.batchSize <= 0 {
+		dispatcher.batchSize = defaultBatchSize
+	}
+	// Deliveries of a batch are sent one after another, so the lease covers
+	// every one of them timing out.
+	dispatcher.lease = time.Duration(dispatcher.batchSize)*timeout + time.Minute
+	return dispatcher
+}
+
+// HandleEvent queues a delivery of event for every active webhook of the
+// event's organization that subscribes to it. It is registered on the event
+// bus. Internal events are skipped.
+func (d *Dispatcher) HandleEvent(ctx context.Context, event events.Event) error {
+	if !events.IsKnownType(event.Type) {
+		return nil
+	}
+	webhooks, err := d.webhookRepository.ListActiveWebhooks(event.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []model.WebhookDelivery
+	for i := range webhooks {
+		if !webhooks[i].Subscribes(event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			OrganizationID: event.OrganizationID,
+			WebhookID:      webhooks[i].ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(event.Payload),
+			OccurredAt:     event.OccurredAt,
+			Status:         string(enums.WebhookDeliveryPending),
+			NextAttemptAt:  now,
+		})
+	}
+	return d.webhookRepository.CreateDeliveries(deliveries)
+}
+
+// Start sends due deliveries until Stop is called.
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done.Add(1)
+	go func() {
+		defer d.done.Done()
+		ticker := time.NewTicker(d.pollInterval)
+		defer ticker.Stop()
+		for {
+			for {
+				sent, err := d.DeliverPending(ctx)
+				if err != nil {
+					log.Error().Err(err).Msg("Error sending webhook deliveries")
+					break
+				}
+				if sent < d.batchSize {
+					break
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop waits for the batch in flight to finish or ctx to expire.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	if d.cancel == nil {
+		return nil
+	}
+	d.cancel()
+	finished := make(chan struct{})
+	go func() {
+		d.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DeliverPending attempts one batch of due deliveries and returns how many
+// were attempted. The batch is claimed in a short transaction and sent
+// after it commits, so no rows stay locked while endpoints respond; the
+// outcomes are written in a second transaction. A failed delivery is
+// retried with exponential backoff until it has been attempted maxAttempts
+// times.
+func (d *Dispatcher) DeliverPending(ctx context.Context) (int, error) {
+	due, err := d.claim(time.Now())
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+
+	webhooks := make(map[uint]*model.Webhook)
+	for i := range due {
+		delivery := &due[i]
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = d.webhookRepository.GetWebhookByID(delivery.OrganizationID, delivery.WebhookID)
+			if err != nil {
+				return 0, err
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+		d.attempt(ctx, webhook, delivery)
+	}
+
+	tx := d.db.Begin()
+	for i := range due {
+		if err := d.webhookRepository.UpdateDeliveryWithTx(tx, &due[i]); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return len(due), nil
+}
+
+// claim leases up to batchSize due deliveries to this worker.
+func (d *Dispatcher) claim(now time.Time) ([]model.WebhookDelivery, error) {
+	tx := d.db.Begin()
+
+	due, err := d.webhookRepository.FetchDueDeliveriesForUpdate(tx, now, d.batchSize)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := d.webhookRepository.ClaimDeliveriesWithTx(tx, due, now.Add(d.lease)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return due, nil
+}
+
+// attempt sends delivery once and records the outcome on it.
+func (d *Dispatcher) attempt(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) {
+	delivery.Attempts++
+	if webhook == nil || !webhook.Active {
+		delivery.Status = string(enums.WebhookDeliveryFailed)
+		delivery.LastError = "webhook is disabled"
+		return
+	}
+
+	code, err := d.send(ctx, webhook, delivery)
+	delivery.ResponseCode = code
+	now := time.Now()
+	if err == nil {
+		delivery.Status = string(enums.WebhookDeliverySucceeded)
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	log.Warn().Err(err).Uint("deliveryID", delivery.ID).Uint("webhookID", webhook.ID).Int("attempts", delivery.Attempts).Msg("Webhook delivery failed")
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.maxAttempts {
+		delivery.Status = string(enums.WebhookDeliveryFailed)
+		return
+	}
+	delivery.NextAttemptAt = now.Add(RetryDelay(delivery.Attempts))
+}
+
+// send posts the signed delivery and returns the response status code. Any
+// status outside 2xx is an error.
+func (d *Dispatcher) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(Payload{
+		DeliveryID: delivery.ID,
+		EventID:    delivery.EventID,
+		Type:       delivery.EventType,
+		OccurredAt: delivery.OccurredAt,
+		Data:       json.RawMessage(delivery.Payload),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// RetryDelay is the wait before the next attempt after the given number of
+// failed attempts: baseRetryDelay, doubling up to maxRetryDelay.
+func RetryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"queue_system/config"
+	"queue_system/internal/enums"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/repository/mocks"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDispatcher(maxAttempts int) *Dispatcher {
+	cfg := &config.Config{}
+	cfg.Webhook.MaxAttempts = maxAttempts
+	// The test receivers listen on localhost.
+	cfg.Webhook.AllowPrivateTargets = true
+	return NewDispatcher(nil, nil, cfg)
+}
+
+func TestDispatcher_Attempt_RefusesPrivateTargets(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the private receiver must not be reached")
+	}))
+	defer receiver.Close()
+	cfg := &config.Config{}
+	cfg.Webhook.MaxAttempts = 3
+	dispatcher := NewDispatcher(nil, nil, cfg)
+	delivery := &model.WebhookDelivery{ID: 1, EventType: events.UserCreated, Payload: "{}"}
+
+	dispatcher.attempt(context.Background(), &model.Webhook{ID: 1, URL: receiver.URL, Active: true}, delivery)
+
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Contains(t, delivery.LastError, "non-public address")
+	assert.Nil(t, delivery.DeliveredAt)
+}
+
+func TestSign_VerifiesOnlyUnchangedBodies(t *testing.T) {
+	body := []byte(`{"type":"user.created"}`)
+	signature := Sign("super-secret-value", "1700000000", body)
+
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.True(t, Verify("super-secret-value", "1700000000", body, signature))
+	assert.False(t, Verify("super-secret-value", "1700000001", body, signature))
+	assert.False(t, Verify("another-secret-value", "1700000000", body, signature))
+	assert.False(t, Verify("super-secret-value", "1700000000", []byte(`{"type":"user.updated"}`), signature))
+}
+
+func TestRetryDelay_DoublesUpToCap(t *testing.T) {
+	assert.Equal(t, 10*time.Second, RetryDelay(1))
+	assert.Equal(t, 20*time.Second, RetryDelay(2))
+	assert.Equal(t, 80*time.Second, RetryDelay(4))
+	assert.Equal(t, maxRetryDelay, RetryDelay(20))
+}
+
+func TestDispatcher_Attempt_SendsSignedPayload(t *testing.T) {
+	// GIVEN
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook := &model.Webhook{ID: 1, URL: server.URL, Secret: "super-secret-value", Active: true}
+	delivery := &model.WebhookDelivery{ID: 7, WebhookID: 1, EventID: 3, EventType: events.UserCreated,
+		Payload: `{"id":5}`, Status: string(enums.WebhookDeliveryPending)}
+
+	// WHEN
+	newTestDispatcher(3).attempt(context.Background(), webhook, delivery)
+
+	// THEN
+	require.NotNil(t, received)
+	assert.Equal(t, string(enums.WebhookDeliverySucceeded), delivery.Status)
+	assert.Equal(t, http.StatusNoContent, delivery.ResponseCode)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.NotNil(t, delivery.DeliveredAt)
+	assert.Equal(t, events.UserCreated, received.Header.Get(EventHeader))
+	assert.Equal(t, "7", received.Header.Get(DeliveryHeader))
+	assert.True(t, Verify("super-secret-value", received.Header.Get(TimestampHeader), body, received.Header.Get(SignatureHeader)))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, uint(3), payload.EventID)
+	assert.JSONEq(t, `{"id":5}`, string(payload.Data))
+}
+
+func TestDispatcher_Attempt_RetriesThenGivesUp(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dispatcher := newTestDispatcher(2)
+	webhook := &model.Webhook{ID: 1, URL: server.URL, Secret: "super-secret-value", Active: true}
+	delivery := &model.WebhookDelivery{ID: 7, WebhookID: 1, EventType: events.UserCreated,
+		Payload: `{}`, Status: string(enums.WebhookDeliveryPending)}
+
+	// WHEN
+	before := time.Now()
+	dispatcher.attempt(context.Background(), webhook, delivery)
+
+	// THEN
+	assert.Equal(t, string(enums.WebhookDeliveryPending), delivery.Status)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.ResponseCode)
+	assert.Contains(t, delivery.LastError, "503")
+	assert.WithinDuration(t, before.Add(RetryDelay(1)), delivery.NextAttemptAt, time.Second)
+
+	// WHEN
+	dispatcher.attempt(context.Background(), webhook, delivery)
+
+	// THEN
+	assert.Equal(t, string(enums.WebhookDeliveryFailed), delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Nil(t, delivery.DeliveredAt)
+}
+
+func TestDispatcher_HandleEvent_QueuesForSubscribedWebhooks(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWebhookRepo := mocks.NewMockWebhookRepository(ctrl)
+	dispatcher := NewDispatcher(nil, mockWebhookRepo, &config.Config{})
+
+	mockWebhookRepo.EXPECT().ListActiveWebhooks(uint(4)).Return([]model.Webhook{
+		{ID: 1, EventTypes: []string{events.AppointmentCreated}},
+		{ID: 2, EventTypes: []string{events.UserCreated}},
+		{ID: 3},
+	}, nil).Times(1)
+	var queued []model.WebhookDelivery
+	mockWebhookRepo.EXPECT().CreateDeliveries(gomock.Any()).DoAndReturn(
+		func(deliveries []model.WebhookDelivery) error {
+			queued = deliveries
+			return nil
+		}).Times(1)
+
+	// WHEN
+	err := dispatcher.HandleEvent(context.Background(), events.Event{ID: 9, OrganizationID: 4, Type: events.UserCreated, Payload: []byte(`{"id":5}`)})
+
+	// THEN
+	assert.NoError(t, err)
+	require.Len(t, queued, 2)
+	assert.Equal(t, uint(2), queued[0].WebhookID)
+	assert.Equal(t, uint(3), queued[1].WebhookID)
+	assert.Equal(t, uint(9), queued[0].EventID)
+	assert.Equal(t, uint(4), queued[0].OrganizationID)
+	assert.Equal(t, string(enums.WebhookDeliveryPending), queued[0].Status)
+}
+
+func TestDispatcher_HandleEvent_SkipsInternalEvents(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWebhookRepo := mocks.NewMockWebhookRepository(ctrl)
+	dispatcher := NewDispatcher(nil, mockWebhookRepo, &config.Config{})
+
+	// WHEN
+	err := dispatcher.HandleEvent(context.Background(), events.Event{ID: 9, Type: events.MagicLinkRequested, Payload: []byte(`{"email":"a@example.com"}`)})
+
+	// THEN
+	assert.NoError(t, err)
+}
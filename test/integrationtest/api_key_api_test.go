@@ -0,0 +1,80 @@
+package integrationtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyAPI_KioskKeyIsScopedAndRevocable(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.APIKey{}, &model.QueueTicket{}, &model.Queue{}, &model.User{})
+
+	clerk := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Key Clerk", Email: "key.clerk@example.com", Role: "staff"})
+	other := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Key Other", Email: "key.other@example.com", Role: "staff"})
+	queue := createQueue(t, clerk.ID, "Kiosk Desk", "Lobby")
+
+	// 1. Creating a key returns it once; listing only shows its prefix
+	rr := MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, "/api/v1/api-keys", request.CreateAPIKeyRequest{
+		Name: "Lobby kiosk", Scopes: []string{"queues:write", "appointments:read"},
+	})
+	require.Equal(t, http.StatusCreated, rr.Code, "Create API key failed. Response: %s", rr.Body.String())
+	var created response.APIKeyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	require.NotEmpty(t, created.Key)
+	assert.NotContains(t, rr.Body.String(), "key_hash")
+
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, "/api/v1/api-keys", request.CreateAPIKeyRequest{
+		Name: "Too much", Scopes: []string{"everything"},
+	})
+	require.Equal(t, http.StatusBadRequest, rr.Code, "Unknown scopes are rejected. Response: %s", rr.Body.String())
+
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodGet, "/api/v1/api-keys", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List API keys failed. Response: %s", rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), created.Key)
+	var keys []model.APIKey
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &keys))
+	require.Len(t, keys, 1)
+	assert.Equal(t, created.Prefix, keys[0].Prefix)
+	assert.Nil(t, keys[0].LastUsedAt)
+
+	// 2. The key acts as the clerk within its scopes
+	rr = MakeRequestWithToken(t, globalTestApp.Router, created.Key, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/tickets", queue.ID), request.TakeTicketRequest{CustomerName: "Walk-in"})
+	require.Equal(t, http.StatusCreated, rr.Code, "Take ticket with API key failed. Response: %s", rr.Body.String())
+	rr = MakeRequestWithToken(t, globalTestApp.Router, created.Key, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/call-next", queue.ID), request.CallNextTicketRequest{Counter: "Desk 1"})
+	require.Equal(t, http.StatusOK, rr.Code, "Call next with API key failed. Response: %s", rr.Body.String())
+	rr = MakeRequestWithToken(t, globalTestApp.Router, created.Key, http.MethodGet, "/api/v1/appointments", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Read-only scope allows listing. Response: %s", rr.Body.String())
+
+	rr = MakeRequestWithToken(t, globalTestApp.Router, created.Key, http.MethodPost, "/api/v1/appointments", map[string]interface{}{})
+	requireDenied(t, rr.Code, rr.Body.Bytes(), "scope_missing")
+	rr = MakeRequestWithToken(t, globalTestApp.Router, created.Key, http.MethodGet, fmt.Sprintf("/api/v1/users/%d", clerk.ID), nil)
+	requireDenied(t, rr.Code, rr.Body.Bytes(), "scope_missing")
+	rr = MakeRequestWithToken(t, globalTestApp.Router, created.Key, http.MethodGet, "/api/v1/api-keys", nil)
+	requireDenied(t, rr.Code, rr.Body.Bytes(), "session_required")
+
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodGet, "/api/v1/api-keys", nil)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &keys))
+	require.NotNil(t, keys[0].LastUsedAt, "use is tracked")
+
+	// 3. Only the owner revokes, after which the key stops working
+	keyURL := fmt.Sprintf("/api/v1/api-keys/%d", created.ID)
+	rr = MakeRequestAs(t, globalTestApp.Router, other.ID, http.MethodDelete, keyURL, nil)
+	require.Equal(t, http.StatusNotFound, rr.Code, "Other users' keys are not found. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodDelete, keyURL, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Revoke failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodDelete, keyURL, nil)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = MakeRequestWithToken(t, globalTestApp.Router, created.Key, http.MethodGet, "/api/v1/queues", nil)
+	require.Equal(t, http.StatusUnauthorized, rr.Code, "Revoked keys are rejected. Response: %s", rr.Body.String())
+}
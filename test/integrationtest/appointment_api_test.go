@@ -0,0 +1,489 @@
+package integrationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/model"
+	"queue_system/internal/service"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppointmentAPI_UpdateAppointment(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.User{})
+
+	creator := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Creator", Email: "creator@example.com", Role: "client"})
+	participant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Participant", Email: "participant@example.com", Role: "provider"})
+
+	base := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	// 1. Create two back-to-back appointments
+	first := createAppointment(t, creator.ID, participant.ID, base, base.Add(time.Hour))
+	second := createAppointment(t, creator.ID, participant.ID, base.Add(2*time.Hour), base.Add(3*time.Hour))
+
+	// 2. Fix the description only
+	description := "Follow-up consultation"
+	rr := MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPatch, fmt.Sprintf("/api/v1/appointments/%d", first.ID),
+		request.UpdateAppointmentRequest{Description: &description})
+	require.Equal(t, http.StatusOK, rr.Code, "Update failed. Response: %s", rr.Body.String())
+
+	var updated model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.Equal(t, description, updated.Description)
+	assert.True(t, first.StartTime.Equal(updated.StartTime))
+
+	// 3. Shifting within its own slot must not conflict with itself
+	newEnd := base.Add(90 * time.Minute).Format(time.RFC3339)
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPatch, fmt.Sprintf("/api/v1/appointments/%d", first.ID),
+		request.UpdateAppointmentRequest{EndTime: &newEnd})
+	require.Equal(t, http.StatusOK, rr.Code, "Reschedule failed. Response: %s", rr.Body.String())
+
+	// 4. Moving onto the second appointment's slot conflicts
+	conflictStart := second.StartTime.Format(time.RFC3339)
+	conflictEnd := second.EndTime.Format(time.RFC3339)
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPatch, fmt.Sprintf("/api/v1/appointments/%d", first.ID),
+		request.UpdateAppointmentRequest{StartTime: &conflictStart, EndTime: &conflictEnd})
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+	var errorResponse map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResponse))
+	assert.Equal(t, service.ErrAppointmentConflict.Error(), errorResponse["error"])
+
+	// 5. Unknown appointment
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPatch, "/api/v1/appointments/999999",
+		request.UpdateAppointmentRequest{Description: &description})
+	require.Equal(t, http.StatusNotFound, rr.Code, "Expected 404 Not Found. Response: %s", rr.Body.String())
+}
+
+func TestAppointmentAPI_StatusTransitions(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.User{})
+
+	creator := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Creator", Email: "creator@example.com", Role: "client"})
+	participant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Participant", Email: "participant@example.com", Role: "provider"})
+
+	base := time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC)
+	appointment := createAppointment(t, creator.ID, participant.ID, base, base.Add(time.Hour))
+	actor := participant.ID
+
+	// 1. Completing a pending appointment is not allowed
+	rr := MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/complete", appointment.ID), nil)
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+
+	// 2. pending -> confirmed records who confirmed it
+	rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/confirm", appointment.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Confirm failed. Response: %s", rr.Body.String())
+	var confirmed model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &confirmed))
+	assert.Equal(t, "confirmed", confirmed.Status)
+	require.NotNil(t, confirmed.ConfirmedByID)
+	assert.Equal(t, participant.ID, *confirmed.ConfirmedByID)
+	assert.NotNil(t, confirmed.ConfirmedAt)
+
+	// 3. confirmed -> completed
+	rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/complete", appointment.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Complete failed. Response: %s", rr.Body.String())
+
+	// 4. Completed is terminal
+	rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/cancel", appointment.ID), nil)
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+	var errorResponse map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResponse))
+	assert.Equal(t, service.ErrInvalidStatusTransition.Error(), errorResponse["error"])
+}
+
+func TestAppointmentAPI_ConcurrentBookingsForSameSlot(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.User{})
+
+	participant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Consultant", Email: "consultant@example.com", Role: "provider"})
+
+	const attempts = 20
+	creators := make([]*model.User, attempts)
+	for i := range creators {
+		creators[i] = CreateUserInDB(t, globalTestApp.DB, &model.User{
+			Name:  fmt.Sprintf("Client %d", i),
+			Email: fmt.Sprintf("client%d@example.com", i),
+			Role:  "client",
+		})
+	}
+
+	start := time.Date(2030, 1, 3, 9, 0, 0, 0, time.UTC)
+	end := start.Add(30 * time.Minute)
+
+	// Every client races for the same slot with the same participant.
+	bodies := make([][]byte, attempts)
+	tokens := make([]string, attempts)
+	for i, creator := range creators {
+		tokens[i] = AccessToken(t, creator.ID)
+		body, err := json.Marshal(request.AppointmentRequest{
+			ParticipantID: participant.ID,
+			StartTime:     start.Format(time.RFC3339),
+			EndTime:       end.Format(time.RFC3339),
+		})
+		require.NoError(t, err)
+		bodies[i] = body
+	}
+
+	codes := make([]int, attempts)
+	var ready, done sync.WaitGroup
+	ready.Add(1)
+	for i := range bodies {
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/appointments", bytes.NewReader(bodies[i]))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tokens[i])
+			rr := httptest.NewRecorder()
+			ready.Wait()
+			globalTestApp.Router.ServeHTTP(rr, req)
+			codes[i] = rr.Code
+		}(i)
+	}
+	ready.Done()
+	done.Wait()
+
+	created, conflicts := 0, 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+			conflicts++
+		}
+	}
+	assert.Equal(t, 1, created, "exactly one booking should win, got codes %v", codes)
+	assert.Equal(t, attempts-1, conflicts, "all other bookings should conflict, got codes %v", codes)
+
+	var stored int64
+	require.NoError(t, globalTestApp.DB.Model(&model.Appointment{}).Where("participant_id = ?", participant.ID).Count(&stored).Error)
+	assert.Equal(t, int64(1), stored)
+}
+
+func TestAppointmentAPI_ConflictsAcrossRoles(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.User{})
+	provider := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Role Provider", Email: "role.provider@example.com", Role: "provider"})
+	colleague := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Role Colleague", Email: "role.colleague@example.com", Role: "provider"})
+	client := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Role Client", Email: "role.client@example.com", Role: "client"})
+
+	// The provider booked a meeting with a colleague as its creator.
+	start := time.Date(2030, 1, 3, 9, 0, 0, 0, time.UTC)
+	meeting := &model.Appointment{OrganizationID: provider.OrganizationID, UserID: provider.ID, ParticipantID: colleague.ID, StartTime: start, EndTime: start.Add(time.Hour), Status: "confirmed"}
+	require.NoError(t, globalTestApp.DB.Create(meeting).Error)
+
+	// The client cannot book the provider as participant at the same time.
+	booking := request.AppointmentRequest{
+		ParticipantID: provider.ID,
+		StartTime:     start.Add(30 * time.Minute).Format(time.RFC3339),
+		EndTime:       start.Add(90 * time.Minute).Format(time.RFC3339),
+	}
+	rr := MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPost, "/api/v1/appointments", booking)
+	assert.Equal(t, http.StatusConflict, rr.Code, "The provider is busy as a creator. Response: %s", rr.Body.String())
+}
+
+func TestAppointmentAPI_ListAppointments(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.User{})
+
+	creator := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Creator", Email: "creator@example.com", Role: "client"})
+	participant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Participant", Email: "participant@example.com", Role: "provider"})
+	other := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Other", Email: "other@example.com", Role: "client"})
+
+	day := time.Date(2030, 1, 4, 0, 0, 0, 0, time.UTC)
+	for hour := 9; hour < 12; hour++ {
+		start := day.Add(time.Duration(hour) * time.Hour)
+		createAppointment(t, creator.ID, participant.ID, start, start.Add(30*time.Minute))
+	}
+	createAppointment(t, other.ID, creator.ID, day.Add(24*time.Hour), day.Add(25*time.Hour))
+
+	// 1. A day's schedule for one participant, first page of two
+	url := fmt.Sprintf("/api/v1/appointments?participant_id=%d&from=%s&to=%s&page_size=2",
+		participant.ID, day.Format(time.RFC3339), day.Add(24*time.Hour).Format(time.RFC3339))
+	rr := MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List failed. Response: %s", rr.Body.String())
+	assert.Equal(t, "3", rr.Header().Get("X-Total-Count"))
+
+	var page []model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	require.Len(t, page, 2)
+	assert.True(t, page[0].StartTime.Before(page[1].StartTime))
+
+	// 2. Descending order puts the latest first
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodGet, fmt.Sprintf("/api/v1/appointments?user_id=%d&sort=desc", creator.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List failed. Response: %s", rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	require.Len(t, page, 3)
+	assert.Equal(t, 11, page[0].StartTime.UTC().Hour())
+
+	// 3. Invalid status is rejected
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodGet, "/api/v1/appointments?status=unknown", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code, "Expected 400 Bad Request. Response: %s", rr.Body.String())
+}
+
+func TestAppointmentAPI_RejectsBookingOutsideWorkingHours(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.WorkingHours{}, &model.AvailabilityOverride{}, &model.User{})
+
+	creator := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Client", Email: "client@example.com", Role: "client"})
+	consultant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Consultant", Email: "consultant@example.com", Role: "provider"})
+
+	// Mondays 09:00-17:00 UTC; 2030-01-07 is a Monday.
+	monday := int(time.Monday)
+	rr := MakeRequestAs(t, globalTestApp.Router, consultant.ID, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/working-hours", consultant.ID),
+		request.WorkingHoursRequest{Weekday: &monday, StartTime: "09:00", EndTime: "17:00", Timezone: "UTC"})
+	require.Equal(t, http.StatusCreated, rr.Code, "Create working hours failed. Response: %s", rr.Body.String())
+
+	// 1. 3am is rejected with a distinct error
+	threeAM := time.Date(2030, 1, 7, 3, 0, 0, 0, time.UTC)
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPost, "/api/v1/appointments", request.AppointmentRequest{
+		ParticipantID: consultant.ID,
+		StartTime:     threeAM.Format(time.RFC3339),
+		EndTime:       threeAM.Add(time.Hour).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Expected 422. Response: %s", rr.Body.String())
+	var errorResponse map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResponse))
+	assert.Equal(t, service.ErrOutsideWorkingHours.Error(), errorResponse["error"])
+
+	// 2. Within working hours is accepted
+	tenAM := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+	createAppointment(t, creator.ID, consultant.ID, tenAM, tenAM.Add(time.Hour))
+}
+
+func TestAppointmentAPI_RecurringSeries(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.AppointmentSeries{}, &model.User{})
+
+	creator := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Client", Email: "client@example.com", Role: "client"})
+	consultant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Consultant", Email: "consultant@example.com", Role: "provider"})
+
+	first := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	seriesRequest := request.RecurringAppointmentRequest{
+		ParticipantID: consultant.ID,
+		StartTime:     first.Format(time.RFC3339),
+		EndTime:       first.Add(time.Hour).Format(time.RFC3339),
+		RRule:         "FREQ=WEEKLY;COUNT=4",
+	}
+
+	// 1. A booking in week three makes the whole series fail with a report
+	blocker := createAppointment(t, creator.ID, consultant.ID, first.AddDate(0, 0, 14), first.AddDate(0, 0, 14).Add(30*time.Minute))
+	rr := MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPost, "/api/v1/appointments/series", seriesRequest)
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+	var conflictResponse struct {
+		Conflicts []struct {
+			Start                     time.Time `json:"start"`
+			ConflictingAppointmentIDs []uint    `json:"conflicting_appointment_ids"`
+		} `json:"conflicts"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &conflictResponse))
+	require.Len(t, conflictResponse.Conflicts, 1)
+	assert.True(t, conflictResponse.Conflicts[0].Start.Equal(first.AddDate(0, 0, 14)))
+	assert.Equal(t, []uint{blocker.ID}, conflictResponse.Conflicts[0].ConflictingAppointmentIDs)
+
+	var count int64
+	require.NoError(t, globalTestApp.DB.Model(&model.Appointment{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "no occurrence should be created when one conflicts")
+
+	// 2. Once the blocker is cancelled all four occurrences are booked
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/cancel", blocker.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Cancel failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPost, "/api/v1/appointments/series", seriesRequest)
+	require.Equal(t, http.StatusCreated, rr.Code, "Create series failed. Response: %s", rr.Body.String())
+	var created struct {
+		Appointments []model.Appointment `json:"appointments"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	require.Len(t, created.Appointments, 4)
+	var series model.AppointmentSeries
+	require.NoError(t, globalTestApp.DB.First(&series, *created.Appointments[0].SeriesID).Error)
+	assert.Equal(t, globalTestApp.Organization.ID, series.OrganizationID)
+
+	// 3. Moving the second occurrence and following by 30 minutes
+	second := created.Appointments[1]
+	newStart := second.StartTime.Add(30 * time.Minute).Format(time.RFC3339)
+	newEnd := second.EndTime.Add(30 * time.Minute).Format(time.RFC3339)
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPatch, fmt.Sprintf("/api/v1/appointments/%d?scope=following", second.ID),
+		request.UpdateAppointmentRequest{StartTime: &newStart, EndTime: &newEnd})
+	require.Equal(t, http.StatusOK, rr.Code, "Update following failed. Response: %s", rr.Body.String())
+	var moved []model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &moved))
+	require.Len(t, moved, 3)
+	for i, appointment := range moved {
+		assert.True(t, appointment.StartTime.Equal(created.Appointments[i+1].StartTime.Add(30*time.Minute)))
+		assert.Equal(t, *moved[0].SeriesID, *appointment.SeriesID)
+	}
+	require.NotEqual(t, series.ID, *moved[0].SeriesID, "the moved occurrences form a series of their own")
+	require.NoError(t, globalTestApp.DB.First(&series, series.ID).Error)
+	assert.Equal(t, "FREQ=WEEKLY;UNTIL=20300114T085959Z", series.RRule)
+	var movedSeries model.AppointmentSeries
+	require.NoError(t, globalTestApp.DB.First(&movedSeries, *moved[0].SeriesID).Error)
+	assert.Equal(t, "FREQ=WEEKLY;UNTIL=20300128T093000Z", movedSeries.RRule)
+	assert.Equal(t, globalTestApp.Organization.ID, movedSeries.OrganizationID)
+
+	// 4. Cancelling the third occurrence and following leaves the first two
+	third := moved[1]
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/cancel?scope=following", third.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Cancel following failed. Response: %s", rr.Body.String())
+	var cancelled []model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &cancelled))
+	require.Len(t, cancelled, 2)
+
+	var open int64
+	require.NoError(t, globalTestApp.DB.Model(&model.Appointment{}).
+		Where("series_id IN ? AND status = ?", []uint{series.ID, movedSeries.ID}, "pending").Count(&open).Error)
+	assert.Equal(t, int64(2), open)
+}
+
+func TestAppointmentAPI_CheckInAndWaitingLine(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.User{})
+
+	consultant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Consultant", Email: "consultant@example.com", Role: "provider"})
+	early := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Early", Email: "early@example.com", Role: "client"})
+	late := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Late", Email: "late@example.com", Role: "client"})
+	actor := consultant.ID
+
+	// The late arrival was booked first, the early one an hour later.
+	now := time.Now().UTC().Truncate(time.Second)
+	lateAppointment := createAppointment(t, late.ID, consultant.ID, now.Add(-20*time.Minute), now.Add(10*time.Minute))
+	earlyAppointment := createAppointment(t, early.ID, consultant.ID, now.Add(time.Hour), now.Add(90*time.Minute))
+
+	// 1. Pending appointments cannot be checked in
+	rr := MakeRequestAs(t, globalTestApp.Router, early.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/check-in", earlyAppointment.ID), nil)
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+
+	for _, appointment := range []model.Appointment{lateAppointment, earlyAppointment} {
+		rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/confirm", appointment.ID), nil)
+		require.Equal(t, http.StatusOK, rr.Code, "Confirm failed. Response: %s", rr.Body.String())
+	}
+
+	// 2. The early arrival checks in first and heads the line
+	rr = MakeRequestAs(t, globalTestApp.Router, early.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/check-in", earlyAppointment.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Check-in failed. Response: %s", rr.Body.String())
+	var entry response.WaitingLineEntry
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &entry))
+	assert.Equal(t, 1, entry.Position)
+	assert.Equal(t, "early", entry.Appointment.ArrivalStatus)
+	assert.NotNil(t, entry.Appointment.CheckedInAt)
+
+	// 3. Checking in twice conflicts
+	rr = MakeRequestAs(t, globalTestApp.Router, early.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/check-in", earlyAppointment.ID), nil)
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+
+	// 4. The late arrival is scheduled earlier, so goes ahead in the line
+	rr = MakeRequestAs(t, globalTestApp.Router, late.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/check-in", lateAppointment.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Check-in failed. Response: %s", rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &entry))
+	assert.Equal(t, 1, entry.Position)
+	assert.Equal(t, "late", entry.Appointment.ArrivalStatus)
+
+	rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/waiting-line", consultant.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Waiting line failed. Response: %s", rr.Body.String())
+	var line response.WaitingLineResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &line))
+	require.Len(t, line.Entries, 2)
+	assert.Equal(t, lateAppointment.ID, line.Entries[0].Appointment.ID)
+	assert.Equal(t, earlyAppointment.ID, line.Entries[1].Appointment.ID)
+
+	// 5. Calling next takes them in line order until the line is empty
+	for _, expected := range []uint{lateAppointment.ID, earlyAppointment.ID} {
+		rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/waiting-line/call-next", consultant.ID), nil)
+		require.Equal(t, http.StatusOK, rr.Code, "Call next failed. Response: %s", rr.Body.String())
+		var called model.Appointment
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &called))
+		assert.Equal(t, expected, called.ID)
+		assert.NotNil(t, called.CalledInAt)
+	}
+	rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/waiting-line/call-next", consultant.ID), nil)
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+}
+
+func TestAppointmentAPI_ActualTimesAndETA(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.User{})
+
+	consultant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Consultant", Email: "consultant@example.com", Role: "provider"})
+	client := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Client", Email: "client@example.com", Role: "client"})
+	actor := consultant.ID
+
+	base := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Hour)
+	first := createAppointment(t, client.ID, consultant.ID, base, base.Add(30*time.Minute))
+	second := createAppointment(t, client.ID, consultant.ID, base.Add(time.Hour), base.Add(90*time.Minute))
+
+	// 1. Only confirmed appointments can be started
+	rr := MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/start", first.ID), nil)
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+
+	rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/confirm", first.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Confirm failed. Response: %s", rr.Body.String())
+
+	// 2. Starting and completing records the actual times
+	rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/start", first.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Start failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/start", first.ID), nil)
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+
+	rr = MakeRequestAs(t, globalTestApp.Router, actor, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/complete", first.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Complete failed. Response: %s", rr.Body.String())
+	var completed model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &completed))
+	require.NotNil(t, completed.ActualStartAt)
+	require.NotNil(t, completed.ActualEndAt)
+
+	// 3. The first appointment finished far ahead of schedule, so the next
+	// one is expected on time
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodGet, fmt.Sprintf("/api/v1/appointments/%d/eta", second.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "ETA failed. Response: %s", rr.Body.String())
+	var estimate response.AppointmentETAResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &estimate))
+	assert.True(t, second.StartTime.Equal(estimate.EstimatedStart), "expected %v, got %v", second.StartTime, estimate.EstimatedStart)
+	assert.Equal(t, 0, estimate.DelayMinutes)
+	assert.Equal(t, 1, estimate.Samples)
+
+	// 4. Closed appointments have no ETA
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodGet, fmt.Sprintf("/api/v1/appointments/%d/eta", first.ID), nil)
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+}
+
+func createAppointment(t *testing.T, userID, participantID uint, start, end time.Time) model.Appointment {
+	t.Helper()
+	rr := MakeRequestAs(t, globalTestApp.Router, userID, http.MethodPost, "/api/v1/appointments", request.AppointmentRequest{
+		ParticipantID: participantID,
+		StartTime:     start.Format(time.RFC3339),
+		EndTime:       end.Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusCreated, rr.Code, "Create Appointment failed. Response: %s", rr.Body.String())
+
+	var appointment model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &appointment))
+	return appointment
+}
@@ -0,0 +1,185 @@
+package integrationtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/notification"
+	"queue_system/internal/notification/smtptest"
+	"queue_system/internal/outbox"
+	"queue_system/internal/repository"
+	"queue_system/internal/service"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthAPI_PasswordLoginRefreshAndLogout(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.RefreshToken{}, &model.Appointment{}, &model.User{})
+
+	// 1. Signing up with a password never returns the hash
+	rr := MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/users", request.CreateUserRequest{
+		Name: "Auth User", Email: "auth.user@example.com", Password: "correct horse",
+	})
+	require.Equal(t, http.StatusCreated, rr.Code, "Create User failed. Response: %s", rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), "correct horse")
+	assert.NotContains(t, strings.ToLower(rr.Body.String()), "password")
+	var user model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &user))
+
+	// 2. A wrong password or an unknown email is rejected the same way
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/auth/login", request.LoginRequest{Email: user.Email, Password: "wrong horse"})
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	wrongPassword := rr.Body.String()
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/auth/login", request.LoginRequest{Email: "nobody@example.com", Password: "correct horse"})
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, wrongPassword, rr.Body.String())
+
+	first := login(t, user.Email, "correct horse")
+	assert.Equal(t, "Bearer", first.TokenType)
+	assert.Equal(t, int(globalTestApp.Config.Auth.AccessTokenTTL.Seconds()), first.ExpiresIn)
+
+	// 3. Protected routes need an access token and act as its user
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodGet, "/api/v1/auth/me", nil)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Bearer")
+	rr = MakeRequestWithToken(t, globalTestApp.Router, "not-a-token", http.MethodGet, "/api/v1/auth/me", nil)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = MakeRequestWithToken(t, globalTestApp.Router, first.AccessToken, http.MethodGet, "/api/v1/auth/me", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Me failed. Response: %s", rr.Body.String())
+	var me model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &me))
+	assert.Equal(t, user.ID, me.ID)
+
+	participant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Auth Participant", Email: "auth.participant@example.com", Role: "provider"})
+	start := time.Date(2030, 5, 6, 9, 0, 0, 0, time.UTC)
+	booking := map[string]interface{}{
+		"user_id":        participant.ID,
+		"participant_id": participant.ID,
+		"start_time":     start.Format(time.RFC3339),
+		"end_time":       start.Add(time.Hour).Format(time.RFC3339),
+	}
+	rr = MakeRequestWithToken(t, globalTestApp.Router, first.AccessToken, http.MethodPost, "/api/v1/appointments", booking)
+	require.Equal(t, http.StatusForbidden, rr.Code, "Clients cannot book for others. Response: %s", rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "booking_for_others")
+
+	delete(booking, "user_id")
+	rr = MakeRequestWithToken(t, globalTestApp.Router, first.AccessToken, http.MethodPost, "/api/v1/appointments", booking)
+	require.Equal(t, http.StatusCreated, rr.Code, "Create Appointment failed. Response: %s", rr.Body.String())
+	var appointment model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &appointment))
+	assert.Equal(t, user.ID, appointment.UserID, "the creator defaults to the token's user")
+
+	// 4. Refreshing replaces the refresh token
+	second := refresh(t, first.RefreshToken, http.StatusOK)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	rr = MakeRequestWithToken(t, globalTestApp.Router, second.AccessToken, http.MethodGet, "/api/v1/auth/me", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// 5. Reusing a replaced refresh token revokes the whole session
+	refresh(t, first.RefreshToken, http.StatusUnauthorized)
+	refresh(t, second.RefreshToken, http.StatusUnauthorized)
+
+	// 6. Logging out ends the session; unknown tokens are ignored
+	third := login(t, user.Email, "correct horse")
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/auth/logout", request.RefreshTokenRequest{RefreshToken: third.RefreshToken})
+	require.Equal(t, http.StatusNoContent, rr.Code, "Logout failed. Response: %s", rr.Body.String())
+	refresh(t, third.RefreshToken, http.StatusUnauthorized)
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/auth/logout", request.RefreshTokenRequest{RefreshToken: "unknown"})
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestAuthAPI_MagicLinkSignsInOnce(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.OutboxEvent{}, &model.MagicLink{}, &model.RefreshToken{}, &model.User{})
+
+	smtpServer, err := smtptest.NewServer()
+	require.NoError(t, err)
+	defer smtpServer.Close()
+
+	cfg := *globalTestApp.Config
+	cfg.Notification.Channel = "smtp"
+	cfg.Notification.SMTP.Host = smtpServer.Host()
+	cfg.Notification.SMTP.Port = smtpServer.Port()
+	cfg.Notification.SMTP.Username = ""
+	cfg.Notification.SMTP.From = "Appointments <noreply@example.com>"
+	notifier, err := notification.NewNotifier(&cfg)
+	require.NoError(t, err)
+	templates, err := notification.LoadTemplates(&cfg)
+	require.NoError(t, err)
+	userRepository := repository.NewUserRepository(globalTestApp.DB)
+	sender := notification.NewSender(notifier, templates, userRepository, repository.NewNotificationRepository(globalTestApp.DB), &cfg)
+	authService := service.NewAuthService(repository.NewAuthRepository(globalTestApp.DB), userRepository,
+		auth.NewTokens(&cfg), sender, globalTestApp.DB, &cfg)
+	bus := events.NewBus()
+	bus.Subscribe("magic-links", authService.SendMagicLink, events.MagicLinkRequested)
+	dispatcher := outbox.NewDispatcher(globalTestApp.DB, repository.NewOutboxRepository(globalTestApp.DB), bus, &cfg)
+	dispatch := func() {
+		_, err := dispatcher.DispatchPending(context.Background())
+		require.NoError(t, err)
+	}
+
+	user := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Link User", Email: "link.user@example.com", Role: "client"})
+
+	// 1. Requests are answered alike and only queued; unknown emails get nothing, known ones a link
+	for _, email := range []string{"nobody@example.com", user.Email} {
+		rr := MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/auth/magic-link", request.MagicLinkRequest{Email: email})
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+	}
+	assert.Empty(t, smtpServer.Messages(), "links are sent from the outbox")
+	dispatch()
+
+	messages := smtpServer.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, []string{user.Email}, messages[0].To)
+	assert.Equal(t, "Your sign-in link", mailSubject(t, messages[0]))
+	unwrapped := strings.ReplaceAll(messages[0].Data, "=\r\n", "")
+	match := regexp.MustCompile(`token=(?:3D)?([0-9a-f]{64})`).FindStringSubmatch(unwrapped)
+	require.NotNil(t, match, "message should contain the link: %s", messages[0].Data)
+
+	// 2. The link signs in once
+	rr := MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/auth/magic-link/verify", request.VerifyMagicLinkRequest{Token: match[1]})
+	require.Equal(t, http.StatusOK, rr.Code, "Verify failed. Response: %s", rr.Body.String())
+	var tokens response.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tokens))
+	rr = MakeRequestWithToken(t, globalTestApp.Router, tokens.AccessToken, http.MethodGet, "/api/v1/auth/me", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/auth/magic-link/verify", request.VerifyMagicLinkRequest{Token: match[1]})
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func login(t *testing.T, email, password string) response.TokenResponse {
+	t.Helper()
+	rr := MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/auth/login", request.LoginRequest{Email: email, Password: password})
+	require.Equal(t, http.StatusOK, rr.Code, "Login failed. Response: %s", rr.Body.String())
+	var tokens response.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tokens))
+	return tokens
+}
+
+func refresh(t *testing.T, refreshToken string, expectedStatus int) response.TokenResponse {
+	t.Helper()
+	rr := MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/auth/refresh", request.RefreshTokenRequest{RefreshToken: refreshToken})
+	require.Equal(t, expectedStatus, rr.Code, "Unexpected refresh status. Response: %s", rr.Body.String())
+	var tokens response.TokenResponse
+	if expectedStatus == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tokens))
+	}
+	return tokens
+}
@@ -0,0 +1,163 @@
+package integrationtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"queue_system/internal/calendarsync"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/model"
+	"queue_system/internal/service"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusyCalendarAPI_ImportedEventsBlockBookings(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.BusyBlock{}, &model.BusyCalendar{}, &model.Appointment{}, &model.User{})
+
+	host := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Busy Host", Email: "busy.host@example.com", Role: "client"})
+	consultant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Busy Consultant", Email: "busy.consultant@example.com", Role: "provider"})
+	calendarsURL := fmt.Sprintf("/api/v1/users/%d/busy-calendars", consultant.ID)
+
+	start := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Hour)
+	icsTime := func(t time.Time) string { return t.Format("20060102T150405Z") }
+
+	// 1. Upload a calendar with a meeting and a daily recurring lunch
+	uploaded := uploadBusyCalendar(t, consultant.ID, "personal.ics", fmt.Sprintf(
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"+
+			"BEGIN:VEVENT\r\nUID:meeting@example.com\r\nDTSTART:%s\r\nDTEND:%s\r\nEND:VEVENT\r\n"+
+			"BEGIN:VEVENT\r\nUID:lunch@example.com\r\nDTSTART:%s\r\nDURATION:PT30M\r\nRRULE:FREQ=DAILY;COUNT=5\r\nEND:VEVENT\r\n"+
+			"END:VCALENDAR\r\n",
+		icsTime(start), icsTime(start.Add(time.Hour)), icsTime(start.Add(3*time.Hour))))
+	assert.Equal(t, "personal.ics", uploaded.Name)
+	assert.Equal(t, 6, uploaded.BlockCount)
+
+	// 2. Booking over the meeting conflicts
+	rr := MakeRequestAs(t, globalTestApp.Router, host.ID, http.MethodPost, "/api/v1/appointments", request.AppointmentRequest{
+		ParticipantID: consultant.ID,
+		StartTime:     start.Add(30 * time.Minute).Format(time.RFC3339),
+		EndTime:       start.Add(90 * time.Minute).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+	var errorResponse map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResponse))
+	assert.Equal(t, service.ErrBusyTimeConflict.Error(), errorResponse["error"])
+
+	// 3. Availability leaves the busy time out
+	rr = MakeRequestAs(t, globalTestApp.Router, consultant.ID, http.MethodGet, fmt.Sprintf("/api/v1/availability?user_ids=%d&from=%s&to=%s&duration=30m",
+		consultant.ID, start.Format(time.RFC3339), start.Add(4*time.Hour).Format(time.RFC3339)), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Availability failed. Response: %s", rr.Body.String())
+	var availability response.AvailabilityResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &availability))
+	require.Len(t, availability.Slots, 2)
+	assert.True(t, start.Add(time.Hour).Equal(availability.Slots[0].Start))
+	assert.True(t, start.Add(3*time.Hour).Equal(availability.Slots[0].End))
+	assert.True(t, start.Add(210*time.Minute).Equal(availability.Slots[1].Start))
+
+	// 4. A subscribed URL is fetched at once and refreshed in the background
+	var mu sync.Mutex
+	served := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:a@example.com\r\nDTSTART:" + icsTime(start.Add(5*time.Hour)) +
+		"\r\nDTEND:" + icsTime(start.Add(6*time.Hour)) + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+		w.Write([]byte(served))
+	}))
+	defer server.Close()
+
+	rr = MakeRequestAs(t, globalTestApp.Router, consultant.ID, http.MethodPost, calendarsURL, request.SubscribeBusyCalendarRequest{URL: server.URL + "/work.ics", Name: "Work"})
+	require.Equal(t, http.StatusCreated, rr.Code, "Subscribe failed. Response: %s", rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), "work.ics", "subscription URLs are secret")
+	var subscribed model.BusyCalendar
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &subscribed))
+	assert.Equal(t, 1, subscribed.BlockCount)
+	require.NotNil(t, subscribed.NextSyncAt)
+
+	mu.Lock()
+	served = "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"
+	mu.Unlock()
+	refreshed, err := globalTestApp.CalendarSync.RefreshDue(context.Background(), subscribed.NextSyncAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, refreshed, "only the URL calendar is due")
+	assert.Equal(t, 0, getBusyCalendar(t, subscribed.ID).BlockCount)
+
+	// 5. A failed refresh keeps the blocks and records the error
+	mu.Lock()
+	status = http.StatusInternalServerError
+	mu.Unlock()
+	_, err = globalTestApp.CalendarSync.RefreshDue(context.Background(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	failed := getBusyCalendar(t, subscribed.ID)
+	assert.Equal(t, calendarsync.ErrFetchFailed.Error(), failed.LastError, "what the server answered is not passed on")
+
+	rr = MakeRequestAs(t, globalTestApp.Router, consultant.ID, http.MethodPost, calendarsURL, request.SubscribeBusyCalendarRequest{URL: server.URL + "/broken.ics"})
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Expected 422. Response: %s", rr.Body.String())
+
+	// 6. Only calendar files are accepted
+	rr = uploadRequest(t, consultant.ID, "notes.txt", "just some notes")
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Expected 422. Response: %s", rr.Body.String())
+
+	// 7. Removing the calendar frees the time
+	rr = MakeRequestAs(t, globalTestApp.Router, consultant.ID, http.MethodGet, calendarsURL, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var calendars []model.BusyCalendar
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &calendars))
+	assert.Len(t, calendars, 2)
+
+	rr = MakeRequestAs(t, globalTestApp.Router, consultant.ID, http.MethodDelete, fmt.Sprintf("/api/v1/users/%d/busy-calendars/%d", host.ID, uploaded.ID), nil)
+	require.Equal(t, http.StatusForbidden, rr.Code, "Other users' calendars are off limits. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, consultant.ID, http.MethodDelete, fmt.Sprintf("%s/%d", calendarsURL, uploaded.ID), nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Delete failed. Response: %s", rr.Body.String())
+
+	createAppointment(t, host.ID, consultant.ID, start.Add(30*time.Minute), start.Add(90*time.Minute))
+}
+
+func uploadBusyCalendar(t *testing.T, userID uint, filename, content string) model.BusyCalendar {
+	t.Helper()
+	rr := uploadRequest(t, userID, filename, content)
+	require.Equal(t, http.StatusCreated, rr.Code, "Upload failed. Response: %s", rr.Body.String())
+
+	var calendar model.BusyCalendar
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &calendar))
+	return calendar
+}
+
+func uploadRequest(t *testing.T, userID uint, filename, content string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/users/%d/busy-calendars/upload", userID), &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+AccessToken(t, userID))
+	rr := httptest.NewRecorder()
+	globalTestApp.Router.ServeHTTP(rr, req)
+	return rr
+}
+
+func getBusyCalendar(t *testing.T, id uint) model.BusyCalendar {
+	t.Helper()
+	var calendar model.BusyCalendar
+	require.NoError(t, globalTestApp.DB.First(&calendar, id).Error)
+	return calendar
+}
@@ -0,0 +1,179 @@
+package integrationtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"queue_system/internal/auth"
+	"queue_system/internal/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalDAVAPI_SyncsAppointments(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.User{})
+
+	passwordHash, err := auth.HashPassword("caldav-password")
+	require.NoError(t, err)
+	host := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "CalDAV Host", Email: "caldav.host@example.com", Role: "provider", PasswordHash: passwordHash})
+	guest := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "CalDAV Guest", Email: "caldav.guest@example.com", Role: "client"})
+
+	start := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Hour)
+	booked := createAppointment(t, host.ID, guest.ID, start, start.Add(time.Hour))
+	collection := fmt.Sprintf("/caldav/users/%d/calendar/", host.ID)
+	bookedHref := fmt.Sprintf("%sappointment-%d.ics", collection, booked.ID)
+
+	// 1. Discovery advertises CalDAV and finds the calendar from the principal
+	rr := davRequest(t, host.ID, http.MethodOptions, collection, nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("DAV"), "calendar-access")
+
+	rr = davRequest(t, host.ID, "PROPFIND", fmt.Sprintf("/caldav/users/%d/", host.ID), map[string]string{"Depth": "0"},
+		`<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><c:calendar-home-set/></d:prop></d:propfind>`)
+	require.Equal(t, http.StatusMultiStatus, rr.Code, "Principal PROPFIND failed. Response: %s", rr.Body.String())
+	assert.Contains(t, rr.Body.String(), fmt.Sprintf("<c:calendar-home-set><d:href>/caldav/users/%d/</d:href></c:calendar-home-set>", host.ID))
+
+	// 2. The collection lists the booked appointment
+	rr = davRequest(t, host.ID, "PROPFIND", collection, map[string]string{"Depth": "1"}, "")
+	require.Equal(t, http.StatusMultiStatus, rr.Code, "Collection PROPFIND failed. Response: %s", rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "<c:calendar/>")
+	assert.Contains(t, rr.Body.String(), "<d:href>"+bookedHref+"</d:href>")
+	ctag := between(rr.Body.String(), "<cs:getctag>", "</cs:getctag>")
+
+	// 3. A client creates an event that invites a registered user
+	clientHref := collection + "client-event.ics"
+	event := davEvent("client-event-1", start.Add(2*time.Hour), start.Add(3*time.Hour), "Follow-up", guest.Email)
+	rr = davRequest(t, host.ID, http.MethodPut, clientHref, map[string]string{"If-None-Match": "*"}, event)
+	require.Equal(t, http.StatusCreated, rr.Code, "PUT failed. Response: %s", rr.Body.String())
+
+	var created model.Appointment
+	require.NoError(t, globalTestApp.DB.Where("resource_name = ?", "client-event.ics").First(&created).Error)
+	assert.Equal(t, host.ID, created.UserID)
+	assert.Equal(t, guest.ID, created.ParticipantID)
+	assert.Equal(t, "Follow-up", created.Description)
+
+	rr = davRequest(t, host.ID, http.MethodGet, clientHref, nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "UID:client-event-1\r\n")
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// 4. The participant's calendar and the collection's ctag see it
+	rr = davRequest(t, guest.ID, "REPORT", fmt.Sprintf("/caldav/users/%d/calendar/", guest.ID), map[string]string{"Depth": "1"},
+		fmt.Sprintf(`<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/><c:calendar-data/></d:prop>
+<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VEVENT"><c:time-range start="%s" end="%s"/></c:comp-filter></c:comp-filter></c:filter>
+</c:calendar-query>`, start.Add(90*time.Minute).Format("20060102T150405Z"), start.Add(4*time.Hour).Format("20060102T150405Z")))
+	require.Equal(t, http.StatusMultiStatus, rr.Code, "REPORT failed. Response: %s", rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "UID:client-event-1")
+	assert.NotContains(t, rr.Body.String(), "appointment-"+fmt.Sprint(booked.ID))
+
+	rr = davRequest(t, host.ID, "PROPFIND", collection, map[string]string{"Depth": "0"}, "")
+	require.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.NotEqual(t, ctag, between(rr.Body.String(), "<cs:getctag>", "</cs:getctag>"))
+
+	// 5. Moving the event with a stale ETag fails, with the current one it reschedules
+	moved := davEvent("client-event-1", start.Add(4*time.Hour), start.Add(5*time.Hour), "Follow-up", guest.Email)
+	rr = davRequest(t, host.ID, http.MethodPut, clientHref, map[string]string{"If-Match": `"0-0"`}, moved)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	rr = davRequest(t, host.ID, http.MethodPut, clientHref, map[string]string{"If-Match": etag}, moved)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Move failed. Response: %s", rr.Body.String())
+	require.NoError(t, globalTestApp.DB.First(&created, created.ID).Error)
+	assert.True(t, created.StartTime.Equal(start.Add(4*time.Hour)))
+
+	// 6. Events clashing with a booking or without a registered attendee are refused
+	clash := davEvent("client-event-2", start, start.Add(time.Hour), "Clash", guest.Email)
+	rr = davRequest(t, host.ID, http.MethodPut, collection+"clash.ics", nil, clash)
+	assert.Equal(t, http.StatusConflict, rr.Code, "Response: %s", rr.Body.String())
+
+	stranger := davEvent("client-event-3", start.Add(6*time.Hour), start.Add(7*time.Hour), "Stranger", "nobody@example.com")
+	rr = davRequest(t, host.ID, http.MethodPut, collection+"stranger.ics", nil, stranger)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Response: %s", rr.Body.String())
+
+	// 7. Deleting cancels the appointment and removes it from the collection
+	rr = davRequest(t, host.ID, http.MethodDelete, bookedHref, nil, "")
+	require.Equal(t, http.StatusNoContent, rr.Code, "DELETE failed. Response: %s", rr.Body.String())
+	var cancelled model.Appointment
+	require.NoError(t, globalTestApp.DB.First(&cancelled, booked.ID).Error)
+	assert.Equal(t, "cancelled", cancelled.Status)
+
+	rr = davRequest(t, host.ID, "REPORT", collection, nil, fmt.Sprintf(
+		`<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/></d:prop><d:href>%s</d:href><d:href>%s</d:href></c:calendar-multiget>`,
+		bookedHref, clientHref))
+	require.Equal(t, http.StatusMultiStatus, rr.Code, "Multiget failed. Response: %s", rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "<d:href>"+bookedHref+"</d:href><d:status>HTTP/1.1 404 Not Found</d:status>")
+	assert.Contains(t, rr.Body.String(), "<d:href>"+clientHref+"</d:href><d:propstat>")
+
+	rr = davRequest(t, host.ID, http.MethodGet, bookedHref, nil, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// 8. Clients may sign in with the password; other users' calendars are forbidden
+	req, err := http.NewRequest("PROPFIND", collection, nil)
+	require.NoError(t, err)
+	req.Header.Set("Depth", "0")
+	rr = httptest.NewRecorder()
+	globalTestApp.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Basic")
+
+	req.SetBasicAuth(host.Email, "caldav-password")
+	rr = httptest.NewRecorder()
+	globalTestApp.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusMultiStatus, rr.Code, "Basic auth PROPFIND failed. Response: %s", rr.Body.String())
+
+	rr = davRequest(t, host.ID, "PROPFIND", fmt.Sprintf("/caldav/users/%d/calendar/", guest.ID), map[string]string{"Depth": "0"}, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// 9. STATUS:CONFIRMED confirms a pending appointment, but only for its host
+	requested := createAppointment(t, guest.ID, host.ID, start.Add(8*time.Hour), start.Add(9*time.Hour))
+	confirmed := strings.Replace(davEvent("requested", requested.StartTime, requested.EndTime, "", host.Email), "END:VEVENT", "STATUS:CONFIRMED\r\nEND:VEVENT", 1)
+	rr = davRequest(t, guest.ID, http.MethodPut, fmt.Sprintf("/caldav/users/%d/calendar/appointment-%d.ics", guest.ID, requested.ID), nil, confirmed)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "Response: %s", rr.Body.String())
+
+	rr = davRequest(t, host.ID, http.MethodPut, fmt.Sprintf("%sappointment-%d.ics", collection, requested.ID), nil, confirmed)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Confirm failed. Response: %s", rr.Body.String())
+	require.NoError(t, globalTestApp.DB.First(&requested, requested.ID).Error)
+	assert.Equal(t, "confirmed", requested.Status)
+}
+
+func davRequest(t *testing.T, userID uint, method, url string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+AccessToken(t, userID))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rr := httptest.NewRecorder()
+	globalTestApp.Router.ServeHTTP(rr, req)
+	return rr
+}
+
+func davEvent(uid string, start, end time.Time, summary, attendee string) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:" + uid,
+		"DTSTART:" + start.Format("20060102T150405Z"),
+		"DTEND:" + end.Format("20060102T150405Z"),
+		"SUMMARY:" + summary,
+		"ATTENDEE:mailto:" + attendee,
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func between(s, open, close string) string {
+	_, rest, _ := strings.Cut(s, open)
+	value, _, _ := strings.Cut(rest, close)
+	return value
+}
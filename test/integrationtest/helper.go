@@ -11,12 +11,23 @@ import (
 	"path/filepath"
 	config_pkg "queue_system/config"
 	"queue_system/database"
+	"queue_system/internal/auth"
+	"queue_system/internal/calendarsync"
 	"queue_system/internal/controller"
+	"queue_system/internal/eta"
+	"queue_system/internal/events"
 	"queue_system/internal/model"
+	"queue_system/internal/notification"
+	"queue_system/internal/outbox"
+	"queue_system/internal/ratelimit"
 	"queue_system/internal/repository"
 	"queue_system/internal/service"
+	"queue_system/internal/stream"
+	"queue_system/internal/tenant"
+	"queue_system/internal/webhook"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
@@ -25,9 +36,16 @@ import (
 )
 
 type TestApp struct {
-	DB     *gorm.DB
-	Router *gin.Engine
-	Config *config_pkg.Config
+	DB           *gorm.DB
+	Router       *gin.Engine
+	Config       *config_pkg.Config
+	Dispatcher   *outbox.Dispatcher
+	Webhooks     *webhook.Dispatcher
+	CalendarSync *calendarsync.Syncer
+	Tokens       *auth.Tokens
+	// Organization is the default organization, which requests naming
+	// none belong to.
+	Organization *model.Organization
 }
 
 var globalTestApp *TestApp
@@ -70,6 +88,9 @@ func SetupTestApp(tLogger MinimalLogger) (*TestApp, error) {
 	//override cfg.Database.Name
 	testDBName := viper.GetString("DATABASE_NAME_TEST")
 	cfg.Database.Name = testDBName
+	// Webhook receivers and subscribed calendars are served from localhost.
+	cfg.Webhook.AllowPrivateTargets = true
+	cfg.Calendar.AllowPrivateURLs = true
 
 	//Make sure DATABASE_NAME_TEST is exists
 	defaultDbConnStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=postgres sslmode=disable",
@@ -104,7 +125,7 @@ func SetupTestApp(tLogger MinimalLogger) (*TestApp, error) {
 		return nil, fmt.Errorf("failed to connect to test database '%s' with GORM: %w", testDBName, err)
 	}
 
-	err = db.AutoMigrate(&model.User{}, &model.Appointment{})
+	err = db.AutoMigrate(&model.Organization{}, &model.User{}, &model.Appointment{}, &model.AppointmentSeries{}, &model.WorkingHours{}, &model.AvailabilityOverride{}, &model.Queue{}, &model.QueueTicket{}, &model.OutboxEvent{}, &model.Webhook{}, &model.WebhookDelivery{}, &model.AppointmentReminder{}, &model.SentNotification{}, &model.CalendarFeed{}, &model.BusyCalendar{}, &model.BusyBlock{}, &model.RefreshToken{}, &model.MagicLink{}, &model.APIKey{})
 	if err != nil {
 		gormSQLDB, _ := db.DB()
 		if gormSQLDB != nil {
@@ -113,36 +134,208 @@ func SetupTestApp(tLogger MinimalLogger) (*TestApp, error) {
 		return nil, fmt.Errorf("failed to migrate test database: %w", err)
 	}
 
+	// The dispatchers are not started; tests run DispatchPending and
+	// DeliverPending themselves so delivery is deterministic.
+	bus := events.NewBus()
+	outboxRepo := repository.NewOutboxRepository(db)
+	dispatcher := outbox.NewDispatcher(db, outboxRepo, bus, cfg)
+	hub := stream.NewHub()
+	bus.SubscribeAll("stream", hub.HandleEvent)
+	streamCtrl := controller.NewStreamController(hub)
+
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhooks := webhook.NewDispatcher(db, webhookRepo, cfg)
+	bus.SubscribeAll("webhooks", webhooks.HandleEvent)
+	webhookSvc := service.NewWebhookService(webhookRepo, cfg)
+	webhookCtrl := controller.NewWebhookController(webhookSvc)
+
 	userRepo := repository.NewUserRepository(db)
-	userSvc := service.NewUserService(userRepo)
-	userCtrl := controller.NewUserController(userSvc)
+
+	notifier, err := notification.NewNotifier(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build notifier: %w", err)
+	}
+	templates, err := notification.LoadTemplates(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification templates: %w", err)
+	}
+	notifications := notification.NewSender(notifier, templates, userRepo, repository.NewNotificationRepository(db), cfg)
+	bus.Subscribe("notifications", notifications.HandleEvent, notifications.EventTypes()...)
+
+	tokens := auth.NewTokens(cfg)
+	authRepo := repository.NewAuthRepository(db)
+	authMiddleware := auth.NewMiddleware(tokens, userRepo, authRepo)
+	authSvc := service.NewAuthService(authRepo, userRepo, tokens, notifications, db, cfg)
+	bus.Subscribe("magic-links", authSvc.SendMagicLink, events.MagicLinkRequested)
+	authCtrl := controller.NewAuthController(authSvc)
+	apiKeyCtrl := controller.NewAPIKeyController(service.NewAPIKeyService(authRepo))
+
+	workingHoursRepo := repository.NewWorkingHoursRepository(db)
+	workingHoursSvc := service.NewWorkingHoursService(workingHoursRepo, userRepo)
+	workingHoursCtrl := controller.NewWorkingHoursController(workingHoursSvc)
 
 	apptRepo := repository.NewAppointmentRepository(db)
-	apptSvc := service.NewAppointmentService(apptRepo, userRepo, db)
+	apptSvc := service.NewAppointmentService(apptRepo, userRepo, workingHoursRepo, db)
 	apptCtrl := controller.NewAppointmentController(apptSvc)
 
+	userSvc := service.NewUserService(userRepo, apptRepo, authRepo, db)
+	userCtrl := controller.NewUserController(userSvc)
+
+	calendarRepo := repository.NewCalendarRepository(db)
+	icalSvc := service.NewICalService(apptRepo, userRepo, calendarRepo, cfg)
+	icalCtrl := controller.NewICalController(icalSvc)
+
+	calendarSync := calendarsync.NewSyncer(db, calendarRepo, cfg)
+	busyCalendarSvc := service.NewBusyCalendarService(calendarRepo, userRepo, calendarSync, db)
+	busyCalendarCtrl := controller.NewBusyCalendarController(busyCalendarSvc)
+
+	caldavSvc := service.NewCalDAVService(apptSvc, apptRepo, userRepo, cfg)
+	caldavCtrl := controller.NewCalDAVController(caldavSvc)
+
+	availabilitySvc := service.NewAvailabilityService(apptRepo, userRepo, calendarRepo, workingHoursRepo)
+	availabilityCtrl := controller.NewAvailabilityController(availabilitySvc)
+
+	estimator, err := eta.New(cfg.ETA.Estimator, cfg.ETA.Window, cfg.ETA.Percentile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ETA estimator: %w", err)
+	}
+	etaSvc := service.NewETAService(apptRepo, estimator)
+	etaCtrl := controller.NewETAController(etaSvc)
+
+	queueRepo := repository.NewQueueRepository(db)
+	queueSvc := service.NewQueueService(queueRepo, userRepo, db)
+	queueCtrl := controller.NewQueueController(queueSvc)
+
+	organizationRepo := repository.NewOrganizationRepository(db)
+	organization, err := organizationRepo.GetBySlug(cfg.Tenancy.DefaultOrganization)
+	if err != nil || organization == nil {
+		return nil, fmt.Errorf("failed to load default organization: %w", err)
+	}
+	tenantResolver := tenant.NewResolver(organizationRepo, cfg)
+	organizationCtrl := controller.NewOrganizationController(service.NewOrganizationService(organizationRepo, userRepo, db, cfg))
+
 	gin.SetMode(gin.TestMode)
 	router := gin.Default()
-
+	router.Use(tenantResolver.Middleware())
+
+	requireUser := authMiddleware.RequireUser()
+	ownUser := auth.RequireSelfOr("id", auth.ManageUsers)
+	ownAppointments := auth.RequireSelfOr("id", auth.ManageAppointments)
+	ownSchedule := auth.RequireSelfOr("id", auth.ManageSchedules)
+	manageQueues := auth.RequirePermission(auth.ManageQueues)
+	manageUsers := auth.RequirePermission(auth.ManageUsers)
+	manageAppointments := auth.RequirePermission(auth.ManageAppointments)
+	restoreDeleted := auth.RequirePermission(auth.RestoreDeleted)
+	authLimit := ratelimit.New(cfg.Auth.RateLimit, cfg.Auth.RateLimitWindow).PerClient()
 	apiV1 := router.Group("/api/v1")
-	userRoutes := apiV1.Group("/users")
+	authRoutes := apiV1.Group("/auth")
+	{
+		authRoutes.POST("/login", authLimit, authCtrl.Login)
+		authRoutes.POST("/magic-link", authLimit, authCtrl.RequestMagicLink)
+		authRoutes.POST("/magic-link/verify", authCtrl.VerifyMagicLink)
+		authRoutes.POST("/refresh", authCtrl.Refresh)
+		authRoutes.POST("/logout", authCtrl.Logout)
+		authRoutes.GET("/me", requireUser, authCtrl.Me)
+	}
+	apiV1.POST("/organizations", requireUser, auth.RequireSession(), auth.RequirePermission(auth.ManageOrganizations), organizationCtrl.CreateOrganization)
+	apiKeyRoutes := apiV1.Group("/api-keys", requireUser, auth.RequireSession())
 	{
-		userRoutes.POST("", userCtrl.CreateUser)
-		userRoutes.GET("/:id", userCtrl.GetUserById)
+		apiKeyRoutes.POST("", apiKeyCtrl.CreateAPIKey)
+		apiKeyRoutes.GET("", apiKeyCtrl.ListAPIKeys)
+		apiKeyRoutes.DELETE("/:id", apiKeyCtrl.RevokeAPIKey)
 	}
-	apptRoutes := apiV1.Group("/appointments")
+	apiV1.POST("/users", authMiddleware.IdentifyUser(), auth.RequireScope("users"), userCtrl.CreateUser)
+	apiV1.GET("/users/:id/calendar.ics", icalCtrl.ExportUserFeed)
+	userRoutes := apiV1.Group("/users", requireUser, auth.RequireScope("users"))
+	{
+		userRoutes.GET("", manageUsers, userCtrl.ListUsers)
+		userRoutes.GET("/:id", ownUser, userCtrl.GetUserById)
+		userRoutes.PATCH("/:id", ownUser, userCtrl.UpdateUser)
+		userRoutes.POST("/:id/deactivate", manageUsers, userCtrl.DeactivateUser)
+		userRoutes.POST("/:id/reactivate", manageUsers, userCtrl.ReactivateUser)
+		userRoutes.DELETE("/:id", manageUsers, userCtrl.DeleteUser)
+		userRoutes.POST("/:id/restore", restoreDeleted, userCtrl.RestoreUser)
+		userRoutes.GET("/:id/appointments", ownAppointments, apptCtrl.GetUserCalendar)
+		userRoutes.POST("/:id/calendar-token", ownUser, icalCtrl.IssueFeedToken)
+		userRoutes.GET("/:id/busy-calendars", ownUser, busyCalendarCtrl.ListBusyCalendars)
+		userRoutes.POST("/:id/busy-calendars", ownUser, busyCalendarCtrl.SubscribeBusyCalendar)
+		userRoutes.POST("/:id/busy-calendars/upload", ownUser, busyCalendarCtrl.ImportBusyCalendar)
+		userRoutes.DELETE("/:id/busy-calendars/:calendarId", ownUser, busyCalendarCtrl.DeleteBusyCalendar)
+		userRoutes.GET("/:id/waiting-line", ownSchedule, apptCtrl.GetWaitingLine)
+		userRoutes.POST("/:id/waiting-line/call-next", ownSchedule, apptCtrl.CallNextInWaitingLine)
+		userRoutes.GET("/:id/working-hours", ownSchedule, workingHoursCtrl.ListWorkingHours)
+		userRoutes.POST("/:id/working-hours", ownSchedule, workingHoursCtrl.CreateWorkingHours)
+		userRoutes.PUT("/:id/working-hours/:windowId", ownSchedule, workingHoursCtrl.UpdateWorkingHours)
+		userRoutes.DELETE("/:id/working-hours/:windowId", ownSchedule, workingHoursCtrl.DeleteWorkingHours)
+		userRoutes.GET("/:id/availability-overrides", ownSchedule, workingHoursCtrl.ListOverrides)
+		userRoutes.POST("/:id/availability-overrides", ownSchedule, workingHoursCtrl.CreateOverride)
+		userRoutes.DELETE("/:id/availability-overrides/:overrideId", ownSchedule, workingHoursCtrl.DeleteOverride)
+	}
+	apptRoutes := apiV1.Group("/appointments", requireUser, auth.RequireScope("appointments"))
 	{
 		apptRoutes.POST("", apptCtrl.CreateAppointment)
-		apptRoutes.GET("/:id", apptCtrl.GetAppointmentByID)
+		apptRoutes.POST("/series", apptCtrl.CreateAppointmentSeries)
+		apptRoutes.GET("", apptCtrl.ListAppointments)
+		apptRoutes.GET("/:id", apptCtrl.AuthorizeAppointment, icalCtrl.WithAppointmentExport(apptCtrl.GetAppointmentByID))
+		apptRoutes.PATCH("/:id", apptCtrl.AuthorizeAppointment, apptCtrl.UpdateAppointment)
+		apptRoutes.DELETE("/:id", manageAppointments, apptCtrl.DeleteAppointment)
+		apptRoutes.POST("/:id/restore", restoreDeleted, apptCtrl.RestoreAppointment)
+		apptRoutes.POST("/:id/confirm", apptCtrl.AuthorizeAppointment, apptCtrl.ConfirmAppointment)
+		apptRoutes.POST("/:id/cancel", apptCtrl.AuthorizeAppointment, apptCtrl.CancelAppointment)
+		apptRoutes.POST("/:id/start", apptCtrl.AuthorizeAppointment, apptCtrl.StartAppointment)
+		apptRoutes.POST("/:id/complete", apptCtrl.AuthorizeAppointment, apptCtrl.CompleteAppointment)
+		apptRoutes.POST("/:id/check-in", apptCtrl.AuthorizeAppointment, apptCtrl.CheckInAppointment)
+		apptRoutes.GET("/:id/eta", apptCtrl.AuthorizeAppointment, etaCtrl.GetAppointmentETA)
+	}
+	apiV1.GET("/availability", requireUser, auth.RequireScope("appointments"), availabilityCtrl.GetAvailability)
+	queueRoutes := apiV1.Group("/queues", requireUser, auth.RequireScope("queues"))
+	{
+		queueRoutes.POST("", manageQueues, queueCtrl.CreateQueue)
+		queueRoutes.GET("", queueCtrl.ListQueues)
+		queueRoutes.GET("/:id", queueCtrl.GetQueueByID)
+		queueRoutes.POST("/:id/tickets", queueCtrl.TakeTicket)
+		queueRoutes.GET("/:id/tickets", manageQueues, queueCtrl.ListTickets)
+		queueRoutes.POST("/:id/call-next", manageQueues, queueCtrl.CallNextTicket)
+		queueRoutes.POST("/:id/tickets/:ticketId/serve", manageQueues, queueCtrl.StartServingTicket)
+		queueRoutes.POST("/:id/tickets/:ticketId/done", manageQueues, queueCtrl.CompleteTicket)
+		queueRoutes.POST("/:id/tickets/:ticketId/no-show", manageQueues, queueCtrl.MarkTicketNoShow)
+	}
+	webhookRoutes := apiV1.Group("/webhooks", requireUser, auth.RequireScope("webhooks"), auth.RequirePermission(auth.ManageWebhooks))
+	{
+		webhookRoutes.POST("", webhookCtrl.CreateWebhook)
+		webhookRoutes.GET("", webhookCtrl.ListWebhooks)
+		webhookRoutes.GET("/:id", webhookCtrl.GetWebhookByID)
+		webhookRoutes.PATCH("/:id", webhookCtrl.UpdateWebhook)
+		webhookRoutes.DELETE("/:id", webhookCtrl.DeleteWebhook)
+		webhookRoutes.GET("/:id/deliveries", webhookCtrl.ListDeliveries)
+		webhookRoutes.GET("/:id/deliveries/:deliveryId", webhookCtrl.GetDelivery)
+		webhookRoutes.POST("/:id/deliveries/:deliveryId/replay", webhookCtrl.ReplayDelivery)
+	}
+	apiV1.GET("/stream", requireUser, auth.RequireScope("appointments"), streamCtrl.Stream)
+	router.OPTIONS("/caldav/users/:id/*path", caldavCtrl.Options)
+	caldavRoutes := router.Group("/caldav/users/:id", authMiddleware.RequireUserOrBasic("Appointments"), auth.RequireScope("appointments"))
+	{
+		caldavRoutes.Handle("PROPFIND", "/", caldavCtrl.PropfindPrincipal)
+		caldavRoutes.Handle("PROPFIND", "/calendar/", caldavCtrl.PropfindCollection)
+		caldavRoutes.Handle("REPORT", "/calendar/", caldavCtrl.Report)
+		caldavRoutes.Handle("PROPFIND", "/calendar/:object", caldavCtrl.PropfindObject)
+		caldavRoutes.GET("/calendar/:object", caldavCtrl.GetObject)
+		caldavRoutes.PUT("/calendar/:object", caldavCtrl.PutObject)
+		caldavRoutes.DELETE("/calendar/:object", caldavCtrl.DeleteObject)
 	}
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
 	return &TestApp{
-		DB:     db,
-		Router: router,
-		Config: cfg,
+		DB:           db,
+		Router:       router,
+		Config:       cfg,
+		Dispatcher:   dispatcher,
+		Webhooks:     webhooks,
+		CalendarSync: calendarSync,
+		Tokens:       tokens,
+		Organization: organization,
 	}, nil
 }
 
@@ -153,7 +346,7 @@ type MinimalLogger interface {
 
 func ClearTables(t *testing.T, db *gorm.DB, tables ...interface{}) {
 	for _, table := range tables {
-		err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(table).Error
+		err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(table).Error
 		if err != nil {
 			t.Fatalf("Failed to clear table for model %T: %v", table, err)
 		}
@@ -185,13 +378,65 @@ func MakeRequest(t *testing.T, router *gin.Engine, method, url string, body inte
 	return rr
 }
 
+// MakeRequestAs makes the request signed in as the user.
+func MakeRequestAs(t *testing.T, router *gin.Engine, userID uint, method, url string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return MakeRequestWithToken(t, router, AccessToken(t, userID), method, url, body)
+}
+
+// MakeRequestWithToken makes the request with the access token or API key
+// as its bearer token.
+func MakeRequestWithToken(t *testing.T, router *gin.Engine, accessToken, method, url string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var reqBodyBytes []byte
+	if body != nil {
+		var err error
+		reqBodyBytes, err = json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Failed to marshal request body: %v", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(reqBodyBytes))
+	if err != nil {
+		t.Fatalf("Failed to create new HTTP request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// AccessToken returns an access token for the user in their organization.
+func AccessToken(t *testing.T, userID uint) string {
+	t.Helper()
+	var user model.User
+	if err := globalTestApp.DB.Select("id", "organization_id").First(&user, userID).Error; err != nil {
+		t.Fatalf("Failed to load user for access token: %v", err)
+	}
+	token, _, err := globalTestApp.Tokens.Issue(userID, user.OrganizationID, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to issue access token: %v", err)
+	}
+	return token
+}
+
 func CheckTestEnv(t *testing.T) {
 	if os.Getenv("RUN_INTEGRATION_TESTS") == "" {
 		t.Skip("Skipping integration tests: RUN_INTEGRATION_TESTS env var not set")
 	}
 }
 
+// CreateUserInDB stores the user, in the default organization unless it
+// names another.
 func CreateUserInDB(t *testing.T, db *gorm.DB, user *model.User) *model.User {
+	if user.OrganizationID == 0 {
+		user.OrganizationID = globalTestApp.Organization.ID
+	}
 	err := db.Create(user).Error
 	if err != nil {
 		t.Fatalf("Failed to create user for test setup: %v", err)
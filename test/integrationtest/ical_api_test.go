@@ -0,0 +1,109 @@
+package integrationtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICalAPI_ExportsAppointmentAndFeed(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.CalendarFeed{}, &model.Appointment{}, &model.User{})
+
+	host := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "ICal Host", Email: "ical.host@example.com", Role: "client"})
+	guest := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "ICal Guest", Email: "ical.guest@example.com", Role: "provider"})
+
+	start := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Hour)
+	appointment := createAppointment(t, host.ID, guest.ID, start, start.Add(time.Hour))
+	other := createAppointment(t, host.ID, guest.ID, start.Add(2*time.Hour), start.Add(3*time.Hour))
+	exportURL := fmt.Sprintf("/api/v1/appointments/%d.ics", appointment.ID)
+	uid := fmt.Sprintf("UID:appointment-%d@%s\r\n", appointment.ID, globalTestApp.Config.Calendar.UIDDomain)
+
+	// 1. A single appointment exports as a tentative event with the first sequence
+	rr := MakeRequestAs(t, globalTestApp.Router, host.ID, http.MethodGet, exportURL, nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Export failed. Response: %s", rr.Body.String())
+	assert.True(t, strings.HasPrefix(rr.Header().Get("Content-Type"), "text/calendar"))
+	exported := rr.Body.String()
+	assert.Contains(t, exported, uid)
+	assert.Contains(t, exported, "SEQUENCE:0\r\n")
+	assert.Contains(t, exported, "STATUS:TENTATIVE\r\n")
+	assert.Contains(t, exported, "mailto:ical.guest@example.com\r\n")
+
+	// 2. The plain route still returns JSON
+	rr = MakeRequestAs(t, globalTestApp.Router, host.ID, http.MethodGet, fmt.Sprintf("/api/v1/appointments/%d", appointment.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Get failed. Response: %s", rr.Body.String())
+	var fetched model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &fetched))
+	assert.Equal(t, appointment.ID, fetched.ID)
+
+	// 3. Rescheduling and cancelling keep the UID and bump the sequence
+	newStart := start.Add(30 * time.Minute).Format(time.RFC3339)
+	newEnd := start.Add(90 * time.Minute).Format(time.RFC3339)
+	rr = MakeRequestAs(t, globalTestApp.Router, host.ID, http.MethodPatch, fmt.Sprintf("/api/v1/appointments/%d", appointment.ID),
+		request.UpdateAppointmentRequest{StartTime: &newStart, EndTime: &newEnd})
+	require.Equal(t, http.StatusOK, rr.Code, "Reschedule failed. Response: %s", rr.Body.String())
+
+	rr = MakeRequestAs(t, globalTestApp.Router, host.ID, http.MethodGet, exportURL, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), uid)
+	assert.Contains(t, rr.Body.String(), "SEQUENCE:1\r\n")
+	assert.Contains(t, rr.Body.String(), "DTSTART:"+start.Add(30*time.Minute).Format("20060102T150405Z")+"\r\n")
+
+	rr = MakeRequestAs(t, globalTestApp.Router, guest.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/cancel", appointment.ID),
+		nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Cancel failed. Response: %s", rr.Body.String())
+
+	rr = MakeRequestAs(t, globalTestApp.Router, host.ID, http.MethodGet, exportURL, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "SEQUENCE:2\r\n")
+	assert.Contains(t, rr.Body.String(), "STATUS:CANCELLED\r\n")
+
+	// 4. Unknown appointments are not found
+	rr = MakeRequestAs(t, globalTestApp.Router, host.ID, http.MethodGet, "/api/v1/appointments/999999.ics", nil)
+	require.Equal(t, http.StatusNotFound, rr.Code, "Expected 404 Not Found. Response: %s", rr.Body.String())
+
+	// 5. The feed needs the user's token, and issuing a new one revokes the old
+	feedURL := fmt.Sprintf("/api/v1/users/%d/calendar.ics", guest.ID)
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodGet, feedURL, nil)
+	require.Equal(t, http.StatusUnauthorized, rr.Code, "Expected 401 without a token. Response: %s", rr.Body.String())
+
+	first := issueFeedToken(t, guest.ID)
+	second := issueFeedToken(t, guest.ID)
+	require.NotEqual(t, first.Token, second.Token)
+	assert.Contains(t, second.URL, feedURL+"?token="+second.Token)
+
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodGet, feedURL+"?token="+first.Token, nil)
+	require.Equal(t, http.StatusUnauthorized, rr.Code, "Expected 401 for a revoked token. Response: %s", rr.Body.String())
+
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodGet, feedURL+"?token="+second.Token, nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Feed failed. Response: %s", rr.Body.String())
+	feed := rr.Body.String()
+	assert.Equal(t, 2, strings.Count(feed, "BEGIN:VEVENT\r\n"), "both the guest's appointments are in the feed")
+	assert.Contains(t, feed, uid)
+	assert.Contains(t, feed, fmt.Sprintf("UID:appointment-%d@", other.ID))
+
+	// 6. Unknown users have no feed
+	rr = MakeRequestAs(t, globalTestApp.Router, guest.ID, http.MethodPost, "/api/v1/users/999999/calendar-token", nil)
+	require.Equal(t, http.StatusNotFound, rr.Code, "Expected 404 Not Found. Response: %s", rr.Body.String())
+}
+
+func issueFeedToken(t *testing.T, userID uint) response.CalendarFeedResponse {
+	t.Helper()
+	rr := MakeRequestAs(t, globalTestApp.Router, userID, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/calendar-token", userID), nil)
+	require.Equal(t, http.StatusCreated, rr.Code, "Issue token failed. Response: %s", rr.Body.String())
+
+	var feed response.CalendarFeedResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &feed))
+	return feed
+}
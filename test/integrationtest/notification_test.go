@@ -0,0 +1,104 @@
+package integrationtest
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/mail"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/notification"
+	"queue_system/internal/notification/smtptest"
+	"queue_system/internal/outbox"
+	"queue_system/internal/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications_EmailBothUsersOnBookingChanges(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.SentNotification{}, &model.OutboxEvent{}, &model.Appointment{}, &model.User{})
+
+	smtpServer, err := smtptest.NewServer()
+	require.NoError(t, err)
+	defer smtpServer.Close()
+
+	cfg := *globalTestApp.Config
+	cfg.Notification.Channel = "smtp"
+	cfg.Notification.SMTP.Host = smtpServer.Host()
+	cfg.Notification.SMTP.Port = smtpServer.Port()
+	cfg.Notification.SMTP.Username = ""
+	cfg.Notification.SMTP.From = "Appointments <noreply@example.com>"
+	notifier, err := notification.NewNotifier(&cfg)
+	require.NoError(t, err)
+	templates, err := notification.LoadTemplates(&cfg)
+	require.NoError(t, err)
+
+	bus := events.NewBus()
+	sender := notification.NewSender(notifier, templates, repository.NewUserRepository(globalTestApp.DB), repository.NewNotificationRepository(globalTestApp.DB), &cfg)
+	bus.Subscribe("notifications", sender.HandleEvent, sender.EventTypes()...)
+	dispatcher := outbox.NewDispatcher(globalTestApp.DB, repository.NewOutboxRepository(globalTestApp.DB), bus, &cfg)
+	dispatch := func() {
+		for {
+			dispatched, err := dispatcher.DispatchPending(context.Background())
+			require.NoError(t, err)
+			if dispatched == 0 {
+				return
+			}
+		}
+	}
+
+	organizer := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Dr. Smith", Email: "dr.smith@example.com", Role: "client"})
+	participant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Ana", Email: "ana@example.com", Role: "provider", Timezone: "Europe/Berlin"})
+	base := time.Date(2030, 6, 3, 14, 0, 0, 0, time.UTC)
+
+	// 1. Booking and confirming an appointment each email both users, in
+	// their own timezone
+	appointment := createAppointment(t, organizer.ID, participant.ID, base, base.Add(30*time.Minute))
+	dispatch()
+	messages := smtpServer.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Appointment booked: Mon, 03 Jun 2030 14:00 UTC", mailSubject(t, messages[0]))
+	assert.Equal(t, "Appointment booked: Mon, 03 Jun 2030 16:00 CEST", mailSubject(t, messages[1]))
+
+	rr := MakeRequestAs(t, globalTestApp.Router, participant.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/confirm", appointment.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Confirm failed. Response: %s", rr.Body.String())
+	dispatch()
+
+	messages = smtpServer.Messages()
+	require.Len(t, messages, 4)
+	assert.Equal(t, []string{"dr.smith@example.com"}, messages[2].To)
+	assert.Equal(t, []string{"ana@example.com"}, messages[3].To)
+	assert.Equal(t, "Appointment confirmed: Mon, 03 Jun 2030 14:00 UTC", mailSubject(t, messages[2]))
+
+	// 2. Rescheduling and cancelling each email both users again
+	rr = MakeRequestAs(t, globalTestApp.Router, organizer.ID, http.MethodPatch, fmt.Sprintf("/api/v1/appointments/%d", appointment.ID), map[string]string{
+		"start_time": base.Add(time.Hour).Format(time.RFC3339),
+		"end_time":   base.Add(90 * time.Minute).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusOK, rr.Code, "Reschedule failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, organizer.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/cancel", appointment.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Cancel failed. Response: %s", rr.Body.String())
+	dispatch()
+
+	messages = smtpServer.Messages()
+	require.Len(t, messages, 8)
+	assert.Equal(t, "Appointment moved to Mon, 03 Jun 2030 15:00 UTC", mailSubject(t, messages[4]))
+	assert.Equal(t, "Appointment cancelled: Mon, 03 Jun 2030 17:00 CEST", mailSubject(t, messages[7]))
+}
+
+func mailSubject(t *testing.T, message smtptest.Message) string {
+	t.Helper()
+	parsed, err := mail.ReadMessage(strings.NewReader(message.Data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	return subject
+}
@@ -0,0 +1,144 @@
+package integrationtest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/outbox"
+	"queue_system/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutbox_RecordsEventsAtomicallyAndRetriesFailures(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.OutboxEvent{}, &model.Appointment{}, &model.User{})
+
+	// 1. Creating a user and an appointment records one event each
+	rr := MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/users", request.CreateUserRequest{
+		Name: "Outbox User", Email: "outbox.user@example.com",
+	})
+	require.Equal(t, http.StatusCreated, rr.Code, "Create User failed. Response: %s", rr.Body.String())
+	participant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Outbox Participant", Email: "outbox.participant@example.com", Role: "provider"})
+	creator := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Outbox Creator", Email: "outbox.creator@example.com", Role: "client"})
+
+	base := time.Date(2030, 3, 1, 9, 0, 0, 0, time.UTC)
+	createAppointment(t, creator.ID, participant.ID, base, base.Add(time.Hour))
+
+	var recorded []model.OutboxEvent
+	require.NoError(t, globalTestApp.DB.Order("id ASC").Find(&recorded).Error)
+	require.Len(t, recorded, 2)
+	assert.Equal(t, events.UserCreated, recorded[0].Type)
+	assert.Equal(t, events.AppointmentCreated, recorded[1].Type)
+
+	// 2. A rejected booking rolls back without leaving an event behind
+	rr = MakeRequestAs(t, globalTestApp.Router, creator.ID, http.MethodPost, "/api/v1/appointments", request.AppointmentRequest{
+		ParticipantID: participant.ID,
+		StartTime:     base.Add(30 * time.Minute).Format(time.RFC3339),
+		EndTime:       base.Add(90 * time.Minute).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected conflict. Response: %s", rr.Body.String())
+	var count int64
+	require.NoError(t, globalTestApp.DB.Model(&model.OutboxEvent{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	// 3. A failing subscriber leaves the event pending with a backoff
+	bus := events.NewBus()
+	failures := 1
+	var delivered []string
+	bus.SubscribeAll("flaky", func(_ context.Context, event events.Event) error {
+		if event.Type == events.AppointmentCreated && failures > 0 {
+			failures--
+			return errors.New("subscriber unavailable")
+		}
+		delivered = append(delivered, event.Type)
+		return nil
+	})
+	dispatcher := outbox.NewDispatcher(globalTestApp.DB, repository.NewOutboxRepository(globalTestApp.DB), bus, globalTestApp.Config)
+
+	dispatched, err := dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, dispatched)
+	assert.Equal(t, []string{events.UserCreated}, delivered)
+
+	var failed model.OutboxEvent
+	require.NoError(t, globalTestApp.DB.First(&failed, recorded[1].ID).Error)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Contains(t, failed.LastError, "subscriber unavailable")
+	assert.Nil(t, failed.DispatchedAt)
+	assert.True(t, failed.AvailableAt.After(time.Now()), "retry should be scheduled in the future")
+
+	dispatched, err = dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, dispatched, "event in backoff should not be retried yet")
+
+	// 4. Once the backoff has elapsed the event is delivered
+	require.NoError(t, globalTestApp.DB.Model(&model.OutboxEvent{}).Where("id = ?", failed.ID).
+		Update("available_at", time.Now().Add(-time.Second)).Error)
+	dispatched, err = dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	assert.Equal(t, []string{events.UserCreated, events.AppointmentCreated}, delivered)
+
+	var done model.OutboxEvent
+	require.NoError(t, globalTestApp.DB.First(&done, failed.ID).Error)
+	assert.NotNil(t, done.DispatchedAt)
+}
+
+func TestOutbox_RetriesOnlyFailedSubscribersAndGivesUp(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.OutboxEvent{})
+	require.NoError(t, globalTestApp.DB.Create(&model.OutboxEvent{Type: events.UserCreated, Payload: "{}", AvailableAt: time.Now()}).Error)
+
+	bus := events.NewBus()
+	steadyCalls := 0
+	bus.SubscribeAll("steady", func(context.Context, events.Event) error {
+		steadyCalls++
+		return nil
+	})
+	bus.SubscribeAll("broken", func(context.Context, events.Event) error {
+		return errors.New("subscriber unavailable")
+	})
+	cfg := *globalTestApp.Config
+	cfg.Outbox.MaxAttempts = 2
+	dispatcher := outbox.NewDispatcher(globalTestApp.DB, repository.NewOutboxRepository(globalTestApp.DB), bus, &cfg)
+	retryNow := func() {
+		require.NoError(t, globalTestApp.DB.Model(&model.OutboxEvent{}).Where("dispatched_at IS NULL").
+			Update("available_at", time.Now().Add(-time.Second)).Error)
+	}
+
+	// 1. The first attempt records which subscriber succeeded
+	dispatched, err := dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	var event model.OutboxEvent
+	require.NoError(t, globalTestApp.DB.First(&event).Error)
+	assert.Equal(t, []string{"steady"}, event.DeliveredTo)
+	assert.Nil(t, event.FailedAt)
+
+	// 2. The retry skips it and, at the last attempt, the event fails for good
+	retryNow()
+	dispatched, err = dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	assert.Equal(t, 1, steadyCalls, "the healthy subscriber sees the event once")
+	require.NoError(t, globalTestApp.DB.First(&event, event.ID).Error)
+	assert.Equal(t, 2, event.Attempts)
+	assert.NotNil(t, event.FailedAt)
+	assert.Nil(t, event.DispatchedAt)
+
+	retryNow()
+	dispatched, err = dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, dispatched, "failed events are not retried")
+}
@@ -0,0 +1,176 @@
+package integrationtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/model"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueAPI_TicketLifecycle(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.QueueTicket{}, &model.Queue{}, &model.User{})
+
+	clerk := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Queue Clerk", Email: "queue.clerk@example.com", Role: "staff"})
+	queue := createQueue(t, clerk.ID, "General", "Front desk")
+
+	// 1. Duplicate queue names at the same service point are rejected
+	rr := MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, "/api/v1/queues",
+		request.CreateQueueRequest{Name: "General", ServicePoint: "Front desk"})
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+
+	// 2. Calling an empty queue conflicts
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/call-next", queue.ID),
+		request.CallNextTicketRequest{Counter: "Desk 1"})
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+
+	// 3. Walk-ins take numbered tickets
+	first := takeTicket(t, clerk.ID, queue.ID, "Alice")
+	second := takeTicket(t, clerk.ID, queue.ID, "Bob")
+	assert.Equal(t, 1, first.Number)
+	assert.Equal(t, 2, second.Number)
+	assert.Equal(t, "waiting", first.Status)
+
+	// 4. The first ticket is called first
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/call-next", queue.ID),
+		request.CallNextTicketRequest{Counter: "Desk 1"})
+	require.Equal(t, http.StatusOK, rr.Code, "Call next failed. Response: %s", rr.Body.String())
+	var called model.QueueTicket
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &called))
+	assert.Equal(t, first.ID, called.ID)
+	assert.Equal(t, "called", called.Status)
+	assert.Equal(t, "Desk 1", called.Counter)
+	assert.NotNil(t, called.CalledAt)
+
+	// 5. A waiting ticket cannot be marked done
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/tickets/%d/done", queue.ID, second.ID), nil)
+	require.Equal(t, http.StatusConflict, rr.Code, "Expected 409 Conflict. Response: %s", rr.Body.String())
+
+	// 6. Serve and finish the called ticket
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/tickets/%d/serve", queue.ID, first.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Serve failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/tickets/%d/done", queue.ID, first.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Done failed. Response: %s", rr.Body.String())
+	var done model.QueueTicket
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &done))
+	assert.Equal(t, "done", done.Status)
+	assert.NotNil(t, done.FinishedAt)
+
+	// 7. The second customer never shows up
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/call-next", queue.ID),
+		request.CallNextTicketRequest{Counter: "Desk 2"})
+	require.Equal(t, http.StatusOK, rr.Code, "Call next failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/tickets/%d/no-show", queue.ID, second.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "No-show failed. Response: %s", rr.Body.String())
+
+	// 8. Filter tickets by status
+	rr = MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodGet, fmt.Sprintf("/api/v1/queues/%d/tickets?status=no_show", queue.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List failed. Response: %s", rr.Body.String())
+	var noShows []model.QueueTicket
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &noShows))
+	require.Len(t, noShows, 1)
+	assert.Equal(t, second.ID, noShows[0].ID)
+
+	// 9. Clients only take tickets for themselves
+	client := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Queue Client", Email: "queue.client@example.com", Role: "client"})
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/tickets", queue.ID),
+		request.TakeTicketRequest{UserID: &clerk.ID, CustomerName: "Carol"})
+	require.Equal(t, http.StatusCreated, rr.Code, "Take ticket failed. Response: %s", rr.Body.String())
+	var own model.QueueTicket
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &own))
+	require.NotNil(t, own.UserID)
+	assert.Equal(t, client.ID, *own.UserID)
+}
+
+func TestQueueAPI_ConcurrentTicketsAndCalls(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.QueueTicket{}, &model.Queue{}, &model.User{})
+
+	clerk := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Pharmacy Clerk", Email: "pharmacy.clerk@example.com", Role: "staff"})
+	queue := createQueue(t, clerk.ID, "Pharmacy", "Building A")
+
+	const walkIns = 20
+	var wg sync.WaitGroup
+	numbers := make([]int, walkIns)
+	for i := 0; i < walkIns; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rr := MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/tickets", queue.ID),
+				request.TakeTicketRequest{CustomerName: fmt.Sprintf("Walk-in %d", i)})
+			if rr.Code != http.StatusCreated {
+				t.Errorf("Take ticket failed with %d: %s", rr.Code, rr.Body.String())
+				return
+			}
+			var ticket model.QueueTicket
+			if err := json.Unmarshal(rr.Body.Bytes(), &ticket); err != nil {
+				t.Errorf("Failed to decode ticket: %v", err)
+				return
+			}
+			numbers[i] = ticket.Number
+		}(i)
+	}
+	wg.Wait()
+
+	// Every walk-in gets a distinct number from 1 to walkIns.
+	sort.Ints(numbers)
+	for i, number := range numbers {
+		assert.Equal(t, i+1, number)
+	}
+
+	// Concurrent counters each get a different ticket, and together they
+	// take the lowest numbers.
+	const counters = 5
+	calledNumbers := make([]int, counters)
+	for i := 0; i < counters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rr := MakeRequestAs(t, globalTestApp.Router, clerk.ID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/call-next", queue.ID),
+				request.CallNextTicketRequest{Counter: fmt.Sprintf("Desk %d", i)})
+			if rr.Code != http.StatusOK {
+				t.Errorf("Call next failed with %d: %s", rr.Code, rr.Body.String())
+				return
+			}
+			var ticket model.QueueTicket
+			if err := json.Unmarshal(rr.Body.Bytes(), &ticket); err != nil {
+				t.Errorf("Failed to decode ticket: %v", err)
+				return
+			}
+			calledNumbers[i] = ticket.Number
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Ints(calledNumbers)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, calledNumbers)
+}
+
+func createQueue(t *testing.T, userID uint, name, servicePoint string) model.Queue {
+	rr := MakeRequestAs(t, globalTestApp.Router, userID, http.MethodPost, "/api/v1/queues",
+		request.CreateQueueRequest{Name: name, ServicePoint: servicePoint})
+	require.Equal(t, http.StatusCreated, rr.Code, "Create queue failed. Response: %s", rr.Body.String())
+	var queue model.Queue
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &queue))
+	return queue
+}
+
+func takeTicket(t *testing.T, userID, queueID uint, customerName string) model.QueueTicket {
+	rr := MakeRequestAs(t, globalTestApp.Router, userID, http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/tickets", queueID),
+		request.TakeTicketRequest{CustomerName: customerName})
+	require.Equal(t, http.StatusCreated, rr.Code, "Take ticket failed. Response: %s", rr.Body.String())
+	var ticket model.QueueTicket
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &ticket))
+	return ticket
+}
@@ -0,0 +1,92 @@
+package integrationtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBACAPI_RolesLimitWhatUsersMayDo(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Webhook{}, &model.Appointment{}, &model.User{})
+
+	client := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "RBAC Client", Email: "rbac.client@example.com", Role: "client"})
+	otherClient := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "RBAC Other", Email: "rbac.other@example.com", Role: "client"})
+	provider := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "RBAC Provider", Email: "rbac.provider@example.com", Role: "provider"})
+	staff := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "RBAC Staff", Email: "rbac.staff@example.com", Role: "staff"})
+	start := time.Date(2030, 7, 1, 9, 0, 0, 0, time.UTC)
+
+	// 1. Sign-ups cannot pick a privileged role
+	rr := MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/users", request.CreateUserRequest{
+		Name: "Wannabe Admin", Email: "wannabe@example.com", Role: "admin",
+	})
+	requireDenied(t, rr.Code, rr.Body.Bytes(), "role_not_assignable")
+
+	// 2. Staff may book on behalf of a client; clients only for themselves
+	rr = MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodPost, "/api/v1/appointments", request.AppointmentRequest{
+		UserID: client.ID, ParticipantID: provider.ID,
+		StartTime: start.Format(time.RFC3339), EndTime: start.Add(time.Hour).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusCreated, rr.Code, "Staff booking failed. Response: %s", rr.Body.String())
+	var appointment model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &appointment))
+	assert.Equal(t, client.ID, appointment.UserID)
+
+	rr = MakeRequestAs(t, globalTestApp.Router, otherClient.ID, http.MethodPost, "/api/v1/appointments", request.AppointmentRequest{
+		UserID: client.ID, ParticipantID: provider.ID,
+		StartTime: start.Add(2 * time.Hour).Format(time.RFC3339), EndTime: start.Add(3 * time.Hour).Format(time.RFC3339),
+	})
+	requireDenied(t, rr.Code, rr.Body.Bytes(), "booking_for_others")
+	createAppointment(t, otherClient.ID, provider.ID, start.Add(2*time.Hour), start.Add(3*time.Hour))
+
+	// 3. Clients only see appointments they are part of
+	appointmentURL := fmt.Sprintf("/api/v1/appointments/%d", appointment.ID)
+	rr = MakeRequestAs(t, globalTestApp.Router, otherClient.ID, http.MethodGet, appointmentURL, nil)
+	requireDenied(t, rr.Code, rr.Body.Bytes(), "not_appointment_party")
+
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodGet, "/api/v1/appointments", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List failed. Response: %s", rr.Body.String())
+	assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+	rr = MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodGet, "/api/v1/appointments", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List failed. Response: %s", rr.Body.String())
+	assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+
+	// 4. Only the participant provider confirms
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPost, appointmentURL+"/confirm", nil)
+	requireDenied(t, rr.Code, rr.Body.Bytes(), "not_appointment_host")
+	rr = MakeRequestAs(t, globalTestApp.Router, provider.ID, http.MethodPost, appointmentURL+"/confirm", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Confirm failed. Response: %s", rr.Body.String())
+
+	// 5. Users cannot read each other's profiles or schedules
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodGet, fmt.Sprintf("/api/v1/users/%d", otherClient.ID), nil)
+	requireDenied(t, rr.Code, rr.Body.Bytes(), "not_owner")
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/working-hours", provider.ID), nil)
+	requireDenied(t, rr.Code, rr.Body.Bytes(), "not_owner")
+	rr = MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/working-hours", provider.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Staff may manage schedules. Response: %s", rr.Body.String())
+
+	// 6. Webhooks are for admins only
+	rr = MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodPost, "/api/v1/webhooks", request.CreateWebhookRequest{
+		URL: "https://example.com/hooks", Secret: "0123456789abcdef",
+	})
+	requireDenied(t, rr.Code, rr.Body.Bytes(), "permission_denied")
+}
+
+// requireDenied checks for a 403 carrying the given reason.
+func requireDenied(t *testing.T, code int, body []byte, reason string) {
+	t.Helper()
+	require.Equal(t, http.StatusForbidden, code, "Expected 403 Forbidden. Response: %s", body)
+	var errorResponse map[string]string
+	require.NoError(t, json.Unmarshal(body, &errorResponse))
+	assert.Equal(t, reason, errorResponse["reason"])
+	assert.NotEmpty(t, errorResponse["error"])
+}
@@ -0,0 +1,99 @@
+package integrationtest
+
+import (
+	"encoding/json"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/reminder"
+	"queue_system/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminderScheduler_SendsEachLeadTimeOnce(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.AppointmentReminder{}, &model.OutboxEvent{}, &model.Appointment{}, &model.User{})
+
+	host := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Reminder Host", Email: "reminder.host@example.com", Role: "client"})
+	guest := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Reminder Guest", Email: "reminder.guest@example.com", Role: "provider"})
+
+	now := time.Now().UTC().Truncate(time.Second)
+	newAppointment := func(startIn time.Duration, status string) *model.Appointment {
+		appointment := &model.Appointment{
+			OrganizationID: host.OrganizationID,
+			UserID:         host.ID,
+			ParticipantID:  guest.ID,
+			StartTime:      now.Add(startIn),
+			EndTime:        now.Add(startIn + 30*time.Minute),
+			Status:         status,
+		}
+		require.NoError(t, globalTestApp.DB.Create(appointment).Error)
+		return appointment
+	}
+	soon := newAppointment(30*time.Minute, "confirmed")
+	later := newAppointment(5*time.Hour, "confirmed")
+	newAppointment(48*time.Hour, "confirmed")
+	newAppointment(2*time.Hour, "pending")
+
+	cfg := *globalTestApp.Config
+	cfg.Reminder.LeadTimes = []time.Duration{24 * time.Hour, time.Hour}
+	newScheduler := func() *reminder.Scheduler {
+		return reminder.NewScheduler(globalTestApp.DB, repository.NewReminderRepository(globalTestApp.DB),
+			repository.NewOutboxRepository(globalTestApp.DB), &cfg)
+	}
+
+	// 1. The 30 minute appointment gets its 1h reminder only; the 5h one its 24h reminder
+	sent, err := newScheduler().SendDue(now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	reminders := reminderEvents(t)
+	require.Len(t, reminders, 2)
+	assert.ElementsMatch(t, []uint{soon.ID, later.ID}, []uint{reminders[0].ID, reminders[1].ID})
+	for _, payload := range reminders {
+		if payload.ID == soon.ID {
+			assert.Equal(t, 60, payload.LeadMinutes)
+		} else {
+			assert.Equal(t, 24*60, payload.LeadMinutes)
+		}
+	}
+	var skipped int64
+	require.NoError(t, globalTestApp.DB.Model(&model.AppointmentReminder{}).Where("skipped = ?", true).Count(&skipped).Error)
+	assert.Equal(t, int64(1), skipped)
+
+	// 2. A restarted scheduler does not send them again
+	sent, err = newScheduler().SendDue(now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	// 3. Lead times are reached as time passes, and rescheduling reminds again
+	sent, err = newScheduler().SendDue(now.Add(4*time.Hour + 30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "the 5h appointment is now within an hour")
+
+	require.NoError(t, globalTestApp.DB.Model(later).Updates(map[string]interface{}{
+		"start_time": now.Add(10 * time.Hour),
+		"end_time":   now.Add(10*time.Hour + 30*time.Minute),
+	}).Error)
+	sent, err = newScheduler().SendDue(now.Add(4*time.Hour + 31*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "the rescheduled appointment gets a new 24h reminder")
+	assert.Len(t, reminderEvents(t), 4)
+}
+
+func reminderEvents(t *testing.T) []reminder.Payload {
+	t.Helper()
+	var recorded []model.OutboxEvent
+	require.NoError(t, globalTestApp.DB.Where("type = ?", events.AppointmentReminder).Order("id ASC").Find(&recorded).Error)
+
+	payloads := make([]reminder.Payload, len(recorded))
+	for i := range recorded {
+		require.NoError(t, json.Unmarshal([]byte(recorded[i].Payload), &payloads[i]))
+	}
+	return payloads
+}
@@ -0,0 +1,220 @@
+package integrationtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"queue_system/config"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/model"
+	"queue_system/internal/repository"
+	"queue_system/internal/retention"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftDeleteAPI_DeleteAndRestore(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.RefreshToken{}, &model.APIKey{}, &model.Appointment{}, &model.User{})
+	admin := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Del Admin", Email: "del.admin@example.com", Role: "admin"})
+	staff := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Del Staff", Email: "del.staff@example.com", Role: "staff"})
+	provider := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Del Provider", Email: "del.provider@example.com", Role: "provider"})
+	client := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Del Client", Email: "del.client@example.com", Role: "client"})
+
+	start := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Hour)
+	booking := map[string]interface{}{
+		"participant_id": provider.ID,
+		"start_time":     start.Format(time.RFC3339),
+		"end_time":       start.Add(time.Hour).Format(time.RFC3339),
+	}
+	rr := MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPost, "/api/v1/appointments", booking)
+	require.Equal(t, http.StatusCreated, rr.Code, "Create Appointment failed. Response: %s", rr.Body.String())
+	var appointment model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &appointment))
+	appointmentURL := fmt.Sprintf("/api/v1/appointments/%d", appointment.ID)
+
+	// 1. Staff delete appointments, which then disappear
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodDelete, appointmentURL, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "Clients cannot delete appointments. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodDelete, appointmentURL, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Delete appointment failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, appointmentURL, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Deleted appointments are not found. Response: %s", rr.Body.String())
+	var stored model.Appointment
+	require.NoError(t, globalTestApp.DB.Unscoped().First(&stored, appointment.ID).Error)
+	assert.True(t, stored.DeletedAt.Valid, "the row is kept")
+
+	// 2. Only admins list deleted appointments
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, "/api/v1/appointments", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List appointments failed. Response: %s", rr.Body.String())
+	assert.Equal(t, "0", rr.Header().Get("X-Total-Count"))
+	rr = MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodGet, "/api/v1/appointments?include_deleted=true", nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "Staff cannot list deleted appointments. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, "/api/v1/appointments?include_deleted=true", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List appointments failed. Response: %s", rr.Body.String())
+	assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+
+	// 3. A restored appointment must not clash with one booked meanwhile
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPost, "/api/v1/appointments", booking)
+	require.Equal(t, http.StatusCreated, rr.Code, "The deleted appointment's slot is free. Response: %s", rr.Body.String())
+	var replacement model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &replacement))
+	rr = MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodPost, appointmentURL+"/restore", nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "Only admins restore. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, appointmentURL+"/restore", nil)
+	assert.Equal(t, http.StatusConflict, rr.Code, "Restoring would double-book. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/cancel", replacement.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Cancel appointment failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, appointmentURL+"/restore", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Restore appointment failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodGet, appointmentURL, nil)
+	assert.Equal(t, http.StatusOK, rr.Code, "Restored appointments are found again. Response: %s", rr.Body.String())
+
+	// 4. Users are deactivated before they are deleted, which signs them out
+	// and frees their email
+	session := &model.RefreshToken{OrganizationID: client.OrganizationID, UserID: client.ID, FamilyID: "del-family", TokenHash: "del-token", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, globalTestApp.DB.Create(session).Error)
+	kioskKey := &model.APIKey{OrganizationID: client.OrganizationID, UserID: client.ID, Name: "Kiosk", Prefix: "del", KeyHash: "del-key"}
+	require.NoError(t, globalTestApp.DB.Create(kioskKey).Error)
+	clientURL := fmt.Sprintf("/api/v1/users/%d", client.ID)
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodDelete, clientURL, nil)
+	assert.Equal(t, http.StatusConflict, rr.Code, "Active users cannot be deleted. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, clientURL+"/deactivate", request.DeactivateUserRequest{Appointments: "keep"})
+	require.Equal(t, http.StatusOK, rr.Code, "Deactivate user failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodDelete, clientURL, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Delete user failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, clientURL, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Deleted users are not found. Response: %s", rr.Body.String())
+	require.NoError(t, globalTestApp.DB.First(session, session.ID).Error)
+	assert.NotNil(t, session.RevokedAt, "deleted users' sessions are revoked")
+	require.NoError(t, globalTestApp.DB.First(kioskKey, kioskKey.ID).Error)
+	assert.NotNil(t, kioskKey.RevokedAt, "deleted users' API keys are revoked")
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, "/api/v1/users?include_deleted=true&search=del.client", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List users failed. Response: %s", rr.Body.String())
+	assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+
+	signUp := request.CreateUserRequest{Name: "New Client", Email: client.Email}
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/users", signUp)
+	require.Equal(t, http.StatusCreated, rr.Code, "A deleted user's email can be reused. Response: %s", rr.Body.String())
+	var newcomer model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &newcomer))
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, clientURL+"/restore", nil)
+	assert.Equal(t, http.StatusConflict, rr.Code, "Restoring would duplicate the email. Response: %s", rr.Body.String())
+
+	require.NoError(t, globalTestApp.DB.Unscoped().Delete(&newcomer).Error)
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, clientURL+"/restore", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Restore user failed. Response: %s", rr.Body.String())
+	var restored model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &restored))
+	assert.False(t, restored.DeletedAt.Valid)
+	assert.False(t, restored.Active(), "restored users stay deactivated")
+
+	// 5. Appointments of deactivated users are not brought back
+	rr = MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodDelete, appointmentURL, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Delete appointment failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, appointmentURL+"/restore", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "The client is deactivated. Response: %s", rr.Body.String())
+}
+
+func TestSoftDeleteAPI_OnlyPendingOrClosedAppointmentsAreDeleted(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.Appointment{}, &model.User{})
+	staff := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Busy Staff", Email: "busy.staff@example.com", Role: "staff"})
+	provider := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Busy Provider", Email: "busy.provider@example.com", Role: "provider"})
+	client := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Busy Client", Email: "busy.client@example.com", Role: "client"})
+	start := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Hour)
+	checkedIn := start.Add(-5 * time.Minute)
+	appointment := &model.Appointment{OrganizationID: client.OrganizationID, UserID: client.ID, ParticipantID: provider.ID, StartTime: start, EndTime: start.Add(time.Hour), Status: "confirmed", CheckedInAt: &checkedIn}
+	require.NoError(t, globalTestApp.DB.Create(appointment).Error)
+	appointmentURL := fmt.Sprintf("/api/v1/appointments/%d", appointment.ID)
+
+	rr := MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodDelete, appointmentURL, nil)
+	assert.Equal(t, http.StatusConflict, rr.Code, "Checked-in appointments cannot be deleted. Response: %s", rr.Body.String())
+
+	rr = MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodPost, appointmentURL+"/cancel", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Cancel appointment failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodDelete, appointmentURL, nil)
+	assert.Equal(t, http.StatusNoContent, rr.Code, "Cancelled appointments can be deleted. Response: %s", rr.Body.String())
+}
+
+func TestRetentionPurger_RemovesExpiredRecords(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.AppointmentReminder{}, &model.WorkingHours{}, &model.QueueTicket{}, &model.Queue{}, &model.WebhookDelivery{}, &model.OutboxEvent{}, &model.Appointment{}, &model.User{})
+	now := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	expired := now.Add(-31 * 24 * time.Hour)
+	recent := now.Add(-24 * time.Hour)
+
+	gone := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Gone User", Email: "gone@example.com", Role: "provider", DeactivatedAt: &expired})
+	kept := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Kept User", Email: "kept@example.com", Role: "client", DeactivatedAt: &recent})
+	require.NoError(t, globalTestApp.DB.Create(&model.WorkingHours{UserID: gone.ID, Weekday: 1, StartTime: "09:00", EndTime: "17:00", Timezone: "UTC"}).Error)
+	old := &model.Appointment{OrganizationID: gone.OrganizationID, UserID: kept.ID, ParticipantID: gone.ID, StartTime: expired, EndTime: expired.Add(time.Hour), Status: "completed"}
+	require.NoError(t, globalTestApp.DB.Create(old).Error)
+	require.NoError(t, globalTestApp.DB.Create(&model.AppointmentReminder{AppointmentID: old.ID, LeadMinutes: 60, StartTime: old.StartTime, SentAt: expired}).Error)
+	live := &model.Appointment{OrganizationID: gone.OrganizationID, UserID: kept.ID, ParticipantID: gone.ID, StartTime: now.Add(24 * time.Hour), EndTime: now.Add(25 * time.Hour), Status: "confirmed"}
+	require.NoError(t, globalTestApp.DB.Create(live).Error)
+	queue := &model.Queue{OrganizationID: gone.OrganizationID, Name: "Purge Desk", ServicePoint: "Lobby"}
+	require.NoError(t, globalTestApp.DB.Create(queue).Error)
+	ticket := &model.QueueTicket{OrganizationID: gone.OrganizationID, QueueID: queue.ID, Number: 1, UserID: &gone.ID, Status: "done"}
+	require.NoError(t, globalTestApp.DB.Create(ticket).Error)
+	profile := fmt.Sprintf(`{"ID": %d, "Name": "Gone User", "Email": "gone@example.com"}`, gone.ID)
+	sent := &model.OutboxEvent{OrganizationID: gone.OrganizationID, Type: "user.updated", Payload: profile, AvailableAt: expired, DispatchedAt: &expired, CreatedAt: expired}
+	require.NoError(t, globalTestApp.DB.Create(sent).Error)
+	delivered := &model.WebhookDelivery{OrganizationID: gone.OrganizationID, WebhookID: 1, EventID: sent.ID, EventType: sent.Type, Payload: profile, Status: "succeeded", NextAttemptAt: expired, CreatedAt: expired}
+	require.NoError(t, globalTestApp.DB.Create(delivered).Error)
+	signIn := &model.OutboxEvent{OrganizationID: gone.OrganizationID, Type: "auth.magic_link_requested", Payload: fmt.Sprintf(`{"organization_id": %d, "email": "gone@example.com"}`, gone.OrganizationID), AvailableAt: expired, DispatchedAt: &expired, CreatedAt: expired}
+	require.NoError(t, globalTestApp.DB.Create(signIn).Error)
+	unrelated := &model.OutboxEvent{OrganizationID: kept.OrganizationID, Type: "user.updated", Payload: fmt.Sprintf(`{"ID": %d}`, kept.ID), AvailableAt: expired, DispatchedAt: &expired, CreatedAt: expired}
+	require.NoError(t, globalTestApp.DB.Create(unrelated).Error)
+	require.NoError(t, globalTestApp.DB.Model(old).Update("deleted_at", expired).Error)
+	require.NoError(t, globalTestApp.DB.Model(gone).Update("deleted_at", expired).Error)
+	require.NoError(t, globalTestApp.DB.Model(kept).Update("deleted_at", recent).Error)
+
+	cfg := &config.Config{Retention: config.Retention{Period: 30 * 24 * time.Hour}}
+	purger := retention.NewPurger(globalTestApp.DB, repository.NewRetentionRepository(globalTestApp.DB), cfg)
+
+	// WHEN
+	purged, err := purger.Purge(now)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged, "the old appointment and the long-deleted user")
+	var tombstone model.User
+	require.NoError(t, globalTestApp.DB.Unscoped().First(&tombstone, gone.ID).Error)
+	assert.NotNil(t, tombstone.PurgedAt)
+	assert.Equal(t, "Deleted user", tombstone.Name)
+	assert.Empty(t, tombstone.Email)
+	var count int64
+	require.NoError(t, globalTestApp.DB.Unscoped().Model(&model.User{}).Where("id IN ? AND purged_at IS NULL", []uint{gone.ID, kept.ID}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "the recently deleted user can still be restored")
+	restorable, err := repository.NewUserRepository(globalTestApp.DB).GetDeletedByIdForUpdate(globalTestApp.DB, gone.OrganizationID, gone.ID)
+	require.NoError(t, err)
+	assert.Nil(t, restorable, "purged users cannot be restored")
+	var stillBooked model.Appointment
+	require.NoError(t, globalTestApp.DB.First(&stillBooked, live.ID).Error, "the other party keeps their appointment")
+	assert.Equal(t, gone.ID, stillBooked.ParticipantID)
+	require.NoError(t, globalTestApp.DB.First(ticket, ticket.ID).Error)
+	assert.Nil(t, ticket.UserID, "tickets are unlinked from purged users")
+	require.NoError(t, globalTestApp.DB.Model(&model.OutboxEvent{}).Where("id IN ?", []uint{sent.ID, signIn.ID}).Count(&count).Error)
+	assert.Zero(t, count, "events carrying the purged user's details are removed")
+	require.NoError(t, globalTestApp.DB.Model(&model.WebhookDelivery{}).Where("id = ?", delivered.ID).Count(&count).Error)
+	assert.Zero(t, count, "so are their webhook deliveries")
+	require.NoError(t, globalTestApp.DB.Model(&model.OutboxEvent{}).Where("id = ?", unrelated.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "other users' events stay")
+	require.NoError(t, globalTestApp.DB.Model(&model.WorkingHours{}).Where("user_id = ?", gone.ID).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, globalTestApp.DB.Model(&model.AppointmentReminder{}).Where("appointment_id = ?", old.ID).Count(&count).Error)
+	assert.Zero(t, count)
+
+	purged, err = purger.Purge(now)
+	require.NoError(t, err)
+	assert.Zero(t, purged, "purged users are not purged again")
+}
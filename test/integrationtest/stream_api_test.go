@@ -0,0 +1,121 @@
+package integrationtest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/stream"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamAPI_PushesFilteredEventsAndResumes(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.OutboxEvent{}, &model.Appointment{}, &model.User{})
+
+	creator := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Creator", Email: "creator@example.com", Role: "client"})
+	participant := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Participant", Email: "participant@example.com", Role: "provider"})
+	bystander := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Bystander", Email: "bystander@example.com", Role: "client"})
+
+	server := httptest.NewServer(globalTestApp.Router)
+	defer server.Close()
+
+	// 1. Subscribe to the participant's events only
+	received, closeStream := openStream(t, participant.ID, fmt.Sprintf("%s/api/v1/stream?user_id=%d", server.URL, participant.ID), "")
+
+	base := time.Date(2030, 2, 1, 9, 0, 0, 0, time.UTC)
+	createAppointment(t, creator.ID, bystander.ID, base, base.Add(time.Hour))
+	appointment := createAppointment(t, creator.ID, participant.ID, base.Add(2*time.Hour), base.Add(3*time.Hour))
+	dispatchOutbox(t)
+
+	created := nextEvent(t, received)
+	assert.Equal(t, events.AppointmentCreated, created.Type)
+	assert.Equal(t, "pending", created.Status)
+	assert.Contains(t, created.UserIDs, participant.ID)
+	closeStream()
+
+	// 2. A status change made while disconnected is replayed on resume
+	rr := MakeRequestAs(t, globalTestApp.Router, participant.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/confirm", appointment.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Confirm failed. Response: %s", rr.Body.String())
+	dispatchOutbox(t)
+
+	received, closeStream = openStream(t, participant.ID, fmt.Sprintf("%s/api/v1/stream?user_id=%d&status=confirmed", server.URL, participant.ID),
+		fmt.Sprint(created.ID))
+	defer closeStream()
+
+	confirmed := nextEvent(t, received)
+	assert.Equal(t, events.AppointmentConfirmed, confirmed.Type)
+	assert.Equal(t, "confirmed", confirmed.Status)
+	assert.Greater(t, confirmed.ID, created.ID)
+
+	// 3. Unknown statuses are rejected
+	rr = MakeRequestAs(t, globalTestApp.Router, participant.ID, http.MethodGet, "/api/v1/stream?status=archived", nil)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// openStream connects to the event stream and decodes its events onto the
+// returned channel until the returned close function is called.
+func openStream(t *testing.T, userID uint, url, lastEventID string) (<-chan stream.Event, func()) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+AccessToken(t, userID))
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	received := make(chan stream.Event, 16)
+	go func() {
+		defer close(received)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var event stream.Event
+			if err := json.Unmarshal([]byte(data), &event); err == nil {
+				received <- event
+			}
+		}
+	}()
+	return received, func() { resp.Body.Close() }
+}
+
+func nextEvent(t *testing.T, received <-chan stream.Event) stream.Event {
+	t.Helper()
+	select {
+	case event, ok := <-received:
+		require.True(t, ok, "stream closed before an event arrived")
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a stream event")
+		return stream.Event{}
+	}
+}
+
+func dispatchOutbox(t *testing.T) {
+	t.Helper()
+	for {
+		dispatched, err := globalTestApp.Dispatcher.DispatchPending(context.Background())
+		require.NoError(t, err)
+		if dispatched == 0 {
+			return
+		}
+	}
+}
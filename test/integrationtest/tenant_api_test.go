@@ -0,0 +1,275 @@
+package integrationtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantAPI_OrganizationsAreIsolated(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.RefreshToken{}, &model.Appointment{}, &model.User{})
+	acme := createOrganization(t, "acme")
+	globex := createOrganization(t, "globex")
+
+	// 1. The same email can sign up once in each organization
+	signUp := request.CreateUserRequest{Name: "Pat", Email: "pat@example.com", Password: "correct horse"}
+	rr := tenantRequest(t, http.MethodPost, "/api/v1/users", "acme", "", signUp)
+	require.Equal(t, http.StatusCreated, rr.Code, "Sign up in acme failed. Response: %s", rr.Body.String())
+	var acmePat model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &acmePat))
+	assert.Equal(t, acme.ID, acmePat.OrganizationID)
+
+	signUp.Password = "battery staple"
+	rr = tenantRequest(t, http.MethodPost, "/api/v1/users", "globex", "", signUp)
+	require.Equal(t, http.StatusCreated, rr.Code, "Sign up in globex failed. Response: %s", rr.Body.String())
+	var globexPat model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &globexPat))
+	assert.Equal(t, globex.ID, globexPat.OrganizationID)
+	assert.NotEqual(t, acmePat.ID, globexPat.ID)
+
+	rr = tenantRequest(t, http.MethodPost, "/api/v1/users", "globex", "", signUp)
+	require.Equal(t, http.StatusConflict, rr.Code, "Emails are unique within an organization. Response: %s", rr.Body.String())
+	rr = tenantRequest(t, http.MethodPost, "/api/v1/users", "initech", "", signUp)
+	require.Equal(t, http.StatusNotFound, rr.Code, "Unknown organizations are rejected. Response: %s", rr.Body.String())
+
+	// 2. Logging in checks the password of the organization's user
+	rr = tenantRequest(t, http.MethodPost, "/api/v1/auth/login", "acme", "", request.LoginRequest{Email: "pat@example.com", Password: "battery staple"})
+	require.Equal(t, http.StatusUnauthorized, rr.Code, "Globex's password does not open acme. Response: %s", rr.Body.String())
+	rr = tenantRequest(t, http.MethodPost, "/api/v1/auth/login", "acme", "", request.LoginRequest{Email: "pat@example.com", Password: "correct horse"})
+	require.Equal(t, http.StatusOK, rr.Code, "Login to acme failed. Response: %s", rr.Body.String())
+	var acmeTokens response.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &acmeTokens))
+
+	// 3. The token works without naming the organization, but not in another
+	rr = MakeRequestWithToken(t, globalTestApp.Router, acmeTokens.AccessToken, http.MethodGet, "/api/v1/auth/me", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Me failed. Response: %s", rr.Body.String())
+	var me model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &me))
+	assert.Equal(t, acmePat.ID, me.ID)
+	rr = tenantRequest(t, http.MethodGet, "/api/v1/auth/me", "globex", acmeTokens.AccessToken, nil)
+	require.Equal(t, http.StatusUnauthorized, rr.Code, "Tokens are bound to their organization. Response: %s", rr.Body.String())
+
+	// 4. Bookings only involve users of the same organization
+	acmeProvider := CreateUserInDB(t, globalTestApp.DB, &model.User{OrganizationID: acme.ID, Name: "Acme Provider", Email: "provider@example.com", Role: "provider"})
+	globexProvider := CreateUserInDB(t, globalTestApp.DB, &model.User{OrganizationID: globex.ID, Name: "Globex Provider", Email: "provider@example.com", Role: "provider"})
+	start := time.Date(2030, 7, 1, 9, 0, 0, 0, time.UTC)
+	booking := map[string]interface{}{
+		"participant_id": globexProvider.ID,
+		"start_time":     start.Format(time.RFC3339),
+		"end_time":       start.Add(time.Hour).Format(time.RFC3339),
+	}
+	rr = MakeRequestWithToken(t, globalTestApp.Router, acmeTokens.AccessToken, http.MethodPost, "/api/v1/appointments", booking)
+	require.Equal(t, http.StatusNotFound, rr.Code, "Other organizations' users cannot be booked. Response: %s", rr.Body.String())
+
+	booking["participant_id"] = acmeProvider.ID
+	rr = MakeRequestWithToken(t, globalTestApp.Router, acmeTokens.AccessToken, http.MethodPost, "/api/v1/appointments", booking)
+	require.Equal(t, http.StatusCreated, rr.Code, "Create Appointment failed. Response: %s", rr.Body.String())
+	var appointment model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &appointment))
+	assert.Equal(t, acme.ID, appointment.OrganizationID)
+
+	// 5. Nothing of acme's is visible from globex, even to its admin
+	globexAdmin := CreateUserInDB(t, globalTestApp.DB, &model.User{OrganizationID: globex.ID, Name: "Globex Admin", Email: "admin@example.com", Role: "admin"})
+	for _, url := range []string{
+		fmt.Sprintf("/api/v1/appointments/%d", appointment.ID),
+		fmt.Sprintf("/api/v1/users/%d", acmePat.ID),
+	} {
+		rr = MakeRequestAs(t, globalTestApp.Router, globexAdmin.ID, http.MethodGet, url, nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code, "%s leaked across organizations. Response: %s", url, rr.Body.String())
+	}
+	rr = MakeRequestAs(t, globalTestApp.Router, globexAdmin.ID, http.MethodGet, "/api/v1/appointments", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List appointments failed. Response: %s", rr.Body.String())
+	assert.Equal(t, "0", rr.Header().Get("X-Total-Count"))
+	rr = MakeRequestAs(t, globalTestApp.Router, globexAdmin.ID, http.MethodPost, fmt.Sprintf("/api/v1/appointments/%d/cancel", appointment.ID), nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Other organizations' appointments cannot be cancelled. Response: %s", rr.Body.String())
+}
+
+func TestTenantAPI_QueuesWebhooksStreamAndFeedsAreIsolated(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.WebhookDelivery{}, &model.Webhook{}, &model.QueueTicket{}, &model.Queue{},
+		&model.CalendarFeed{}, &model.OutboxEvent{}, &model.RefreshToken{}, &model.Appointment{}, &model.User{})
+	acme := createOrganization(t, "acme")
+	globex := createOrganization(t, "globex")
+	acmeAdmin := CreateUserInDB(t, globalTestApp.DB, &model.User{OrganizationID: acme.ID, Name: "Acme Admin", Email: "admin@example.com", Role: "admin"})
+	acmeProvider := CreateUserInDB(t, globalTestApp.DB, &model.User{OrganizationID: acme.ID, Name: "Acme Provider", Email: "provider@example.com", Role: "provider"})
+	globexAdmin := CreateUserInDB(t, globalTestApp.DB, &model.User{OrganizationID: globex.ID, Name: "Globex Admin", Email: "admin@example.com", Role: "admin"})
+
+	// 1. Queue names are unique per organization and queues are not shared
+	queueRequest := request.CreateQueueRequest{Name: "General", ServicePoint: "Front desk"}
+	rr := MakeRequestAs(t, globalTestApp.Router, acmeAdmin.ID, http.MethodPost, "/api/v1/queues", queueRequest)
+	require.Equal(t, http.StatusCreated, rr.Code, "Create Queue in acme failed. Response: %s", rr.Body.String())
+	var acmeQueue model.Queue
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &acmeQueue))
+	assert.Equal(t, acme.ID, acmeQueue.OrganizationID)
+	rr = MakeRequestAs(t, globalTestApp.Router, globexAdmin.ID, http.MethodPost, "/api/v1/queues", queueRequest)
+	require.Equal(t, http.StatusCreated, rr.Code, "Create Queue in globex failed. Response: %s", rr.Body.String())
+
+	for _, call := range []struct{ method, url string }{
+		{http.MethodGet, fmt.Sprintf("/api/v1/queues/%d", acmeQueue.ID)},
+		{http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/tickets", acmeQueue.ID)},
+		{http.MethodGet, fmt.Sprintf("/api/v1/queues/%d/tickets", acmeQueue.ID)},
+		{http.MethodPost, fmt.Sprintf("/api/v1/queues/%d/call-next", acmeQueue.ID)},
+	} {
+		rr = MakeRequestAs(t, globalTestApp.Router, globexAdmin.ID, call.method, call.url, map[string]interface{}{"customer_name": "Walk-in", "counter": "1"})
+		assert.Equal(t, http.StatusNotFound, rr.Code, "%s %s leaked across organizations. Response: %s", call.method, call.url, rr.Body.String())
+	}
+	rr = MakeRequestAs(t, globalTestApp.Router, globexAdmin.ID, http.MethodGet, "/api/v1/queues", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List Queues failed. Response: %s", rr.Body.String())
+	var globexQueues []model.Queue
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &globexQueues))
+	require.Len(t, globexQueues, 1)
+	assert.Equal(t, globex.ID, globexQueues[0].OrganizationID)
+
+	// 2. Webhooks are only visible to, and only sent events of, their organization
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }))
+	defer receiver.Close()
+	rr = MakeRequestAs(t, globalTestApp.Router, acmeAdmin.ID, http.MethodPost, "/api/v1/webhooks", request.CreateWebhookRequest{
+		URL: receiver.URL, Secret: "integration-test-secret", EventTypes: []string{events.UserCreated},
+	})
+	require.Equal(t, http.StatusCreated, rr.Code, "Create Webhook failed. Response: %s", rr.Body.String())
+	var hook model.Webhook
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &hook))
+	assert.Equal(t, acme.ID, hook.OrganizationID)
+
+	for _, url := range []string{fmt.Sprintf("/api/v1/webhooks/%d", hook.ID), fmt.Sprintf("/api/v1/webhooks/%d/deliveries", hook.ID)} {
+		rr = MakeRequestAs(t, globalTestApp.Router, globexAdmin.ID, http.MethodGet, url, nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code, "%s leaked across organizations. Response: %s", url, rr.Body.String())
+	}
+	rr = MakeRequestAs(t, globalTestApp.Router, globexAdmin.ID, http.MethodDelete, fmt.Sprintf("/api/v1/webhooks/%d", hook.ID), nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Other organizations' webhooks cannot be deleted. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, globexAdmin.ID, http.MethodGet, "/api/v1/webhooks", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List Webhooks failed. Response: %s", rr.Body.String())
+	assert.JSONEq(t, "[]", rr.Body.String())
+
+	signUp := request.CreateUserRequest{Name: "Sam", Email: "sam@example.com"}
+	rr = tenantRequest(t, http.MethodPost, "/api/v1/users", "globex", "", signUp)
+	require.Equal(t, http.StatusCreated, rr.Code, "Sign up in globex failed. Response: %s", rr.Body.String())
+	rr = tenantRequest(t, http.MethodPost, "/api/v1/users", "acme", "", signUp)
+	require.Equal(t, http.StatusCreated, rr.Code, "Sign up in acme failed. Response: %s", rr.Body.String())
+	var acmeSam model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &acmeSam))
+	dispatchOutbox(t)
+
+	var deliveries []model.WebhookDelivery
+	require.NoError(t, globalTestApp.DB.Where("webhook_id = ?", hook.ID).Find(&deliveries).Error)
+	require.Len(t, deliveries, 1, "Only acme's sign up is sent to acme's webhook")
+	assert.Equal(t, acme.ID, deliveries[0].OrganizationID)
+	var delivered model.User
+	require.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &delivered))
+	assert.Equal(t, acmeSam.ID, delivered.ID)
+
+	// 3. The stream only carries events of the subscriber's organization
+	server := httptest.NewServer(globalTestApp.Router)
+	defer server.Close()
+	received, closeStream := openStream(t, globexAdmin.ID, server.URL+"/api/v1/stream", "")
+	defer closeStream()
+
+	start := time.Date(2030, 7, 2, 9, 0, 0, 0, time.UTC)
+	createAppointment(t, acmeAdmin.ID, acmeProvider.ID, start, start.Add(time.Hour))
+	rr = tenantRequest(t, http.MethodPost, "/api/v1/users", "globex", "", request.CreateUserRequest{Name: "Lee", Email: "lee@example.com"})
+	require.Equal(t, http.StatusCreated, rr.Code, "Sign up in globex failed. Response: %s", rr.Body.String())
+	var globexLee model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &globexLee))
+	dispatchOutbox(t)
+
+	event := nextEvent(t, received)
+	assert.Equal(t, events.UserCreated, event.Type, "Acme's appointment must not reach globex's stream")
+	assert.Equal(t, []uint{globexLee.ID}, event.UserIDs)
+
+	// 4. Feed URLs work without naming the organization, as calendar clients cannot
+	feed := issueFeedToken(t, globexAdmin.ID)
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodGet, feed.URL, nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Feed of a non-default organization failed. Response: %s", rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "Appointments - Globex Admin")
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/calendar.ics?token=%s", acmeAdmin.ID, feed.Token), nil)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Tokens only open their own user's feed. Response: %s", rr.Body.String())
+}
+
+func TestTenantAPI_DefaultOrganizationAdminsCreateOrganizations(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.RefreshToken{}, &model.Appointment{}, &model.User{})
+	require.NoError(t, globalTestApp.DB.Where("slug = ?", "umbrella").Delete(&model.Organization{}).Error)
+	acme := createOrganization(t, "acme")
+	operator := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Operator", Email: "operator@example.com", Role: "admin"})
+	acmeAdmin := CreateUserInDB(t, globalTestApp.DB, &model.User{OrganizationID: acme.ID, Name: "Acme Admin", Email: "admin@example.com", Role: "admin"})
+	staff := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Staff", Email: "staff@example.com", Role: "staff"})
+	create := request.CreateOrganizationRequest{
+		Name: "Umbrella", Slug: "umbrella",
+		Admin: request.OrganizationAdminRequest{Name: "Alice", Email: "alice@umbrella.test", Password: "correct horse"},
+	}
+
+	// 1. Only admins of the default organization create organizations
+	rr := MakeRequestAs(t, globalTestApp.Router, staff.ID, http.MethodPost, "/api/v1/organizations", create)
+	require.Equal(t, http.StatusForbidden, rr.Code, "Staff cannot create organizations. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, acmeAdmin.ID, http.MethodPost, "/api/v1/organizations", create)
+	require.Equal(t, http.StatusForbidden, rr.Code, "Admins of other organizations cannot create organizations. Response: %s", rr.Body.String())
+
+	rr = MakeRequestAs(t, globalTestApp.Router, operator.ID, http.MethodPost, "/api/v1/organizations", create)
+	require.Equal(t, http.StatusCreated, rr.Code, "Create Organization failed. Response: %s", rr.Body.String())
+	var created response.OrganizationResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, "umbrella", created.Slug)
+	assert.Equal(t, created.ID, created.Admin.OrganizationID)
+	assert.Equal(t, "admin", created.Admin.Role)
+
+	rr = MakeRequestAs(t, globalTestApp.Router, operator.ID, http.MethodPost, "/api/v1/organizations", create)
+	require.Equal(t, http.StatusConflict, rr.Code, "Slugs are unique. Response: %s", rr.Body.String())
+	create.Slug = "Umbrella Corp"
+	rr = MakeRequestAs(t, globalTestApp.Router, operator.ID, http.MethodPost, "/api/v1/organizations", create)
+	require.Equal(t, http.StatusBadRequest, rr.Code, "Slugs must fit a subdomain. Response: %s", rr.Body.String())
+
+	// 2. The first admin signs in to the new organization
+	rr = tenantRequest(t, http.MethodPost, "/api/v1/auth/login", "umbrella", "", request.LoginRequest{Email: "alice@umbrella.test", Password: "correct horse"})
+	require.Equal(t, http.StatusOK, rr.Code, "Login to the new organization failed. Response: %s", rr.Body.String())
+}
+
+// createOrganization returns the organization with the slug, creating it
+// on first use.
+func createOrganization(t *testing.T, slug string) *model.Organization {
+	t.Helper()
+	organization := &model.Organization{Name: strings.ToUpper(slug[:1]) + slug[1:], Slug: slug}
+	require.NoError(t, globalTestApp.DB.Where("slug = ?", slug).FirstOrCreate(organization).Error)
+	return organization
+}
+
+// tenantRequest makes the request naming the organization in the tenancy
+// header, signed in with the access token unless it is empty.
+func tenantRequest(t *testing.T, method, url, slug, accessToken string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, url, strings.NewReader(string(payload)))
+	require.NoError(t, err)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(globalTestApp.Config.Tenancy.Header, slug)
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	rr := httptest.NewRecorder()
+	globalTestApp.Router.ServeHTTP(rr, req)
+	return rr
+}
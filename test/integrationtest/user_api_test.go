@@ -26,7 +26,6 @@ func TestUserAPI_CreateAndGetUser(t *testing.T) {
 	createUserReq := request.CreateUserRequest{
 		Name:  "API Test User",
 		Email: "api.user@example.com",
-		Role:  "tester",
 	}
 	rr := MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/users", createUserReq)
 	require.Equal(t, http.StatusCreated, rr.Code, "Create User failed. Response: %s", rr.Body.String())
@@ -36,11 +35,12 @@ func TestUserAPI_CreateAndGetUser(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, createUserReq.Name, createdUser.Name)
 	assert.Equal(t, createUserReq.Email, createdUser.Email)
+	assert.Equal(t, "client", createdUser.Role, "Sign-ups default to the client role")
 	assert.NotZero(t, createdUser.ID)
 	createdUserID := createdUser.ID
 
 	// 2. Get User by ID
-	rrGet := MakeRequest(t, globalTestApp.Router, http.MethodGet, fmt.Sprintf("/api/v1/users/%d", createdUserID), nil)
+	rrGet := MakeRequestAs(t, globalTestApp.Router, createdUserID, http.MethodGet, fmt.Sprintf("/api/v1/users/%d", createdUserID), nil)
 	require.Equal(t, http.StatusOK, rrGet.Code)
 
 	var fetchedUser model.User
@@ -58,11 +58,15 @@ func TestUserAPI_CreateAndGetUser(t *testing.T) {
 	assert.Contains(t, errorResponseConflict["error"], service.ErrEmailExists.Error())
 
 	// 4. Get non-existent user (should fail with 404)
-	rrNotFound := MakeRequest(t, globalTestApp.Router, http.MethodGet, "/api/v1/users/999999", nil)
+	admin := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "API Admin", Email: "api.admin@example.com", Role: "admin"})
+	rrNotFound := MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, "/api/v1/users/999999", nil)
 	require.Equal(t, http.StatusNotFound, rrNotFound.Code, "Expected 404 Not Found for non-existent user. Body: %s", rrNotFound.Body.String())
 	var errorResponseNotFound map[string]string
 	err = json.Unmarshal(rrNotFound.Body.Bytes(), &errorResponseNotFound)
 	require.NoError(t, err)
 	assert.Contains(t, strings.ToLower(errorResponseNotFound["error"]), strings.ToLower(service.ErrUserNotFound.Error()))
 
+	// 5. Create user with an invalid email (should fail with 400)
+	rrInvalid := MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/users", request.CreateUserRequest{Name: "No Email", Email: "not-an-email"})
+	require.Equal(t, http.StatusBadRequest, rrInvalid.Code, "Expected 400 Bad Request for an invalid email. Response: %s", rrInvalid.Body.String())
 }
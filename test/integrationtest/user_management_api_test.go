@@ -0,0 +1,117 @@
+package integrationtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"queue_system/internal/auth"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/dto/response"
+	"queue_system/internal/enums"
+	"queue_system/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserManagementAPI_ListUpdateDeactivate(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.RefreshToken{}, &model.Appointment{}, &model.User{})
+	admin := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Ada Admin", Email: "ada@example.com", Role: "admin"})
+	providerHash, err := auth.HashPassword("provider password")
+	require.NoError(t, err)
+	provider := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Pia Provider", Email: "pia@example.com", Role: "provider", PasswordHash: providerHash})
+	client := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Cal Client", Email: "cal@example.com", Role: "client"})
+
+	// 1. Only admins list users, filtered and paged
+	rr := MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodGet, "/api/v1/users", nil)
+	require.Equal(t, http.StatusForbidden, rr.Code, "Clients cannot list users. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, "/api/v1/users?role=provider", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List users failed. Response: %s", rr.Body.String())
+	var users []model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &users))
+	require.Len(t, users, 1)
+	assert.Equal(t, provider.ID, users[0].ID)
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, "/api/v1/users?search=EXAMPLE.com&page_size=2", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List users failed. Response: %s", rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &users))
+	assert.Len(t, users, 2)
+	assert.Equal(t, "3", rr.Header().Get("X-Total-Count"))
+
+	// 2. Users update their own profile, but not their role or a taken email
+	name, role, email := "Cal C. Client", "admin", provider.Email
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPatch, fmt.Sprintf("/api/v1/users/%d", client.ID), request.UpdateUserRequest{Name: &name})
+	require.Equal(t, http.StatusOK, rr.Code, "Update user failed. Response: %s", rr.Body.String())
+	var updated model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.Equal(t, name, updated.Name)
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPatch, fmt.Sprintf("/api/v1/users/%d", client.ID), request.UpdateUserRequest{Role: &role})
+	assert.Equal(t, http.StatusForbidden, rr.Code, "Clients cannot promote themselves. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPatch, fmt.Sprintf("/api/v1/users/%d", client.ID), request.UpdateUserRequest{Email: &email})
+	assert.Equal(t, http.StatusConflict, rr.Code, "Emails stay unique. Response: %s", rr.Body.String())
+	notAnEmail := "cal at example.com"
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPatch, fmt.Sprintf("/api/v1/users/%d", client.ID), request.UpdateUserRequest{Email: &notAnEmail})
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Emails must be valid. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPatch, fmt.Sprintf("/api/v1/users/%d", provider.ID), request.UpdateUserRequest{Name: &name})
+	assert.Equal(t, http.StatusForbidden, rr.Code, "Clients cannot edit others. Response: %s", rr.Body.String())
+
+	// 3. Deactivating the provider cancels their upcoming appointments
+	start := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Hour)
+	booking := map[string]interface{}{
+		"participant_id": provider.ID,
+		"start_time":     start.Format(time.RFC3339),
+		"end_time":       start.Add(time.Hour).Format(time.RFC3339),
+	}
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPost, "/api/v1/appointments", booking)
+	require.Equal(t, http.StatusCreated, rr.Code, "Create Appointment failed. Response: %s", rr.Body.String())
+	var appointment model.Appointment
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &appointment))
+	providerSession := login(t, provider.Email, "provider password")
+
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/deactivate", admin.ID), nil)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Admins cannot deactivate themselves. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/deactivate", provider.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Deactivate user failed. Response: %s", rr.Body.String())
+	var deactivated response.DeactivatedUserResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &deactivated))
+	assert.NotNil(t, deactivated.DeactivatedAt)
+	require.Len(t, deactivated.CancelledAppointments, 1)
+	assert.Equal(t, appointment.ID, deactivated.CancelledAppointments[0].ID)
+	var stored model.Appointment
+	require.NoError(t, globalTestApp.DB.First(&stored, appointment.ID).Error)
+	assert.Equal(t, string(enums.Cancelled), stored.Status)
+
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/deactivate", provider.ID), nil)
+	assert.Equal(t, http.StatusConflict, rr.Code, "Deactivating twice conflicts. Response: %s", rr.Body.String())
+
+	// 4. A deactivated user can neither sign in nor be booked
+	rr = MakeRequestAs(t, globalTestApp.Router, provider.ID, http.MethodGet, "/api/v1/auth/me", nil)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Deactivated users are signed out. Response: %s", rr.Body.String())
+	refresh(t, providerSession.RefreshToken, http.StatusUnauthorized)
+	var liveTokens int64
+	require.NoError(t, globalTestApp.DB.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", provider.ID).Count(&liveTokens).Error)
+	assert.Zero(t, liveTokens, "Deactivation revokes the refresh tokens")
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPost, "/api/v1/appointments", booking)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Deactivated users cannot be booked. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, "/api/v1/users?status=deactivated", nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List users failed. Response: %s", rr.Body.String())
+	assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+
+	// 5. Reactivating lets them be booked again
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/reactivate", provider.ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code, "Reactivate user failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/reactivate", provider.ID), nil)
+	assert.Equal(t, http.StatusConflict, rr.Code, "Reactivating an active user conflicts. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, client.ID, http.MethodPost, "/api/v1/appointments", booking)
+	assert.Equal(t, http.StatusCreated, rr.Code, "Reactivated users can be booked. Response: %s", rr.Body.String())
+	refresh(t, providerSession.RefreshToken, http.StatusUnauthorized)
+
+	// 6. Refresh tokens of users deactivated by other means stop working too
+	providerSession = login(t, provider.Email, "provider password")
+	require.NoError(t, globalTestApp.DB.Model(&model.User{}).Where("id = ?", provider.ID).Update("deactivated_at", time.Now()).Error)
+	refresh(t, providerSession.RefreshToken, http.StatusUnauthorized)
+}
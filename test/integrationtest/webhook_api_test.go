@@ -0,0 +1,175 @@
+package integrationtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"queue_system/internal/dto/request"
+	"queue_system/internal/events"
+	"queue_system/internal/model"
+	"queue_system/internal/webhook"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func TestWebhookAPI_SignsRetriesAndReplaysDeliveries(t *testing.T) {
+	CheckTestEnv(t)
+	require.NotNil(t, globalTestApp, "globalTestApp not initialized")
+
+	ClearTables(t, globalTestApp.DB, &model.WebhookDelivery{}, &model.Webhook{}, &model.OutboxEvent{}, &model.Appointment{}, &model.User{})
+
+	var mu sync.Mutex
+	var received []receivedWebhook
+	status := http.StatusInternalServerError
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, receivedWebhook{header: r.Header.Clone(), body: body})
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+	respondWith := func(code int) {
+		mu.Lock()
+		defer mu.Unlock()
+		status = code
+	}
+
+	admin := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Webhook Admin", Email: "webhook.admin@example.com", Role: "admin"})
+
+	// 1. Register a webhook for user.created; unknown event types are rejected
+	secret := "integration-test-secret"
+	rr := MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, "/api/v1/webhooks", request.CreateWebhookRequest{
+		URL: receiver.URL, Secret: secret, EventTypes: []string{"user.renamed"},
+	})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, "/api/v1/webhooks", request.CreateWebhookRequest{
+		URL: receiver.URL, Secret: secret, EventTypes: []string{events.UserCreated},
+	})
+	require.Equal(t, http.StatusCreated, rr.Code, "Create Webhook failed. Response: %s", rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), secret)
+	var hook model.Webhook
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &hook))
+	assert.True(t, hook.Active)
+
+	// 2. Creating a user queues a delivery; appointment events are not subscribed
+	rr = MakeRequest(t, globalTestApp.Router, http.MethodPost, "/api/v1/users", request.CreateUserRequest{
+		Name: "Hooked User", Email: "hooked.user@example.com",
+	})
+	require.Equal(t, http.StatusCreated, rr.Code, "Create User failed. Response: %s", rr.Body.String())
+	var user model.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &user))
+	other := CreateUserInDB(t, globalTestApp.DB, &model.User{Name: "Other", Email: "hooked.other@example.com", Role: "client"})
+	base := time.Date(2030, 4, 1, 9, 0, 0, 0, time.UTC)
+	createAppointment(t, user.ID, other.ID, base, base.Add(time.Hour))
+	dispatchOutbox(t)
+
+	deliveries := listDeliveries(t, admin.ID, hook.ID, "")
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, events.UserCreated, deliveries[0].EventType)
+	assert.Equal(t, "pending", deliveries[0].Status)
+
+	// 3. A failing endpoint leaves the delivery pending with a backoff
+	sent, err := globalTestApp.Webhooks.DeliverPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	deliveries = listDeliveries(t, admin.ID, hook.ID, "")
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "pending", deliveries[0].Status)
+	assert.Equal(t, http.StatusInternalServerError, deliveries[0].ResponseCode)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.True(t, deliveries[0].NextAttemptAt.After(time.Now()))
+
+	sent, err = globalTestApp.Webhooks.DeliverPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent, "delivery in backoff should not be retried yet")
+
+	// 4. Once the backoff has elapsed the retry succeeds
+	respondWith(http.StatusOK)
+	require.NoError(t, globalTestApp.DB.Model(&model.WebhookDelivery{}).Where("id = ?", deliveries[0].ID).
+		Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+	sent, err = globalTestApp.Webhooks.DeliverPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, fmt.Sprintf("/api/v1/webhooks/%d/deliveries/%d", hook.ID, deliveries[0].ID), nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var delivered model.WebhookDelivery
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &delivered))
+	assert.Equal(t, "succeeded", delivered.Status)
+	assert.Equal(t, http.StatusOK, delivered.ResponseCode)
+	assert.Equal(t, 2, delivered.Attempts)
+	assert.NotNil(t, delivered.DeliveredAt)
+
+	mu.Lock()
+	require.Len(t, received, 2)
+	last := received[1]
+	mu.Unlock()
+	assert.Equal(t, events.UserCreated, last.header.Get(webhook.EventHeader))
+	assert.True(t, webhook.Verify(secret, last.header.Get(webhook.TimestampHeader), last.body, last.header.Get(webhook.SignatureHeader)),
+		"delivery signature should verify with the webhook secret")
+	var payload webhook.Payload
+	require.NoError(t, json.Unmarshal(last.body, &payload))
+	assert.Equal(t, delivered.EventID, payload.EventID)
+	assert.Contains(t, string(payload.Data), "hooked.user@example.com")
+
+	// 5. Handing the same event to the webhooks again does not duplicate it
+	require.NoError(t, globalTestApp.Webhooks.HandleEvent(context.Background(), events.Event{
+		ID: delivered.EventID, Type: events.UserCreated, Payload: []byte(delivered.Payload),
+	}))
+	assert.Len(t, listDeliveries(t, admin.ID, hook.ID, ""), 1)
+
+	// 6. Replaying sends the event again as a new delivery
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, fmt.Sprintf("/api/v1/webhooks/%d/deliveries/%d/replay", hook.ID, delivered.ID), nil)
+	require.Equal(t, http.StatusAccepted, rr.Code, "Replay failed. Response: %s", rr.Body.String())
+	var replay model.WebhookDelivery
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &replay))
+	require.NotNil(t, replay.ReplayOfID)
+	assert.Equal(t, delivered.ID, *replay.ReplayOfID)
+
+	sent, err = globalTestApp.Webhooks.DeliverPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	succeeded := listDeliveries(t, admin.ID, hook.ID, "succeeded")
+	require.Len(t, succeeded, 2)
+	assert.Equal(t, replay.ID, succeeded[0].ID, "deliveries are listed newest first")
+
+	// 7. Disabled webhooks cannot be replayed; deleted ones are gone
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPatch, fmt.Sprintf("/api/v1/webhooks/%d", hook.ID), map[string]bool{"active": false})
+	require.Equal(t, http.StatusOK, rr.Code, "Update Webhook failed. Response: %s", rr.Body.String())
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodPost, fmt.Sprintf("/api/v1/webhooks/%d/deliveries/%d/replay", hook.ID, delivered.ID), nil)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodDelete, fmt.Sprintf("/api/v1/webhooks/%d", hook.ID), nil)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = MakeRequestAs(t, globalTestApp.Router, admin.ID, http.MethodGet, fmt.Sprintf("/api/v1/webhooks/%d", hook.ID), nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func listDeliveries(t *testing.T, userID, webhookID uint, status string) []model.WebhookDelivery {
+	t.Helper()
+	url := fmt.Sprintf("/api/v1/webhooks/%d/deliveries", webhookID)
+	if status != "" {
+		url += "?status=" + status
+	}
+	rr := MakeRequestAs(t, globalTestApp.Router, userID, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusOK, rr.Code, "List Deliveries failed. Response: %s", rr.Body.String())
+
+	var deliveries []model.WebhookDelivery
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &deliveries))
+	return deliveries
+}
@@ -0,0 +1,106 @@
+// Package txdb opens a gorm database for unit tests of code that runs
+// transactions. It only begins, commits and rolls back transactions, which
+// it records; running statements fails, so repositories must be mocked.
+package txdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var errNoStatements = errors.New("txdb: statements are not supported")
+
+// Log records what happened to the transactions of one database.
+type Log struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+// Entries returns "begin", "commit" and "rollback" in the order they
+// happened.
+func (l *Log) Entries() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.entries...)
+}
+
+func (l *Log) add(entry string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+// Open returns a database whose transactions are recorded in the returned
+// log. It is closed when the test ends.
+func Open(t *testing.T) (*gorm.DB, *Log) {
+	t.Helper()
+	log := &Log{}
+	sqlDB := sql.OpenDB(connector{log: log})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("opening txdb: %v", err)
+	}
+	return db, log
+}
+
+type connector struct {
+	log *Log
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return conn(c), nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("txdb: use txdb.Open")
+}
+
+type conn struct {
+	log *Log
+}
+
+func (c conn) Prepare(string) (driver.Stmt, error) {
+	return nil, errNoStatements
+}
+
+func (c conn) Close() error {
+	return nil
+}
+
+func (c conn) Begin() (driver.Tx, error) {
+	c.log.add("begin")
+	return tx(c), nil
+}
+
+type tx struct {
+	log *Log
+}
+
+func (t tx) Commit() error {
+	t.log.add("commit")
+	return nil
+}
+
+func (t tx) Rollback() error {
+	t.log.add("rollback")
+	return nil
+}